@@ -4,12 +4,27 @@ import (
 	"context"
 	"github.com/Quizert/PostCommentService/internal/app"
 	"log"
+	"os"
 )
 
 func main() {
+	ctx := context.Background()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := app.RunMigrateCommand(ctx, os.Args[2:]); err != nil {
+			log.Fatalf("Migration error: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		if err := app.RunTokenCommand(os.Args[2:]); err != nil {
+			log.Fatalf("Token error: %v", err)
+		}
+		return
+	}
+
 	log.Println("Starting app...")
 
-	ctx := context.Background()
 	application, err := app.InitApp(ctx)
 	if err != nil {
 		log.Fatalf("Failed to initialize application: %v", err)
@@ -2,20 +2,32 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"expvar"
 	"fmt"
-	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/Quizert/PostCommentService/graph"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/cache"
 	"github.com/Quizert/PostCommentService/internal/config"
+	"github.com/Quizert/PostCommentService/internal/consts"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/moderation"
+	"github.com/Quizert/PostCommentService/internal/ratelimit"
+	"github.com/Quizert/PostCommentService/internal/render"
 	graphql "github.com/Quizert/PostCommentService/internal/resolvers"
+	"github.com/Quizert/PostCommentService/internal/rest"
 	"github.com/Quizert/PostCommentService/internal/service"
 	in_memory "github.com/Quizert/PostCommentService/internal/storage/in-memory"
 	"github.com/Quizert/PostCommentService/internal/storage/postgres"
+	"github.com/Quizert/PostCommentService/internal/storage/sqlite"
+	"github.com/Quizert/PostCommentService/internal/webhook"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/zap"
-	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
@@ -23,20 +35,106 @@ import (
 )
 
 func NewDatabasePool(ctx context.Context, cfg *config.Config, logger *zap.Logger) (*pgxpool.Pool, error) {
-	log.Println(cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
-	connString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
-	logger.Info("Connecting to database", zap.String("connection_string", connString))
-	return pgxpool.Connect(ctx, connString)
+	connURL := &url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.DBUser, cfg.DBPassword),
+		Host:     net.JoinHostPort(cfg.DBHost, cfg.DBPort),
+		Path:     "/" + cfg.DBName,
+		RawQuery: "sslmode=disable",
+	}
+	// Пароль в логи не пишется
+	logger.Info("Connecting to database", zap.String("connection_string", connURL.Redacted()))
+	return pgxpool.Connect(ctx, connURL.String())
+}
+
+// NewDatabase подключается к основной базе и репликам из DB_REPLICA_DSNS
+func NewDatabase(ctx context.Context, cfg *config.Config, logger *zap.Logger) (*postgres.DB, error) {
+	primary, err := NewDatabasePool(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := make([]*pgxpool.Pool, 0, len(cfg.DBReplicaDSNs))
+	for i, dsn := range cfg.DBReplicaDSNs {
+		replica, err := pgxpool.Connect(ctx, dsn)
+		if err != nil {
+			primary.Close()
+			for _, opened := range replicas {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("connect to replica %d: %w", i, err)
+		}
+		replicas = append(replicas, replica)
+	}
+	logger.Info("Connected to database", zap.Int("replicas", len(replicas)), zap.Duration("sticky", cfg.DBReplicaSticky))
+	return postgres.NewDB(primary, replicas, cfg.DBReplicaSticky), nil
+}
+
+// NewInMemoryStorage создаёт хранилище в памяти. Если задан MEMORY_DATA_DIR, оно восстанавливается с диска и пишет журнал
+func NewInMemoryStorage(cfg *config.Config, logger *zap.Logger) (*in_memory.InMemoryStorage, error) {
+	if cfg.MemoryDataDir == "" {
+		return in_memory.NewInMemoryStorage(), nil
+	}
+	return in_memory.OpenInMemoryStorage(logger, in_memory.PersistenceConfig{
+		Dir:              cfg.MemoryDataDir,
+		Fsync:            cfg.MemoryFsync,
+		SnapshotInterval: cfg.MemorySnapshotInterval,
+	})
+}
+
+// NewStorageCache оборачивает провайдеры постов и комментариев read-through кэшем.
+// Счётчики попаданий и промахов публикуются в /debug/vars на DEBUG_ADDR
+func NewStorageCache(cfg *config.Config, logger *zap.Logger, postProvider service.PostProvider, commentProvider service.CommentProvider, moderationProvider service.ModerationProvider) (service.PostProvider, service.CommentProvider, service.ModerationProvider) {
+	lru := cache.NewLRU(cfg.CacheMaxEntries, cfg.CacheMaxBytes)
+	posts := cache.NewPostProvider(logger, postProvider, lru, cfg.CachePostTTL)
+	comments := cache.NewCommentProvider(logger, commentProvider, lru, cfg.CacheCommentsTTL)
+	expvar.Publish("storage_cache", expvar.Func(func() any {
+		return map[string]any{
+			"posts":    posts.Stats(),
+			"comments": comments.Stats(),
+			"lru":      lru.Stats(),
+		}
+	}))
+	return posts, comments, cache.NewModerationProvider(logger, moderationProvider, posts, comments)
 }
 
-func NewInMemoryStorage() *in_memory.InMemoryStorage {
-	return in_memory.NewInMemoryStorage()
+// NewDebugServer создаёт внутренний сервер с /debug/vars. Он не входит в публичный mux,
+// чтобы счётчики и командная строка процесса не были видны клиентам. Пустой адрес отключает сервер
+func NewDebugServer(addr string) *http.Server {
+	if addr == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	return &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
 }
 
 type App struct {
-	DbPool *pgxpool.Pool
-	Log    *zap.Logger
-	Server *http.Server
+	DB            *postgres.DB
+	SQLiteDB      *sql.DB
+	MemoryStorage *in_memory.InMemoryStorage
+	Log           *zap.Logger
+	Server        *http.Server
+	// DebugServer отдаёт /debug/vars на отдельном адресе, nil если DEBUG_ADDR не задан
+	DebugServer *http.Server
+	Scheduler   *service.PublishScheduler
+	Outbox      *service.OutboxDispatcher
+	Webhooks    *service.WebhookDispatcher
+}
+
+// GrantModerators выдаёт роль модератора пользователям из MODERATOR_IDS. Роль не отзывается,
+// если пользователя убрали из списка
+func GrantModerators(ctx context.Context, log *zap.Logger, userProvider service.UserProvider, userIDs []int) error {
+	for _, userID := range userIDs {
+		if err := userProvider.SetUserRole(ctx, userID, models.UserRoleModerator); err != nil {
+			return fmt.Errorf("user %d: %w", userID, err)
+		}
+		log.Info("Moderator role granted", zap.Int("UserID", userID))
+	}
+	return nil
 }
 
 func InitApp(ctx context.Context) (*App, error) {
@@ -51,52 +149,144 @@ func InitApp(ctx context.Context) (*App, error) {
 		postProvider    service.PostProvider
 		commentProvider service.CommentProvider
 		userProvider    service.UserProvider
+
+		notificationProvider service.NotificationProvider
+		moderationProvider   service.ModerationProvider
+		outboxProvider       service.OutboxProvider
+		webhookProvider      service.WebhookProvider
 	)
-	var dbPool *pgxpool.Pool
+	var db *postgres.DB
+	var sqliteDB *sql.DB
+	var memoryStorage *in_memory.InMemoryStorage
 	switch cfg.StorageMode {
 	case "memory":
-		memoryStorage := NewInMemoryStorage()
+		memoryStorage, err = NewInMemoryStorage(cfg, log)
+		if err != nil {
+			log.Fatal("Error restoring in-memory storage", zap.String("dir", cfg.MemoryDataDir), zap.Error(err))
+		}
 
 		postProvider = in_memory.NewPostMemoryStorage(log, memoryStorage)
 		commentProvider = in_memory.NewCommentMemoryStorage(log, memoryStorage)
 		userProvider = in_memory.NewUserMemoryStorage(log, memoryStorage)
+		notificationProvider = in_memory.NewNotificationMemoryStorage(log, memoryStorage)
+		moderationProvider = in_memory.NewModerationMemoryStorage(log, memoryStorage)
+		outboxProvider = in_memory.NewOutboxMemoryStorage(log, memoryStorage)
+		webhookProvider = in_memory.NewWebhookMemoryStorage(log, memoryStorage)
 
-		log.Info("Using in-memory storage")
+		log.Info("Using in-memory storage", zap.String("dir", cfg.MemoryDataDir), zap.String("fsync", string(cfg.MemoryFsync)))
 	case "postgres":
-		dbPool, err = NewDatabasePool(ctx, cfg, log)
+		db, err = NewDatabase(ctx, cfg, log)
 		if err != nil {
 			log.Fatal("Error connecting to database", zap.Error(err))
 		}
+		if cfg.AutoMigrate {
+			if err = MigrateUp(ctx, log, db.Pool); err != nil {
+				log.Fatal("Error applying migrations", zap.Error(err))
+			}
+		}
 
-		postProvider = postgres.NewPostPostgresRepository(dbPool, log)
-		commentProvider = postgres.NewCommentPostgresRepository(dbPool, log)
-		userProvider = postgres.NewUserPostgresRepository(dbPool, log)
+		postProvider = postgres.NewPostPostgresRepository(db, log)
+		commentProvider = postgres.NewCommentPostgresRepository(db, log)
+		userProvider = postgres.NewUserPostgresRepository(db, log)
+		notificationProvider = postgres.NewNotificationPostgresRepository(db, log)
+		moderationProvider = postgres.NewModerationPostgresRepository(db, log)
+		outboxProvider = postgres.NewOutboxPostgresRepository(db, log)
+		webhookProvider = postgres.NewWebhookPostgresRepository(db, log)
 
 		log.Info("Using postgres storage")
+	case "sqlite":
+		sqliteDB, err = sqlite.Open(ctx, log, cfg.SQLitePath)
+		if err != nil {
+			log.Fatal("Error opening sqlite database", zap.String("path", cfg.SQLitePath), zap.Error(err))
+		}
+
+		postProvider = sqlite.NewPostSQLiteRepository(sqliteDB, log)
+		commentProvider = sqlite.NewCommentSQLiteRepository(sqliteDB, log)
+		userProvider = sqlite.NewUserSQLiteRepository(sqliteDB, log)
+		notificationProvider = sqlite.NewNotificationSQLiteRepository(sqliteDB, log)
+		moderationProvider = sqlite.NewModerationSQLiteRepository(sqliteDB, log)
+		outboxProvider = sqlite.NewOutboxSQLiteRepository(sqliteDB, log)
+		webhookProvider = sqlite.NewWebhookSQLiteRepository(sqliteDB, log)
+
+		log.Info("Using sqlite storage", zap.String("path", cfg.SQLitePath))
+	default:
+		log.Fatal("Unknown storage mode", zap.String("mode", cfg.StorageMode))
+	}
+
+	if cfg.CacheMaxEntries > 0 {
+		postProvider, commentProvider, moderationProvider = NewStorageCache(cfg, log, postProvider, commentProvider, moderationProvider)
+		log.Info("Storage cache enabled", zap.Int("entries", cfg.CacheMaxEntries), zap.Int("bytes", cfg.CacheMaxBytes))
+	}
+
+	if err = GrantModerators(ctx, log, userProvider, cfg.ModeratorIDs); err != nil {
+		log.Fatal("Error granting moderator role", zap.Error(err))
 	}
 
-	storage := service.NewStorage(postProvider, commentProvider, userProvider)
+	storage := service.NewStorage(postProvider, commentProvider, userProvider, notificationProvider, moderationProvider)
+
+	moderationConfig := moderation.DefaultConfig()
+	if cfg.ModerationConfigPath != "" {
+		moderationConfig, err = moderation.LoadConfig(cfg.ModerationConfigPath)
+		if err != nil {
+			log.Fatal("Error loading moderation config", zap.String("path", cfg.ModerationConfigPath), zap.Error(err))
+		}
+	}
+	moderator, err := moderation.NewChainFromConfig(moderationConfig)
+	if err != nil {
+		log.Fatal("Invalid moderation config", zap.Error(err))
+	}
 
-	postService := service.NewPostService(log, storage)
-	commentService := service.NewCommentService(log, storage)
 	subManager := service.NewSubscriptionService()
-	resolver := graphql.NewResolver(log, postService, commentService, subManager)
+	limiter := ratelimit.NewActionLimiter(map[string]ratelimit.Limit{
+		ratelimit.ActionCreatePost:    cfg.PostRateLimit,
+		ratelimit.ActionCreateComment: cfg.CommentRateLimit,
+	})
+	postService := service.NewPostService(log, storage, moderator, limiter)
+	commentService := service.NewCommentService(log, storage, subManager, moderator, limiter)
+	notificationService := service.NewNotificationService(log, storage)
+	moderationService := service.NewModerationService(log, storage, subManager, cfg.ReportThreshold)
+	blockService := service.NewBlockService(log, storage)
+	webhookService := service.NewWebhookService(log, storage, webhookProvider)
+	scheduler := service.NewPublishScheduler(log, storage, cfg.PublishInterval)
+	outbox := service.NewOutboxDispatcher(log, outboxProvider, cfg.OutboxPollInterval, cfg.OutboxRetention,
+		service.NewCommentPublishedHandler(log, commentProvider, subManager),
+		service.NewWebhookEventHandler(log, postProvider, commentProvider, webhookProvider),
+	)
+	webhooks := service.NewWebhookDispatcher(log, webhookProvider, webhook.NewSender(cfg.WebhookTimeout), cfg.WebhookPollInterval, cfg.WebhookTimeout, cfg.WebhookMaxAttempts)
+	renderer := render.NewRenderer(consts.RenderCacheSize)
+	resolver := graphql.NewResolver(log, postService, commentService, subManager, notificationService, moderationService, renderer, blockService, webhookService)
 
 	mux := http.NewServeMux()
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	srv, err := NewGraphQLServer(log, cfg, graph.Config{Resolvers: resolver})
+	if err != nil {
+		log.Fatal("Error creating GraphQL server", zap.Error(err))
+	}
 
 	mux.Handle("/", playground.Handler("GraphQL Playground", "/query"))
-	mux.Handle("/query", srv)
+	// Лимит по IP общий для GraphQL и REST, чтобы клиент не получал двойную квоту. Он стоит перед проверкой
+	// токена, чтобы запросы с неверным токеном тоже списывались из лимита
+	ipLimiter := ratelimit.NewLimiter(cfg.IPRateLimit)
+	authenticator := auth.NewAuthenticator([]byte(cfg.AuthSecret), cfg.AuthInsecureUserHeader)
+	mux.Handle("/query", ratelimit.Middleware(ipLimiter, authenticator.Middleware(srv)))
+	restHandler := rest.NewHandler(log, postService, commentService, renderer)
+	mux.Handle(rest.Prefix+"/", http.StripPrefix(rest.Prefix, ratelimit.Middleware(ipLimiter, authenticator.Middleware(restHandler))))
 
 	server := &http.Server{
 		Addr:    ":" + cfg.HTTPPort,
 		Handler: mux,
 	}
+	debugServer := NewDebugServer(cfg.DebugAddr)
 
 	app := &App{
-		DbPool: dbPool,
-		Log:    log,
-		Server: server,
+		DB:            db,
+		SQLiteDB:      sqliteDB,
+		MemoryStorage: memoryStorage,
+		Log:           log,
+		Server:        server,
+		DebugServer:   debugServer,
+		Scheduler:     scheduler,
+		Outbox:        outbox,
+		Webhooks:      webhooks,
 	}
 
 	return app, nil
@@ -116,6 +306,22 @@ func (a *App) Start() error {
 
 	a.Log.Info("Server is running", zap.String("address", a.Server.Addr))
 
+	if a.DebugServer != nil {
+		go func() {
+			if err := a.DebugServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				a.Log.Fatal("Debug server error", zap.Error(err))
+			}
+		}()
+		a.Log.Info("Debug server is running", zap.String("address", a.DebugServer.Addr))
+	}
+
+	a.Scheduler.Start(context.Background())
+	a.Log.Info("Publish scheduler started")
+	a.Outbox.Start(context.Background())
+	a.Log.Info("Outbox dispatcher started")
+	a.Webhooks.Start(context.Background())
+	a.Log.Info("Webhook dispatcher started")
+
 	sig := <-signalChan
 	a.Log.Info("Received shutdown signal", zap.String("signal", sig.String()))
 
@@ -134,10 +340,36 @@ func (a *App) Stop() error {
 	}
 	a.Log.Info("HTTP server stopped gracefully")
 
-	if a.DbPool != nil {
-		a.DbPool.Close()
+	if a.DebugServer != nil {
+		if err := a.DebugServer.Shutdown(ctx); err != nil {
+			a.Log.Error("Debug server forced to shutdown", zap.Error(err))
+		}
+		a.Log.Info("Debug server stopped")
+	}
+
+	a.Scheduler.Stop()
+	a.Log.Info("Publish scheduler stopped")
+	a.Outbox.Stop()
+	a.Log.Info("Outbox dispatcher stopped")
+	a.Webhooks.Stop()
+	a.Log.Info("Webhook dispatcher stopped")
+
+	if a.DB != nil {
+		a.DB.Close()
 		a.Log.Info("Database connection closed")
 	}
+	if a.SQLiteDB != nil {
+		if err := a.SQLiteDB.Close(); err != nil {
+			a.Log.Error("Failed to close sqlite database", zap.Error(err))
+		}
+		a.Log.Info("SQLite database closed")
+	}
+	if a.MemoryStorage != nil {
+		if err := a.MemoryStorage.Close(); err != nil {
+			a.Log.Error("Failed to persist in-memory storage", zap.Error(err))
+		}
+		a.Log.Info("In-memory storage closed")
+	}
 
 	a.Log.Info("Application stopped successfully")
 	return nil
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/Quizert/PostCommentService/graph"
+	"github.com/Quizert/PostCommentService/internal/config"
+	"github.com/Quizert/PostCommentService/internal/persisted"
+	"github.com/Quizert/PostCommentService/internal/querylimit"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.uber.org/zap"
+	"time"
+)
+
+// queryCacheSize - число разобранных запросов, которые сервер держит в кэше
+const queryCacheSize = 1000
+
+// NewGraphQLServer собирает GraphQL-обработчик: транспорты, кэш разобранных запросов, ограничения сложности и глубины.
+// Если задан манифест persisted queries, выполняются только запросы из него, иначе включены автоматические
+// persisted queries (APQ) с LRU-кэшем
+func NewGraphQLServer(log *zap.Logger, cfg *config.Config, schemaConfig graph.Config) (*handler.Server, error) {
+	querylimit.ConfigureComplexity(&schemaConfig)
+	srv := handler.New(graph.NewExecutableSchema(schemaConfig))
+
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](queryCacheSize))
+
+	srv.Use(extension.Introspection{})
+	if cfg.PersistedQueriesManifest != "" {
+		manifest, err := persisted.LoadManifest(cfg.PersistedQueriesManifest)
+		if err != nil {
+			return nil, err
+		}
+		srv.Use(persisted.AllowList{Manifest: manifest})
+		log.Info("Persisted queries allow-list enabled", zap.Int("Queries", manifest.Len()))
+	} else {
+		srv.Use(extension.AutomaticPersistedQuery{
+			Cache: lru.New[string](cfg.APQCacheSize),
+		})
+	}
+	srv.Use(&querylimit.ComplexityLimit{Max: cfg.QueryMaxComplexity})
+	srv.Use(&querylimit.DepthLimit{Max: cfg.QueryMaxDepth})
+
+	return srv, nil
+}
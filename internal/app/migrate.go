@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Quizert/PostCommentService/internal/config"
+	"github.com/Quizert/PostCommentService/internal/migrator"
+	"github.com/Quizert/PostCommentService/migrations"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+func newMigrator(ctx context.Context, log *zap.Logger, pool *pgxpool.Pool) (*migrator.Migrator, func(), error) {
+	migrationList, err := migrator.Load(migrations.FS)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load migrations: %w", err)
+	}
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return migrator.New(log, conn, migrationList), conn.Release, nil
+}
+
+// MigrateUp применяет встроенные миграции к базе
+func MigrateUp(ctx context.Context, log *zap.Logger, pool *pgxpool.Pool) error {
+	m, release, err := newMigrator(ctx, log, pool)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		return err
+	}
+	log.Info("Migrations are up to date", zap.Int("Applied", applied))
+	return nil
+}
+
+// RunMigrateCommand выполняет подкоманду migrate: up, down [steps] или status
+func RunMigrateCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	log, err := zap.NewProduction()
+	if err != nil {
+		return fmt.Errorf("logger init error: %w", err)
+	}
+	cfg := config.MustLoad(log)
+
+	pool, err := NewDatabasePool(ctx, cfg, log)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	m, release, err := newMigrator(ctx, log, pool)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("invalid steps %q: %s", args[1], migrateUsage)
+			}
+		}
+		reverted, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migration(s)\n", reverted)
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(os.Stdout, status)
+	default:
+		return fmt.Errorf("unknown command %q: %s", args[0], migrateUsage)
+	}
+	return nil
+}
+
+func printMigrationStatus(w io.Writer, status *migrator.Status) {
+	fmt.Fprintf(w, "version: %d", status.Version)
+	if status.Dirty {
+		fmt.Fprint(w, " (dirty)")
+	}
+	fmt.Fprintln(w)
+	if len(status.Pending) == 0 {
+		fmt.Fprintln(w, "no pending migrations")
+		return
+	}
+	fmt.Fprintln(w, "pending:")
+	for _, migration := range status.Pending {
+		fmt.Fprintf(w, "  %s\n", migration)
+	}
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"os"
+	"strconv"
+	"time"
+)
+
+const tokenUsage = "usage: token <userID> [ttl]"
+
+// defaultTokenTTL - срок действия токена, если ttl не указан
+const defaultTokenTTL = 24 * time.Hour
+
+// RunTokenCommand выполняет подкоманду token: выдаёт токен пользователю, подписанный AUTH_SECRET.
+// Токен передаётся в заголовке Authorization: Bearer <token>
+func RunTokenCommand(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New(tokenUsage)
+	}
+	userID, err := strconv.Atoi(args[0])
+	if err != nil || userID <= 0 {
+		return fmt.Errorf("invalid userID %q: %s", args[0], tokenUsage)
+	}
+	ttl := defaultTokenTTL
+	if len(args) > 1 {
+		ttl, err = time.ParseDuration(args[1])
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid ttl %q: %s", args[1], tokenUsage)
+		}
+	}
+
+	authenticator := auth.NewAuthenticator([]byte(os.Getenv("AUTH_SECRET")), false)
+	token, err := authenticator.IssueToken(userID, time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
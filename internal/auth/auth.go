@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UserIDHeader - заголовок с id пользователя для локальной разработки. Учитывается, только если
+// включён AUTH_INSECURE_USER_HEADER: значение никак не проверяется, поэтому в production его включать нельзя
+const UserIDHeader = "X-User-ID"
+
+type identityKey struct{}
+
+type identity struct {
+	userID int
+	// verified - id подтверждён подписанным токеном, а не взят из UserIDHeader
+	verified bool
+}
+
+// WithUserID кладёт в контекст подтверждённый id пользователя
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity{userID: userID, verified: true})
+}
+
+// WithUnverifiedUserID кладёт в контекст id пользователя из UserIDHeader
+func WithUnverifiedUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity{userID: userID})
+}
+
+// UserIDFromContext возвращает id пользователя, от имени которого выполняется запрос
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(identityKey{}).(identity)
+	return id.userID, ok
+}
+
+// VerifiedUserIDFromContext возвращает id пользователя, только если он подтверждён токеном.
+// Действия модераторов проверяются только по нему
+func VerifiedUserIDFromContext(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(identityKey{}).(identity)
+	if !ok || !id.verified {
+		return 0, false
+	}
+	return id.userID, true
+}
+
+// Authenticator выдаёт и проверяет токены пользователей
+type Authenticator struct {
+	secret []byte
+	// allowUserHeader - принимать UserIDHeader без токена. Только для локальной разработки
+	allowUserHeader bool
+	now             func() time.Time
+}
+
+// NewAuthenticator создаёт Authenticator. С пустым secret токены не выдаются и не принимаются
+func NewAuthenticator(secret []byte, allowUserHeader bool) *Authenticator {
+	return &Authenticator{
+		secret:          secret,
+		allowUserHeader: allowUserHeader,
+		now:             time.Now,
+	}
+}
+
+// Middleware кладёт в контекст id пользователя из заголовка Authorization: Bearer <token>. На неверный
+// или истёкший токен отвечает 401. Без токена запрос анонимный, если не включён UserIDHeader
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header := r.Header.Get("Authorization"); header != "" {
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				writeUnauthenticated(w)
+				return
+			}
+			userID, err := a.ParseToken(token)
+			if err != nil {
+				writeUnauthenticated(w)
+				return
+			}
+			r = r.WithContext(WithUserID(r.Context(), userID))
+		} else if a.allowUserHeader {
+			userID, err := strconv.Atoi(r.Header.Get(UserIDHeader))
+			if err == nil && userID > 0 {
+				r = r.WithContext(WithUnverifiedUserID(r.Context(), userID))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeUnauthenticated отвечает 401 с ошибкой в формате GraphQL, как и ratelimit.Middleware
+func writeUnauthenticated(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []*gqlerror.Error{errdefs.HandleError(errdefs.UnauthenticatedError())},
+	})
+}
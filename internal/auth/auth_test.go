@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator_ParseToken(t *testing.T) {
+	now := time.Now()
+	a := NewAuthenticator([]byte("secret"), false)
+	a.now = func() time.Time { return now }
+
+	token, err := a.IssueToken(7, now.Add(time.Hour))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		auth    *Authenticator
+		want    int
+		wantErr error
+	}{
+		{name: "valid", token: token, auth: a, want: 7},
+		{name: "other secret", token: token, auth: NewAuthenticator([]byte("other"), false), wantErr: ErrInvalidToken},
+		{name: "empty secret", token: token, auth: NewAuthenticator(nil, true), wantErr: ErrInvalidToken},
+		{name: "forged user", token: "2" + token[1:], auth: a, wantErr: ErrInvalidToken},
+		{name: "garbage", token: "not-a-token", auth: a, wantErr: ErrInvalidToken},
+		{name: "expired", token: mustIssue(t, a, 7, now.Add(-time.Second)), auth: a, wantErr: ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.auth.now = a.now
+			userID, err := tt.auth.ParseToken(tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, userID)
+		})
+	}
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	type result struct {
+		status   int
+		userID   int
+		ok       bool
+		verified bool
+	}
+	serve := func(a *Authenticator, headers map[string]string) result {
+		var got result
+		handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got.userID, got.ok = UserIDFromContext(r.Context())
+			_, got.verified = VerifiedUserIDFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}))
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		got.status = w.Code
+		return got
+	}
+
+	strict := NewAuthenticator([]byte("secret"), false)
+	insecure := NewAuthenticator([]byte("secret"), true)
+	token := mustIssue(t, strict, 2, time.Now().Add(time.Hour))
+
+	assert.Equal(t, result{status: http.StatusOK, userID: 2, ok: true, verified: true},
+		serve(strict, map[string]string{"Authorization": "Bearer " + token}))
+	assert.Equal(t, result{status: http.StatusUnauthorized},
+		serve(strict, map[string]string{"Authorization": "Bearer " + token + "x"}))
+	assert.Equal(t, result{status: http.StatusOK},
+		serve(strict, map[string]string{UserIDHeader: "2"}), "без AUTH_INSECURE_USER_HEADER заголовок игнорируется")
+	assert.Equal(t, result{status: http.StatusOK, userID: 2, ok: true},
+		serve(insecure, map[string]string{UserIDHeader: "2"}), "заголовок не подтверждает пользователя")
+	assert.Equal(t, result{status: http.StatusOK, userID: 2, ok: true, verified: true},
+		serve(insecure, map[string]string{"Authorization": "Bearer " + token, UserIDHeader: strconv.Itoa(3)}), "токен важнее заголовка")
+}
+
+func mustIssue(t *testing.T, a *Authenticator, userID int, expiresAt time.Time) string {
+	t.Helper()
+	token, err := a.IssueToken(userID, expiresAt)
+	require.NoError(t, err)
+	return token
+}
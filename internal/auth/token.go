@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// IssueToken выдаёт токен пользователю userID, действующий до expiresAt.
+// Формат: userID.expiresAt.signature, где signature - HMAC-SHA256 первых двух частей в base64url
+func (a *Authenticator) IssueToken(userID int, expiresAt time.Time) (string, error) {
+	if len(a.secret) == 0 {
+		return "", errors.New("auth secret is not configured")
+	}
+	payload := strconv.Itoa(userID) + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + a.sign(payload), nil
+}
+
+// ParseToken проверяет подпись и срок действия токена и возвращает id пользователя
+func (a *Authenticator) ParseToken(token string) (int, error) {
+	if len(a.secret) == 0 {
+		return 0, ErrInvalidToken
+	}
+	payload, signature, ok := cutLast(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(a.sign(payload))) {
+		return 0, ErrInvalidToken
+	}
+	rawUserID, rawExpiresAt, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	userID, err := strconv.Atoi(rawUserID)
+	if err != nil || userID <= 0 {
+		return 0, ErrInvalidToken
+	}
+	expiresAt, err := strconv.ParseInt(rawExpiresAt, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	if !a.now().Before(time.Unix(expiresAt, 0)) {
+		return 0, ErrTokenExpired
+	}
+	return userID, nil
+}
+
+func (a *Authenticator) sign(payload string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
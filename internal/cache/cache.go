@@ -0,0 +1,85 @@
+// Package cache содержит read-through кэш для хранилища: обёртки над провайдерами постов и комментариев
+// и LRU-кэш в памяти процесса. Внешний кэш подключается своей реализацией интерфейса Cache
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"go.uber.org/zap"
+	"math/rand/v2"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Cache хранит сериализованные значения. Ошибки внешнего кэша реализация логирует сама,
+// для обёрток они выглядят как промах
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set сохраняет значение на время ttl, 0 - без ограничения
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	Delete(ctx context.Context, keys ...string)
+}
+
+// Stats - счётчики попаданий и промахов
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// layer - общая часть обёрток: чтение и запись значений, версии групп ключей и счётчики
+type layer struct {
+	cache  Cache
+	log    *zap.Logger
+	ttl    time.Duration
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func (l *layer) Stats() Stats {
+	return Stats{Hits: l.hits.Load(), Misses: l.misses.Load()}
+}
+
+// version возвращает текущую версию группы ключей, создавая её при первом обращении.
+// Версия входит в ключи значений группы, поэтому инвалидация - это смена версии. Значение, прочитанное
+// из базы до записи, сохраняется под старой версией и больше не читается
+func (l *layer) version(ctx context.Context, key string) string {
+	if version, ok := l.cache.Get(ctx, key); ok {
+		return string(version)
+	}
+	version := newVersion()
+	l.cache.Set(ctx, key, []byte(version), l.ttl)
+	return version
+}
+
+// bump меняет версию группы ключей. Вызывается после записи в базу
+func (l *layer) bump(ctx context.Context, key string) {
+	l.cache.Set(ctx, key, []byte(newVersion()), l.ttl)
+}
+
+// load читает значение в value. Каждый вызов возвращает новую копию, поэтому вызывающий может её менять
+func (l *layer) load(ctx context.Context, key string, value any) bool {
+	if data, ok := l.cache.Get(ctx, key); ok {
+		err := json.Unmarshal(data, value)
+		if err == nil {
+			l.hits.Add(1)
+			return true
+		}
+		l.log.Warn("Failed to decode cached value", zap.String("Key", key), zap.Error(err))
+	}
+	l.misses.Add(1)
+	return false
+}
+
+func (l *layer) store(ctx context.Context, key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		l.log.Warn("Failed to encode value for cache", zap.String("Key", key), zap.Error(err))
+		return
+	}
+	l.cache.Set(ctx, key, data, l.ttl)
+}
+
+func newVersion() string {
+	return strconv.FormatUint(rand.Uint64(), 36)
+}
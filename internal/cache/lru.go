@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU - кэш в памяти процесса. При переполнении по числу записей или по суммарному размеру значений
+// вытесняются записи, которые дольше всех не читали. Просроченные записи удаляются при чтении и вытеснении
+type LRU struct {
+	maxEntries int
+	maxBytes   int
+	now        func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*list.Element
+	order     *list.List
+	bytes     int
+	evictions int64
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+	// expiresAt - момент устаревания, нулевой - без ограничения
+	expiresAt time.Time
+}
+
+// LRUStats - заполненность кэша
+type LRUStats struct {
+	Entries   int   `json:"entries"`
+	Bytes     int   `json:"bytes"`
+	Evictions int64 `json:"evictions"`
+}
+
+// NewLRU создаёт кэш не больше чем на maxEntries записей и maxBytes байт значений, 0 снимает ограничение
+func NewLRU(maxEntries, maxBytes int) *LRU {
+	return &LRU{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if c.expired(entry) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set сохраняет value без копирования, после вызова его нельзя менять
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	// Значение больше всего кэша вытеснило бы всё остальное и всё равно не поместилось
+	if c.maxBytes > 0 && len(value) > c.maxBytes {
+		return
+	}
+
+	entry := &lruEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expiresAt = c.now().Add(ttl)
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	c.bytes += len(value)
+
+	for (c.maxEntries > 0 && c.order.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+func (c *LRU) Delete(_ context.Context, keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *LRU) Stats() LRUStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return LRUStats{Entries: c.order.Len(), Bytes: c.bytes, Evictions: c.evictions}
+}
+
+func (c *LRU) expired(entry *lruEntry) bool {
+	return !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt)
+}
+
+func (c *LRU) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*lruEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= len(entry.value)
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int
+		set        []string
+		get        []string
+		expected   []string
+		stats      LRUStats
+	}{
+		{
+			name:       "evicts least recently used by entries",
+			maxEntries: 2,
+			set:        []string{"a", "b"},
+			get:        []string{"a"},
+			expected:   []string{"a", "c"},
+			stats:      LRUStats{Entries: 2, Bytes: 2, Evictions: 1},
+		},
+		{
+			name:     "evicts least recently used by bytes",
+			maxBytes: 2,
+			set:      []string{"a", "b"},
+			get:      []string{"b"},
+			expected: []string{"b", "c"},
+			stats:    LRUStats{Entries: 2, Bytes: 2, Evictions: 1},
+		},
+		{
+			name:     "unbounded",
+			set:      []string{"a", "b"},
+			expected: []string{"a", "b", "c"},
+			stats:    LRUStats{Entries: 3, Bytes: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lru := NewLRU(tt.maxEntries, tt.maxBytes)
+			for _, key := range tt.set {
+				lru.Set(ctx, key, []byte(key), 0)
+			}
+			for _, key := range tt.get {
+				_, ok := lru.Get(ctx, key)
+				assert.True(t, ok)
+			}
+			lru.Set(ctx, "c", []byte("c"), 0)
+
+			var present []string
+			for _, key := range []string{"a", "b", "c"} {
+				if _, ok := lru.Get(ctx, key); ok {
+					present = append(present, key)
+				}
+			}
+			assert.Equal(t, tt.expected, present)
+			assert.Equal(t, tt.stats, lru.Stats())
+		})
+	}
+}
+
+func TestLRU_TTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	lru := NewLRU(0, 0)
+	lru.now = func() time.Time { return now }
+
+	lru.Set(ctx, "short", []byte("1"), time.Second)
+	lru.Set(ctx, "forever", []byte("2"), 0)
+
+	now = now.Add(time.Second)
+	_, ok := lru.Get(ctx, "short")
+	assert.False(t, ok, "запись устаревает по ttl")
+	value, ok := lru.Get(ctx, "forever")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("2"), value)
+	assert.Equal(t, LRUStats{Entries: 1, Bytes: 1}, lru.Stats())
+}
+
+func TestLRU_SetAndDelete(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(0, 4)
+
+	lru.Set(ctx, "key", []byte("old"), 0)
+	lru.Set(ctx, "key", []byte("new!"), 0)
+	value, ok := lru.Get(ctx, "key")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("new!"), value)
+	assert.Equal(t, LRUStats{Entries: 1, Bytes: 4}, lru.Stats())
+
+	lru.Set(ctx, "huge", []byte("too large"), 0)
+	_, ok = lru.Get(ctx, "huge")
+	assert.False(t, ok, "значение больше кэша не сохраняется")
+	_, ok = lru.Get(ctx, "key")
+	assert.True(t, ok, "и не вытесняет остальные")
+
+	lru.Delete(ctx, "key", "missing")
+	_, ok = lru.Get(ctx, "key")
+	assert.False(t, ok)
+	assert.Equal(t, LRUStats{}, lru.Stats())
+}
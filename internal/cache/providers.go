@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/service"
+	"go.uber.org/zap"
+	"time"
+)
+
+// PostProvider кэширует GetPostByID. Остальные методы передаются хранилищу без изменений
+type PostProvider struct {
+	service.PostProvider
+	layer
+}
+
+func NewPostProvider(log *zap.Logger, next service.PostProvider, cache Cache, ttl time.Duration) *PostProvider {
+	return &PostProvider{
+		PostProvider: next,
+		layer:        layer{cache: cache, log: log, ttl: ttl},
+	}
+}
+
+func (p *PostProvider) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
+	key := fmt.Sprintf("post:%d:%s", id, p.version(ctx, postVersionKey(id)))
+
+	var cached models.Post
+	if p.load(ctx, key, &cached) {
+		return &cached, nil
+	}
+
+	post, err := p.PostProvider.GetPostByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Отложенный пост публикует планировщик, и об этом обёртка не узнает
+	if post.Status != models.PostStatusScheduled {
+		p.store(ctx, key, post)
+	}
+	return post, nil
+}
+
+func (p *PostProvider) UpdatePostStatus(ctx context.Context, postID int, status models.PostStatus, publishAt *time.Time) (*models.Post, error) {
+	post, err := p.PostProvider.UpdatePostStatus(ctx, postID, status, publishAt)
+	if err == nil {
+		p.Invalidate(ctx, postID)
+	}
+	return post, err
+}
+
+func (p *PostProvider) UpdatePost(ctx context.Context, postID int, input models.EditPost, editorID int, hold *models.ModerationItem) (*models.Post, error) {
+	post, err := p.PostProvider.UpdatePost(ctx, postID, input, editorID, hold)
+	if err == nil {
+		p.Invalidate(ctx, postID)
+	}
+	return post, err
+}
+
+// Invalidate сбрасывает закэшированный пост
+func (p *PostProvider) Invalidate(ctx context.Context, postID int) {
+	p.bump(ctx, postVersionKey(postID))
+}
+
+func postVersionKey(postID int) string {
+	return fmt.Sprintf("post:%d:version", postID)
+}
+
+// CommentProvider кэширует первую страницу комментариев поста. Страница для вошедшего пользователя
+// зависит от его блокировок, поэтому кэшируется только страница для анонимных зрителей
+type CommentProvider struct {
+	service.CommentProvider
+	layer
+}
+
+func NewCommentProvider(log *zap.Logger, next service.CommentProvider, cache Cache, ttl time.Duration) *CommentProvider {
+	return &CommentProvider{
+		CommentProvider: next,
+		layer:           layer{cache: cache, log: log, ttl: ttl},
+	}
+}
+
+func (c *CommentProvider) GetCommentsByPostID(ctx context.Context, limit int, offset int, postID int, viewerID int) ([]*models.Comment, error) {
+	if offset != 0 || viewerID != 0 {
+		return c.CommentProvider.GetCommentsByPostID(ctx, limit, offset, postID, viewerID)
+	}
+	key := fmt.Sprintf("comments:%d:%s:%d", postID, c.version(ctx, commentsVersionKey(postID)), limit)
+
+	var cached []*models.Comment
+	if c.load(ctx, key, &cached) {
+		return cached, nil
+	}
+
+	comments, err := c.CommentProvider.GetCommentsByPostID(ctx, limit, offset, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	c.store(ctx, key, comments)
+	return comments, nil
+}
+
+func (c *CommentProvider) CreateComment(ctx context.Context, input models.NewComment, hidden bool, mentionIDs []int) (*models.Comment, error) {
+	comment, err := c.CommentProvider.CreateComment(ctx, input, hidden, mentionIDs)
+	if err == nil {
+		c.InvalidatePost(ctx, input.PostID)
+	}
+	return comment, err
+}
+
+func (c *CommentProvider) SetCommentPinned(ctx context.Context, commentID int, pinned bool) (*models.Comment, error) {
+	comment, err := c.CommentProvider.SetCommentPinned(ctx, commentID, pinned)
+	if err == nil {
+		c.InvalidatePost(ctx, comment.PostID)
+	}
+	return comment, err
+}
+
+func (c *CommentProvider) LockThread(ctx context.Context, commentID int) (*models.Comment, error) {
+	comment, err := c.CommentProvider.LockThread(ctx, commentID)
+	if err == nil {
+		c.InvalidatePost(ctx, comment.PostID)
+	}
+	return comment, err
+}
+
+func (c *CommentProvider) UpdateComment(ctx context.Context, commentID int, payload string, editorID int, hold *models.ModerationItem) (*models.Comment, error) {
+	comment, err := c.CommentProvider.UpdateComment(ctx, commentID, payload, editorID, hold)
+	if err == nil {
+		c.InvalidatePost(ctx, comment.PostID)
+	}
+	return comment, err
+}
+
+// InvalidatePost сбрасывает закэшированные страницы комментариев поста
+func (c *CommentProvider) InvalidatePost(ctx context.Context, postID int) {
+	c.bump(ctx, commentsVersionKey(postID))
+}
+
+func commentsVersionKey(postID int) string {
+	return fmt.Sprintf("comments:%d:version", postID)
+}
+
+// ModerationProvider сбрасывает кэш контента, который модерация скрыла или снова показала
+type ModerationProvider struct {
+	service.ModerationProvider
+	log      *zap.Logger
+	posts    *PostProvider
+	comments *CommentProvider
+}
+
+func NewModerationProvider(log *zap.Logger, next service.ModerationProvider, posts *PostProvider, comments *CommentProvider) *ModerationProvider {
+	return &ModerationProvider{
+		ModerationProvider: next,
+		log:                log,
+		posts:              posts,
+		comments:           comments,
+	}
+}
+
+func (m *ModerationProvider) EnqueueModeration(ctx context.Context, item *models.ModerationItem) (*models.ModerationItem, error) {
+	stored, err := m.ModerationProvider.EnqueueModeration(ctx, item)
+	if err == nil {
+		m.invalidate(ctx, item.TargetType, item.TargetID)
+	}
+	return stored, err
+}
+
+func (m *ModerationProvider) ResolveModerationItem(ctx context.Context, itemID int, status models.ModerationStatus, moderatorID int, reason *string) (*models.ModerationItem, error) {
+	item, err := m.ModerationProvider.ResolveModerationItem(ctx, itemID, status, moderatorID, reason)
+	if err == nil {
+		m.invalidate(ctx, item.TargetType, item.TargetID)
+	}
+	return item, err
+}
+
+func (m *ModerationProvider) invalidate(ctx context.Context, targetType models.TargetType, targetID int) {
+	switch targetType {
+	case models.TargetTypePost:
+		m.posts.Invalidate(ctx, targetID)
+	case models.TargetTypeComment:
+		comment, err := m.comments.GetCommentByID(ctx, targetID)
+		if err != nil {
+			m.log.Warn("Failed to find post of moderated comment", zap.Int("CommentID", targetID), zap.Error(err))
+			return
+		}
+		m.comments.InvalidatePost(ctx, comment.PostID)
+	}
+}
@@ -0,0 +1,336 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPostProvider_GetPostByID(t *testing.T) {
+	ctx := context.Background()
+	published := &models.Post{ID: 1, Title: "title", Status: models.PostStatusPublished, Author: &models.User{ID: 1, Username: "Alice"}, Tags: []string{"go"}}
+	scheduled := &models.Post{ID: 2, Status: models.PostStatusScheduled, Author: &models.User{ID: 1}}
+
+	tests := []struct {
+		name      string
+		post      *models.Post
+		postErr   error
+		dbReads   int
+		stats     Stats
+		expectErr bool
+	}{
+		{
+			name:    "second read is served from cache",
+			post:    published,
+			dbReads: 1,
+			stats:   Stats{Hits: 1, Misses: 1},
+		},
+		{
+			name:    "scheduled post is not cached",
+			post:    scheduled,
+			dbReads: 2,
+			stats:   Stats{Misses: 2},
+		},
+		{
+			name:      "errors are not cached",
+			postErr:   errors.New("db error"),
+			dbReads:   2,
+			stats:     Stats{Misses: 2},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			next := mocks.NewMockPostProvider(ctl)
+			id := 1
+			if tt.post != nil {
+				id = tt.post.ID
+			}
+			next.EXPECT().GetPostByID(gomock.Any(), id).Return(tt.post, tt.postErr).Times(tt.dbReads)
+
+			posts := NewPostProvider(zap.NewNop(), next, NewLRU(0, 0), time.Minute)
+			for i := 0; i < 2; i++ {
+				post, err := posts.GetPostByID(ctx, id)
+				if tt.expectErr {
+					assert.Error(t, err)
+					continue
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.post, post)
+			}
+			assert.Equal(t, tt.stats, posts.Stats())
+		})
+	}
+}
+
+func TestPostProvider_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	next := mocks.NewMockPostProvider(ctl)
+	next.EXPECT().GetPostByID(gomock.Any(), 1).
+		Return(&models.Post{ID: 1, Title: "title", Status: models.PostStatusPublished, Author: &models.User{ID: 1}}, nil)
+
+	posts := NewPostProvider(zap.NewNop(), next, NewLRU(0, 0), time.Minute)
+	first, err := posts.GetPostByID(ctx, 1)
+	require.NoError(t, err)
+	first.Title = "changed by caller"
+	first.Author.Username = "changed by caller"
+
+	second, err := posts.GetPostByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "title", second.Title)
+	assert.Empty(t, second.Author.Username)
+}
+
+func TestPostProvider_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	title := "edited"
+	status := models.PostStatusPublished
+
+	tests := []struct {
+		name   string
+		write  func(posts *PostProvider, next *mocks.MockPostProvider) error
+		reload bool
+	}{
+		{
+			name: "edit",
+			write: func(posts *PostProvider, next *mocks.MockPostProvider) error {
+				next.EXPECT().UpdatePost(gomock.Any(), 1, models.EditPost{Title: &title}, 1, nil).Return(&models.Post{ID: 1}, nil)
+				_, err := posts.UpdatePost(ctx, 1, models.EditPost{Title: &title}, 1, nil)
+				return err
+			},
+			reload: true,
+		},
+		{
+			name: "status change",
+			write: func(posts *PostProvider, next *mocks.MockPostProvider) error {
+				next.EXPECT().UpdatePostStatus(gomock.Any(), 1, status, nil).Return(&models.Post{ID: 1}, nil)
+				_, err := posts.UpdatePostStatus(ctx, 1, status, nil)
+				return err
+			},
+			reload: true,
+		},
+		{
+			name: "failed edit keeps cache",
+			write: func(posts *PostProvider, next *mocks.MockPostProvider) error {
+				next.EXPECT().UpdatePost(gomock.Any(), 1, models.EditPost{Title: &title}, 1, nil).Return(nil, errors.New("db error"))
+				_, err := posts.UpdatePost(ctx, 1, models.EditPost{Title: &title}, 1, nil)
+				assert.Error(t, err)
+				return nil
+			},
+		},
+		{
+			name: "other post",
+			write: func(posts *PostProvider, next *mocks.MockPostProvider) error {
+				next.EXPECT().UpdatePost(gomock.Any(), 2, models.EditPost{Title: &title}, 1, nil).Return(&models.Post{ID: 2}, nil)
+				_, err := posts.UpdatePost(ctx, 2, models.EditPost{Title: &title}, 1, nil)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			next := mocks.NewMockPostProvider(ctl)
+			reads := 1
+			if tt.reload {
+				reads = 2
+			}
+			next.EXPECT().GetPostByID(gomock.Any(), 1).
+				Return(&models.Post{ID: 1, Status: models.PostStatusPublished}, nil).
+				Times(reads)
+
+			posts := NewPostProvider(zap.NewNop(), next, NewLRU(0, 0), time.Minute)
+			_, err := posts.GetPostByID(ctx, 1)
+			require.NoError(t, err)
+			require.NoError(t, tt.write(posts, next))
+			_, err = posts.GetPostByID(ctx, 1)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCommentProvider_GetCommentsByPostID(t *testing.T) {
+	ctx := context.Background()
+	page := []*models.Comment{{ID: 1, PostID: 1, Payload: "first"}, {ID: 2, PostID: 1, Payload: "second"}}
+
+	tests := []struct {
+		name     string
+		limit    int
+		offset   int
+		viewerID int
+		dbReads  int
+		stats    Stats
+	}{
+		{
+			name:    "anonymous first page is cached",
+			limit:   10,
+			dbReads: 1,
+			stats:   Stats{Hits: 1, Misses: 1},
+		},
+		{
+			name:    "other pages are not cached",
+			limit:   10,
+			offset:  10,
+			dbReads: 2,
+		},
+		{
+			name:     "viewer page is not cached",
+			limit:    10,
+			viewerID: 3,
+			dbReads:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			next := mocks.NewMockCommentProvider(ctl)
+			next.EXPECT().GetCommentsByPostID(gomock.Any(), tt.limit, tt.offset, 1, tt.viewerID).Return(page, nil).Times(tt.dbReads)
+
+			comments := NewCommentProvider(zap.NewNop(), next, NewLRU(0, 0), time.Minute)
+			for i := 0; i < 2; i++ {
+				result, err := comments.GetCommentsByPostID(ctx, tt.limit, tt.offset, 1, tt.viewerID)
+				require.NoError(t, err)
+				assert.Equal(t, page, result)
+			}
+			assert.Equal(t, tt.stats, comments.Stats())
+		})
+	}
+}
+
+func TestCommentProvider_Invalidation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		write func(comments *CommentProvider, next *mocks.MockCommentProvider) error
+	}{
+		{
+			name: "create",
+			write: func(comments *CommentProvider, next *mocks.MockCommentProvider) error {
+				input := models.NewComment{PostID: 1, AuthorID: 1, Payload: "new"}
+				next.EXPECT().CreateComment(gomock.Any(), input, false, nil).Return(&models.Comment{ID: 3, PostID: 1}, nil)
+				_, err := comments.CreateComment(ctx, input, false, nil)
+				return err
+			},
+		},
+		{
+			name: "edit",
+			write: func(comments *CommentProvider, next *mocks.MockCommentProvider) error {
+				next.EXPECT().UpdateComment(gomock.Any(), 2, "edited", 1, nil).Return(&models.Comment{ID: 2, PostID: 1}, nil)
+				_, err := comments.UpdateComment(ctx, 2, "edited", 1, nil)
+				return err
+			},
+		},
+		{
+			name: "pin",
+			write: func(comments *CommentProvider, next *mocks.MockCommentProvider) error {
+				next.EXPECT().SetCommentPinned(gomock.Any(), 2, true).Return(&models.Comment{ID: 2, PostID: 1}, nil)
+				_, err := comments.SetCommentPinned(ctx, 2, true)
+				return err
+			},
+		},
+		{
+			name: "lock",
+			write: func(comments *CommentProvider, next *mocks.MockCommentProvider) error {
+				next.EXPECT().LockThread(gomock.Any(), 2).Return(&models.Comment{ID: 2, PostID: 1}, nil)
+				_, err := comments.LockThread(ctx, 2)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			next := mocks.NewMockCommentProvider(ctl)
+			next.EXPECT().GetCommentsByPostID(gomock.Any(), 10, 0, 1, 0).Return([]*models.Comment{}, nil).Times(2)
+			next.EXPECT().GetCommentsByPostID(gomock.Any(), 10, 0, 2, 0).Return([]*models.Comment{}, nil).Times(1)
+
+			comments := NewCommentProvider(zap.NewNop(), next, NewLRU(0, 0), time.Minute)
+			for _, postID := range []int{1, 2} {
+				_, err := comments.GetCommentsByPostID(ctx, 10, 0, postID, 0)
+				require.NoError(t, err)
+			}
+			require.NoError(t, tt.write(comments, next))
+			for _, postID := range []int{1, 2} {
+				_, err := comments.GetCommentsByPostID(ctx, 10, 0, postID, 0)
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModerationProvider_Invalidation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		item       *models.ModerationItem
+		postReads  int
+		pageReads  int
+		findParent bool
+	}{
+		{
+			name:      "post",
+			item:      &models.ModerationItem{ID: 1, TargetType: models.TargetTypePost, TargetID: 1},
+			postReads: 2,
+			pageReads: 1,
+		},
+		{
+			name:       "comment",
+			item:       &models.ModerationItem{ID: 1, TargetType: models.TargetTypeComment, TargetID: 5},
+			postReads:  1,
+			pageReads:  2,
+			findParent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			nextPosts := mocks.NewMockPostProvider(ctl)
+			nextComments := mocks.NewMockCommentProvider(ctl)
+			nextModeration := mocks.NewMockModerationProvider(ctl)
+
+			nextPosts.EXPECT().GetPostByID(gomock.Any(), 1).
+				Return(&models.Post{ID: 1, Status: models.PostStatusPublished}, nil).
+				Times(tt.postReads)
+			nextComments.EXPECT().GetCommentsByPostID(gomock.Any(), 10, 0, 1, 0).
+				Return([]*models.Comment{}, nil).
+				Times(tt.pageReads)
+			if tt.findParent {
+				nextComments.EXPECT().GetCommentByID(gomock.Any(), 5).Return(&models.Comment{ID: 5, PostID: 1}, nil)
+			}
+			nextModeration.EXPECT().EnqueueModeration(gomock.Any(), tt.item).Return(tt.item, nil)
+
+			lru := NewLRU(0, 0)
+			posts := NewPostProvider(zap.NewNop(), nextPosts, lru, time.Minute)
+			comments := NewCommentProvider(zap.NewNop(), nextComments, lru, time.Minute)
+			moderation := NewModerationProvider(zap.NewNop(), nextModeration, posts, comments)
+
+			read := func() {
+				_, err := posts.GetPostByID(ctx, 1)
+				require.NoError(t, err)
+				_, err = comments.GetCommentsByPostID(ctx, 10, 0, 1, 0)
+				require.NoError(t, err)
+			}
+			read()
+			_, err := moderation.EnqueueModeration(ctx, tt.item)
+			require.NoError(t, err)
+			read()
+		})
+	}
+}
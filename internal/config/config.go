@@ -1,8 +1,13 @@
 package config
 
 import (
+	"github.com/Quizert/PostCommentService/internal/ratelimit"
+	in_memory "github.com/Quizert/PostCommentService/internal/storage/in-memory"
 	"go.uber.org/zap"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func mustGetEnv(log *zap.Logger, key string) string {
@@ -13,6 +18,90 @@ func mustGetEnv(log *zap.Logger, key string) string {
 	return value
 }
 
+func getEnvDuration(log *zap.Logger, key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatal("invalid duration in environment variable", zap.String("key", key), zap.Error(err))
+	}
+	return duration
+}
+
+func getEnvInt(log *zap.Logger, key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatal("invalid integer in environment variable", zap.String("key", key), zap.Error(err))
+	}
+	return number
+}
+
+// getEnvList разбирает список через запятую, пустые элементы пропускаются
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvIntList(log *zap.Logger, key string) []int {
+	values := getEnvList(key)
+	numbers := make([]int, 0, len(values))
+	for _, value := range values {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatal("invalid integer list in environment variable", zap.String("key", key), zap.Error(err))
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers
+}
+
+func getEnvBool(log *zap.Logger, key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatal("invalid boolean in environment variable", zap.String("key", key), zap.Error(err))
+	}
+	return flag
+}
+
+func getEnvLimit(log *zap.Logger, key string, defaultValue string) ratelimit.Limit {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		value = defaultValue
+	}
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		log.Fatal("invalid rate limit in environment variable", zap.String("key", key), zap.Error(err))
+	}
+	return limit
+}
+
+func getEnvFsync(log *zap.Logger, key string, defaultValue in_memory.FsyncPolicy) in_memory.FsyncPolicy {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	policy, err := in_memory.ParseFsyncPolicy(value)
+	if err != nil {
+		log.Fatal("invalid fsync policy in environment variable", zap.String("key", key), zap.Error(err))
+	}
+	return policy
+}
+
 type Config struct {
 	DBHost     string
 	DBPort     string
@@ -20,9 +109,75 @@ type Config struct {
 	DBPassword string
 	DBName     string
 
+	// DBReplicaDSNs - строки подключения к репликам Postgres. Чтения постов, комментариев и пользователей идут в них
+	DBReplicaDSNs []string
+	// DBReplicaSticky - сколько после своей записи пользователь читает из основной базы
+	DBReplicaSticky time.Duration
+
 	HTTPPort string
+	// DebugAddr - адрес внутреннего листенера с /debug/vars. Если не задан, отладочные счётчики не публикуются наружу
+	DebugAddr string
 
 	StorageMode string
+	// SQLitePath - файл базы для STORAGE_MODE=sqlite
+	SQLitePath string
+
+	// MemoryDataDir - каталог журнала и снапшотов для STORAGE_MODE=memory. Если не задан, данные живут только в памяти
+	MemoryDataDir          string
+	MemoryFsync            in_memory.FsyncPolicy
+	MemorySnapshotInterval time.Duration
+
+	// CacheMaxEntries и CacheMaxBytes ограничивают кэш постов и первых страниц комментариев, CacheMaxEntries=0 отключает кэш
+	CacheMaxEntries  int
+	CacheMaxBytes    int
+	CachePostTTL     time.Duration
+	CacheCommentsTTL time.Duration
+
+	// AutoMigrate - применять миграции при старте. Реплики ждут друг друга на advisory lock
+	AutoMigrate bool
+
+	PublishInterval time.Duration
+
+	// OutboxPollInterval - как часто диспетчер забирает события из outbox
+	OutboxPollInterval time.Duration
+	// OutboxRetention - сколько хранятся доставленные события, 0 - без удаления
+	OutboxRetention time.Duration
+
+	// WebhookPollInterval - как часто отправляются доставки вебхуков
+	WebhookPollInterval time.Duration
+	// WebhookTimeout - таймаут одного запроса к получателю
+	WebhookTimeout time.Duration
+	// WebhookMaxAttempts - после стольких неудачных попыток доставка помечается FAILED
+	WebhookMaxAttempts int
+
+	// ModerationConfigPath - JSON-файл с правилами модерации. Если не задан, используются правила по умолчанию
+	ModerationConfigPath string
+
+	// ModeratorIDs - пользователи, которым при старте выдаётся роль модератора
+	ModeratorIDs []int
+
+	// ReportThreshold - число жалоб, после которого контент скрывается и попадает в очередь модерации
+	ReportThreshold int
+
+	// Лимиты в формате count/duration, пустое значение отключает лимит
+	PostRateLimit    ratelimit.Limit
+	CommentRateLimit ratelimit.Limit
+	// IPRateLimit ограничивает все запросы по IP, в том числе от авторизованных пользователей
+	IPRateLimit ratelimit.Limit
+
+	// AuthSecret - ключ подписи токенов пользователей
+	AuthSecret string
+	// AuthInsecureUserHeader - принимать X-User-ID без проверки. Только для локальной разработки
+	AuthInsecureUserHeader bool
+
+	// Ограничения GraphQL-запросов, 0 отключает проверку
+	QueryMaxComplexity int
+	QueryMaxDepth      int
+
+	// APQCacheSize - число запросов в LRU-кэше automatic persisted queries
+	APQCacheSize int
+	// PersistedQueriesManifest - манифест разрешённых запросов. Если задан, выполняются только запросы из него
+	PersistedQueriesManifest string
 }
 
 func MustLoad(log *zap.Logger) *Config {
@@ -31,9 +186,54 @@ func MustLoad(log *zap.Logger) *Config {
 	dbPort := mustGetEnv(log, "DB_PORT")
 	dbUser := mustGetEnv(log, "DB_USER")
 	dbPassword := mustGetEnv(log, "DB_PASSWORD")
+	dbReplicaDSNs := getEnvList("DB_REPLICA_DSNS")
+	dbReplicaSticky := getEnvDuration(log, "DB_REPLICA_STICKY", 5*time.Second)
 	httpPort := mustGetEnv(log, "HTTP_PORT")
+	debugAddr := os.Getenv("DEBUG_ADDR")
 
 	storageMode := mustGetEnv(log, "STORAGE_MODE")
+	sqlitePath := os.Getenv("SQLITE_PATH")
+	if sqlitePath == "" {
+		sqlitePath = "posts.db"
+	}
+	memoryDataDir := os.Getenv("MEMORY_DATA_DIR")
+	memoryFsync := getEnvFsync(log, "MEMORY_FSYNC", in_memory.FsyncInterval)
+	memorySnapshotInterval := getEnvDuration(log, "MEMORY_SNAPSHOT_INTERVAL", 5*time.Minute)
+	cacheMaxEntries := getEnvInt(log, "CACHE_MAX_ENTRIES", 10000)
+	cacheMaxBytes := getEnvInt(log, "CACHE_MAX_BYTES", 64<<20)
+	cachePostTTL := getEnvDuration(log, "CACHE_POST_TTL", time.Minute)
+	cacheCommentsTTL := getEnvDuration(log, "CACHE_COMMENTS_TTL", 10*time.Second)
+	autoMigrate := getEnvBool(log, "AUTO_MIGRATE", false)
+
+	publishInterval := getEnvDuration(log, "PUBLISH_INTERVAL", 5*time.Second)
+	outboxPollInterval := getEnvDuration(log, "OUTBOX_POLL_INTERVAL", 250*time.Millisecond)
+	outboxRetention := getEnvDuration(log, "OUTBOX_RETENTION", 24*time.Hour)
+	webhookPollInterval := getEnvDuration(log, "WEBHOOK_POLL_INTERVAL", time.Second)
+	webhookTimeout := getEnvDuration(log, "WEBHOOK_TIMEOUT", 10*time.Second)
+	webhookMaxAttempts := getEnvInt(log, "WEBHOOK_MAX_ATTEMPTS", 8)
+
+	moderationConfigPath := os.Getenv("MODERATION_CONFIG")
+	moderatorIDs := getEnvIntList(log, "MODERATOR_IDS")
+	reportThreshold := getEnvInt(log, "REPORT_THRESHOLD", 3)
+
+	postRateLimit := getEnvLimit(log, "RATE_LIMIT_POSTS", "10/1h")
+	commentRateLimit := getEnvLimit(log, "RATE_LIMIT_COMMENTS", "5/10s")
+	ipRateLimit := getEnvLimit(log, "RATE_LIMIT_IP", "60/1m")
+
+	authSecret := os.Getenv("AUTH_SECRET")
+	authInsecureUserHeader := getEnvBool(log, "AUTH_INSECURE_USER_HEADER", false)
+	if authSecret == "" && !authInsecureUserHeader {
+		log.Fatal("missing environment variable", zap.String("key", "AUTH_SECRET"))
+	}
+	if authInsecureUserHeader {
+		log.Warn("AUTH_INSECURE_USER_HEADER is enabled: X-User-ID is trusted without verification")
+	}
+
+	queryMaxComplexity := getEnvInt(log, "QUERY_MAX_COMPLEXITY", 1000)
+	queryMaxDepth := getEnvInt(log, "QUERY_MAX_DEPTH", 10)
+
+	apqCacheSize := getEnvInt(log, "APQ_CACHE_SIZE", 1000)
+	persistedQueriesManifest := os.Getenv("PERSISTED_QUERIES_MANIFEST")
 
 	return &Config{
 		DBName:      dbName,
@@ -42,6 +242,47 @@ func MustLoad(log *zap.Logger) *Config {
 		DBUser:      dbUser,
 		DBPassword:  dbPassword,
 		HTTPPort:    httpPort,
+		DebugAddr:   debugAddr,
 		StorageMode: storageMode,
+		SQLitePath:  sqlitePath,
+
+		DBReplicaDSNs:   dbReplicaDSNs,
+		DBReplicaSticky: dbReplicaSticky,
+
+		MemoryDataDir:          memoryDataDir,
+		MemoryFsync:            memoryFsync,
+		MemorySnapshotInterval: memorySnapshotInterval,
+
+		CacheMaxEntries:  cacheMaxEntries,
+		CacheMaxBytes:    cacheMaxBytes,
+		CachePostTTL:     cachePostTTL,
+		CacheCommentsTTL: cacheCommentsTTL,
+
+		AutoMigrate:     autoMigrate,
+		PublishInterval: publishInterval,
+
+		OutboxPollInterval: outboxPollInterval,
+		OutboxRetention:    outboxRetention,
+
+		WebhookPollInterval: webhookPollInterval,
+		WebhookTimeout:      webhookTimeout,
+		WebhookMaxAttempts:  webhookMaxAttempts,
+
+		ModerationConfigPath: moderationConfigPath,
+		ModeratorIDs:         moderatorIDs,
+		ReportThreshold:      reportThreshold,
+
+		PostRateLimit:    postRateLimit,
+		CommentRateLimit: commentRateLimit,
+		IPRateLimit:      ipRateLimit,
+
+		AuthSecret:             authSecret,
+		AuthInsecureUserHeader: authInsecureUserHeader,
+
+		QueryMaxComplexity: queryMaxComplexity,
+		QueryMaxDepth:      queryMaxDepth,
+
+		APQCacheSize:             apqCacheSize,
+		PersistedQueriesManifest: persistedQueriesManifest,
 	}
 }
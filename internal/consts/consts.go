@@ -5,4 +5,13 @@ const (
 	MaxLimit       = 30
 	DefaultLimit   = 10
 	DefaultOffset  = 0
+
+	MaxTagsCount = 10
+	MaxTagLength = 50
+
+	MaxMentionsCount = 10
+
+	RenderCacheSize = 1000
+
+	MaxReportNoteLength = 500
 )
@@ -42,3 +42,326 @@ func HandleError(err error) *gqlerror.Error {
 		},
 	}
 }
+
+func TooManyTagsError(maxCount, currentCount int) *AppError {
+	return &AppError{
+		Code:    "TOO_MANY_TAGS",
+		Message: "Post exceeds maximum allowed number of tags",
+		Extensions: map[string]interface{}{
+			"maxCount":     maxCount,
+			"currentCount": currentCount,
+		},
+	}
+}
+
+func TagTooLongError(tag string, maxLength int) *AppError {
+	return &AppError{
+		Code:    "TAG_TOO_LONG",
+		Message: "Tag exceeds maximum allowed length",
+		Extensions: map[string]interface{}{
+			"tag":       tag,
+			"maxLength": maxLength,
+		},
+	}
+}
+
+func UnauthenticatedError() *AppError {
+	return &AppError{
+		Code:    "UNAUTHENTICATED",
+		Message: "User is not authenticated",
+	}
+}
+
+func ForbiddenError(userID int) *AppError {
+	return &AppError{
+		Code:    "FORBIDDEN",
+		Message: "User is not allowed to perform this action",
+		Extensions: map[string]interface{}{
+			"userID": userID,
+		},
+	}
+}
+
+func InvalidPublishAtError(publishAt interface{}) *AppError {
+	return &AppError{
+		Code:    "INVALID_PUBLISH_AT",
+		Message: "publishAt must be in the future and is allowed only for scheduled posts",
+		Extensions: map[string]interface{}{
+			"publishAt": publishAt,
+		},
+	}
+}
+
+func PostAlreadyPublishedError(postID int) *AppError {
+	return &AppError{
+		Code:    "POST_ALREADY_PUBLISHED",
+		Message: "Post is already published",
+		Extensions: map[string]interface{}{
+			"postID": postID,
+		},
+	}
+}
+
+func CommentDoesNotExistError(commentID int) *AppError {
+	return &AppError{
+		Code:    "COMMENT_DOES_NOT_EXIST",
+		Message: "Comment does not exist",
+		Extensions: map[string]interface{}{
+			"commentID": commentID,
+		},
+	}
+}
+
+func ThreadLockedError(commentID int) *AppError {
+	return &AppError{
+		Code:    "THREAD_LOCKED",
+		Message: "Thread is locked for new replies",
+		Extensions: map[string]interface{}{
+			"commentID": commentID,
+		},
+	}
+}
+
+func ReplyToOtherPostError(commentID, postID int) *AppError {
+	return &AppError{
+		Code:    "REPLY_TO_OTHER_POST",
+		Message: "Parent comment belongs to another post",
+		Extensions: map[string]interface{}{
+			"commentID": commentID,
+			"postID":    postID,
+		},
+	}
+}
+
+func CannotPinReplyError(commentID int) *AppError {
+	return &AppError{
+		Code:    "CANNOT_PIN_REPLY",
+		Message: "Only top-level comments can be pinned",
+		Extensions: map[string]interface{}{
+			"commentID": commentID,
+		},
+	}
+}
+
+func ContentRejectedError(filter, reason string) *AppError {
+	return &AppError{
+		Code:    "CONTENT_REJECTED",
+		Message: "Content was rejected by moderation",
+		Extensions: map[string]interface{}{
+			"filter": filter,
+			"reason": reason,
+		},
+	}
+}
+
+func ModerationItemDoesNotExistError(itemID int) *AppError {
+	return &AppError{
+		Code:    "MODERATION_ITEM_DOES_NOT_EXIST",
+		Message: "Moderation queue item does not exist",
+		Extensions: map[string]interface{}{
+			"itemID": itemID,
+		},
+	}
+}
+
+func ModerationItemAlreadyResolvedError(itemID int, status interface{}) *AppError {
+	return &AppError{
+		Code:    "MODERATION_ITEM_ALREADY_RESOLVED",
+		Message: "Moderation queue item is already resolved",
+		Extensions: map[string]interface{}{
+			"itemID": itemID,
+			"status": status,
+		},
+	}
+}
+
+func AlreadyReportedError(targetType interface{}, targetID int) *AppError {
+	return &AppError{
+		Code:    "ALREADY_REPORTED",
+		Message: "Content is already reported by this user",
+		Extensions: map[string]interface{}{
+			"targetType": targetType,
+			"targetID":   targetID,
+		},
+	}
+}
+
+func ReportNoteTooLongError(maxLength, currentLength int) *AppError {
+	return &AppError{
+		Code:    "REPORT_NOTE_TOO_LONG",
+		Message: "Report note exceeds maximum allowed length",
+		Extensions: map[string]interface{}{
+			"maxLength":     maxLength,
+			"currentLength": currentLength,
+		},
+	}
+}
+
+func UserBannedError(userID int, until interface{}, reason string) *AppError {
+	return &AppError{
+		Code:    "USER_BANNED",
+		Message: "User is banned from creating posts and comments",
+		Extensions: map[string]interface{}{
+			"userID": userID,
+			"until":  until,
+			"reason": reason,
+		},
+	}
+}
+
+func UserMutedError(postID, userID int, until interface{}) *AppError {
+	return &AppError{
+		Code:    "USER_MUTED",
+		Message: "User is muted on this post",
+		Extensions: map[string]interface{}{
+			"postID": postID,
+			"userID": userID,
+			"until":  until,
+		},
+	}
+}
+
+func InvalidUntilError(until interface{}) *AppError {
+	return &AppError{
+		Code:    "INVALID_UNTIL",
+		Message: "until must be in the future",
+		Extensions: map[string]interface{}{
+			"until": until,
+		},
+	}
+}
+
+func CannotBlockSelfError(userID int) *AppError {
+	return &AppError{
+		Code:    "CANNOT_BLOCK_SELF",
+		Message: "User cannot block themselves",
+		Extensions: map[string]interface{}{
+			"userID": userID,
+		},
+	}
+}
+
+func BlockedByUserError(userID int) *AppError {
+	return &AppError{
+		Code:    "BLOCKED_BY_USER",
+		Message: "User has blocked you",
+		Extensions: map[string]interface{}{
+			"userID": userID,
+		},
+	}
+}
+
+func RateLimitedError(action string, retryAfter int) *AppError {
+	return &AppError{
+		Code:    "RATE_LIMITED",
+		Message: "Too many requests, try again later",
+		Extensions: map[string]interface{}{
+			"action":     action,
+			"retryAfter": retryAfter,
+		},
+	}
+}
+
+func QueryTooComplexError(complexity, maxComplexity int) *AppError {
+	return &AppError{
+		Code:    "QUERY_TOO_COMPLEX",
+		Message: "Query exceeds maximum allowed complexity",
+		Extensions: map[string]interface{}{
+			"complexity":    complexity,
+			"maxComplexity": maxComplexity,
+		},
+	}
+}
+
+func QueryTooDeepError(depth, maxDepth int) *AppError {
+	return &AppError{
+		Code:    "QUERY_TOO_DEEP",
+		Message: "Query exceeds maximum allowed depth",
+		Extensions: map[string]interface{}{
+			"depth":    depth,
+			"maxDepth": maxDepth,
+		},
+	}
+}
+
+func PersistedQueryNotFoundError(hash string) *AppError {
+	return &AppError{
+		Code:    "PERSISTED_QUERY_NOT_FOUND",
+		Message: "PersistedQueryNotFound",
+		Extensions: map[string]interface{}{
+			"sha256Hash": hash,
+		},
+	}
+}
+
+func QueryNotAllowedError(hash string) *AppError {
+	return &AppError{
+		Code:    "QUERY_NOT_ALLOWED",
+		Message: "Only persisted queries from the manifest are allowed",
+		Extensions: map[string]interface{}{
+			"sha256Hash": hash,
+		},
+	}
+}
+
+func ConflictError(field string) *AppError {
+	return &AppError{
+		Code:    "CONFLICT",
+		Message: "Resource already exists",
+		Extensions: map[string]interface{}{
+			"field": field,
+		},
+	}
+}
+
+func ReferenceDoesNotExistError(field string) *AppError {
+	return &AppError{
+		Code:    "REFERENCE_DOES_NOT_EXIST",
+		Message: "Referenced resource does not exist",
+		Extensions: map[string]interface{}{
+			"field": field,
+		},
+	}
+}
+
+func WebhookDoesNotExistError(webhookID int) *AppError {
+	return &AppError{
+		Code:    "WEBHOOK_DOES_NOT_EXIST",
+		Message: "Webhook does not exist",
+		Extensions: map[string]interface{}{
+			"webhookID": webhookID,
+		},
+	}
+}
+
+func WebhookDeliveryDoesNotExistError(deliveryID int) *AppError {
+	return &AppError{
+		Code:    "WEBHOOK_DELIVERY_DOES_NOT_EXIST",
+		Message: "Webhook delivery does not exist",
+		Extensions: map[string]interface{}{
+			"deliveryID": deliveryID,
+		},
+	}
+}
+
+func InvalidWebhookError(field, reason string) *AppError {
+	return &AppError{
+		Code:    "INVALID_WEBHOOK",
+		Message: "Webhook is invalid",
+		Extensions: map[string]interface{}{
+			"field":  field,
+			"reason": reason,
+		},
+	}
+}
+
+func InvalidRequestError(field, reason string) *AppError {
+	return &AppError{
+		Code:    "INVALID_REQUEST",
+		Message: "Request is invalid",
+		Extensions: map[string]interface{}{
+			"field":  field,
+			"reason": reason,
+		},
+	}
+}
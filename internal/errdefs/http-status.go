@@ -0,0 +1,50 @@
+package errdefs
+
+import (
+	"errors"
+	"net/http"
+)
+
+// httpStatuses сопоставляет коды ошибок со статусами HTTP для REST API. Коды не из списка считаются ошибкой клиента
+var httpStatuses = map[string]int{
+	"INTERNAL_SERVER_ERROR": http.StatusInternalServerError,
+
+	"UNAUTHENTICATED": http.StatusUnauthorized,
+
+	"FORBIDDEN":       http.StatusForbidden,
+	"USER_BANNED":     http.StatusForbidden,
+	"USER_MUTED":      http.StatusForbidden,
+	"BLOCKED_BY_USER": http.StatusForbidden,
+
+	"THREAD_LOCKED":        http.StatusForbidden,
+	"COMMENTS_NOT_ALLOWED": http.StatusForbidden,
+
+	"POST_DOES_NOT_EXIST":             http.StatusNotFound,
+	"COMMENT_DOES_NOT_EXIST":          http.StatusNotFound,
+	"USER_DOES_NOT_EXIST":             http.StatusNotFound,
+	"MODERATION_ITEM_DOES_NOT_EXIST":  http.StatusNotFound,
+	"WEBHOOK_DOES_NOT_EXIST":          http.StatusNotFound,
+	"WEBHOOK_DELIVERY_DOES_NOT_EXIST": http.StatusNotFound,
+
+	"CONFLICT":                         http.StatusConflict,
+	"ALREADY_REPORTED":                 http.StatusConflict,
+	"POST_ALREADY_PUBLISHED":           http.StatusConflict,
+	"MODERATION_ITEM_ALREADY_RESOLVED": http.StatusConflict,
+
+	"REFERENCE_DOES_NOT_EXIST": http.StatusUnprocessableEntity,
+	"CONTENT_REJECTED":         http.StatusUnprocessableEntity,
+
+	"RATE_LIMITED": http.StatusTooManyRequests,
+}
+
+// HTTPStatus возвращает статус HTTP для ошибки сервиса. Ошибки, не являющиеся AppError, - внутренние
+func HTTPStatus(err error) int {
+	var appErr *AppError
+	if !errors.As(err, &appErr) {
+		return http.StatusInternalServerError
+	}
+	if status, ok := httpStatuses[appErr.Code]; ok {
+		return status
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,210 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+// lockID - ключ advisory lock, под которым выполняются миграции. Реплики, стартующие одновременно,
+// ждут, пока первая из них не закончит накатывать миграции
+const lockID int64 = 7_146_205_318
+
+// Таблица версий совместима с golang-migrate, поэтому базы, размеченные отдельным контейнером migrate,
+// продолжают работать без ручных действий
+const (
+	createVersionTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`
+	selectVersionQuery      = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+	deleteVersionQuery      = `DELETE FROM schema_migrations`
+	insertVersionQuery      = `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`
+	lockQuery               = `SELECT pg_advisory_lock($1)`
+	unlockQuery             = `SELECT pg_advisory_unlock($1)`
+)
+
+var (
+	ErrDirty          = errors.New("database is in dirty state, fix the last migration manually")
+	ErrUnknownVersion = errors.New("database version is not among known migrations")
+)
+
+// Conn - соединение, на котором выполняются миграции. Advisory lock сессионный, поэтому все запросы
+// должны идти через одно соединение, а не через пул
+type Conn interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type Migrator struct {
+	log        *zap.Logger
+	conn       Conn
+	migrations []Migration
+}
+
+func New(log *zap.Logger, conn Conn, migrations []Migration) *Migrator {
+	return &Migrator{log: log, conn: conn, migrations: migrations}
+}
+
+// Status - текущая версия схемы и миграции, которые ещё не применены
+type Status struct {
+	Version int
+	Dirty   bool
+	Pending []Migration
+}
+
+// Up применяет все непримененные миграции по порядку, каждую в своей транзакции. Возвращает число применённых миграций
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	log := m.log.With(zap.String("Layer", "Migrator.Up"))
+
+	applied := 0
+	err := m.withLock(ctx, func() error {
+		version, err := m.version(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err = m.apply(ctx, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %s up: %w", migration, err)
+			}
+			log.Info("Migration applied", zap.String("Migration", migration.String()))
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down откатывает steps последних применённых миграций. Возвращает число откаченных миграций
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	log := m.log.With(zap.String("Layer", "Migrator.Down"), zap.Int("Steps", steps))
+
+	reverted := 0
+	err := m.withLock(ctx, func() error {
+		version, err := m.version(ctx)
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			return nil
+		}
+		idx := m.index(version)
+		if idx < 0 {
+			return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+		}
+		for ; idx >= 0 && reverted < steps; idx-- {
+			migration := m.migrations[idx]
+			if migration.Down == "" {
+				return fmt.Errorf("migration %s has no down file", migration)
+			}
+			previous := 0
+			if idx > 0 {
+				previous = m.migrations[idx-1].Version
+			}
+			if err = m.apply(ctx, migration.Down, previous); err != nil {
+				return fmt.Errorf("migration %s down: %w", migration, err)
+			}
+			log.Info("Migration reverted", zap.String("Migration", migration.String()))
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	if _, err := m.conn.Exec(ctx, createVersionTableQuery); err != nil {
+		return nil, err
+	}
+	version, dirty, err := m.readVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Version: version, Dirty: dirty}
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func() error) (err error) {
+	if _, err = m.conn.Exec(ctx, lockQuery, lockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, unlockErr := m.conn.Exec(ctx, unlockQuery, lockID); unlockErr != nil && err == nil {
+			err = fmt.Errorf("release migration lock: %w", unlockErr)
+		}
+	}()
+
+	if _, err = m.conn.Exec(ctx, createVersionTableQuery); err != nil {
+		return err
+	}
+	return fn()
+}
+
+// version возвращает текущую версию схемы, 0 - если миграции ещё не применялись
+func (m *Migrator) version(ctx context.Context) (int, error) {
+	version, dirty, err := m.readVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w (version %d)", ErrDirty, version)
+	}
+	return version, nil
+}
+
+func (m *Migrator) readVersion(ctx context.Context) (int, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := m.conn.QueryRow(ctx, selectVersionQuery).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return int(version), dirty, nil
+}
+
+// apply выполняет SQL миграции и записывает новую версию в одной транзакции, так что упавшая миграция
+// не оставляет схему в промежуточном состоянии
+func (m *Migrator) apply(ctx context.Context, sql string, version int) error {
+	tx, err := m.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, deleteVersionQuery); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err = tx.Exec(ctx, insertVersionQuery, int64(version)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (m *Migrator) index(version int) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
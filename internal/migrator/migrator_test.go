@@ -0,0 +1,221 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Quizert/PostCommentService/migrations"
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "init", Up: "CREATE TABLE a (id int)", Down: "DROP TABLE a"},
+	{Version: 2, Name: "b", Up: "CREATE TABLE b (id int)", Down: "DROP TABLE b"},
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"10_ten.up.sql":   {Data: []byte("ten up")},
+		"10_ten.down.sql": {Data: []byte("ten down")},
+		"2_two.up.sql":    {Data: []byte("two up")},
+		"README.md":       {Data: []byte("not a migration")},
+	}
+
+	loaded, err := Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, Migration{Version: 2, Name: "two", Up: "two up"}, loaded[0])
+	assert.Equal(t, Migration{Version: 10, Name: "ten", Up: "ten up", Down: "ten down"}, loaded[1])
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "missing up",
+			fsys: fstest.MapFS{"1_init.down.sql": {Data: []byte("down")}},
+		},
+		{
+			name: "different names",
+			fsys: fstest.MapFS{
+				"1_init.up.sql":  {Data: []byte("up")},
+				"1_other.up.sql": {Data: []byte("up")},
+			},
+		},
+		{
+			name: "zero version",
+			fsys: fstest.MapFS{"0_init.up.sql": {Data: []byte("up")}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(tc.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+	for i, migration := range loaded {
+		assert.Equal(t, i+1, migration.Version, "versions must be consecutive")
+		assert.NotEmpty(t, migration.Down, "migration %s must have a down file", migration)
+	}
+}
+
+func newMock(t *testing.T) pgxmock.PgxConnIface {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	return mock
+}
+
+func expectLock(mock pgxmock.PgxConnIface) {
+	mock.ExpectExec(lockQuery).WithArgs(lockID).WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectExec(createVersionTableQuery).WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+}
+
+func expectUnlock(mock pgxmock.PgxConnIface) {
+	mock.ExpectExec(unlockQuery).WithArgs(lockID).WillReturnResult(pgxmock.NewResult("SELECT", 1))
+}
+
+func expectVersion(mock pgxmock.PgxConnIface, version int64, dirty bool) {
+	expected := mock.ExpectQuery(selectVersionQuery)
+	if version == 0 {
+		expected.WillReturnError(pgx.ErrNoRows)
+		return
+	}
+	expected.WillReturnRows(pgxmock.NewRows([]string{"version", "dirty"}).AddRow(version, dirty))
+}
+
+func expectApply(mock pgxmock.PgxConnIface, sql string, version int64) {
+	mock.ExpectBegin()
+	mock.ExpectExec(sql).WillReturnResult(pgxmock.NewResult("", 0))
+	mock.ExpectExec(deleteVersionQuery).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	if version > 0 {
+		mock.ExpectExec(insertVersionQuery).WithArgs(version).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestMigrator_Up(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fresh database", func(t *testing.T) {
+		mock := newMock(t)
+		expectLock(mock)
+		expectVersion(mock, 0, false)
+		expectApply(mock, testMigrations[0].Up, 1)
+		expectApply(mock, testMigrations[1].Up, 2)
+		expectUnlock(mock)
+
+		applied, err := New(zap.NewNop(), mock, testMigrations).Up(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("partially migrated", func(t *testing.T) {
+		mock := newMock(t)
+		expectLock(mock)
+		expectVersion(mock, 1, false)
+		expectApply(mock, testMigrations[1].Up, 2)
+		expectUnlock(mock)
+
+		applied, err := New(zap.NewNop(), mock, testMigrations).Up(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("dirty", func(t *testing.T) {
+		mock := newMock(t)
+		expectLock(mock)
+		expectVersion(mock, 1, true)
+		expectUnlock(mock)
+
+		_, err := New(zap.NewNop(), mock, testMigrations).Up(ctx)
+		assert.ErrorIs(t, err, ErrDirty)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed migration is rolled back", func(t *testing.T) {
+		mock := newMock(t)
+		expectLock(mock)
+		expectVersion(mock, 1, false)
+		mock.ExpectBegin()
+		mock.ExpectExec(testMigrations[1].Up).WillReturnError(errors.New("syntax error"))
+		mock.ExpectRollback()
+		expectUnlock(mock)
+
+		applied, err := New(zap.NewNop(), mock, testMigrations).Up(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, 0, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrator_Down(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("one step", func(t *testing.T) {
+		mock := newMock(t)
+		expectLock(mock)
+		expectVersion(mock, 2, false)
+		expectApply(mock, testMigrations[1].Down, 1)
+		expectUnlock(mock)
+
+		reverted, err := New(zap.NewNop(), mock, testMigrations).Down(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, reverted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("more steps than applied", func(t *testing.T) {
+		mock := newMock(t)
+		expectLock(mock)
+		expectVersion(mock, 2, false)
+		expectApply(mock, testMigrations[1].Down, 1)
+		expectApply(mock, testMigrations[0].Down, 0)
+		expectUnlock(mock)
+
+		reverted, err := New(zap.NewNop(), mock, testMigrations).Down(ctx, 5)
+		require.NoError(t, err)
+		assert.Equal(t, 2, reverted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		mock := newMock(t)
+		expectLock(mock)
+		expectVersion(mock, 42, false)
+		expectUnlock(mock)
+
+		_, err := New(zap.NewNop(), mock, testMigrations).Down(ctx, 1)
+		assert.ErrorIs(t, err, ErrUnknownVersion)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrator_Status(t *testing.T) {
+	mock := newMock(t)
+	mock.ExpectExec(createVersionTableQuery).WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+	expectVersion(mock, 1, false)
+
+	status, err := New(zap.NewNop(), mock, testMigrations).Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Version)
+	assert.False(t, status.Dirty)
+	assert.Equal(t, []Migration{testMigrations[1]}, status.Pending)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,53 +3,524 @@
 package models
 
 import (
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
 type Comment struct {
-	ID        int        `json:"id"`
-	Payload   string     `json:"payload"`
-	PostID    int        `json:"postID"`
-	Author    *User      `json:"author"`
-	ReplyTo   *int       `json:"replyTo,omitempty"`
-	Replies   []*Comment `json:"replies,omitempty"`
-	CreatedAt time.Time  `json:"createdAt"`
+	ID          int           `json:"id"`
+	Payload     string        `json:"payload"`
+	Format      ContentFormat `json:"format"`
+	PayloadHTML string        `json:"payloadHTML"`
+	PostID      int           `json:"postID"`
+	Author      *User         `json:"author"`
+	ReplyTo     *int          `json:"replyTo,omitempty"`
+	Replies     []*Comment    `json:"replies,omitempty"`
+	IsPinned    bool          `json:"isPinned"`
+	IsLocked    bool          `json:"isLocked"`
+	IsHidden    bool          `json:"isHidden"`
+	EditedAt    *time.Time    `json:"editedAt,omitempty"`
+	Revisions   []*Revision   `json:"revisions,omitempty"`
+	Mentions    []*User       `json:"mentions,omitempty"`
+	CreatedAt   time.Time     `json:"createdAt"`
+}
+
+type EditPost struct {
+	Title   *string `json:"title,omitempty"`
+	Payload *string `json:"payload,omitempty"`
+}
+
+type EditWebhook struct {
+	URL        *string            `json:"url,omitempty"`
+	Secret     *string            `json:"secret,omitempty"`
+	EventTypes []WebhookEventType `json:"eventTypes,omitempty"`
+	IsActive   *bool              `json:"isActive,omitempty"`
+}
+
+type ModerationItem struct {
+	ID             int              `json:"id"`
+	TargetType     TargetType       `json:"targetType"`
+	TargetID       int              `json:"targetID"`
+	Title          *string          `json:"title,omitempty"`
+	Payload        string           `json:"payload"`
+	Author         *User            `json:"author"`
+	Filter         *string          `json:"filter,omitempty"`
+	Reason         string           `json:"reason"`
+	Status         ModerationStatus `json:"status"`
+	Moderator      *User            `json:"moderator,omitempty"`
+	DecisionReason *string          `json:"decisionReason,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	DecidedAt      *time.Time       `json:"decidedAt,omitempty"`
+	WasPublished   bool             `json:"wasPublished"`
+}
+
+type ModerationQueueConnection struct {
+	Nodes       []*ModerationItem `json:"nodes"`
+	EndCursor   *int              `json:"endCursor,omitempty"`
+	HasNextPage bool              `json:"hasNextPage"`
 }
 
 type Mutation struct {
 }
 
 type NewComment struct {
-	Payload  string `json:"payload"`
-	PostID   int    `json:"postID"`
-	AuthorID int    `json:"authorID"`
-	ReplyTo  *int   `json:"replyTo,omitempty"`
+	Payload  string         `json:"payload"`
+	Format   *ContentFormat `json:"format,omitempty"`
+	PostID   int            `json:"postID"`
+	AuthorID int            `json:"authorID"`
+	ReplyTo  *int           `json:"replyTo,omitempty"`
 }
 
 type NewPost struct {
-	Title             string `json:"title"`
-	Payload           string `json:"payload"`
-	AuthorID          int    `json:"authorID"`
-	IsCommentsAllowed bool   `json:"IsCommentsAllowed"`
+	Title             string         `json:"title"`
+	Payload           string         `json:"payload"`
+	AuthorID          int            `json:"authorID"`
+	IsCommentsAllowed bool           `json:"IsCommentsAllowed"`
+	Format            *ContentFormat `json:"format,omitempty"`
+	Tags              []string       `json:"tags,omitempty"`
+	Status            *PostStatus    `json:"status,omitempty"`
+	PublishAt         *time.Time     `json:"publishAt,omitempty"`
+}
+
+type NewWebhook struct {
+	URL        string             `json:"url"`
+	Secret     string             `json:"secret"`
+	EventTypes []WebhookEventType `json:"eventTypes"`
+}
+
+type NotificationConnection struct {
+	Nodes       []*Notification `json:"nodes"`
+	EndCursor   *int            `json:"endCursor,omitempty"`
+	HasNextPage bool            `json:"hasNextPage"`
 }
 
 type Post struct {
-	ID                int        `json:"id"`
-	Title             string     `json:"title"`
-	Payload           string     `json:"payload"`
-	Author            *User      `json:"author"`
-	IsCommentsAllowed bool       `json:"isCommentsAllowed"`
-	Comments          []*Comment `json:"comments,omitempty"`
-	CreatedAt         time.Time  `json:"createdAt"`
+	ID                int           `json:"id"`
+	Title             string        `json:"title"`
+	Payload           string        `json:"payload"`
+	Format            ContentFormat `json:"format"`
+	PayloadHTML       string        `json:"payloadHTML"`
+	Author            *User         `json:"author"`
+	IsCommentsAllowed bool          `json:"isCommentsAllowed"`
+	Comments          []*Comment    `json:"comments,omitempty"`
+	Tags              []string      `json:"tags"`
+	Status            PostStatus    `json:"status"`
+	IsHidden          bool          `json:"isHidden"`
+	PublishAt         *time.Time    `json:"publishAt,omitempty"`
+	EditedAt          *time.Time    `json:"editedAt,omitempty"`
+	Revisions         []*Revision   `json:"revisions,omitempty"`
+	CreatedAt         time.Time     `json:"createdAt"`
+}
+
+type PostMute struct {
+	PostID    int        `json:"postID"`
+	User      *User      `json:"user"`
+	Until     *time.Time `json:"until,omitempty"`
+	MutedBy   *User      `json:"mutedBy"`
+	CreatedAt time.Time  `json:"createdAt"`
 }
 
 type Query struct {
 }
 
+type Report struct {
+	ID         int          `json:"id"`
+	TargetType TargetType   `json:"targetType"`
+	TargetID   int          `json:"targetID"`
+	Reason     ReportReason `json:"reason"`
+	Note       *string      `json:"note,omitempty"`
+	Reporter   *User        `json:"reporter"`
+	CreatedAt  time.Time    `json:"createdAt"`
+}
+
+type ReportReasonCount struct {
+	Reason ReportReason `json:"reason"`
+	Count  int          `json:"count"`
+}
+
+type ReportSummary struct {
+	TargetType     TargetType           `json:"targetType"`
+	TargetID       int                  `json:"targetID"`
+	Count          int                  `json:"count"`
+	Reasons        []*ReportReasonCount `json:"reasons"`
+	LastReportedAt time.Time            `json:"lastReportedAt"`
+}
+
+type Revision struct {
+	ID       int       `json:"id"`
+	Version  int       `json:"version"`
+	Title    *string   `json:"title,omitempty"`
+	Payload  string    `json:"payload"`
+	Editor   *User     `json:"editor"`
+	EditedAt time.Time `json:"editedAt"`
+}
+
 type Subscription struct {
 }
 
-type User struct {
-	ID       int    `json:"id"`
-	Username string `json:"username"`
+type UserBan struct {
+	User      *User      `json:"user"`
+	Until     *time.Time `json:"until,omitempty"`
+	Reason    string     `json:"reason"`
+	Moderator *User      `json:"moderator"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type WebhookDelivery struct {
+	ID             int                   `json:"id"`
+	WebhookID      int                   `json:"webhookID"`
+	EventID        int                   `json:"eventID"`
+	EventType      WebhookEventType      `json:"eventType"`
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus *int                  `json:"responseStatus,omitempty"`
+	LastError      *string               `json:"lastError,omitempty"`
+	NextAttemptAt  time.Time             `json:"nextAttemptAt"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+}
+
+type ContentFormat string
+
+const (
+	ContentFormatPlain    ContentFormat = "PLAIN"
+	ContentFormatMarkdown ContentFormat = "MARKDOWN"
+)
+
+var AllContentFormat = []ContentFormat{
+	ContentFormatPlain,
+	ContentFormatMarkdown,
+}
+
+func (e ContentFormat) IsValid() bool {
+	switch e {
+	case ContentFormatPlain, ContentFormatMarkdown:
+		return true
+	}
+	return false
+}
+
+func (e ContentFormat) String() string {
+	return string(e)
+}
+
+func (e *ContentFormat) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ContentFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ContentFormat", str)
+	}
+	return nil
+}
+
+func (e ContentFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ModerationStatus string
+
+const (
+	ModerationStatusPending  ModerationStatus = "PENDING"
+	ModerationStatusApproved ModerationStatus = "APPROVED"
+	ModerationStatusRejected ModerationStatus = "REJECTED"
+)
+
+var AllModerationStatus = []ModerationStatus{
+	ModerationStatusPending,
+	ModerationStatusApproved,
+	ModerationStatusRejected,
+}
+
+func (e ModerationStatus) IsValid() bool {
+	switch e {
+	case ModerationStatusPending, ModerationStatusApproved, ModerationStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e ModerationStatus) String() string {
+	return string(e)
+}
+
+func (e *ModerationStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ModerationStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ModerationStatus", str)
+	}
+	return nil
+}
+
+func (e ModerationStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type NotificationType string
+
+const (
+	NotificationTypeReply       NotificationType = "REPLY"
+	NotificationTypePostComment NotificationType = "POST_COMMENT"
+	NotificationTypeMention     NotificationType = "MENTION"
+)
+
+var AllNotificationType = []NotificationType{
+	NotificationTypeReply,
+	NotificationTypePostComment,
+	NotificationTypeMention,
+}
+
+func (e NotificationType) IsValid() bool {
+	switch e {
+	case NotificationTypeReply, NotificationTypePostComment, NotificationTypeMention:
+		return true
+	}
+	return false
+}
+
+func (e NotificationType) String() string {
+	return string(e)
+}
+
+func (e *NotificationType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationType", str)
+	}
+	return nil
+}
+
+func (e NotificationType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type PostStatus string
+
+const (
+	PostStatusDraft     PostStatus = "DRAFT"
+	PostStatusScheduled PostStatus = "SCHEDULED"
+	PostStatusPublished PostStatus = "PUBLISHED"
+)
+
+var AllPostStatus = []PostStatus{
+	PostStatusDraft,
+	PostStatusScheduled,
+	PostStatusPublished,
+}
+
+func (e PostStatus) IsValid() bool {
+	switch e {
+	case PostStatusDraft, PostStatusScheduled, PostStatusPublished:
+		return true
+	}
+	return false
+}
+
+func (e PostStatus) String() string {
+	return string(e)
+}
+
+func (e *PostStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PostStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PostStatus", str)
+	}
+	return nil
+}
+
+func (e PostStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReportReason string
+
+const (
+	ReportReasonSpam           ReportReason = "SPAM"
+	ReportReasonAbuse          ReportReason = "ABUSE"
+	ReportReasonHarassment     ReportReason = "HARASSMENT"
+	ReportReasonMisinformation ReportReason = "MISINFORMATION"
+	ReportReasonOther          ReportReason = "OTHER"
+)
+
+var AllReportReason = []ReportReason{
+	ReportReasonSpam,
+	ReportReasonAbuse,
+	ReportReasonHarassment,
+	ReportReasonMisinformation,
+	ReportReasonOther,
+}
+
+func (e ReportReason) IsValid() bool {
+	switch e {
+	case ReportReasonSpam, ReportReasonAbuse, ReportReasonHarassment, ReportReasonMisinformation, ReportReasonOther:
+		return true
+	}
+	return false
+}
+
+func (e ReportReason) String() string {
+	return string(e)
+}
+
+func (e *ReportReason) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReportReason(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReportReason", str)
+	}
+	return nil
+}
+
+func (e ReportReason) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TargetType string
+
+const (
+	TargetTypePost    TargetType = "POST"
+	TargetTypeComment TargetType = "COMMENT"
+)
+
+var AllTargetType = []TargetType{
+	TargetTypePost,
+	TargetTypeComment,
+}
+
+func (e TargetType) IsValid() bool {
+	switch e {
+	case TargetTypePost, TargetTypeComment:
+		return true
+	}
+	return false
+}
+
+func (e TargetType) String() string {
+	return string(e)
+}
+
+func (e *TargetType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TargetType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TargetType", str)
+	}
+	return nil
+}
+
+func (e TargetType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "FAILED"
+)
+
+var AllWebhookDeliveryStatus = []WebhookDeliveryStatus{
+	WebhookDeliveryStatusPending,
+	WebhookDeliveryStatusSucceeded,
+	WebhookDeliveryStatusFailed,
+}
+
+func (e WebhookDeliveryStatus) IsValid() bool {
+	switch e {
+	case WebhookDeliveryStatusPending, WebhookDeliveryStatusSucceeded, WebhookDeliveryStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e WebhookDeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookDeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookDeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e WebhookDeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WebhookEventType string
+
+const (
+	WebhookEventTypePostPublished    WebhookEventType = "POST_PUBLISHED"
+	WebhookEventTypeCommentPublished WebhookEventType = "COMMENT_PUBLISHED"
+)
+
+var AllWebhookEventType = []WebhookEventType{
+	WebhookEventTypePostPublished,
+	WebhookEventTypeCommentPublished,
+}
+
+func (e WebhookEventType) IsValid() bool {
+	switch e {
+	case WebhookEventTypePostPublished, WebhookEventTypeCommentPublished:
+		return true
+	}
+	return false
+}
+
+func (e WebhookEventType) String() string {
+	return string(e)
+}
+
+func (e *WebhookEventType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookEventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookEventType", str)
+	}
+	return nil
+}
+
+func (e WebhookEventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Notification - событие в ленте уведомлений пользователя
+type Notification struct {
+	ID        int              `json:"id"`
+	Type      NotificationType `json:"type"`
+	Actor     *User            `json:"actor"`
+	PostID    int              `json:"postID"`
+	CommentID int              `json:"commentID"`
+	IsRead    bool             `json:"isRead"`
+	CreatedAt time.Time        `json:"createdAt"`
+	// RecipientID не отдаётся клиентам, по нему уведомление доставляется получателю
+	RecipientID int `json:"-"`
+}
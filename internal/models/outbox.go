@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// OutboxEventType - тип события в outbox
+type OutboxEventType string
+
+const (
+	// OutboxEventCommentPublished - комментарий стал виден: создан без модерации или одобрен модератором.
+	// AggregateID - id комментария
+	OutboxEventCommentPublished OutboxEventType = "comment.published"
+	// OutboxEventPostPublished - пост стал виден всем: опубликован и не скрыт модерацией. AggregateID - id поста
+	OutboxEventPostPublished OutboxEventType = "post.published"
+)
+
+// OutboxEvent - событие, записанное в одной транзакции с изменением, которое его породило.
+// Диспетчер доставляет его подписчикам, пока доставка не удастся
+type OutboxEvent struct {
+	ID          int             `json:"id"`
+	Type        OutboxEventType `json:"type"`
+	AggregateID int             `json:"aggregateID"`
+	// Attempts - число выданных диспетчеру попыток доставки, включая текущую
+	Attempts      int        `json:"attempts"`
+	LastError     *string    `json:"lastError,omitempty"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
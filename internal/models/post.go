@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// IsVisibleTo сообщает, может ли пользователь viewerID видеть пост в момент now.
+// Черновики, ещё не наступившие отложенные посты и скрытые модерацией посты видит только автор
+func (p *Post) IsVisibleTo(viewerID int, now time.Time) bool {
+	isAuthor := p.Author != nil && p.Author.ID == viewerID
+	if p.IsHidden {
+		return isAuthor
+	}
+
+	switch p.Status {
+	case PostStatusDraft:
+		return isAuthor
+	case PostStatusScheduled:
+		return isAuthor || (p.PublishAt != nil && !p.PublishAt.After(now))
+	default:
+		return true
+	}
+}
+
+// SortTime - время, по которому пост сортируется в ленте
+func (p *Post) SortTime() time.Time {
+	if p.PublishAt != nil {
+		return *p.PublishAt
+	}
+	return p.CreatedAt
+}
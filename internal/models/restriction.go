@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// IsActive сообщает, действует ли бан в момент now
+func (b *UserBan) IsActive(now time.Time) bool {
+	return b.Until == nil || b.Until.After(now)
+}
+
+// IsActive сообщает, действует ли запрет комментировать пост в момент now
+func (m *PostMute) IsActive(now time.Time) bool {
+	return m.Until == nil || m.Until.After(now)
+}
@@ -0,0 +1,19 @@
+package models
+
+type UserRole string
+
+const (
+	UserRoleUser      UserRole = "USER"
+	UserRoleModerator UserRole = "MODERATOR"
+)
+
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	// Role не отдаётся клиентам, используется только для проверки прав
+	Role UserRole `json:"-"`
+}
+
+func (u *User) IsModerator() bool {
+	return u != nil && u.Role == UserRoleModerator
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Webhook - подписка внешней системы на события контента
+type Webhook struct {
+	ID         int                `json:"id"`
+	URL        string             `json:"url"`
+	EventTypes []WebhookEventType `json:"eventTypes"`
+	IsActive   bool               `json:"isActive"`
+	CreatedAt  time.Time          `json:"createdAt"`
+	// Secret не отдаётся клиентам, им подписываются доставки
+	Secret string `json:"-"`
+}
+
+// Subscribed сообщает, подписан ли вебхук на события типа eventType
+func (w *Webhook) Subscribed(eventType WebhookEventType) bool {
+	for _, subscribed := range w.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
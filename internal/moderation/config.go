@@ -0,0 +1,84 @@
+package moderation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Config - настройки фильтров модерации, загружаются из JSON-файла
+type Config struct {
+	BannedWords      []string     `json:"bannedWords"`
+	MaxLinks         int          `json:"maxLinks"`
+	MaxRepeatedChars int          `json:"maxRepeatedChars"`
+	Spam             SpamConfig   `json:"spam"`
+	Rules            []RuleConfig `json:"rules"`
+}
+
+type SpamConfig struct {
+	MinLetters    int     `json:"minLetters"`
+	MaxUpperRatio float64 `json:"maxUpperRatio"`
+	MaxWordRatio  float64 `json:"maxWordRatio"`
+}
+
+type RuleConfig struct {
+	Name    string  `json:"name"`
+	Pattern string  `json:"pattern"`
+	Action  Verdict `json:"action"`
+	Reason  string  `json:"reason"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		MaxLinks:         3,
+		MaxRepeatedChars: 10,
+		Spam: SpamConfig{
+			MinLetters:    20,
+			MaxUpperRatio: 0.8,
+			MaxWordRatio:  0.5,
+		},
+	}
+}
+
+// LoadConfig читает конфигурацию из файла. Незаданные в файле поля берутся из DefaultConfig
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultConfig()
+	if err = json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse moderation config: %w", err)
+	}
+	return cfg, nil
+}
+
+// NewChainFromConfig собирает цепочку фильтров: сначала дешёвые проверки, затем правила из конфигурации
+func NewChainFromConfig(cfg *Config) (*Chain, error) {
+	rules := make([]RegexRule, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		if rule.Action != VerdictReject && rule.Action != VerdictHold {
+			return nil, fmt.Errorf("rule %q: action must be %s or %s", rule.Name, VerdictReject, VerdictHold)
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		rules = append(rules, RegexRule{
+			Name:    rule.Name,
+			Pattern: pattern,
+			Verdict: rule.Action,
+			Reason:  rule.Reason,
+		})
+	}
+
+	return NewChain(
+		NewBannedWordsFilter(cfg.BannedWords),
+		NewLinkLimitFilter(cfg.MaxLinks),
+		NewRepeatedCharsFilter(cfg.MaxRepeatedChars),
+		NewSpamFilter(cfg.Spam.MinLetters, cfg.Spam.MaxUpperRatio, cfg.Spam.MaxWordRatio),
+		NewRegexFilter(rules),
+	), nil
+}
@@ -0,0 +1,173 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var wordRegexp = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+var linkRegexp = regexp.MustCompile(`(?i)\bhttps?://|\bwww\.`)
+
+// BannedWordsFilter отклоняет контент, содержащий слово из списка. Сравнение без учёта регистра и по целым словам
+type BannedWordsFilter struct {
+	words map[string]bool
+}
+
+func NewBannedWordsFilter(words []string) *BannedWordsFilter {
+	filter := &BannedWordsFilter{words: make(map[string]bool, len(words))}
+	for _, word := range words {
+		filter.words[strings.ToLower(word)] = true
+	}
+	return filter
+}
+
+func (f *BannedWordsFilter) Name() string {
+	return "banned_words"
+}
+
+func (f *BannedWordsFilter) Check(content Content) Decision {
+	for _, word := range wordRegexp.FindAllString(content.Text(), -1) {
+		if f.words[strings.ToLower(word)] {
+			return Decision{Verdict: VerdictReject, Filter: f.Name(), Reason: "content contains a banned word"}
+		}
+	}
+	return Allow()
+}
+
+// LinkLimitFilter отклоняет контент, в котором ссылок больше maxLinks
+type LinkLimitFilter struct {
+	maxLinks int
+}
+
+func NewLinkLimitFilter(maxLinks int) *LinkLimitFilter {
+	return &LinkLimitFilter{maxLinks: maxLinks}
+}
+
+func (f *LinkLimitFilter) Name() string {
+	return "link_limit"
+}
+
+func (f *LinkLimitFilter) Check(content Content) Decision {
+	count := len(linkRegexp.FindAllStringIndex(content.Text(), -1))
+	if count > f.maxLinks {
+		return Decision{
+			Verdict: VerdictReject,
+			Filter:  f.Name(),
+			Reason:  fmt.Sprintf("content contains %d links, at most %d allowed", count, f.maxLinks),
+		}
+	}
+	return Allow()
+}
+
+// RepeatedCharsFilter отправляет на проверку контент, где один символ повторяется подряд больше maxRun раз
+type RepeatedCharsFilter struct {
+	maxRun int
+}
+
+func NewRepeatedCharsFilter(maxRun int) *RepeatedCharsFilter {
+	return &RepeatedCharsFilter{maxRun: maxRun}
+}
+
+func (f *RepeatedCharsFilter) Name() string {
+	return "repeated_chars"
+}
+
+func (f *RepeatedCharsFilter) Check(content Content) Decision {
+	var prev rune
+	run := 0
+	for _, r := range content.Text() {
+		if r == prev && !unicode.IsSpace(r) {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		if run > f.maxRun {
+			return Decision{Verdict: VerdictHold, Filter: f.Name(), Reason: "content contains too many repeated characters"}
+		}
+	}
+	return Allow()
+}
+
+// SpamFilter - эвристики спама: текст набран заглавными буквами или состоит из одного повторяющегося слова.
+// Короткие тексты не проверяются, чтобы не задевать "OK" и подобное
+type SpamFilter struct {
+	minLetters    int
+	maxUpperRatio float64
+	maxWordRatio  float64
+}
+
+func NewSpamFilter(minLetters int, maxUpperRatio, maxWordRatio float64) *SpamFilter {
+	return &SpamFilter{
+		minLetters:    minLetters,
+		maxUpperRatio: maxUpperRatio,
+		maxWordRatio:  maxWordRatio,
+	}
+}
+
+func (f *SpamFilter) Name() string {
+	return "spam"
+}
+
+func (f *SpamFilter) Check(content Content) Decision {
+	text := content.Text()
+
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters < f.minLetters {
+		return Allow()
+	}
+	if float64(upper)/float64(letters) > f.maxUpperRatio {
+		return Decision{Verdict: VerdictHold, Filter: f.Name(), Reason: "content is written mostly in capital letters"}
+	}
+
+	words := wordRegexp.FindAllString(strings.ToLower(text), -1)
+	counts := make(map[string]int, len(words))
+	for _, word := range words {
+		counts[word]++
+		if len(words) >= 5 && float64(counts[word])/float64(len(words)) > f.maxWordRatio {
+			return Decision{Verdict: VerdictHold, Filter: f.Name(), Reason: "content repeats the same word"}
+		}
+	}
+	return Allow()
+}
+
+// RegexRule - правило из файла конфигурации модерации
+type RegexRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Verdict Verdict
+	Reason  string
+}
+
+// RegexFilter применяет правила по порядку, срабатывает первое совпавшее
+type RegexFilter struct {
+	rules []RegexRule
+}
+
+func NewRegexFilter(rules []RegexRule) *RegexFilter {
+	return &RegexFilter{rules: rules}
+}
+
+func (f *RegexFilter) Name() string {
+	return "regex"
+}
+
+func (f *RegexFilter) Check(content Content) Decision {
+	text := content.Text()
+	for _, rule := range f.rules {
+		if rule.Pattern.MatchString(text) {
+			return Decision{Verdict: rule.Verdict, Filter: f.Name() + ":" + rule.Name, Reason: rule.Reason}
+		}
+	}
+	return Allow()
+}
@@ -0,0 +1,74 @@
+package moderation
+
+type Verdict string
+
+const (
+	VerdictAllow  Verdict = "ALLOW"
+	VerdictReject Verdict = "REJECT"
+	VerdictHold   Verdict = "HOLD"
+)
+
+type ContentType string
+
+const (
+	ContentTypePost    ContentType = "POST"
+	ContentTypeComment ContentType = "COMMENT"
+)
+
+// Content - проверяемый пост или комментарий. Title пуст у комментариев
+type Content struct {
+	Type     ContentType
+	AuthorID int
+	Title    string
+	Payload  string
+}
+
+// Text возвращает весь проверяемый текст контента
+func (c Content) Text() string {
+	if c.Title == "" {
+		return c.Payload
+	}
+	return c.Title + "\n" + c.Payload
+}
+
+// Decision - результат проверки. Filter и Reason заполняются для REJECT и HOLD
+type Decision struct {
+	Verdict Verdict
+	Filter  string
+	Reason  string
+}
+
+func Allow() Decision {
+	return Decision{Verdict: VerdictAllow}
+}
+
+type Filter interface {
+	Name() string
+	Check(content Content) Decision
+}
+
+// Chain прогоняет контент через фильтры по порядку. Первый REJECT прерывает проверку,
+// HOLD запоминается, но остальные фильтры ещё могут отклонить контент
+type Chain struct {
+	filters []Filter
+}
+
+func NewChain(filters ...Filter) *Chain {
+	return &Chain{filters: filters}
+}
+
+func (c *Chain) Check(content Content) Decision {
+	result := Allow()
+	for _, filter := range c.filters {
+		decision := filter.Check(content)
+		switch decision.Verdict {
+		case VerdictReject:
+			return decision
+		case VerdictHold:
+			if result.Verdict == VerdictAllow {
+				result = decision
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+package moderation
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		payload  string
+		expected Verdict
+	}{
+		{"banned word", NewBannedWordsFilter([]string{"Casino"}), "best CASINO ever", VerdictReject},
+		{"banned word inside another word", NewBannedWordsFilter([]string{"casino"}), "casinos", VerdictAllow},
+		{"links within limit", NewLinkLimitFilter(2), "see https://a.com and www.b.com", VerdictAllow},
+		{"too many links", NewLinkLimitFilter(2), "http://a.com https://b.com www.c.com", VerdictReject},
+		{"repeated chars", NewRepeatedCharsFilter(5), "nooooooo", VerdictHold},
+		{"repeated spaces are fine", NewRepeatedCharsFilter(5), "a          b", VerdictAllow},
+		{"caps", NewSpamFilter(10, 0.8, 0.5), "THIS IS THE BEST OFFER EVER", VerdictHold},
+		{"short caps", NewSpamFilter(10, 0.8, 0.5), "OK", VerdictAllow},
+		{"same word", NewSpamFilter(10, 0.8, 0.5), "buy buy buy buy buy now please", VerdictHold},
+		{"normal text", NewSpamFilter(10, 0.8, 0.5), "Thanks for the detailed explanation", VerdictAllow},
+		{
+			"regex rule",
+			NewRegexFilter([]RegexRule{{Name: "phone", Pattern: regexp.MustCompile(`\d{10}`), Verdict: VerdictHold}}),
+			"call 8005553535",
+			VerdictHold,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := tt.filter.Check(Content{Type: ContentTypeComment, Payload: tt.payload})
+			assert.Equal(t, tt.expected, decision.Verdict)
+			if tt.expected != VerdictAllow {
+				assert.True(t, strings.HasPrefix(decision.Filter, tt.filter.Name()))
+			}
+		})
+	}
+}
+
+func TestChain_RejectWinsOverHold(t *testing.T) {
+	chain := NewChain(
+		NewRepeatedCharsFilter(3),
+		NewBannedWordsFilter([]string{"spam"}),
+	)
+
+	decision := chain.Check(Content{Payload: "spam!!!!!"})
+	assert.Equal(t, VerdictReject, decision.Verdict)
+	assert.Equal(t, "banned_words", decision.Filter)
+
+	decision = chain.Check(Content{Payload: "wow!!!!!"})
+	assert.Equal(t, VerdictHold, decision.Verdict)
+
+	decision = chain.Check(Content{Title: "spam", Payload: "ok"})
+	assert.Equal(t, VerdictReject, decision.Verdict, "заголовок поста тоже проверяется")
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moderation.json")
+	err := os.WriteFile(path, []byte(`{
+		"bannedWords": ["scam"],
+		"rules": [{"name": "crypto", "pattern": "(?i)free btc", "action": "REJECT", "reason": "crypto scam"}]
+	}`), 0o600)
+	require.NoError(t, err)
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig().MaxLinks, cfg.MaxLinks, "незаданные поля берутся по умолчанию")
+
+	chain, err := NewChainFromConfig(cfg)
+	require.NoError(t, err)
+
+	decision := chain.Check(Content{Payload: "get FREE BTC now"})
+	assert.Equal(t, Decision{Verdict: VerdictReject, Filter: "regex:crypto", Reason: "crypto scam"}, decision)
+
+	cfg.Rules = append(cfg.Rules, RuleConfig{Name: "bad", Pattern: "x", Action: VerdictAllow})
+	_, err = NewChainFromConfig(cfg)
+	assert.Error(t, err)
+}
+
+func TestDefaultConfigFile(t *testing.T) {
+	cfg, err := LoadConfig("../../configs/moderation.json")
+	require.NoError(t, err)
+
+	_, err = NewChainFromConfig(cfg)
+	require.NoError(t, err)
+}
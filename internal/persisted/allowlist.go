@@ -0,0 +1,57 @@
+package persisted
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// AllowList выполняет только запросы из манифеста. Клиент может прислать хэш запроса в extensions.persistedQuery,
+// как при APQ, или полный текст запроса - тогда он должен совпадать с одним из запросов манифеста.
+// Регистрация новых запросов, в отличие от APQ, невозможна
+type AllowList struct {
+	Manifest *Manifest
+}
+
+var _ interface {
+	graphql.OperationParameterMutator
+	graphql.HandlerExtension
+} = AllowList{}
+
+func (a AllowList) ExtensionName() string {
+	return "PersistedQueryAllowList"
+}
+
+func (a AllowList) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (a AllowList) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	hash := persistedQueryHash(rawParams.Extensions)
+	if hash == "" {
+		if _, ok := a.Manifest.Get(Hash(rawParams.Query)); !ok {
+			return errdefs.HandleError(errdefs.QueryNotAllowedError(Hash(rawParams.Query)))
+		}
+		return nil
+	}
+
+	query, ok := a.Manifest.Get(hash)
+	if !ok {
+		return errdefs.HandleError(errdefs.PersistedQueryNotFoundError(hash))
+	}
+	if rawParams.Query != "" && rawParams.Query != query {
+		return errdefs.HandleError(errdefs.QueryNotAllowedError(Hash(rawParams.Query)))
+	}
+	rawParams.Query = query
+	return nil
+}
+
+func persistedQueryHash(extensions map[string]interface{}) string {
+	persistedQuery, ok := extensions["persistedQuery"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	hash, _ := persistedQuery["sha256Hash"].(string)
+	return hash
+}
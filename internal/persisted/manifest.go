@@ -0,0 +1,78 @@
+package persisted
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Manifest - разрешённые запросы по sha256 их текста. Формат файла совместим с Apollo persisted query manifest
+type Manifest struct {
+	queries map[string]string
+}
+
+type manifestFile struct {
+	Format     string              `json:"format"`
+	Version    int                 `json:"version"`
+	Operations []manifestOperation `json:"operations"`
+}
+
+type manifestOperation struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Body string `json:"body"`
+}
+
+// LoadManifest читает манифест из файла. id операции можно не указывать, тогда он вычисляется по body;
+// указанный id должен совпадать с sha256 body
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file manifestFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse persisted query manifest: %w", err)
+	}
+
+	queries := make(map[string]string, len(file.Operations))
+	for _, operation := range file.Operations {
+		if operation.Body == "" {
+			return nil, fmt.Errorf("persisted query %q has empty body", operation.Name)
+		}
+		hash := Hash(operation.Body)
+		if operation.ID != "" && operation.ID != hash {
+			return nil, fmt.Errorf("persisted query %q: id %s does not match sha256 of body %s", operation.Name, operation.ID, hash)
+		}
+		queries[hash] = operation.Body
+	}
+	return &Manifest{queries: queries}, nil
+}
+
+// NewManifest собирает манифест из текстов запросов
+func NewManifest(queries ...string) *Manifest {
+	manifest := &Manifest{queries: make(map[string]string, len(queries))}
+	for _, query := range queries {
+		manifest.queries[Hash(query)] = query
+	}
+	return manifest
+}
+
+func (m *Manifest) Get(hash string) (string, bool) {
+	query, ok := m.queries[hash]
+	return query, ok
+}
+
+func (m *Manifest) Len() int {
+	return len(m.queries)
+}
+
+// Hash - sha256 текста запроса в hex, как в расширении persistedQuery
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,152 @@
+package persisted
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/Quizert/PostCommentService/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const typenameQuery = `query Typename { __typename }`
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newServer(manifest *Manifest) http.Handler {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(AllowList{Manifest: manifest})
+	return srv
+}
+
+func post(t *testing.T, srv http.Handler, params map[string]interface{}) response {
+	body, err := json.Marshal(params)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+
+	var resp response
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return resp
+}
+
+func persistedQuery(hash string) map[string]interface{} {
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hash},
+	}
+}
+
+func writeManifest(t *testing.T, operations ...manifestOperation) string {
+	data, err := json.Marshal(manifestFile{Format: "apollo-persisted-query-manifest", Version: 1, Operations: operations})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestLoadManifest(t *testing.T) {
+	path := writeManifest(t,
+		manifestOperation{ID: Hash(typenameQuery), Name: "Typename", Type: "query", Body: typenameQuery},
+		manifestOperation{Name: "Posts", Type: "query", Body: `query Posts { GetAllPosts { id } }`},
+	)
+
+	manifest, err := LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, manifest.Len())
+	query, ok := manifest.Get(Hash(typenameQuery))
+	assert.True(t, ok)
+	assert.Equal(t, typenameQuery, query)
+}
+
+func TestLoadManifest_Invalid(t *testing.T) {
+	testCases := []struct {
+		name       string
+		operations []manifestOperation
+	}{
+		{
+			name:       "id mismatch",
+			operations: []manifestOperation{{ID: Hash("other"), Name: "Typename", Body: typenameQuery}},
+		},
+		{
+			name:       "empty body",
+			operations: []manifestOperation{{Name: "Empty"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadManifest(writeManifest(t, tc.operations...))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := LoadManifest(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestAllowList(t *testing.T) {
+	srv := newServer(NewManifest(typenameQuery))
+
+	testCases := []struct {
+		name     string
+		params   map[string]interface{}
+		wantCode string
+	}{
+		{
+			name:   "known hash",
+			params: map[string]interface{}{"extensions": persistedQuery(Hash(typenameQuery))},
+		},
+		{
+			name:   "query from manifest",
+			params: map[string]interface{}{"query": typenameQuery},
+		},
+		{
+			name:     "unknown hash",
+			params:   map[string]interface{}{"extensions": persistedQuery(Hash(`{ __typename }`))},
+			wantCode: "PERSISTED_QUERY_NOT_FOUND",
+		},
+		{
+			name:     "ad-hoc query",
+			params:   map[string]interface{}{"query": `{ __typename }`},
+			wantCode: "QUERY_NOT_ALLOWED",
+		},
+		{
+			name: "query does not match hash",
+			params: map[string]interface{}{
+				"query":      `{ __typename }`,
+				"extensions": persistedQuery(Hash(typenameQuery)),
+			},
+			wantCode: "QUERY_NOT_ALLOWED",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := post(t, srv, tc.params)
+			if tc.wantCode == "" {
+				assert.Empty(t, resp.Errors)
+				assert.Equal(t, "Query", resp.Data["__typename"])
+				return
+			}
+			require.Len(t, resp.Errors, 1)
+			assert.Equal(t, tc.wantCode, resp.Errors[0].Extensions["code"])
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package querylimit
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Quizert/PostCommentService/graph"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/utils"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"strings"
+)
+
+// ConfigureComplexity задаёт стоимость полей с пагинацией: поле стоит столько, сколько элементов
+// оно может вернуть (limit с учётом значения по умолчанию и MaxLimit), умноженное на стоимость одного элемента
+func ConfigureComplexity(cfg *graph.Config) {
+	cfg.Complexity.Post.Comments = func(childComplexity int, limit *int, offset *int) int {
+		return paginated(childComplexity, limit)
+	}
+	cfg.Complexity.Comment.Replies = func(childComplexity int, limit *int, offset *int) int {
+		return paginated(childComplexity, limit)
+	}
+	cfg.Complexity.Query.GetAllPosts = func(childComplexity int, limit *int, offset *int) int {
+		return paginated(childComplexity, limit)
+	}
+	cfg.Complexity.Query.PostsByTag = func(childComplexity int, tag string, limit *int, offset *int) int {
+		return paginated(childComplexity, limit)
+	}
+	cfg.Complexity.Query.Tags = func(childComplexity int, prefix string, limit *int) int {
+		return paginated(childComplexity, limit)
+	}
+	cfg.Complexity.Query.Reports = func(childComplexity int, limit *int, offset *int) int {
+		return paginated(childComplexity, limit)
+	}
+	cfg.Complexity.Query.Notifications = func(childComplexity int, unreadOnly *bool, first *int, after *int) int {
+		return paginated(childComplexity, first)
+	}
+	cfg.Complexity.Query.ModerationQueue = func(childComplexity int, status *models.ModerationStatus, first *int, after *int) int {
+		return paginated(childComplexity, first)
+	}
+	cfg.Complexity.Query.WebhookDeliveries = func(childComplexity int, webhookID int, status *models.WebhookDeliveryStatus, limit *int, offset *int) int {
+		return paginated(childComplexity, limit)
+	}
+}
+
+func paginated(childComplexity int, limit *int) int {
+	limitValue, _ := utils.ParseLimitOffset(limit, nil)
+	return 1 + limitValue*childComplexity
+}
+
+// ComplexityLimit отклоняет запросы, стоимость которых больше Max. Max <= 0 отключает проверку
+type ComplexityLimit struct {
+	Max int
+
+	schema graphql.ExecutableSchema
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = &ComplexityLimit{}
+
+func (c *ComplexityLimit) ExtensionName() string {
+	return "QueryComplexityLimit"
+}
+
+func (c *ComplexityLimit) Validate(schema graphql.ExecutableSchema) error {
+	c.schema = schema
+	return nil
+}
+
+func (c *ComplexityLimit) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if c.Max <= 0 {
+		return nil
+	}
+	value := complexity.Calculate(c.schema, opCtx.Operation, opCtx.Variables)
+	if value > c.Max {
+		return errdefs.HandleError(errdefs.QueryTooComplexError(value, c.Max))
+	}
+	return nil
+}
+
+// DepthLimit отклоняет запросы с вложенностью полей больше Max. Поля интроспекции не учитываются,
+// чтобы работали playground и генераторы клиентов. Max <= 0 отключает проверку
+type DepthLimit struct {
+	Max int
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = &DepthLimit{}
+
+func (d *DepthLimit) ExtensionName() string {
+	return "QueryDepthLimit"
+}
+
+func (d *DepthLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d *DepthLimit) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if d.Max <= 0 || opCtx.Operation == nil {
+		return nil
+	}
+	depth := Depth(opCtx.Operation.SelectionSet)
+	if depth > d.Max {
+		return errdefs.HandleError(errdefs.QueryTooDeepError(depth, d.Max))
+	}
+	return nil
+}
+
+// Depth возвращает глубину вложенности полей. Фрагменты раскрываются и своего уровня не добавляют
+func Depth(selectionSet ast.SelectionSet) int {
+	maxDepth := 0
+	for _, selection := range selectionSet {
+		depth := 0
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			depth = 1 + Depth(selection.SelectionSet)
+		case *ast.InlineFragment:
+			depth = Depth(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				depth = Depth(selection.Definition.SelectionSet)
+			}
+		}
+		maxDepth = max(maxDepth, depth)
+	}
+	return maxDepth
+}
@@ -0,0 +1,118 @@
+package querylimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/Quizert/PostCommentService/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// newServer собирает сервер без резолверов: запросы, прошедшие проверки, в тестах используют только __typename
+func newServer(maxComplexity, maxDepth int) http.Handler {
+	cfg := graph.Config{}
+	ConfigureComplexity(&cfg)
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(&ComplexityLimit{Max: maxComplexity})
+	srv.Use(&DepthLimit{Max: maxDepth})
+	return srv
+}
+
+func post(t *testing.T, srv http.Handler, query string) response {
+	body, err := json.Marshal(map[string]string{"query": query})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+
+	var resp response
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return resp
+}
+
+func TestComplexityLimit(t *testing.T) {
+	srv := newServer(1000, 0)
+
+	// 1 + 30 * (1 + 30 * (1 + 30 * 1)) = 27931
+	resp := post(t, srv, `{ GetAllPosts(limit: 30) { comments(limit: 30) { replies(limit: 30) { id } } } }`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "QUERY_TOO_COMPLEX", resp.Errors[0].Extensions["code"])
+	details := resp.Errors[0].Extensions["details"].(map[string]interface{})
+	assert.EqualValues(t, 27931, details["complexity"])
+	assert.EqualValues(t, 1000, details["maxComplexity"])
+
+	resp = post(t, srv, `{ __typename }`)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, "Query", resp.Data["__typename"])
+}
+
+func TestComplexityLimit_DefaultLimit(t *testing.T) {
+	// Без limit поле стоит DefaultLimit элементов: 1 + 10 * (1 + 10 * 1) = 111
+	resp := post(t, newServer(110, 0), `{ GetAllPosts { comments { id } } }`)
+	require.Len(t, resp.Errors, 1)
+	details := resp.Errors[0].Extensions["details"].(map[string]interface{})
+	assert.EqualValues(t, 111, details["complexity"])
+}
+
+func TestComplexityLimit_WebhookDeliveries(t *testing.T) {
+	// limit ограничен MaxLimit: 1 + 30 * 2 = 61
+	resp := post(t, newServer(60, 0), `{ WebhookDeliveries(webhookID: 1, limit: 50) { id status } }`)
+	require.Len(t, resp.Errors, 1)
+	details := resp.Errors[0].Extensions["details"].(map[string]interface{})
+	assert.EqualValues(t, 61, details["complexity"])
+}
+
+func TestDepthLimit(t *testing.T) {
+	srv := newServer(0, 3)
+
+	resp := post(t, srv, `{ GetAllPosts { comments { replies { replies { id } } } } }`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "QUERY_TOO_DEEP", resp.Errors[0].Extensions["code"])
+	details := resp.Errors[0].Extensions["details"].(map[string]interface{})
+	assert.EqualValues(t, 5, details["depth"])
+
+	// Поля интроспекции глубину не увеличивают
+	resp = post(t, srv, `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`)
+	assert.Empty(t, resp.Errors)
+}
+
+func TestDepth_Fragments(t *testing.T) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: `
+		query {
+			GetAllPosts { ...PostFields }
+		}
+		fragment PostFields on Post {
+			id
+			comments { ... on Comment { replies { id } } }
+		}
+	`})
+	require.NoError(t, err)
+
+	// Определения фрагментов проставляет валидатор, здесь связываем их вручную
+	for _, selection := range doc.Operations[0].SelectionSet[0].(*ast.Field).SelectionSet {
+		spread := selection.(*ast.FragmentSpread)
+		spread.Definition = doc.Fragments.ForName(spread.Name)
+	}
+	assert.Equal(t, 4, Depth(doc.Operations[0].SelectionSet))
+}
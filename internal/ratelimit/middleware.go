@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Middleware ограничивает запросы по IP клиента. Запросы авторизованных пользователей тоже списываются из лимита IP,
+// а создание постов и комментариев дополнительно ограничивается в сервисах по пользователю
+func Middleware(limiter *Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, retryAfter := limiter.Allow(clientIP(r))
+		if !allowed {
+			writeRateLimited(w, retryAfter)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RetryAfterSeconds округляет ожидание вверх до целых секунд, как в заголовке Retry-After
+func RetryAfterSeconds(retryAfter time.Duration) int {
+	return int(math.Ceil(retryAfter.Seconds()))
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeRateLimited отвечает 429 с ошибкой в формате GraphQL, чтобы клиенты обрабатывали её так же, как ошибки сервисов
+func writeRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := RetryAfterSeconds(retryAfter)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []*gqlerror.Error{errdefs.HandleError(errdefs.RateLimitedError(ActionRequest, seconds))},
+	})
+}
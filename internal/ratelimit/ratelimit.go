@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Действия, частота которых ограничивается
+const (
+	ActionCreatePost    = "create_post"
+	ActionCreateComment = "create_comment"
+	// ActionRequest - любой HTTP-запрос, включая запросы авторизованных пользователей. Ограничивается по IP
+	ActionRequest = "request"
+)
+
+// Limit - не больше Count действий за Per. Нулевой лимит ничего не ограничивает
+type Limit struct {
+	Count int
+	Per   time.Duration
+}
+
+func (l Limit) IsZero() bool {
+	return l.Count <= 0 || l.Per <= 0
+}
+
+// ParseLimit разбирает лимит в формате "5/10s". Пустая строка - лимит не задан
+func ParseLimit(value string) (Limit, error) {
+	if value == "" {
+		return Limit{}, nil
+	}
+	count, per, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit %q: expected format count/duration", value)
+	}
+
+	limit := Limit{}
+	var err error
+	if limit.Count, err = strconv.Atoi(count); err != nil || limit.Count <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: count must be a positive integer", value)
+	}
+	if limit.Per, err = time.ParseDuration(per); err != nil || limit.Per <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: duration must be positive", value)
+	}
+	return limit, nil
+}
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+// Limiter - token bucket для каждого ключа. Ёмкость корзины - Count, за Per она полностью восстанавливается
+type Limiter struct {
+	limit Limit
+	now   func() time.Time
+
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow списывает токен для ключа. Если токенов нет, возвращает время, через которое появится следующий
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.limit.IsZero() {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	capacity := float64(l.limit.Count)
+	rate := capacity / float64(l.limit.Per)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(capacity, b.tokens+float64(now.Sub(b.updated))*rate)
+	b.updated = now
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate)
+}
+
+// sweep удаляет корзины, к которым не обращались дольше Per: они уже полностью восстановились.
+// Выполняется не чаще раза за Per. Вызывается под блокировкой
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.limit.Per {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.limit.Per {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// ActionLimiter ограничивает действия пользователей: у каждого действия свой лимит и свои корзины
+type ActionLimiter struct {
+	limiters map[string]*Limiter
+}
+
+// NewActionLimiter создаёт ограничитель по лимитам для действий. Действия без лимита не ограничиваются
+func NewActionLimiter(limits map[string]Limit) *ActionLimiter {
+	limiters := make(map[string]*Limiter, len(limits))
+	for action, limit := range limits {
+		limiters[action] = NewLimiter(limit)
+	}
+	return &ActionLimiter{limiters: limiters}
+}
+
+func (a *ActionLimiter) Allow(userID int, action string) (bool, time.Duration) {
+	limiter, ok := a.limiters[action]
+	if !ok {
+		return true, 0
+	}
+	return limiter.Allow(strconv.Itoa(userID))
+}
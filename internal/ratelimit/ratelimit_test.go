@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("5/10s")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Count: 5, Per: 10 * time.Second}, limit)
+
+	limit, err = ParseLimit("")
+	require.NoError(t, err)
+	assert.True(t, limit.IsZero())
+
+	for _, value := range []string{"5", "0/1s", "x/1s", "5/abc", "5/-1s"} {
+		_, err = ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(Limit{Count: 2, Per: 10 * time.Second})
+	limiter.now = func() time.Time { return now }
+
+	allowed, _ := limiter.Allow("1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("1")
+	assert.True(t, allowed)
+
+	allowed, retryAfter := limiter.Allow("1")
+	assert.False(t, allowed)
+	assert.Equal(t, 5*time.Second, retryAfter, "один токен восстанавливается за Per/Count")
+
+	allowed, _ = limiter.Allow("2")
+	assert.True(t, allowed, "у каждого ключа своя корзина")
+
+	now = now.Add(5 * time.Second)
+	allowed, _ = limiter.Allow("1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("1")
+	assert.False(t, allowed)
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(Limit{Count: 1, Per: time.Second})
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("1")
+	limiter.Allow("2")
+	require.Len(t, limiter.buckets, 2)
+
+	now = now.Add(2 * time.Second)
+	limiter.Allow("3")
+	assert.Len(t, limiter.buckets, 1, "восстановившиеся корзины удаляются")
+}
+
+func TestActionLimiter_Allow(t *testing.T) {
+	limiter := NewActionLimiter(map[string]Limit{
+		ActionCreateComment: {Count: 1, Per: time.Hour},
+	})
+
+	allowed, _ := limiter.Allow(1, ActionCreateComment)
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow(1, ActionCreateComment)
+	assert.False(t, allowed)
+
+	allowed, _ = limiter.Allow(1, ActionCreatePost)
+	assert.True(t, allowed, "действие без лимита не ограничивается")
+}
+
+func TestMiddleware(t *testing.T) {
+	limiter := NewLimiter(Limit{Count: 1, Per: time.Minute})
+	handler := Middleware(limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(authenticated bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		r.RemoteAddr = "10.0.0.1:5000"
+		if authenticated {
+			r = r.WithContext(auth.WithUserID(r.Context(), 1))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request(false).Code)
+
+	limited := request(false)
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "60", limited.Header().Get("Retry-After"))
+
+	var body struct {
+		Errors []struct {
+			Extensions map[string]interface{} `json:"extensions"`
+		} `json:"errors"`
+	}
+	require.NoError(t, json.NewDecoder(limited.Body).Decode(&body))
+	require.Len(t, body.Errors, 1)
+	assert.Equal(t, "RATE_LIMITED", body.Errors[0].Extensions["code"])
+
+	assert.Equal(t, http.StatusTooManyRequests, request(true).Code, "авторизованные запросы тоже ограничиваются по IP")
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"html"
+	"strings"
+	"sync"
+)
+
+// Renderer переводит payload в HTML. Результат всегда проходит через санитайзер
+// и кэшируется по хэшу формата и текста, поэтому правка контента сама инвалидирует кэш
+type Renderer struct {
+	markdown goldmark.Markdown
+	policy   *bluemonday.Policy
+
+	capacity int
+	entries  map[[sha256.Size]byte]*list.Element
+	order    *list.List
+	mu       sync.Mutex
+}
+
+type cacheEntry struct {
+	key  [sha256.Size]byte
+	html string
+}
+
+func NewRenderer(cacheSize int) *Renderer {
+	return &Renderer{
+		// Сырой HTML в Markdown goldmark по умолчанию не пропускает
+		markdown: goldmark.New(goldmark.WithExtensions(extension.Strikethrough, extension.Linkify)),
+		policy:   newPolicy(),
+		capacity: cacheSize,
+		entries:  make(map[[sha256.Size]byte]*list.Element, cacheSize),
+		order:    list.New(),
+	}
+}
+
+// newPolicy - строгий allow-list: только базовая разметка текста и ссылки http(s)/mailto
+func newPolicy() *bluemonday.Policy {
+	policy := bluemonday.NewPolicy()
+	policy.AllowElements(
+		"p", "br", "hr", "em", "strong", "del", "code", "pre", "blockquote",
+		"ul", "ol", "li", "h1", "h2", "h3", "h4", "h5", "h6",
+	)
+	policy.AllowAttrs("href").OnElements("a")
+	policy.AllowURLSchemes("http", "https", "mailto")
+	policy.RequireParseableURLs(true)
+	policy.RequireNoFollowOnLinks(true)
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+	return policy
+}
+
+func (r *Renderer) Render(format models.ContentFormat, payload string) string {
+	key := sha256.Sum256([]byte(string(format) + "\x00" + payload))
+
+	r.mu.Lock()
+	if element, ok := r.entries[key]; ok {
+		r.order.MoveToFront(element)
+		r.mu.Unlock()
+		return element.Value.(*cacheEntry).html
+	}
+	r.mu.Unlock()
+
+	// Рендерим без блокировки, чтобы долгий Markdown не задерживал остальные запросы
+	rendered := r.policy.Sanitize(r.toHTML(format, payload))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[key]; !ok && r.capacity > 0 {
+		r.entries[key] = r.order.PushFront(&cacheEntry{key: key, html: rendered})
+		if r.order.Len() > r.capacity {
+			oldest := r.order.Back()
+			r.order.Remove(oldest)
+			delete(r.entries, oldest.Value.(*cacheEntry).key)
+		}
+	}
+	return rendered
+}
+
+func (r *Renderer) toHTML(format models.ContentFormat, payload string) string {
+	if format == models.ContentFormatMarkdown {
+		var buf bytes.Buffer
+		if err := r.markdown.Convert([]byte(payload), &buf); err == nil {
+			return buf.String()
+		}
+	}
+	return plainToHTML(payload)
+}
+
+// plainToHTML экранирует текст, пустые строки разделяют абзацы, одиночные переносы становятся <br>
+func plainToHTML(payload string) string {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.ReplaceAll(payload, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.Trim(paragraph, "\n")
+		if paragraph == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
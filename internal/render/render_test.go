@@ -0,0 +1,75 @@
+package render
+
+import (
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   models.ContentFormat
+		payload  string
+		expected string
+	}{
+		{
+			name:     "plain text is escaped",
+			format:   models.ContentFormatPlain,
+			payload:  "<b>bold</b> & co\nnext line\n\nsecond",
+			expected: "<p>&lt;b&gt;bold&lt;/b&gt; &amp; co<br>\nnext line</p>\n<p>second</p>\n",
+		},
+		{
+			name:     "markdown emphasis and lists",
+			format:   models.ContentFormatMarkdown,
+			payload:  "**bold** and *em*\n\n- one\n- two",
+			expected: "<p><strong>bold</strong> and <em>em</em></p>\n<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n",
+		},
+		{
+			name:     "raw html in markdown is dropped",
+			format:   models.ContentFormatMarkdown,
+			payload:  "hi <script>alert(1)</script>",
+			expected: "<p>hi alert(1)</p>\n",
+		},
+		{
+			name:     "javascript links are removed",
+			format:   models.ContentFormatMarkdown,
+			payload:  "[click](javascript:alert(1))",
+			expected: "<p>click</p>\n",
+		},
+		{
+			name:     "external links get nofollow",
+			format:   models.ContentFormatMarkdown,
+			payload:  "[site](https://example.com)",
+			expected: `<p><a href="https://example.com" rel="nofollow noopener" target="_blank">site</a></p>` + "\n",
+		},
+		{
+			name:     "images are not allowed",
+			format:   models.ContentFormatMarkdown,
+			payload:  "![alt](https://example.com/a.png)",
+			expected: "<p></p>\n",
+		},
+	}
+
+	renderer := NewRenderer(10)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, renderer.Render(tt.format, tt.payload))
+		})
+	}
+}
+
+func TestRenderer_Cache(t *testing.T) {
+	renderer := NewRenderer(2)
+
+	first := renderer.Render(models.ContentFormatMarkdown, "*a*")
+	assert.Equal(t, first, renderer.Render(models.ContentFormatMarkdown, "*a*"))
+	assert.Equal(t, 1, renderer.order.Len())
+
+	// Тот же текст в другом формате - отдельная запись
+	assert.NotEqual(t, first, renderer.Render(models.ContentFormatPlain, "*a*"))
+	renderer.Render(models.ContentFormatPlain, "b")
+
+	assert.Equal(t, 2, renderer.order.Len(), "кэш не растёт сверх лимита")
+	assert.Equal(t, "<p>b</p>\n", renderer.order.Front().Value.(*cacheEntry).html)
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/Quizert/PostCommentService/internal/models"
 	gomock "github.com/golang/mock/gomock"
@@ -50,6 +51,21 @@ func (mr *MockPostServiceMockRecorder) CreatePost(ctx, input interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockPostService)(nil).CreatePost), ctx, input)
 }
 
+// EditPost mocks base method.
+func (m *MockPostService) EditPost(ctx context.Context, postID int, input models.EditPost) (*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditPost", ctx, postID, input)
+	ret0, _ := ret[0].(*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EditPost indicates an expected call of EditPost.
+func (mr *MockPostServiceMockRecorder) EditPost(ctx, postID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditPost", reflect.TypeOf((*MockPostService)(nil).EditPost), ctx, postID, input)
+}
+
 // GetAllPosts mocks base method.
 func (m *MockPostService) GetAllPosts(ctx context.Context, limit, offset *int) ([]*models.Post, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +96,66 @@ func (mr *MockPostServiceMockRecorder) GetPostByID(ctx, id interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostByID", reflect.TypeOf((*MockPostService)(nil).GetPostByID), ctx, id)
 }
 
+// GetPostRevisions mocks base method.
+func (m *MockPostService) GetPostRevisions(ctx context.Context, post *models.Post) ([]*models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostRevisions", ctx, post)
+	ret0, _ := ret[0].([]*models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostRevisions indicates an expected call of GetPostRevisions.
+func (mr *MockPostServiceMockRecorder) GetPostRevisions(ctx, post interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostRevisions", reflect.TypeOf((*MockPostService)(nil).GetPostRevisions), ctx, post)
+}
+
+// GetPostsByTag mocks base method.
+func (m *MockPostService) GetPostsByTag(ctx context.Context, tag string, limit, offset *int) ([]*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostsByTag", ctx, tag, limit, offset)
+	ret0, _ := ret[0].([]*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostsByTag indicates an expected call of GetPostsByTag.
+func (mr *MockPostServiceMockRecorder) GetPostsByTag(ctx, tag, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByTag", reflect.TypeOf((*MockPostService)(nil).GetPostsByTag), ctx, tag, limit, offset)
+}
+
+// GetTags mocks base method.
+func (m *MockPostService) GetTags(ctx context.Context, prefix string, limit *int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", ctx, prefix, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTags indicates an expected call of GetTags.
+func (mr *MockPostServiceMockRecorder) GetTags(ctx, prefix, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockPostService)(nil).GetTags), ctx, prefix, limit)
+}
+
+// PublishPost mocks base method.
+func (m *MockPostService) PublishPost(ctx context.Context, postID int, publishAt *time.Time) (*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishPost", ctx, postID, publishAt)
+	ret0, _ := ret[0].(*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishPost indicates an expected call of PublishPost.
+func (mr *MockPostServiceMockRecorder) PublishPost(ctx, postID, publishAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishPost", reflect.TypeOf((*MockPostService)(nil).PublishPost), ctx, postID, publishAt)
+}
+
 // MockCommentService is a mock of CommentService interface.
 type MockCommentService struct {
 	ctrl     *gomock.Controller
@@ -118,6 +194,51 @@ func (mr *MockCommentServiceMockRecorder) CreateComment(ctx, input interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockCommentService)(nil).CreateComment), ctx, input)
 }
 
+// EditComment mocks base method.
+func (m *MockCommentService) EditComment(ctx context.Context, commentID int, payload string) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditComment", ctx, commentID, payload)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EditComment indicates an expected call of EditComment.
+func (mr *MockCommentServiceMockRecorder) EditComment(ctx, commentID, payload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditComment", reflect.TypeOf((*MockCommentService)(nil).EditComment), ctx, commentID, payload)
+}
+
+// GetCommentMentions mocks base method.
+func (m *MockCommentService) GetCommentMentions(ctx context.Context, comment *models.Comment) ([]*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentMentions", ctx, comment)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentMentions indicates an expected call of GetCommentMentions.
+func (mr *MockCommentServiceMockRecorder) GetCommentMentions(ctx, comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentMentions", reflect.TypeOf((*MockCommentService)(nil).GetCommentMentions), ctx, comment)
+}
+
+// GetCommentRevisions mocks base method.
+func (m *MockCommentService) GetCommentRevisions(ctx context.Context, comment *models.Comment) ([]*models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentRevisions", ctx, comment)
+	ret0, _ := ret[0].([]*models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentRevisions indicates an expected call of GetCommentRevisions.
+func (mr *MockCommentServiceMockRecorder) GetCommentRevisions(ctx, comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentRevisions", reflect.TypeOf((*MockCommentService)(nil).GetCommentRevisions), ctx, comment)
+}
+
 // GetCommentsByPostID mocks base method.
 func (m *MockCommentService) GetCommentsByPostID(ctx context.Context, limit, offset *int, postID int) ([]*models.Comment, error) {
 	m.ctrl.T.Helper()
@@ -133,6 +254,36 @@ func (mr *MockCommentServiceMockRecorder) GetCommentsByPostID(ctx, limit, offset
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByPostID", reflect.TypeOf((*MockCommentService)(nil).GetCommentsByPostID), ctx, limit, offset, postID)
 }
 
+// LockThread mocks base method.
+func (m *MockCommentService) LockThread(ctx context.Context, commentID int) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockThread", ctx, commentID)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockThread indicates an expected call of LockThread.
+func (mr *MockCommentServiceMockRecorder) LockThread(ctx, commentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockThread", reflect.TypeOf((*MockCommentService)(nil).LockThread), ctx, commentID)
+}
+
+// PinComment mocks base method.
+func (m *MockCommentService) PinComment(ctx context.Context, commentID int) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinComment", ctx, commentID)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PinComment indicates an expected call of PinComment.
+func (mr *MockCommentServiceMockRecorder) PinComment(ctx, commentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinComment", reflect.TypeOf((*MockCommentService)(nil).PinComment), ctx, commentID)
+}
+
 // Replies mocks base method.
 func (m *MockCommentService) Replies(ctx context.Context, commentID int, limit, offset *int) ([]*models.Comment, error) {
 	m.ctrl.T.Helper()
@@ -148,6 +299,21 @@ func (mr *MockCommentServiceMockRecorder) Replies(ctx, commentID, limit, offset
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replies", reflect.TypeOf((*MockCommentService)(nil).Replies), ctx, commentID, limit, offset)
 }
 
+// UnpinComment mocks base method.
+func (m *MockCommentService) UnpinComment(ctx context.Context, commentID int) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpinComment", ctx, commentID)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnpinComment indicates an expected call of UnpinComment.
+func (mr *MockCommentServiceMockRecorder) UnpinComment(ctx, commentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpinComment", reflect.TypeOf((*MockCommentService)(nil).UnpinComment), ctx, commentID)
+}
+
 // MockSubscriptionService is a mock of SubscriptionService interface.
 type MockSubscriptionService struct {
 	ctrl     *gomock.Controller
@@ -171,6 +337,36 @@ func (m *MockSubscriptionService) EXPECT() *MockSubscriptionServiceMockRecorder
 	return m.recorder
 }
 
+// CreateMentionsSubscription mocks base method.
+func (m *MockSubscriptionService) CreateMentionsSubscription(ctx context.Context, userID int) (chan *models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMentionsSubscription", ctx, userID)
+	ret0, _ := ret[0].(chan *models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMentionsSubscription indicates an expected call of CreateMentionsSubscription.
+func (mr *MockSubscriptionServiceMockRecorder) CreateMentionsSubscription(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMentionsSubscription", reflect.TypeOf((*MockSubscriptionService)(nil).CreateMentionsSubscription), ctx, userID)
+}
+
+// CreateNotificationsSubscription mocks base method.
+func (m *MockSubscriptionService) CreateNotificationsSubscription(ctx context.Context, userID int) (chan *models.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNotificationsSubscription", ctx, userID)
+	ret0, _ := ret[0].(chan *models.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNotificationsSubscription indicates an expected call of CreateNotificationsSubscription.
+func (mr *MockSubscriptionServiceMockRecorder) CreateNotificationsSubscription(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNotificationsSubscription", reflect.TypeOf((*MockSubscriptionService)(nil).CreateNotificationsSubscription), ctx, userID)
+}
+
 // CreateSubscription mocks base method.
 func (m *MockSubscriptionService) CreateSubscription(ctx context.Context, postID int) (chan *models.Comment, error) {
 	m.ctrl.T.Helper()
@@ -186,6 +382,34 @@ func (mr *MockSubscriptionServiceMockRecorder) CreateSubscription(ctx, postID in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockSubscriptionService)(nil).CreateSubscription), ctx, postID)
 }
 
+// DeleteMentionsSubscription mocks base method.
+func (m *MockSubscriptionService) DeleteMentionsSubscription(ctx context.Context, userID int, ch chan *models.Comment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMentionsSubscription", ctx, userID, ch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMentionsSubscription indicates an expected call of DeleteMentionsSubscription.
+func (mr *MockSubscriptionServiceMockRecorder) DeleteMentionsSubscription(ctx, userID, ch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMentionsSubscription", reflect.TypeOf((*MockSubscriptionService)(nil).DeleteMentionsSubscription), ctx, userID, ch)
+}
+
+// DeleteNotificationsSubscription mocks base method.
+func (m *MockSubscriptionService) DeleteNotificationsSubscription(ctx context.Context, userID int, ch chan *models.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNotificationsSubscription", ctx, userID, ch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNotificationsSubscription indicates an expected call of DeleteNotificationsSubscription.
+func (mr *MockSubscriptionServiceMockRecorder) DeleteNotificationsSubscription(ctx, userID, ch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotificationsSubscription", reflect.TypeOf((*MockSubscriptionService)(nil).DeleteNotificationsSubscription), ctx, userID, ch)
+}
+
 // DeleteSubscription mocks base method.
 func (m *MockSubscriptionService) DeleteSubscription(ctx context.Context, postID int, ch chan *models.Comment) error {
 	m.ctrl.T.Helper()
@@ -200,16 +424,400 @@ func (mr *MockSubscriptionServiceMockRecorder) DeleteSubscription(ctx, postID, c
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockSubscriptionService)(nil).DeleteSubscription), ctx, postID, ch)
 }
 
-// Notify mocks base method.
-func (m *MockSubscriptionService) Notify(ctx context.Context, comment *models.Comment) error {
+// MockNotificationService is a mock of NotificationService interface.
+type MockNotificationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationServiceMockRecorder
+}
+
+// MockNotificationServiceMockRecorder is the mock recorder for MockNotificationService.
+type MockNotificationServiceMockRecorder struct {
+	mock *MockNotificationService
+}
+
+// NewMockNotificationService creates a new mock instance.
+func NewMockNotificationService(ctrl *gomock.Controller) *MockNotificationService {
+	mock := &MockNotificationService{ctrl: ctrl}
+	mock.recorder = &MockNotificationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationService) EXPECT() *MockNotificationServiceMockRecorder {
+	return m.recorder
+}
+
+// GetNotifications mocks base method.
+func (m *MockNotificationService) GetNotifications(ctx context.Context, unreadOnly *bool, first, after *int) (*models.NotificationConnection, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Notify", ctx, comment)
-	ret0, _ := ret[0].(error)
+	ret := m.ctrl.Call(m, "GetNotifications", ctx, unreadOnly, first, after)
+	ret0, _ := ret[0].(*models.NotificationConnection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotifications indicates an expected call of GetNotifications.
+func (mr *MockNotificationServiceMockRecorder) GetNotifications(ctx, unreadOnly, first, after interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotifications", reflect.TypeOf((*MockNotificationService)(nil).GetNotifications), ctx, unreadOnly, first, after)
+}
+
+// MarkNotificationsRead mocks base method.
+func (m *MockNotificationService) MarkNotificationsRead(ctx context.Context, ids []int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationsRead", ctx, ids)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkNotificationsRead indicates an expected call of MarkNotificationsRead.
+func (mr *MockNotificationServiceMockRecorder) MarkNotificationsRead(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationsRead", reflect.TypeOf((*MockNotificationService)(nil).MarkNotificationsRead), ctx, ids)
+}
+
+// MockModerationService is a mock of ModerationService interface.
+type MockModerationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockModerationServiceMockRecorder
+}
+
+// MockModerationServiceMockRecorder is the mock recorder for MockModerationService.
+type MockModerationServiceMockRecorder struct {
+	mock *MockModerationService
+}
+
+// NewMockModerationService creates a new mock instance.
+func NewMockModerationService(ctrl *gomock.Controller) *MockModerationService {
+	mock := &MockModerationService{ctrl: ctrl}
+	mock.recorder = &MockModerationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockModerationService) EXPECT() *MockModerationServiceMockRecorder {
+	return m.recorder
+}
+
+// ApproveContent mocks base method.
+func (m *MockModerationService) ApproveContent(ctx context.Context, itemID int, reason *string) (*models.ModerationItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveContent", ctx, itemID, reason)
+	ret0, _ := ret[0].(*models.ModerationItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveContent indicates an expected call of ApproveContent.
+func (mr *MockModerationServiceMockRecorder) ApproveContent(ctx, itemID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveContent", reflect.TypeOf((*MockModerationService)(nil).ApproveContent), ctx, itemID, reason)
+}
+
+// BanUser mocks base method.
+func (m *MockModerationService) BanUser(ctx context.Context, userID int, until *time.Time, reason string) (*models.UserBan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanUser", ctx, userID, until, reason)
+	ret0, _ := ret[0].(*models.UserBan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BanUser indicates an expected call of BanUser.
+func (mr *MockModerationServiceMockRecorder) BanUser(ctx, userID, until, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanUser", reflect.TypeOf((*MockModerationService)(nil).BanUser), ctx, userID, until, reason)
+}
+
+// GetModerationQueue mocks base method.
+func (m *MockModerationService) GetModerationQueue(ctx context.Context, status *models.ModerationStatus, first, after *int) (*models.ModerationQueueConnection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetModerationQueue", ctx, status, first, after)
+	ret0, _ := ret[0].(*models.ModerationQueueConnection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetModerationQueue indicates an expected call of GetModerationQueue.
+func (mr *MockModerationServiceMockRecorder) GetModerationQueue(ctx, status, first, after interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetModerationQueue", reflect.TypeOf((*MockModerationService)(nil).GetModerationQueue), ctx, status, first, after)
+}
+
+// GetReports mocks base method.
+func (m *MockModerationService) GetReports(ctx context.Context, limit, offset *int) ([]*models.ReportSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReports", ctx, limit, offset)
+	ret0, _ := ret[0].([]*models.ReportSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReports indicates an expected call of GetReports.
+func (mr *MockModerationServiceMockRecorder) GetReports(ctx, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReports", reflect.TypeOf((*MockModerationService)(nil).GetReports), ctx, limit, offset)
+}
+
+// MuteUserOnPost mocks base method.
+func (m *MockModerationService) MuteUserOnPost(ctx context.Context, postID, userID int, until *time.Time) (*models.PostMute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MuteUserOnPost", ctx, postID, userID, until)
+	ret0, _ := ret[0].(*models.PostMute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MuteUserOnPost indicates an expected call of MuteUserOnPost.
+func (mr *MockModerationServiceMockRecorder) MuteUserOnPost(ctx, postID, userID, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MuteUserOnPost", reflect.TypeOf((*MockModerationService)(nil).MuteUserOnPost), ctx, postID, userID, until)
+}
+
+// RejectContent mocks base method.
+func (m *MockModerationService) RejectContent(ctx context.Context, itemID int, reason string) (*models.ModerationItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectContent", ctx, itemID, reason)
+	ret0, _ := ret[0].(*models.ModerationItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectContent indicates an expected call of RejectContent.
+func (mr *MockModerationServiceMockRecorder) RejectContent(ctx, itemID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectContent", reflect.TypeOf((*MockModerationService)(nil).RejectContent), ctx, itemID, reason)
+}
+
+// Report mocks base method.
+func (m *MockModerationService) Report(ctx context.Context, targetType models.TargetType, targetID int, reason models.ReportReason, note *string) (*models.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Report", ctx, targetType, targetID, reason, note)
+	ret0, _ := ret[0].(*models.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Report indicates an expected call of Report.
+func (mr *MockModerationServiceMockRecorder) Report(ctx, targetType, targetID, reason, note interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockModerationService)(nil).Report), ctx, targetType, targetID, reason, note)
+}
+
+// MockBlockService is a mock of BlockService interface.
+type MockBlockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlockServiceMockRecorder
+}
+
+// MockBlockServiceMockRecorder is the mock recorder for MockBlockService.
+type MockBlockServiceMockRecorder struct {
+	mock *MockBlockService
+}
+
+// NewMockBlockService creates a new mock instance.
+func NewMockBlockService(ctrl *gomock.Controller) *MockBlockService {
+	mock := &MockBlockService{ctrl: ctrl}
+	mock.recorder = &MockBlockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlockService) EXPECT() *MockBlockServiceMockRecorder {
+	return m.recorder
+}
+
+// BlockUser mocks base method.
+func (m *MockBlockService) BlockUser(ctx context.Context, userID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUser", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockUser indicates an expected call of BlockUser.
+func (mr *MockBlockServiceMockRecorder) BlockUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUser", reflect.TypeOf((*MockBlockService)(nil).BlockUser), ctx, userID)
+}
+
+// FilterBlockedComments mocks base method.
+func (m *MockBlockService) FilterBlockedComments(ctx context.Context, comments <-chan *models.Comment) <-chan *models.Comment {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterBlockedComments", ctx, comments)
+	ret0, _ := ret[0].(<-chan *models.Comment)
+	return ret0
+}
+
+// FilterBlockedComments indicates an expected call of FilterBlockedComments.
+func (mr *MockBlockServiceMockRecorder) FilterBlockedComments(ctx, comments interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterBlockedComments", reflect.TypeOf((*MockBlockService)(nil).FilterBlockedComments), ctx, comments)
+}
+
+// UnblockUser mocks base method.
+func (m *MockBlockService) UnblockUser(ctx context.Context, userID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnblockUser", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnblockUser indicates an expected call of UnblockUser.
+func (mr *MockBlockServiceMockRecorder) UnblockUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnblockUser", reflect.TypeOf((*MockBlockService)(nil).UnblockUser), ctx, userID)
+}
+
+// MockWebhookService is a mock of WebhookService interface.
+type MockWebhookService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookServiceMockRecorder
+}
+
+// MockWebhookServiceMockRecorder is the mock recorder for MockWebhookService.
+type MockWebhookServiceMockRecorder struct {
+	mock *MockWebhookService
+}
+
+// NewMockWebhookService creates a new mock instance.
+func NewMockWebhookService(ctrl *gomock.Controller) *MockWebhookService {
+	mock := &MockWebhookService{ctrl: ctrl}
+	mock.recorder = &MockWebhookServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookService) EXPECT() *MockWebhookServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhook mocks base method.
+func (m *MockWebhookService) CreateWebhook(ctx context.Context, input models.NewWebhook) (*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, input)
+	ret0, _ := ret[0].(*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockWebhookServiceMockRecorder) CreateWebhook(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockWebhookService)(nil).CreateWebhook), ctx, input)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockWebhookService) DeleteWebhook(ctx context.Context, webhookID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, webhookID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockWebhookServiceMockRecorder) DeleteWebhook(ctx, webhookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockWebhookService)(nil).DeleteWebhook), ctx, webhookID)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockWebhookService) GetWebhookDeliveries(ctx context.Context, webhookID int, status *models.WebhookDeliveryStatus, limit, offset *int) ([]*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", ctx, webhookID, status, limit, offset)
+	ret0, _ := ret[0].([]*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockWebhookServiceMockRecorder) GetWebhookDeliveries(ctx, webhookID, status, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockWebhookService)(nil).GetWebhookDeliveries), ctx, webhookID, status, limit, offset)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockWebhookService) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks", ctx)
+	ret0, _ := ret[0].([]*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockWebhookServiceMockRecorder) GetWebhooks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockWebhookService)(nil).GetWebhooks), ctx)
+}
+
+// ReplayWebhookDelivery mocks base method.
+func (m *MockWebhookService) ReplayWebhookDelivery(ctx context.Context, deliveryID int) (*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayWebhookDelivery", ctx, deliveryID)
+	ret0, _ := ret[0].(*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayWebhookDelivery indicates an expected call of ReplayWebhookDelivery.
+func (mr *MockWebhookServiceMockRecorder) ReplayWebhookDelivery(ctx, deliveryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayWebhookDelivery", reflect.TypeOf((*MockWebhookService)(nil).ReplayWebhookDelivery), ctx, deliveryID)
+}
+
+// UpdateWebhook mocks base method.
+func (m *MockWebhookService) UpdateWebhook(ctx context.Context, webhookID int, input models.EditWebhook) (*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhook", ctx, webhookID, input)
+	ret0, _ := ret[0].(*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook.
+func (mr *MockWebhookServiceMockRecorder) UpdateWebhook(ctx, webhookID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhook", reflect.TypeOf((*MockWebhookService)(nil).UpdateWebhook), ctx, webhookID, input)
+}
+
+// MockRenderer is a mock of Renderer interface.
+type MockRenderer struct {
+	ctrl     *gomock.Controller
+	recorder *MockRendererMockRecorder
+}
+
+// MockRendererMockRecorder is the mock recorder for MockRenderer.
+type MockRendererMockRecorder struct {
+	mock *MockRenderer
+}
+
+// NewMockRenderer creates a new mock instance.
+func NewMockRenderer(ctrl *gomock.Controller) *MockRenderer {
+	mock := &MockRenderer{ctrl: ctrl}
+	mock.recorder = &MockRendererMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRenderer) EXPECT() *MockRendererMockRecorder {
+	return m.recorder
+}
+
+// Render mocks base method.
+func (m *MockRenderer) Render(format models.ContentFormat, payload string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Render", format, payload)
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// Notify indicates an expected call of Notify.
-func (mr *MockSubscriptionServiceMockRecorder) Notify(ctx, comment interface{}) *gomock.Call {
+// Render indicates an expected call of Render.
+func (mr *MockRendererMockRecorder) Render(format, payload interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MockSubscriptionService)(nil).Notify), ctx, comment)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Render", reflect.TypeOf((*MockRenderer)(nil).Render), format, payload)
 }
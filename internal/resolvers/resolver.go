@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/Quizert/PostCommentService/internal/models"
 	"go.uber.org/zap"
+	"time"
 )
 
 // This file will not be regenerated automatically.
@@ -15,18 +16,66 @@ type PostService interface {
 	CreatePost(ctx context.Context, input models.NewPost) (*models.Post, error)
 	GetPostByID(ctx context.Context, id int) (*models.Post, error)
 	GetAllPosts(ctx context.Context, limit *int, offset *int) ([]*models.Post, error)
+	GetPostsByTag(ctx context.Context, tag string, limit *int, offset *int) ([]*models.Post, error)
+	GetTags(ctx context.Context, prefix string, limit *int) ([]string, error)
+	PublishPost(ctx context.Context, postID int, publishAt *time.Time) (*models.Post, error)
+	EditPost(ctx context.Context, postID int, input models.EditPost) (*models.Post, error)
+	GetPostRevisions(ctx context.Context, post *models.Post) ([]*models.Revision, error)
 }
 
 type CommentService interface {
 	CreateComment(ctx context.Context, input models.NewComment) (*models.Comment, error)
 	GetCommentsByPostID(ctx context.Context, limit *int, offset *int, postID int) ([]*models.Comment, error)
 	Replies(ctx context.Context, commentID int, limit *int, offset *int) ([]*models.Comment, error)
+	PinComment(ctx context.Context, commentID int) (*models.Comment, error)
+	UnpinComment(ctx context.Context, commentID int) (*models.Comment, error)
+	LockThread(ctx context.Context, commentID int) (*models.Comment, error)
+	EditComment(ctx context.Context, commentID int, payload string) (*models.Comment, error)
+	GetCommentRevisions(ctx context.Context, comment *models.Comment) ([]*models.Revision, error)
+	GetCommentMentions(ctx context.Context, comment *models.Comment) ([]*models.User, error)
 }
 
 type SubscriptionService interface {
 	CreateSubscription(ctx context.Context, postID int) (chan *models.Comment, error)
 	DeleteSubscription(ctx context.Context, postID int, ch chan *models.Comment) error
-	Notify(ctx context.Context, comment *models.Comment) error
+	CreateMentionsSubscription(ctx context.Context, userID int) (chan *models.Comment, error)
+	DeleteMentionsSubscription(ctx context.Context, userID int, ch chan *models.Comment) error
+	CreateNotificationsSubscription(ctx context.Context, userID int) (chan *models.Notification, error)
+	DeleteNotificationsSubscription(ctx context.Context, userID int, ch chan *models.Notification) error
+}
+
+type NotificationService interface {
+	GetNotifications(ctx context.Context, unreadOnly *bool, first *int, after *int) (*models.NotificationConnection, error)
+	MarkNotificationsRead(ctx context.Context, ids []int) (int, error)
+}
+
+type ModerationService interface {
+	GetModerationQueue(ctx context.Context, status *models.ModerationStatus, first *int, after *int) (*models.ModerationQueueConnection, error)
+	ApproveContent(ctx context.Context, itemID int, reason *string) (*models.ModerationItem, error)
+	RejectContent(ctx context.Context, itemID int, reason string) (*models.ModerationItem, error)
+	BanUser(ctx context.Context, userID int, until *time.Time, reason string) (*models.UserBan, error)
+	MuteUserOnPost(ctx context.Context, postID int, userID int, until *time.Time) (*models.PostMute, error)
+	Report(ctx context.Context, targetType models.TargetType, targetID int, reason models.ReportReason, note *string) (*models.Report, error)
+	GetReports(ctx context.Context, limit *int, offset *int) ([]*models.ReportSummary, error)
+}
+
+type BlockService interface {
+	BlockUser(ctx context.Context, userID int) (bool, error)
+	UnblockUser(ctx context.Context, userID int) (bool, error)
+	FilterBlockedComments(ctx context.Context, comments <-chan *models.Comment) <-chan *models.Comment
+}
+
+type WebhookService interface {
+	GetWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	CreateWebhook(ctx context.Context, input models.NewWebhook) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhookID int, input models.EditWebhook) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID int) (bool, error)
+	GetWebhookDeliveries(ctx context.Context, webhookID int, status *models.WebhookDeliveryStatus, limit *int, offset *int) ([]*models.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, deliveryID int) (*models.WebhookDelivery, error)
+}
+
+type Renderer interface {
+	Render(format models.ContentFormat, payload string) string
 }
 
 type Resolver struct {
@@ -34,13 +83,39 @@ type Resolver struct {
 	postService         PostService
 	commentService      CommentService
 	subscriptionManager SubscriptionService
+	notificationService NotificationService
+	moderationService   ModerationService
+	renderer            Renderer
+	blockService        BlockService
+	webhookService      WebhookService
 }
 
-func NewResolver(log *zap.Logger, postService PostService, commentService CommentService, subscriptionManager SubscriptionService) *Resolver {
+func NewResolver(log *zap.Logger, postService PostService, commentService CommentService, subscriptionManager SubscriptionService, notificationService NotificationService, moderationService ModerationService, renderer Renderer, blockService BlockService, webhookService WebhookService) *Resolver {
 	return &Resolver{
 		log:                 log,
 		postService:         postService,
 		commentService:      commentService,
 		subscriptionManager: subscriptionManager,
+		notificationService: notificationService,
+		moderationService:   moderationService,
+		renderer:            renderer,
+		blockService:        blockService,
+		webhookService:      webhookService,
 	}
 }
+
+// forwardSubscription пересылает события подписки клиенту. Входной канал читается до закрытия, даже если клиент
+// уже отключился, выходной закрывается вместе с ним
+func forwardSubscription[T any](ctx context.Context, events <-chan T) <-chan T {
+	forwarded := make(chan T)
+	go func() {
+		defer close(forwarded)
+		for event := range events {
+			select {
+			case forwarded <- event:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return forwarded
+}
@@ -7,11 +7,18 @@ package graphql
 import (
 	"context"
 	"github.com/Quizert/PostCommentService/graph"
+	"github.com/Quizert/PostCommentService/internal/auth"
 	"github.com/Quizert/PostCommentService/internal/errdefs"
 	"github.com/Quizert/PostCommentService/internal/models"
 	"go.uber.org/zap"
+	"time"
 )
 
+// PayloadHTML is the resolver for the payloadHTML field.
+func (r *commentResolver) PayloadHTML(ctx context.Context, obj *models.Comment) (string, error) {
+	return r.renderer.Render(obj.Format, obj.Payload), nil
+}
+
 // Replies is the resolver for the replies field.
 func (r *commentResolver) Replies(ctx context.Context, obj *models.Comment, limit *int, offset *int) ([]*models.Comment, error) {
 	log := r.log.With(
@@ -28,6 +35,38 @@ func (r *commentResolver) Replies(ctx context.Context, obj *models.Comment, limi
 	return comments, nil
 }
 
+// Revisions is the resolver for the revisions field.
+func (r *commentResolver) Revisions(ctx context.Context, obj *models.Comment) ([]*models.Revision, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.CommentRevisions"),
+		zap.Int("CommentID", obj.ID),
+	)
+	log.Info("Received request to get comment revisions")
+
+	revisions, err := r.commentService.GetCommentRevisions(ctx, obj)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Revisions", len(revisions))).Info("Successfully got comment revisions")
+	return revisions, nil
+}
+
+// Mentions is the resolver for the mentions field.
+func (r *commentResolver) Mentions(ctx context.Context, obj *models.Comment) ([]*models.User, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.Mentions"),
+		zap.Int("CommentID", obj.ID),
+	)
+	log.Info("Received request to get comment mentions")
+
+	users, err := r.commentService.GetCommentMentions(ctx, obj)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Mentions", len(users))).Info("Successfully got comment mentions")
+	return users, nil
+}
+
 // CreatePost is the resolver for the CreatePost field.
 func (r *mutationResolver) CreatePost(ctx context.Context, input models.NewPost) (*models.Post, error) {
 	log := r.log.With(
@@ -60,14 +99,324 @@ func (r *mutationResolver) CreateComment(ctx context.Context, input models.NewCo
 		return nil, errdefs.HandleError(err)
 	}
 
-	err = r.subscriptionManager.Notify(ctx, comment)
+	// Подписчикам комментарий разошлёт диспетчер outbox: событие сохранено в одной транзакции с комментарием
+	log.With(zap.Int("CommentID", comment.ID)).Info("Successfully created new comment")
+	return comment, nil
+}
+
+// PublishPost is the resolver for the PublishPost field.
+func (r *mutationResolver) PublishPost(ctx context.Context, postID int, publishAt *time.Time) (*models.Post, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.PublishPost"),
+		zap.Int("PostID", postID),
+	)
+	log.Info("Received request to publish post")
+
+	post, err := r.postService.PublishPost(ctx, postID, publishAt)
 	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to publish post")
 		return nil, errdefs.HandleError(err)
 	}
-	log.With(zap.Int("CommentID", comment.ID)).Info("Successfully created new comment")
+	log.With(zap.String("Status", string(post.Status))).Info("Successfully published post")
+	return post, nil
+}
+
+// PinComment is the resolver for the PinComment field.
+func (r *mutationResolver) PinComment(ctx context.Context, commentID int) (*models.Comment, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.PinComment"),
+		zap.Int("CommentID", commentID),
+	)
+	log.Info("Received request to pin comment")
+
+	comment, err := r.commentService.PinComment(ctx, commentID)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to pin comment")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully pinned comment")
+	return comment, nil
+}
+
+// UnpinComment is the resolver for the UnpinComment field.
+func (r *mutationResolver) UnpinComment(ctx context.Context, commentID int) (*models.Comment, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.UnpinComment"),
+		zap.Int("CommentID", commentID),
+	)
+	log.Info("Received request to unpin comment")
+
+	comment, err := r.commentService.UnpinComment(ctx, commentID)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to unpin comment")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully unpinned comment")
+	return comment, nil
+}
+
+// LockThread is the resolver for the LockThread field.
+func (r *mutationResolver) LockThread(ctx context.Context, commentID int) (*models.Comment, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.LockThread"),
+		zap.Int("CommentID", commentID),
+	)
+	log.Info("Received request to lock thread")
+
+	comment, err := r.commentService.LockThread(ctx, commentID)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to lock thread")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully locked thread")
 	return comment, nil
 }
 
+// EditPost is the resolver for the EditPost field.
+func (r *mutationResolver) EditPost(ctx context.Context, postID int, input models.EditPost) (*models.Post, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.EditPost"),
+		zap.Int("PostID", postID),
+	)
+	log.Info("Received request to edit post")
+
+	post, err := r.postService.EditPost(ctx, postID, input)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to edit post")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully edited post")
+	return post, nil
+}
+
+// EditComment is the resolver for the EditComment field.
+func (r *mutationResolver) EditComment(ctx context.Context, commentID int, payload string) (*models.Comment, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.EditComment"),
+		zap.Int("CommentID", commentID),
+	)
+	log.Info("Received request to edit comment")
+
+	comment, err := r.commentService.EditComment(ctx, commentID, payload)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to edit comment")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully edited comment")
+	return comment, nil
+}
+
+// MarkNotificationsRead is the resolver for the MarkNotificationsRead field.
+func (r *mutationResolver) MarkNotificationsRead(ctx context.Context, ids []int) (int, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.MarkNotificationsRead"),
+		zap.Ints("IDs", ids),
+	)
+	log.Info("Received request to mark notifications read")
+
+	count, err := r.notificationService.MarkNotificationsRead(ctx, ids)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to mark notifications read")
+		return 0, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Count", count)).Info("Successfully marked notifications read")
+	return count, nil
+}
+
+// ApproveContent is the resolver for the ApproveContent field.
+func (r *mutationResolver) ApproveContent(ctx context.Context, itemID int, reason *string) (*models.ModerationItem, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.ApproveContent"),
+		zap.Int("ItemID", itemID),
+	)
+	log.Info("Received request to approve content")
+
+	item, err := r.moderationService.ApproveContent(ctx, itemID, reason)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to approve content")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully approved content")
+	return item, nil
+}
+
+// RejectContent is the resolver for the RejectContent field.
+func (r *mutationResolver) RejectContent(ctx context.Context, itemID int, reason string) (*models.ModerationItem, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.RejectContent"),
+		zap.Int("ItemID", itemID),
+	)
+	log.Info("Received request to reject content")
+
+	item, err := r.moderationService.RejectContent(ctx, itemID, reason)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to reject content")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully rejected content")
+	return item, nil
+}
+
+// BanUser is the resolver for the BanUser field.
+func (r *mutationResolver) BanUser(ctx context.Context, userID int, until *time.Time, reason string) (*models.UserBan, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.BanUser"),
+		zap.Int("UserID", userID),
+	)
+	log.Info("Received request to ban user")
+
+	ban, err := r.moderationService.BanUser(ctx, userID, until, reason)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to ban user")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully banned user")
+	return ban, nil
+}
+
+// MuteUserOnPost is the resolver for the MuteUserOnPost field.
+func (r *mutationResolver) MuteUserOnPost(ctx context.Context, postID int, userID int, until *time.Time) (*models.PostMute, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.MuteUserOnPost"),
+		zap.Int("PostID", postID),
+		zap.Int("UserID", userID),
+	)
+	log.Info("Received request to mute user on post")
+
+	mute, err := r.moderationService.MuteUserOnPost(ctx, postID, userID, until)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to mute user on post")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully muted user on post")
+	return mute, nil
+}
+
+// BlockUser is the resolver for the BlockUser field.
+func (r *mutationResolver) BlockUser(ctx context.Context, userID int) (bool, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.BlockUser"),
+		zap.Int("UserID", userID),
+	)
+	log.Info("Received request to block user")
+
+	changed, err := r.blockService.BlockUser(ctx, userID)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to block user")
+		return false, errdefs.HandleError(err)
+	}
+	log.With(zap.Bool("Changed", changed)).Info("Successfully blocked user")
+	return changed, nil
+}
+
+// UnblockUser is the resolver for the UnblockUser field.
+func (r *mutationResolver) UnblockUser(ctx context.Context, userID int) (bool, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.UnblockUser"),
+		zap.Int("UserID", userID),
+	)
+	log.Info("Received request to unblock user")
+
+	changed, err := r.blockService.UnblockUser(ctx, userID)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to unblock user")
+		return false, errdefs.HandleError(err)
+	}
+	log.With(zap.Bool("Changed", changed)).Info("Successfully unblocked user")
+	return changed, nil
+}
+
+// Report is the resolver for the Report field.
+func (r *mutationResolver) Report(ctx context.Context, targetType models.TargetType, targetID int, reason models.ReportReason, note *string) (*models.Report, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.Report"),
+		zap.String("TargetType", string(targetType)),
+		zap.Int("TargetID", targetID),
+		zap.String("Reason", string(reason)),
+	)
+	log.Info("Received request to report content")
+
+	report, err := r.moderationService.Report(ctx, targetType, targetID, reason, note)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to report content")
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("ReportID", report.ID)).Info("Successfully reported content")
+	return report, nil
+}
+
+// CreateWebhook is the resolver for the CreateWebhook field.
+func (r *mutationResolver) CreateWebhook(ctx context.Context, input models.NewWebhook) (*models.Webhook, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.CreateWebhook"),
+	)
+	log.Info("Received request to create webhook")
+
+	webhook, err := r.webhookService.CreateWebhook(ctx, input)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to create webhook")
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("WebhookID", webhook.ID)).Info("Successfully created webhook")
+	return webhook, nil
+}
+
+// UpdateWebhook is the resolver for the UpdateWebhook field.
+func (r *mutationResolver) UpdateWebhook(ctx context.Context, webhookID int, input models.EditWebhook) (*models.Webhook, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.UpdateWebhook"),
+		zap.Int("WebhookID", webhookID),
+	)
+	log.Info("Received request to update webhook")
+
+	webhook, err := r.webhookService.UpdateWebhook(ctx, webhookID, input)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to update webhook")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully updated webhook")
+	return webhook, nil
+}
+
+// DeleteWebhook is the resolver for the DeleteWebhook field.
+func (r *mutationResolver) DeleteWebhook(ctx context.Context, webhookID int) (bool, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.DeleteWebhook"),
+		zap.Int("WebhookID", webhookID),
+	)
+	log.Info("Received request to delete webhook")
+
+	deleted, err := r.webhookService.DeleteWebhook(ctx, webhookID)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to delete webhook")
+		return false, errdefs.HandleError(err)
+	}
+	log.Info("Successfully deleted webhook")
+	return deleted, nil
+}
+
+// ReplayWebhookDelivery is the resolver for the ReplayWebhookDelivery field.
+func (r *mutationResolver) ReplayWebhookDelivery(ctx context.Context, deliveryID int) (*models.WebhookDelivery, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.ReplayWebhookDelivery"),
+		zap.Int("DeliveryID", deliveryID),
+	)
+	log.Info("Received request to replay webhook delivery")
+
+	delivery, err := r.webhookService.ReplayWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		log.With(zap.Error(err)).Error("Failed to replay webhook delivery")
+		return nil, errdefs.HandleError(err)
+	}
+	log.Info("Successfully scheduled webhook delivery replay")
+	return delivery, nil
+}
+
+// PayloadHTML is the resolver for the payloadHTML field.
+func (r *postResolver) PayloadHTML(ctx context.Context, obj *models.Post) (string, error) {
+	return r.renderer.Render(obj.Format, obj.Payload), nil
+}
+
 // Comments is the resolver for the comments field.
 func (r *postResolver) Comments(ctx context.Context, obj *models.Post, limit *int, offset *int) ([]*models.Comment, error) {
 	log := r.log.With(
@@ -85,6 +434,22 @@ func (r *postResolver) Comments(ctx context.Context, obj *models.Post, limit *in
 	return comments, nil
 }
 
+// Revisions is the resolver for the revisions field.
+func (r *postResolver) Revisions(ctx context.Context, obj *models.Post) ([]*models.Revision, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.PostRevisions"),
+		zap.Int("PostID", obj.ID),
+	)
+	log.Info("Received request to get post revisions")
+
+	revisions, err := r.postService.GetPostRevisions(ctx, obj)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Revisions", len(revisions))).Info("Successfully got post revisions")
+	return revisions, nil
+}
+
 // GetPostByID is the resolver for the GetPostByID field.
 func (r *queryResolver) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
 	log := r.log.With(
@@ -117,6 +482,114 @@ func (r *queryResolver) GetAllPosts(ctx context.Context, limit *int, offset *int
 	return posts, nil
 }
 
+// PostsByTag is the resolver for the PostsByTag field.
+func (r *queryResolver) PostsByTag(ctx context.Context, tag string, limit *int, offset *int) ([]*models.Post, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.PostsByTag"),
+		zap.String("Tag", tag),
+	)
+	log.Info("Received request to get posts by tag")
+
+	posts, err := r.postService.GetPostsByTag(ctx, tag, limit, offset)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Posts", len(posts))).Info("Successfully got posts by tag")
+	return posts, nil
+}
+
+// Tags is the resolver for the Tags field.
+func (r *queryResolver) Tags(ctx context.Context, prefix string, limit *int) ([]string, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.Tags"),
+		zap.String("Prefix", prefix),
+	)
+	log.Info("Received request to get tags")
+
+	tags, err := r.postService.GetTags(ctx, prefix, limit)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Tags", len(tags))).Info("Successfully got tags")
+	return tags, nil
+}
+
+// Notifications is the resolver for the Notifications field.
+func (r *queryResolver) Notifications(ctx context.Context, unreadOnly *bool, first *int, after *int) (*models.NotificationConnection, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.Notifications"),
+	)
+	log.Info("Received request to get notifications")
+
+	connection, err := r.notificationService.GetNotifications(ctx, unreadOnly, first, after)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Notifications", len(connection.Nodes))).Info("Successfully got notifications")
+	return connection, nil
+}
+
+// ModerationQueue is the resolver for the ModerationQueue field.
+func (r *queryResolver) ModerationQueue(ctx context.Context, status *models.ModerationStatus, first *int, after *int) (*models.ModerationQueueConnection, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.ModerationQueue"),
+	)
+	log.Info("Received request to get moderation queue")
+
+	connection, err := r.moderationService.GetModerationQueue(ctx, status, first, after)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Items", len(connection.Nodes))).Info("Successfully got moderation queue")
+	return connection, nil
+}
+
+// Reports is the resolver for the Reports field.
+func (r *queryResolver) Reports(ctx context.Context, limit *int, offset *int) ([]*models.ReportSummary, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.Reports"),
+	)
+	log.Info("Received request to get reports")
+
+	reports, err := r.moderationService.GetReports(ctx, limit, offset)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Targets", len(reports))).Info("Successfully got reports")
+	return reports, nil
+}
+
+// Webhooks is the resolver for the Webhooks field.
+func (r *queryResolver) Webhooks(ctx context.Context) ([]*models.Webhook, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.Webhooks"),
+	)
+	log.Info("Received request to get webhooks")
+
+	webhooks, err := r.webhookService.GetWebhooks(ctx)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Webhooks", len(webhooks))).Info("Successfully got webhooks")
+	return webhooks, nil
+}
+
+// WebhookDeliveries is the resolver for the WebhookDeliveries field.
+func (r *queryResolver) WebhookDeliveries(ctx context.Context, webhookID int, status *models.WebhookDeliveryStatus, limit *int, offset *int) ([]*models.WebhookDelivery, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.WebhookDeliveries"),
+		zap.Int("WebhookID", webhookID),
+	)
+	log.Info("Received request to get webhook deliveries")
+
+	deliveries, err := r.webhookService.GetWebhookDeliveries(ctx, webhookID, status, limit, offset)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	log.With(zap.Int("Deliveries", len(deliveries))).Info("Successfully got webhook deliveries")
+	return deliveries, nil
+}
+
 // CommentsSubscription is the resolver for the CommentsSubscription field.
 func (r *subscriptionResolver) CommentsSubscription(ctx context.Context, postID int) (<-chan *models.Comment, error) {
 	log := r.log.With(
@@ -124,6 +597,12 @@ func (r *subscriptionResolver) CommentsSubscription(ctx context.Context, postID
 	)
 	log.Info("Received request to get comments subscription")
 
+	// Подписаться можно только на существующий пост, который виден текущему пользователю
+	if _, err := r.postService.GetPostByID(ctx, postID); err != nil {
+		log.Error("Failed to get post", zap.Int("PostID", postID), zap.Error(err))
+		return nil, errdefs.HandleError(err)
+	}
+
 	ch, err := r.subscriptionManager.CreateSubscription(ctx, postID)
 	if err != nil {
 		return nil, errdefs.HandleError(err)
@@ -137,7 +616,68 @@ func (r *subscriptionResolver) CommentsSubscription(ctx context.Context, postID
 	}()
 
 	log.Info("Successfully got comments subscription")
-	return ch, nil
+	return r.blockService.FilterBlockedComments(ctx, ch), nil
+}
+
+// MentionsSubscription is the resolver for the MentionsSubscription field.
+func (r *subscriptionResolver) MentionsSubscription(ctx context.Context, userID int) (<-chan *models.Comment, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.MentionsSubscription"),
+		zap.Int("UserID", userID),
+	)
+	log.Info("Received request to get mentions subscription")
+
+	// Подписаться можно только на свои упоминания
+	viewerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, errdefs.HandleError(errdefs.UnauthenticatedError())
+	}
+	if viewerID != userID {
+		return nil, errdefs.HandleError(errdefs.ForbiddenError(viewerID))
+	}
+
+	ch, err := r.subscriptionManager.CreateMentionsSubscription(ctx, userID)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	go func() {
+		<-ctx.Done()
+		err = r.subscriptionManager.DeleteMentionsSubscription(ctx, userID, ch)
+		if err != nil {
+			log.Error("Failed to delete subscription")
+		}
+	}()
+
+	log.Info("Successfully got mentions subscription")
+	return forwardSubscription(ctx, ch), nil
+}
+
+// NotificationsSubscription is the resolver for the NotificationsSubscription field.
+func (r *subscriptionResolver) NotificationsSubscription(ctx context.Context) (<-chan *models.Notification, error) {
+	log := r.log.With(
+		zap.String("Layer", "Resolver.NotificationsSubscription"),
+	)
+	log.Info("Received request to get notifications subscription")
+
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, errdefs.HandleError(errdefs.UnauthenticatedError())
+	}
+
+	ch, err := r.subscriptionManager.CreateNotificationsSubscription(ctx, userID)
+	if err != nil {
+		return nil, errdefs.HandleError(err)
+	}
+	go func() {
+		<-ctx.Done()
+		err = r.subscriptionManager.DeleteNotificationsSubscription(ctx, userID, ch)
+		if err != nil {
+			log.Error("Failed to delete subscription")
+		}
+	}()
+
+	log.With(zap.Int("UserID", userID)).Info("Successfully got notifications subscription")
+	return forwardSubscription(ctx, ch), nil
 }
 
 // Comment returns graph.CommentResolver implementation.
@@ -11,6 +11,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
 	"github.com/Quizert/PostCommentService/internal/models"
 	"github.com/Quizert/PostCommentService/internal/resolvers/mocks"
 	"go.uber.org/zap"
@@ -25,7 +27,7 @@ func TestCommentResolver_Replies(t *testing.T) {
 	subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
 
 	logger := zap.NewNop()
-	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock)
+	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock, nil, nil, nil, nil, nil)
 	commentResolver := res.Comment()
 
 	ctx := context.Background()
@@ -75,7 +77,7 @@ func TestMutationResolver_CreatePost(t *testing.T) {
 	subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
 
 	logger := zap.NewNop()
-	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock)
+	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock, nil, nil, nil, nil, nil)
 	mutationResolver := res.Mutation()
 
 	ctx := context.Background()
@@ -120,7 +122,7 @@ func TestMutationResolver_CreateComment(t *testing.T) {
 	subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
 
 	logger := zap.NewNop()
-	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock)
+	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock, nil, nil, nil, nil, nil)
 	mutationResolver := res.Mutation()
 
 	ctx := context.Background()
@@ -134,12 +136,7 @@ func TestMutationResolver_CreateComment(t *testing.T) {
 			CreateComment(gomock.Any(), input).
 			Return(createdComment, nil).
 			Times(1)
-
-		subscriptionServiceMock.
-			EXPECT().
-			Notify(gomock.Any(), createdComment).
-			Return(nil).
-			Times(1)
+		// Подписчиков резолвер не трогает: комментарий им разошлёт диспетчер outbox
 
 		got, err := mutationResolver.CreateComment(ctx, input)
 		require.NoError(t, err)
@@ -160,29 +157,6 @@ func TestMutationResolver_CreateComment(t *testing.T) {
 		require.Error(t, err)
 		assert.ErrorAs(t, err, &appErr)
 	})
-
-	t.Run("notify error", func(t *testing.T) {
-		createdComment := &models.Comment{ID: 11, Payload: "hello"}
-
-		commentServiceMock.
-			EXPECT().
-			CreateComment(gomock.Any(), input).
-			Return(createdComment, nil).
-			Times(1)
-
-		subscriptionServiceMock.
-			EXPECT().
-			Notify(gomock.Any(), createdComment).
-			Return(errors.New("notify failed")).
-			Times(1)
-
-		got, err := mutationResolver.CreateComment(ctx, input)
-		assert.Nil(t, got)
-
-		var appErr *gqlerror.Error
-		require.Error(t, err)
-		assert.ErrorAs(t, err, &appErr)
-	})
 }
 
 func TestPostResolver_Comments(t *testing.T) {
@@ -194,7 +168,7 @@ func TestPostResolver_Comments(t *testing.T) {
 	subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
 
 	logger := zap.NewNop()
-	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock)
+	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock, nil, nil, nil, nil, nil)
 	postResolver := res.Post()
 
 	ctx := context.Background()
@@ -239,7 +213,7 @@ func TestQueryResolver_GetPostByID(t *testing.T) {
 	subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
 
 	logger := zap.NewNop()
-	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock)
+	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock, nil, nil, nil, nil, nil)
 	queryResolver := res.Query()
 
 	ctx := context.Background()
@@ -281,7 +255,7 @@ func TestQueryResolver_GetAllPosts(t *testing.T) {
 	subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
 
 	logger := zap.NewNop()
-	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock)
+	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock, nil, nil, nil, nil, nil)
 	queryResolver := res.Query()
 
 	ctx := context.Background()
@@ -323,26 +297,52 @@ func TestSubscriptionResolver_CommentsSubscription(t *testing.T) {
 	postServiceMock := mocks.NewMockPostService(ctl)
 	commentServiceMock := mocks.NewMockCommentService(ctl)
 	subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
+	blockServiceMock := mocks.NewMockBlockService(ctl)
 
 	logger := zap.NewNop()
-	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock)
+	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock, nil, nil, nil, blockServiceMock, nil)
 	subscriptionResolver := res.Subscription()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	t.Run("invisible post", func(t *testing.T) {
+		// Черновик или несуществующий пост: подписка не регистрируется
+		postServiceMock.
+			EXPECT().
+			GetPostByID(gomock.Any(), 404).
+			Return(nil, errdefs.PostDoesNotExistError(404)).
+			Times(1)
+
+		got, err := subscriptionResolver.CommentsSubscription(ctx, 404)
+		assert.Nil(t, got)
+		assert.Equal(t, errdefs.HandleError(errdefs.PostDoesNotExistError(404)), err)
+	})
+
 	t.Run("success", func(t *testing.T) {
 		ch := make(chan *models.Comment)
+		filtered := make(<-chan *models.Comment)
 
+		postServiceMock.
+			EXPECT().
+			GetPostByID(gomock.Any(), 123).
+			Return(&models.Post{ID: 123}, nil).
+			Times(1)
 		subscriptionServiceMock.
 			EXPECT().
 			CreateSubscription(gomock.Any(), 123).
 			Return(ch, nil).
 			Times(1)
+		// Комментарии заблокированных авторов отсеиваются для текущего пользователя
+		blockServiceMock.
+			EXPECT().
+			FilterBlockedComments(gomock.Any(), (<-chan *models.Comment)(ch)).
+			Return(filtered).
+			Times(1)
 
 		got, err := subscriptionResolver.CommentsSubscription(ctx, 123)
 		require.NoError(t, err)
-		assert.Equal(t, (<-chan *models.Comment)(ch), got)
+		assert.Equal(t, filtered, got)
 
 		done := make(chan struct{})
 
@@ -364,3 +364,189 @@ func TestSubscriptionResolver_CommentsSubscription(t *testing.T) {
 		}
 	})
 }
+
+func TestSubscriptionResolver_MentionsSubscription(t *testing.T) {
+	tests := []struct {
+		name          string
+		viewer        int
+		expectedError error
+	}{
+		{
+			name:          "anonymous",
+			expectedError: errdefs.HandleError(errdefs.UnauthenticatedError()),
+		},
+		{
+			name:          "someone else's mentions",
+			viewer:        2,
+			expectedError: errdefs.HandleError(errdefs.ForbiddenError(2)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
+			res := NewResolver(zap.NewNop(), nil, nil, subscriptionServiceMock, nil, nil, nil, nil, nil)
+
+			ctx := context.Background()
+			if tt.viewer != 0 {
+				ctx = auth.WithUserID(ctx, tt.viewer)
+			}
+			got, err := res.Subscription().MentionsSubscription(ctx, 1)
+			assert.Nil(t, got)
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
+func TestQueryResolver_Tags(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postServiceMock := mocks.NewMockPostService(ctl)
+	commentServiceMock := mocks.NewMockCommentService(ctl)
+	subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
+
+	logger := zap.NewNop()
+	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock, nil, nil, nil, nil, nil)
+	queryResolver := res.Query()
+
+	ctx := context.Background()
+	limit := 5
+
+	t.Run("success", func(t *testing.T) {
+		tags := []string{"go", "golang"}
+		postServiceMock.
+			EXPECT().
+			GetTags(gomock.Any(), "go", &limit).
+			Return(tags, nil).
+			Times(1)
+
+		got, err := queryResolver.Tags(ctx, "go", &limit)
+		require.NoError(t, err)
+		require.Equal(t, tags, got)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		postServiceMock.
+			EXPECT().
+			GetTags(gomock.Any(), "go", &limit).
+			Return(nil, errors.New("some error")).
+			Times(1)
+
+		got, err := queryResolver.Tags(ctx, "go", &limit)
+		assert.Nil(t, got)
+		var appErr *gqlerror.Error
+		require.Error(t, err)
+		assert.ErrorAs(t, err, &appErr)
+	})
+}
+
+func TestMutationResolver_PublishPost(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postServiceMock := mocks.NewMockPostService(ctl)
+	commentServiceMock := mocks.NewMockCommentService(ctl)
+	subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
+
+	logger := zap.NewNop()
+	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock, nil, nil, nil, nil, nil)
+	mutationResolver := res.Mutation()
+
+	ctx := context.Background()
+	publishAt := time.Now().Add(time.Hour)
+
+	t.Run("success", func(t *testing.T) {
+		post := &models.Post{ID: 1, Status: models.PostStatusScheduled, PublishAt: &publishAt}
+		postServiceMock.
+			EXPECT().
+			PublishPost(gomock.Any(), 1, &publishAt).
+			Return(post, nil).
+			Times(1)
+
+		got, err := mutationResolver.PublishPost(ctx, 1, &publishAt)
+		require.NoError(t, err)
+		require.Equal(t, post, got)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		postServiceMock.
+			EXPECT().
+			PublishPost(gomock.Any(), 1, &publishAt).
+			Return(nil, errors.New("some error")).
+			Times(1)
+
+		got, err := mutationResolver.PublishPost(ctx, 1, &publishAt)
+		assert.Nil(t, got)
+		var appErr *gqlerror.Error
+		require.Error(t, err)
+		assert.ErrorAs(t, err, &appErr)
+	})
+}
+
+func TestMutationResolver_LockThread(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postServiceMock := mocks.NewMockPostService(ctl)
+	commentServiceMock := mocks.NewMockCommentService(ctl)
+	subscriptionServiceMock := mocks.NewMockSubscriptionService(ctl)
+
+	logger := zap.NewNop()
+	res := NewResolver(logger, postServiceMock, commentServiceMock, subscriptionServiceMock, nil, nil, nil, nil, nil)
+	mutationResolver := res.Mutation()
+
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		comment := &models.Comment{ID: 7, IsLocked: true}
+		commentServiceMock.
+			EXPECT().
+			LockThread(gomock.Any(), 7).
+			Return(comment, nil).
+			Times(1)
+
+		got, err := mutationResolver.LockThread(ctx, 7)
+		require.NoError(t, err)
+		require.Equal(t, comment, got)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		commentServiceMock.
+			EXPECT().
+			LockThread(gomock.Any(), 7).
+			Return(nil, errors.New("some error")).
+			Times(1)
+
+		got, err := mutationResolver.LockThread(ctx, 7)
+		assert.Nil(t, got)
+		var appErr *gqlerror.Error
+		require.Error(t, err)
+		assert.ErrorAs(t, err, &appErr)
+	})
+}
+
+func TestPostResolver_PayloadHTML(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	rendererMock := mocks.NewMockRenderer(ctl)
+
+	logger := zap.NewNop()
+	res := NewResolver(logger, nil, nil, nil, nil, nil, rendererMock, nil, nil)
+	postResolver := res.Post()
+
+	post := &models.Post{ID: 1, Payload: "**hi**", Format: models.ContentFormatMarkdown}
+	rendererMock.
+		EXPECT().
+		Render(models.ContentFormatMarkdown, "**hi**").
+		Return("<p><strong>hi</strong></p>\n").
+		Times(1)
+
+	got, err := postResolver.PayloadHTML(context.Background(), post)
+	require.NoError(t, err)
+	assert.Equal(t, "<p><strong>hi</strong></p>\n", got)
+}
@@ -0,0 +1,412 @@
+package rest
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Prefix - путь, под которым REST API монтируется в общий mux
+const Prefix = "/api/v1"
+
+// maxBodySize ограничивает тело запроса на создание поста или комментария
+const maxBodySize = 1 << 20
+
+//go:embed openapi.json
+var openAPI []byte
+
+//go:generate mockgen -source=rest.go -destination=mocks/services-mock.go -package=mocks
+type PostService interface {
+	CreatePost(ctx context.Context, input models.NewPost) (*models.Post, error)
+	GetPostByID(ctx context.Context, id int) (*models.Post, error)
+	GetAllPosts(ctx context.Context, limit *int, offset *int) ([]*models.Post, error)
+	GetPostsByTag(ctx context.Context, tag string, limit *int, offset *int) ([]*models.Post, error)
+}
+
+type CommentService interface {
+	CreateComment(ctx context.Context, input models.NewComment) (*models.Comment, error)
+	GetCommentsByPostID(ctx context.Context, limit *int, offset *int, postID int) ([]*models.Comment, error)
+	Replies(ctx context.Context, commentID int, limit *int, offset *int) ([]*models.Comment, error)
+}
+
+type Renderer interface {
+	Render(format models.ContentFormat, payload string) string
+}
+
+// Handler - REST/JSON API поверх тех же сервисов, что и GraphQL. Ошибки отдаются в формате GraphQL
+// ({"errors": [{"message", "extensions": {"code", "details"}}]}) со статусом из errdefs.HTTPStatus
+type Handler struct {
+	log            *zap.Logger
+	postService    PostService
+	commentService CommentService
+	renderer       Renderer
+	mux            *http.ServeMux
+}
+
+func NewHandler(log *zap.Logger, postService PostService, commentService CommentService, renderer Renderer) *Handler {
+	h := &Handler{
+		log:            log,
+		postService:    postService,
+		commentService: commentService,
+		renderer:       renderer,
+		mux:            http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET /posts", h.getPosts)
+	h.mux.HandleFunc("POST /posts", h.createPost)
+	h.mux.HandleFunc("GET /posts/{id}", h.getPost)
+	h.mux.HandleFunc("GET /posts/{id}/comments", h.getComments)
+	h.mux.HandleFunc("POST /posts/{id}/comments", h.createComment)
+	h.mux.HandleFunc("GET /comments/{id}/replies", h.getReplies)
+	h.mux.HandleFunc("GET /openapi.json", h.getOpenAPI)
+	return h
+}
+
+// ServeHTTP обрабатывает запросы с путями без Prefix, поэтому в mux монтируется через http.StripPrefix
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+type newPostRequest struct {
+	Title   string `json:"title"`
+	Payload string `json:"payload"`
+	// AuthorID - автор поста, как в мутации CreatePost
+	AuthorID int `json:"authorID"`
+	// IsCommentsAllowed по умолчанию true
+	IsCommentsAllowed *bool                 `json:"isCommentsAllowed"`
+	Format            *models.ContentFormat `json:"format"`
+	Tags              []string              `json:"tags"`
+	Status            *models.PostStatus    `json:"status"`
+	PublishAt         *time.Time            `json:"publishAt"`
+}
+
+type newCommentRequest struct {
+	Payload  string                `json:"payload"`
+	AuthorID int                   `json:"authorID"`
+	Format   *models.ContentFormat `json:"format"`
+	ReplyTo  *int                  `json:"replyTo"`
+}
+
+func (h *Handler) getPosts(w http.ResponseWriter, r *http.Request) {
+	log := h.log.With(
+		zap.String("Layer", "REST.GetPosts"),
+	)
+	log.Info("Received request to get posts")
+
+	limit, offset, err := pagination(r)
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+
+	var posts []*models.Post
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		posts, err = h.postService.GetPostsByTag(r.Context(), tag, limit, offset)
+	} else {
+		posts, err = h.postService.GetAllPosts(r.Context(), limit, offset)
+	}
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	rendered := make([]*models.Post, 0, len(posts))
+	for _, post := range posts {
+		rendered = append(rendered, h.renderPost(post))
+	}
+	log.With(zap.Int("Posts", len(rendered))).Info("Successfully got posts")
+	writeJSON(w, http.StatusOK, rendered)
+}
+
+func (h *Handler) createPost(w http.ResponseWriter, r *http.Request) {
+	log := h.log.With(
+		zap.String("Layer", "REST.CreatePost"),
+	)
+	log.Info("Received request to create new post")
+
+	var req newPostRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	if err := validateAuthor(req.AuthorID); err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	if err := validateFormat(req.Format); err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	if req.Status != nil && !req.Status.IsValid() {
+		h.writeError(w, log, errdefs.InvalidRequestError("status", "unknown post status"))
+		return
+	}
+	isCommentsAllowed := true
+	if req.IsCommentsAllowed != nil {
+		isCommentsAllowed = *req.IsCommentsAllowed
+	}
+
+	post, err := h.postService.CreatePost(r.Context(), models.NewPost{
+		Title:             req.Title,
+		Payload:           req.Payload,
+		AuthorID:          req.AuthorID,
+		IsCommentsAllowed: isCommentsAllowed,
+		Format:            req.Format,
+		Tags:              req.Tags,
+		Status:            req.Status,
+		PublishAt:         req.PublishAt,
+	})
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	log.With(zap.Int("PostID", post.ID)).Info("Successfully created new post")
+	w.Header().Set("Location", Prefix+"/posts/"+strconv.Itoa(post.ID))
+	writeJSON(w, http.StatusCreated, h.renderPost(post))
+}
+
+func (h *Handler) getPost(w http.ResponseWriter, r *http.Request) {
+	log := h.log.With(
+		zap.String("Layer", "REST.GetPost"),
+	)
+	log.Info("Received request to get post by id")
+
+	postID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	post, err := h.postService.GetPostByID(r.Context(), postID)
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	log.With(zap.Int("PostID", post.ID)).Info("Successfully got post by id")
+	writeJSON(w, http.StatusOK, h.renderPost(post))
+}
+
+func (h *Handler) getComments(w http.ResponseWriter, r *http.Request) {
+	log := h.log.With(
+		zap.String("Layer", "REST.GetComments"),
+	)
+	log.Info("Received request to get comments")
+
+	postID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	limit, offset, err := pagination(r)
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	// В GraphQL комментарии запрашиваются через пост, здесь пост проверяется отдельно, чтобы на скрытый
+	// или несуществующий пост ответить 404, а не пустым списком
+	if _, err = h.postService.GetPostByID(r.Context(), postID); err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+
+	comments, err := h.commentService.GetCommentsByPostID(r.Context(), limit, offset, postID)
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	comments = h.renderComments(comments)
+	log.With(zap.Int("PostID", postID), zap.Int("Comments", len(comments))).Info("Successfully got comments")
+	writeJSON(w, http.StatusOK, comments)
+}
+
+func (h *Handler) createComment(w http.ResponseWriter, r *http.Request) {
+	log := h.log.With(
+		zap.String("Layer", "REST.CreateComment"),
+	)
+	log.Info("Received request to create new comment")
+
+	postID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	var req newCommentRequest
+	if err = decodeBody(w, r, &req); err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	if err = validateAuthor(req.AuthorID); err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	if err = validateFormat(req.Format); err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(r.Context(), models.NewComment{
+		Payload:  req.Payload,
+		Format:   req.Format,
+		PostID:   postID,
+		AuthorID: req.AuthorID,
+		ReplyTo:  req.ReplyTo,
+	})
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	log.With(zap.Int("PostID", postID), zap.Int("CommentID", comment.ID)).Info("Successfully created new comment")
+	writeJSON(w, http.StatusCreated, h.renderComment(comment))
+}
+
+func (h *Handler) getReplies(w http.ResponseWriter, r *http.Request) {
+	log := h.log.With(
+		zap.String("Layer", "REST.GetReplies"),
+	)
+	log.Info("Received request to get replies")
+
+	commentID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	limit, offset, err := pagination(r)
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+
+	replies, err := h.commentService.Replies(r.Context(), commentID, limit, offset)
+	if err != nil {
+		h.writeError(w, log, err)
+		return
+	}
+	replies = h.renderComments(replies)
+	log.With(zap.Int("CommentID", commentID), zap.Int("Replies", len(replies))).Info("Successfully got replies")
+	writeJSON(w, http.StatusOK, replies)
+}
+
+func (h *Handler) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPI)
+}
+
+// renderPost возвращает копию поста с payloadHTML, который в GraphQL вычисляет резолвер поля. Пост без тегов
+// отдаётся с пустым списком, как в GraphQL. Сам пост не меняется: его может одновременно отдавать кэш другим запросам
+func (h *Handler) renderPost(post *models.Post) *models.Post {
+	rendered := *post
+	rendered.PayloadHTML = h.renderer.Render(post.Format, post.Payload)
+	if rendered.Tags == nil {
+		rendered.Tags = []string{}
+	}
+	return &rendered
+}
+
+// renderComment работает как renderPost
+func (h *Handler) renderComment(comment *models.Comment) *models.Comment {
+	rendered := *comment
+	rendered.PayloadHTML = h.renderer.Render(comment.Format, comment.Payload)
+	return &rendered
+}
+
+func (h *Handler) renderComments(comments []*models.Comment) []*models.Comment {
+	rendered := make([]*models.Comment, 0, len(comments))
+	for _, comment := range comments {
+		rendered = append(rendered, h.renderComment(comment))
+	}
+	return rendered
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, log *zap.Logger, err error) {
+	status := errdefs.HTTPStatus(err)
+	if status >= http.StatusInternalServerError {
+		log.With(zap.Error(err)).Error("Request failed")
+	} else {
+		log.With(zap.Error(err), zap.Int("Status", status)).Info("Request rejected")
+	}
+
+	var appErr *errdefs.AppError
+	if errors.As(err, &appErr) {
+		if retryAfter, ok := appErr.Extensions["retryAfter"].(int); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		}
+	}
+	writeJSON(w, status, map[string]interface{}{
+		"errors": []*gqlerror.Error{errdefs.HandleError(err)},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	// payloadHTML отдаётся как есть, без \u003c вместо <
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+}
+
+// decodeBody читает JSON-тело запроса. Неизвестные поля - ошибка, чтобы опечатка в имени поля не терялась молча
+func decodeBody(w http.ResponseWriter, r *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		return errdefs.InvalidRequestError("body", err.Error())
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errdefs.InvalidRequestError("body", "must contain a single JSON object")
+	}
+	return nil
+}
+
+func pathID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		return 0, errdefs.InvalidRequestError("id", "must be a positive integer")
+	}
+	return id, nil
+}
+
+// pagination разбирает limit и offset из query. Отсутствующие значения - nil, их заменяют значения по умолчанию сервисов
+func pagination(r *http.Request) (*int, *int, error) {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		return nil, nil, err
+	}
+	offset, err := queryInt(r, "offset")
+	if err != nil {
+		return nil, nil, err
+	}
+	return limit, offset, nil
+}
+
+func queryInt(r *http.Request, key string) (*int, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return nil, errdefs.InvalidRequestError(key, "must be a non-negative integer")
+	}
+	return &number, nil
+}
+
+func validateAuthor(authorID int) error {
+	if authorID <= 0 {
+		return errdefs.InvalidRequestError("authorID", "is required")
+	}
+	return nil
+}
+
+func validateFormat(format *models.ContentFormat) error {
+	if format != nil && !format.IsValid() {
+		return errdefs.InvalidRequestError("format", "unknown content format")
+	}
+	return nil
+}
@@ -0,0 +1,552 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/moderation"
+	"github.com/Quizert/PostCommentService/internal/ratelimit"
+	"github.com/Quizert/PostCommentService/internal/render"
+	"github.com/Quizert/PostCommentService/internal/rest/mocks"
+	"github.com/Quizert/PostCommentService/internal/service"
+	in_memory "github.com/Quizert/PostCommentService/internal/storage/in-memory"
+	"go.uber.org/zap"
+)
+
+type errorBody struct {
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code    string                 `json:"code"`
+			Details map[string]interface{} `json:"details"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+var testAuthenticator = auth.NewAuthenticator([]byte("test-secret"), false)
+
+func newTestServer(t *testing.T, postService PostService, commentService CommentService) *httptest.Server {
+	handler := NewHandler(zap.NewNop(), postService, commentService, render.NewRenderer(10))
+	mux := http.NewServeMux()
+	mux.Handle(Prefix+"/", http.StripPrefix(Prefix, testAuthenticator.Middleware(handler)))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func doRequest(t *testing.T, method, url, body string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	token, err := testAuthenticator.IssueToken(1, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func decodeError(t *testing.T, resp *http.Response) string {
+	var body errorBody
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Errors, 1)
+	return body.Errors[0].Extensions.Code
+}
+
+func TestHandler_GetPosts(t *testing.T) {
+	posts := []*models.Post{
+		{ID: 1, Title: "first", Payload: "**bold**", Format: models.ContentFormatMarkdown, Author: &models.User{ID: 1, Username: "Alice"}, Tags: []string{"go"}},
+	}
+	limit, offset := 5, 10
+
+	tests := []struct {
+		name         string
+		query        string
+		setup        func(postService *mocks.MockPostService)
+		expectedCode int
+		errorCode    string
+	}{
+		{
+			name:  "all posts with pagination",
+			query: "?limit=5&offset=10",
+			setup: func(postService *mocks.MockPostService) {
+				postService.EXPECT().GetAllPosts(gomock.Any(), &limit, &offset).Return(posts, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:  "posts by tag with default pagination",
+			query: "?tag=go",
+			setup: func(postService *mocks.MockPostService) {
+				postService.EXPECT().GetPostsByTag(gomock.Any(), "go", nil, nil).Return(posts, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid limit",
+			query:        "?limit=ten",
+			setup:        func(postService *mocks.MockPostService) {},
+			expectedCode: http.StatusBadRequest,
+			errorCode:    "INVALID_REQUEST",
+		},
+		{
+			name:  "internal error",
+			query: "",
+			setup: func(postService *mocks.MockPostService) {
+				postService.EXPECT().GetAllPosts(gomock.Any(), nil, nil).Return(nil, errdefs.InternalServerError())
+			},
+			expectedCode: http.StatusInternalServerError,
+			errorCode:    "INTERNAL_SERVER_ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postService := mocks.NewMockPostService(ctl)
+			tt.setup(postService)
+			server := newTestServer(t, postService, mocks.NewMockCommentService(ctl))
+
+			resp := doRequest(t, http.MethodGet, server.URL+Prefix+"/posts"+tt.query, "")
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			if tt.errorCode != "" {
+				assert.Equal(t, tt.errorCode, decodeError(t, resp))
+				return
+			}
+
+			var got []map[string]interface{}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			require.Len(t, got, 1)
+			assert.Equal(t, "first", got[0]["title"])
+			assert.Equal(t, "<p><strong>bold</strong></p>\n", got[0]["payloadHTML"])
+			assert.Equal(t, map[string]interface{}{"id": float64(1), "username": "Alice"}, got[0]["author"])
+		})
+	}
+}
+
+func TestHandler_GetPost(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		setup        func(postService *mocks.MockPostService)
+		expectedCode int
+		errorCode    string
+	}{
+		{
+			name: "found",
+			path: "/posts/3",
+			setup: func(postService *mocks.MockPostService) {
+				postService.EXPECT().GetPostByID(gomock.Any(), 3).Return(&models.Post{ID: 3, Payload: "text", Format: models.ContentFormatPlain}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "not found",
+			path: "/posts/4",
+			setup: func(postService *mocks.MockPostService) {
+				postService.EXPECT().GetPostByID(gomock.Any(), 4).Return(nil, errdefs.PostDoesNotExistError(4))
+			},
+			expectedCode: http.StatusNotFound,
+			errorCode:    "POST_DOES_NOT_EXIST",
+		},
+		{
+			name:         "invalid id",
+			path:         "/posts/abc",
+			setup:        func(postService *mocks.MockPostService) {},
+			expectedCode: http.StatusBadRequest,
+			errorCode:    "INVALID_REQUEST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postService := mocks.NewMockPostService(ctl)
+			tt.setup(postService)
+			server := newTestServer(t, postService, mocks.NewMockCommentService(ctl))
+
+			resp := doRequest(t, http.MethodGet, server.URL+Prefix+tt.path, "")
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+			if tt.errorCode != "" {
+				assert.Equal(t, tt.errorCode, decodeError(t, resp))
+				return
+			}
+			raw, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(raw), `"payloadHTML":"<p>text</p>\n"`, "HTML не экранируется")
+			assert.Contains(t, string(raw), `"tags":[]`)
+			var got models.Post
+			require.NoError(t, json.Unmarshal(raw, &got))
+			assert.Equal(t, 3, got.ID)
+		})
+	}
+}
+
+func TestHandler_CreatePost(t *testing.T) {
+	markdown := models.ContentFormatMarkdown
+	scheduled := models.PostStatusScheduled
+	publishAt := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		body         string
+		setup        func(postService *mocks.MockPostService)
+		expectedCode int
+		errorCode    string
+	}{
+		{
+			name: "created with comments allowed by default",
+			body: `{"title":"title","payload":"payload","authorID":1,"format":"MARKDOWN","tags":["go"],"status":"SCHEDULED","publishAt":"2030-01-01T12:00:00Z"}`,
+			setup: func(postService *mocks.MockPostService) {
+				postService.EXPECT().CreatePost(gomock.Any(), models.NewPost{
+					Title:             "title",
+					Payload:           "payload",
+					AuthorID:          1,
+					IsCommentsAllowed: true,
+					Format:            &markdown,
+					Tags:              []string{"go"},
+					Status:            &scheduled,
+					PublishAt:         &publishAt,
+				}).DoAndReturn(func(ctx context.Context, input models.NewPost) (*models.Post, error) {
+					userID, ok := auth.UserIDFromContext(ctx)
+					assert.True(t, ok)
+					assert.Equal(t, 1, userID)
+					return &models.Post{ID: 7, Title: input.Title, Payload: input.Payload, Format: *input.Format}, nil
+				})
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "comments disabled",
+			body: `{"title":"title","payload":"payload","authorID":1,"isCommentsAllowed":false}`,
+			setup: func(postService *mocks.MockPostService) {
+				postService.EXPECT().CreatePost(gomock.Any(), models.NewPost{Title: "title", Payload: "payload", AuthorID: 1}).
+					Return(&models.Post{ID: 7}, nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "malformed json",
+			body:         `{"title":`,
+			setup:        func(postService *mocks.MockPostService) {},
+			expectedCode: http.StatusBadRequest,
+			errorCode:    "INVALID_REQUEST",
+		},
+		{
+			name:         "unknown field",
+			body:         `{"title":"title","payload":"payload","authorID":1,"allowComments":true}`,
+			setup:        func(postService *mocks.MockPostService) {},
+			expectedCode: http.StatusBadRequest,
+			errorCode:    "INVALID_REQUEST",
+		},
+		{
+			name:         "missing author",
+			body:         `{"title":"title","payload":"payload"}`,
+			setup:        func(postService *mocks.MockPostService) {},
+			expectedCode: http.StatusBadRequest,
+			errorCode:    "INVALID_REQUEST",
+		},
+		{
+			name:         "unknown format",
+			body:         `{"title":"title","payload":"payload","authorID":1,"format":"HTML"}`,
+			setup:        func(postService *mocks.MockPostService) {},
+			expectedCode: http.StatusBadRequest,
+			errorCode:    "INVALID_REQUEST",
+		},
+		{
+			name: "rejected by moderation",
+			body: `{"title":"title","payload":"spam","authorID":1}`,
+			setup: func(postService *mocks.MockPostService) {
+				postService.EXPECT().CreatePost(gomock.Any(), gomock.Any()).Return(nil, errdefs.ContentRejectedError("stopwords", "spam"))
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+			errorCode:    "CONTENT_REJECTED",
+		},
+		{
+			name: "banned",
+			body: `{"title":"title","payload":"payload","authorID":1}`,
+			setup: func(postService *mocks.MockPostService) {
+				postService.EXPECT().CreatePost(gomock.Any(), gomock.Any()).Return(nil, errdefs.UserBannedError(1, nil, "spam"))
+			},
+			expectedCode: http.StatusForbidden,
+			errorCode:    "USER_BANNED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postService := mocks.NewMockPostService(ctl)
+			tt.setup(postService)
+			server := newTestServer(t, postService, mocks.NewMockCommentService(ctl))
+
+			resp := doRequest(t, http.MethodPost, server.URL+Prefix+"/posts", tt.body)
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+			if tt.errorCode != "" {
+				assert.Equal(t, tt.errorCode, decodeError(t, resp))
+				return
+			}
+			assert.Equal(t, Prefix+"/posts/7", resp.Header.Get("Location"))
+		})
+	}
+}
+
+func TestHandler_Comments(t *testing.T) {
+	comments := []*models.Comment{{ID: 5, PostID: 3, Payload: "hi", Format: models.ContentFormatPlain}}
+	limit := 2
+	replyTo := 5
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		setup        func(postService *mocks.MockPostService, commentService *mocks.MockCommentService)
+		expectedCode int
+		errorCode    string
+	}{
+		{
+			name:   "comments of post",
+			method: http.MethodGet,
+			path:   "/posts/3/comments?limit=2",
+			setup: func(postService *mocks.MockPostService, commentService *mocks.MockCommentService) {
+				postService.EXPECT().GetPostByID(gomock.Any(), 3).Return(&models.Post{ID: 3}, nil)
+				commentService.EXPECT().GetCommentsByPostID(gomock.Any(), &limit, nil, 3).Return(comments, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "comments of hidden post",
+			method: http.MethodGet,
+			path:   "/posts/3/comments",
+			setup: func(postService *mocks.MockPostService, commentService *mocks.MockCommentService) {
+				postService.EXPECT().GetPostByID(gomock.Any(), 3).Return(nil, errdefs.PostDoesNotExistError(3))
+			},
+			expectedCode: http.StatusNotFound,
+			errorCode:    "POST_DOES_NOT_EXIST",
+		},
+		{
+			name:   "reply to comment",
+			method: http.MethodPost,
+			path:   "/posts/3/comments",
+			body:   `{"payload":"hi","authorID":1,"replyTo":5}`,
+			setup: func(postService *mocks.MockPostService, commentService *mocks.MockCommentService) {
+				commentService.EXPECT().CreateComment(gomock.Any(), models.NewComment{Payload: "hi", PostID: 3, AuthorID: 1, ReplyTo: &replyTo}).
+					Return(comments[0], nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:   "comments disabled",
+			method: http.MethodPost,
+			path:   "/posts/3/comments",
+			body:   `{"payload":"hi","authorID":1}`,
+			setup: func(postService *mocks.MockPostService, commentService *mocks.MockCommentService) {
+				commentService.EXPECT().CreateComment(gomock.Any(), gomock.Any()).Return(nil, errdefs.CommentsNotAllowed(3))
+			},
+			expectedCode: http.StatusForbidden,
+			errorCode:    "COMMENTS_NOT_ALLOWED",
+		},
+		{
+			name:   "rate limited",
+			method: http.MethodPost,
+			path:   "/posts/3/comments",
+			body:   `{"payload":"hi","authorID":1}`,
+			setup: func(postService *mocks.MockPostService, commentService *mocks.MockCommentService) {
+				commentService.EXPECT().CreateComment(gomock.Any(), gomock.Any()).Return(nil, errdefs.RateLimitedError("createComment", 7))
+			},
+			expectedCode: http.StatusTooManyRequests,
+			errorCode:    "RATE_LIMITED",
+		},
+		{
+			name:   "replies",
+			method: http.MethodGet,
+			path:   "/comments/5/replies",
+			setup: func(postService *mocks.MockPostService, commentService *mocks.MockCommentService) {
+				commentService.EXPECT().Replies(gomock.Any(), 5, nil, nil).Return(comments, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "replies of unknown comment",
+			method: http.MethodGet,
+			path:   "/comments/9/replies",
+			setup: func(postService *mocks.MockPostService, commentService *mocks.MockCommentService) {
+				commentService.EXPECT().Replies(gomock.Any(), 9, nil, nil).Return(nil, errdefs.CommentDoesNotExistError(9))
+			},
+			expectedCode: http.StatusNotFound,
+			errorCode:    "COMMENT_DOES_NOT_EXIST",
+		},
+		{
+			name:         "replies with negative offset",
+			method:       http.MethodGet,
+			path:         "/comments/5/replies?offset=-1",
+			setup:        func(postService *mocks.MockPostService, commentService *mocks.MockCommentService) {},
+			expectedCode: http.StatusBadRequest,
+			errorCode:    "INVALID_REQUEST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postService := mocks.NewMockPostService(ctl)
+			commentService := mocks.NewMockCommentService(ctl)
+			tt.setup(postService, commentService)
+			server := newTestServer(t, postService, commentService)
+
+			resp := doRequest(t, tt.method, server.URL+Prefix+tt.path, tt.body)
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+			if tt.errorCode != "" {
+				if tt.expectedCode == http.StatusTooManyRequests {
+					assert.Equal(t, "7", resp.Header.Get("Retry-After"))
+				}
+				assert.Equal(t, tt.errorCode, decodeError(t, resp))
+				return
+			}
+
+			if tt.method == http.MethodPost {
+				var got models.Comment
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+				assert.Equal(t, "<p>hi</p>\n", got.PayloadHTML)
+				return
+			}
+			var got []*models.Comment
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			require.Len(t, got, 1)
+			assert.Equal(t, "<p>hi</p>\n", got[0].PayloadHTML)
+		})
+	}
+}
+
+// TestHandler_DoesNotMutateServiceResults проверяет, что ответ собирается из копий: объекты из сервиса
+// могут лежать в кэше и одновременно отдаваться другим запросам
+func TestHandler_DoesNotMutateServiceResults(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	post := &models.Post{ID: 3, Payload: "**hi**", Format: models.ContentFormatMarkdown}
+	comment := &models.Comment{ID: 5, PostID: 3, Payload: "**hi**", Format: models.ContentFormatMarkdown}
+	postService := mocks.NewMockPostService(ctl)
+	commentService := mocks.NewMockCommentService(ctl)
+	postService.EXPECT().GetPostByID(gomock.Any(), 3).Return(post, nil).Times(2)
+	commentService.EXPECT().GetCommentsByPostID(gomock.Any(), gomock.Any(), gomock.Any(), 3).Return([]*models.Comment{comment}, nil)
+	server := newTestServer(t, postService, commentService)
+
+	resp := doRequest(t, http.MethodGet, server.URL+Prefix+"/posts/3", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var gotPost models.Post
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&gotPost))
+	assert.Equal(t, "<p><strong>hi</strong></p>\n", gotPost.PayloadHTML)
+	assert.Equal(t, []string{}, gotPost.Tags)
+
+	resp = doRequest(t, http.MethodGet, server.URL+Prefix+"/posts/3/comments", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var gotComments []*models.Comment
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&gotComments))
+	require.Len(t, gotComments, 1)
+	assert.Equal(t, "<p><strong>hi</strong></p>\n", gotComments[0].PayloadHTML)
+
+	assert.Empty(t, post.PayloadHTML)
+	assert.Nil(t, post.Tags)
+	assert.Empty(t, comment.PayloadHTML)
+}
+
+// TestHandler_RepliesOfHiddenPost проверяет на настоящих сервисах, что ответы под черновиком видны только автору поста
+func TestHandler_RepliesOfHiddenPost(t *testing.T) {
+	log := zap.NewNop()
+	memory := in_memory.NewInMemoryStorage()
+	storage := service.NewStorage(
+		in_memory.NewPostMemoryStorage(log, memory),
+		in_memory.NewCommentMemoryStorage(log, memory),
+		in_memory.NewUserMemoryStorage(log, memory),
+		in_memory.NewNotificationMemoryStorage(log, memory),
+		in_memory.NewModerationMemoryStorage(log, memory),
+	)
+	postService := service.NewPostService(log, storage, moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+	commentService := service.NewCommentService(log, storage, service.NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+	server := newTestServer(t, postService, commentService)
+
+	author := auth.WithUserID(context.Background(), 2)
+	draft := models.PostStatusDraft
+	post, err := postService.CreatePost(author, models.NewPost{Title: "draft", Payload: "text", AuthorID: 2, IsCommentsAllowed: true, Status: &draft})
+	require.NoError(t, err)
+	parent, err := commentService.CreateComment(author, models.NewComment{Payload: "parent", PostID: post.ID, AuthorID: 2})
+	require.NoError(t, err)
+	_, err = commentService.CreateComment(author, models.NewComment{Payload: "reply", PostID: post.ID, AuthorID: 2, ReplyTo: &parent.ID})
+	require.NoError(t, err)
+
+	resp := doRequest(t, http.MethodGet, server.URL+Prefix+"/comments/"+strconv.Itoa(parent.ID)+"/replies", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "COMMENT_DOES_NOT_EXIST", decodeError(t, resp))
+
+	replies, err := commentService.Replies(author, parent.ID, nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, replies, 1, "автор поста видит ответы")
+}
+
+func TestHandler_UnknownRoute(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	server := newTestServer(t, mocks.NewMockPostService(ctl), mocks.NewMockCommentService(ctl))
+
+	assert.Equal(t, http.StatusNotFound, doRequest(t, http.MethodGet, server.URL+Prefix+"/users", "").StatusCode)
+	assert.Equal(t, http.StatusMethodNotAllowed, doRequest(t, http.MethodDelete, server.URL+Prefix+"/posts/1", "").StatusCode)
+}
+
+// TestOpenAPI проверяет, что документ описывает ровно те маршруты, которые обслуживает Handler
+func TestOpenAPI(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	server := newTestServer(t, mocks.NewMockPostService(ctl), mocks.NewMockCommentService(ctl))
+	resp := doRequest(t, http.MethodGet, server.URL+Prefix+"/openapi.json", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var document struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&document))
+	assert.Equal(t, "3.0.3", document.OpenAPI)
+
+	routes := make([]string, 0)
+	for path, operations := range document.Paths {
+		for method := range operations {
+			routes = append(routes, strings.ToUpper(method)+" "+path)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"GET /posts",
+		"POST /posts",
+		"GET /posts/{id}",
+		"GET /posts/{id}/comments",
+		"POST /posts/{id}/comments",
+		"GET /comments/{id}/replies",
+		"GET /openapi.json",
+	}, routes)
+}
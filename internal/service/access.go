@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
+	"time"
+)
+
+// authorizeOwnerOrModerator проверяет, что текущий пользователь - владелец контента ownerID или модератор.
+// Возвращает id текущего пользователя
+func authorizeOwnerOrModerator(ctx context.Context, storage *Storage, ownerID int) (int, error) {
+	viewerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return 0, errdefs.UnauthenticatedError()
+	}
+	if viewerID == ownerID {
+		return viewerID, nil
+	}
+	return requireModerator(ctx, storage)
+}
+
+// authorizeAuthor проверяет, что контент создаётся от имени текущего пользователя
+func authorizeAuthor(ctx context.Context, authorID int) error {
+	viewerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return errdefs.UnauthenticatedError()
+	}
+	if viewerID != authorID {
+		return errdefs.ForbiddenError(viewerID)
+	}
+	return nil
+}
+
+// requireModerator проверяет, что текущий пользователь - модератор, и возвращает его id.
+// Пользователь должен быть подтверждён токеном: непроверенному X-User-ID роль модератора не даётся
+func requireModerator(ctx context.Context, storage *Storage) (int, error) {
+	viewerID, ok := auth.VerifiedUserIDFromContext(ctx)
+	if !ok {
+		if unverifiedID, ok := auth.UserIDFromContext(ctx); ok {
+			return 0, errdefs.ForbiddenError(unverifiedID)
+		}
+		return 0, errdefs.UnauthenticatedError()
+	}
+
+	viewer, err := storage.GetUserByID(ctx, viewerID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return 0, errdefs.UserDoesNotExistError(viewerID)
+		}
+		return 0, errdefs.InternalServerError()
+	}
+	if !viewer.IsModerator() {
+		return 0, errdefs.ForbiddenError(viewerID)
+	}
+	return viewerID, nil
+}
+
+// checkNotBanned возвращает ошибку, если у пользователя есть действующий бан
+func checkNotBanned(ctx context.Context, storage *Storage, userID int, now time.Time) error {
+	ban, err := storage.GetUserBan(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil
+		}
+		return errdefs.InternalServerError()
+	}
+	if ban.IsActive(now) {
+		return errdefs.UserBannedError(userID, ban.Until, ban.Reason)
+	}
+	return nil
+}
+
+// checkNotMuted возвращает ошибку, если пользователю запрещено комментировать пост
+func checkNotMuted(ctx context.Context, storage *Storage, post *models.Post, userID int, now time.Time) error {
+	mute, err := storage.GetPostMute(ctx, post.ID, userID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil
+		}
+		return errdefs.InternalServerError()
+	}
+	if mute.IsActive(now) {
+		return errdefs.UserMutedError(post.ID, userID, mute.Until)
+	}
+	return nil
+}
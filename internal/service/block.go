@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
+	"go.uber.org/zap"
+)
+
+type BlockService struct {
+	log     *zap.Logger
+	storage *Storage
+}
+
+func NewBlockService(log *zap.Logger, storage *Storage) *BlockService {
+	return &BlockService{
+		log,
+		storage,
+	}
+}
+
+// BlockUser скрывает от текущего пользователя комментарии userID и запрещает userID отвечать на его комментарии.
+// Возвращает false, если пользователь уже заблокирован
+func (b *BlockService) BlockUser(ctx context.Context, userID int) (bool, error) {
+	blockerID, err := b.checkBlockTarget(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	changed, err := b.storage.BlockUser(ctx, blockerID, userID)
+	if err != nil {
+		return false, errdefs.InternalServerError()
+	}
+	return changed, nil
+}
+
+// UnblockUser снимает блокировку. Возвращает false, если пользователь не был заблокирован
+func (b *BlockService) UnblockUser(ctx context.Context, userID int) (bool, error) {
+	blockerID, err := b.checkBlockTarget(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	changed, err := b.storage.UnblockUser(ctx, blockerID, userID)
+	if err != nil {
+		return false, errdefs.InternalServerError()
+	}
+	return changed, nil
+}
+
+func (b *BlockService) checkBlockTarget(ctx context.Context, userID int) (int, error) {
+	viewerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return 0, errdefs.UnauthenticatedError()
+	}
+	if viewerID == userID {
+		return 0, errdefs.CannotBlockSelfError(userID)
+	}
+
+	if _, err := b.storage.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return 0, errdefs.UserDoesNotExistError(userID)
+		}
+		return 0, errdefs.InternalServerError()
+	}
+	return viewerID, nil
+}
+
+// FilterBlockedComments пропускает из подписки только комментарии авторов, которых текущий пользователь не заблокировал.
+// Блокировка проверяется при каждой доставке, поэтому изменения применяются к уже открытой подписке.
+// Выходной канал закрывается вместе с входным
+func (b *BlockService) FilterBlockedComments(ctx context.Context, comments <-chan *models.Comment) <-chan *models.Comment {
+	viewerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return comments
+	}
+	log := b.log.With(
+		zap.String("Layer", "BlockService.FilterBlockedComments"),
+		zap.Int("ViewerID", viewerID),
+	)
+
+	filtered := make(chan *models.Comment)
+	go func() {
+		defer close(filtered)
+		for comment := range comments {
+			if comment.Author != nil && comment.Author.ID != viewerID {
+				blocked, err := b.storage.IsBlocked(ctx, viewerID, comment.Author.ID)
+				if err != nil {
+					log.Error("Failed to check block", zap.Int("CommentID", comment.ID), zap.Error(err))
+				}
+				if blocked {
+					continue
+				}
+			}
+			// Входной канал читается до закрытия, даже если клиент уже отключился, чтобы не блокировать рассылку
+			select {
+			case filtered <- comment:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return filtered
+}
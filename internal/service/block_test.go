@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/service/mocks"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestBlockService_BlockUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		viewerID      int
+		userID        int
+		mockUserErr   error
+		mockChanged   bool
+		expected      bool
+		expectedError error
+	}{
+		{
+			name:        "blocked",
+			viewerID:    1,
+			userID:      3,
+			mockChanged: true,
+			expected:    true,
+		},
+		{
+			name:     "already blocked",
+			viewerID: 1,
+			userID:   3,
+		},
+		{
+			name:          "self",
+			viewerID:      1,
+			userID:        1,
+			expectedError: errdefs.CannotBlockSelfError(1),
+		},
+		{
+			name:          "user not found",
+			viewerID:      1,
+			userID:        99,
+			mockUserErr:   storageerr.ErrNotFound,
+			expectedError: errdefs.UserDoesNotExistError(99),
+		},
+		{
+			name:          "unauthenticated",
+			userID:        3,
+			expectedError: errdefs.UnauthenticatedError(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			userProvider := mocks.NewMockUserProvider(ctl)
+			if tt.viewerID != 0 && tt.viewerID != tt.userID {
+				userProvider.EXPECT().GetUserByID(gomock.Any(), tt.userID).Return(&models.User{ID: tt.userID}, tt.mockUserErr)
+				if tt.mockUserErr == nil {
+					userProvider.EXPECT().BlockUser(gomock.Any(), tt.viewerID, tt.userID).Return(tt.mockChanged, nil)
+				}
+			}
+
+			blockService := NewBlockService(zap.NewNop(), NewStorage(nil, nil, userProvider, nil, nil))
+
+			ctx := context.Background()
+			if tt.viewerID != 0 {
+				ctx = auth.WithUserID(ctx, tt.viewerID)
+			}
+			changed, err := blockService.BlockUser(ctx, tt.userID)
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, changed)
+		})
+	}
+}
+
+func TestBlockService_FilterBlockedComments(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	userProvider := mocks.NewMockUserProvider(ctl)
+	userProvider.EXPECT().IsBlocked(gomock.Any(), 1, 2).Return(false, nil)
+	userProvider.EXPECT().IsBlocked(gomock.Any(), 1, 3).Return(true, nil)
+
+	blockService := NewBlockService(zap.NewNop(), NewStorage(nil, nil, userProvider, nil, nil))
+
+	ctx, cancel := context.WithCancel(auth.WithUserID(context.Background(), 1))
+	defer cancel()
+
+	comments := make(chan *models.Comment)
+	filtered := blockService.FilterBlockedComments(ctx, comments)
+
+	go func() {
+		comments <- &models.Comment{ID: 1, Author: &models.User{ID: 3}}
+		comments <- &models.Comment{ID: 2, Author: &models.User{ID: 2}}
+		comments <- &models.Comment{ID: 3, Author: &models.User{ID: 1}}
+		close(comments)
+	}()
+
+	received := make([]int, 0)
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case comment, ok := <-filtered:
+			if !ok {
+				assert.Equal(t, []int{2, 3}, received, "комментарий заблокированного автора не доставляется")
+				return
+			}
+			received = append(received, comment.ID)
+		case <-timeout:
+			t.Fatal("timeout: filtered channel was not closed")
+		}
+	}
+}
+
+func TestBlockService_FilterBlockedComments_Anonymous(t *testing.T) {
+	blockService := NewBlockService(zap.NewNop(), NewStorage(nil, nil, nil, nil, nil))
+
+	comments := make(chan *models.Comment)
+	filtered := blockService.FilterBlockedComments(context.Background(), comments)
+	assert.Equal(t, (<-chan *models.Comment)(comments), filtered)
+}
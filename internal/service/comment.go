@@ -3,73 +3,421 @@ package service
 import (
 	"context"
 	"errors"
+	"github.com/Quizert/PostCommentService/internal/auth"
 	"github.com/Quizert/PostCommentService/internal/consts"
 	"github.com/Quizert/PostCommentService/internal/errdefs"
 	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/moderation"
+	"github.com/Quizert/PostCommentService/internal/ratelimit"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
 	"github.com/Quizert/PostCommentService/internal/utils"
-	"github.com/jackc/pgx/v4"
 	"go.uber.org/zap"
+	"time"
 )
 
 type CommentService struct {
-	log     *zap.Logger
-	storage *Storage
+	log       *zap.Logger
+	storage   *Storage
+	publisher NotificationPublisher
+	moderator Moderator
+	limiter   RateLimiter
 }
 
-func NewCommentService(log *zap.Logger, storage *Storage) *CommentService {
+func NewCommentService(log *zap.Logger, storage *Storage, publisher NotificationPublisher, moderator Moderator, limiter RateLimiter) *CommentService {
 	return &CommentService{
 		log,
 		storage,
+		publisher,
+		moderator,
+		limiter,
 	}
 }
 
 func (c *CommentService) CreateComment(ctx context.Context, input models.NewComment) (*models.Comment, error) {
+	if err := authorizeAuthor(ctx, input.AuthorID); err != nil {
+		return nil, err
+	}
 	author, err := c.storage.GetUserByID(ctx, input.AuthorID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
+		if errors.Is(err, storageerr.ErrNotFound) {
 			return nil, errdefs.UserDoesNotExistError(input.AuthorID)
 		}
 		return nil, errdefs.InternalServerError()
 	}
+	now := time.Now()
+	if err = checkNotBanned(ctx, c.storage, author.ID, now); err != nil {
+		return nil, err
+	}
+	if err = checkRateLimit(ctx, c.limiter, ratelimit.ActionCreateComment); err != nil {
+		return nil, err
+	}
 	if len(input.Payload) > consts.MaxPayloadSize {
 		return nil, errdefs.CommentTooLongError(consts.MaxPayloadSize, len(input.Payload))
 	}
 
 	post, err := c.storage.GetPostByID(ctx, input.PostID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
+		if errors.Is(err, storageerr.ErrNotFound) {
 			return nil, errdefs.PostDoesNotExistError(input.PostID)
 		}
 		return nil, errdefs.InternalServerError()
 	}
+	if !post.IsVisibleTo(input.AuthorID, now) {
+		return nil, errdefs.PostDoesNotExistError(input.PostID)
+	}
+	if err = checkNotMuted(ctx, c.storage, post, author.ID, now); err != nil {
+		return nil, err
+	}
 	if !post.IsCommentsAllowed {
 		return nil, errdefs.CommentsNotAllowed(post.ID)
 	}
-	comment, err := c.storage.CreateComment(ctx, input)
+	if input.ReplyTo != nil {
+		locked, err := c.storage.IsThreadLocked(ctx, *input.ReplyTo)
+		if err != nil {
+			return nil, errdefs.InternalServerError()
+		}
+		if locked {
+			return nil, errdefs.ThreadLockedError(*input.ReplyTo)
+		}
+		if err = c.checkReplyAllowed(ctx, *input.ReplyTo, input.PostID, input.AuthorID); err != nil {
+			return nil, err
+		}
+	}
+
+	decision, err := moderate(c.moderator, moderation.Content{
+		Type:     moderation.ContentTypeComment,
+		AuthorID: input.AuthorID,
+		Payload:  input.Payload,
+	})
 	if err != nil {
-		return nil, errdefs.InternalServerError()
+		return nil, err
+	}
+
+	// Упоминания сохраняются в одной транзакции с комментарием, чтобы событие о нём уже видело их
+	mentions := c.resolveMentions(ctx, input.Payload)
+	var mentionIDs []int
+	for _, user := range mentions {
+		mentionIDs = append(mentionIDs, user.ID)
+	}
+
+	comment, err := c.storage.CreateComment(ctx, input, decision != nil, mentionIDs)
+	if err != nil {
+		references := map[string]error{
+			"postid":   errdefs.PostDoesNotExistError(input.PostID),
+			"authorid": errdefs.UserDoesNotExistError(input.AuthorID),
+		}
+		if input.ReplyTo != nil {
+			references["replyto"] = errdefs.CommentDoesNotExistError(*input.ReplyTo)
+		}
+		return nil, storageError(err, nil, references)
 	}
 	comment.Author = author
+	comment.Mentions = mentions
+
+	// Отложенный комментарий ждёт модератора, уведомления о нём создаются после одобрения
+	if decision != nil {
+		err = holdContent(ctx, c.storage, decision, &models.ModerationItem{
+			TargetType: models.TargetTypeComment,
+			TargetID:   comment.ID,
+			Payload:    comment.Payload,
+			Author:     author,
+		})
+		if err != nil {
+			c.log.Error("Failed to enqueue comment for moderation", zap.Int("CommentID", comment.ID), zap.Error(err))
+			return nil, errdefs.InternalServerError()
+		}
+		return comment, nil
+	}
+
+	log := c.log.With(zap.String("Layer", "CommentService.CreateComment"), zap.Int("CommentID", comment.ID))
+	recordCommentNotifications(ctx, log, c.storage, c.publisher, post, comment)
 
 	return comment, nil
 }
 
+// checkReplyAllowed проверяет, что родительский комментарий относится к тому же посту, и запрещает
+// отвечать на него пользователю, которого заблокировал его автор
+func (c *CommentService) checkReplyAllowed(ctx context.Context, parentID int, postID int, authorID int) error {
+	parent, err := c.storage.GetCommentByID(ctx, parentID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return errdefs.CommentDoesNotExistError(parentID)
+		}
+		return errdefs.InternalServerError()
+	}
+	if parent.PostID != postID {
+		return errdefs.ReplyToOtherPostError(parentID, postID)
+	}
+	if parent.Author == nil || parent.Author.ID == authorID {
+		return nil
+	}
+
+	blocked, err := c.storage.IsBlocked(ctx, parent.Author.ID, authorID)
+	if err != nil {
+		return errdefs.InternalServerError()
+	}
+	if blocked {
+		return errdefs.BlockedByUserError(parent.Author.ID)
+	}
+	return nil
+}
+
+// recordCommentNotifications сохраняет уведомления об ответе, упоминании и комментарии к посту и рассылает их подписчикам.
+// Каждый получатель получает одно уведомление на комментарий, автор комментария уведомлений о себе не получает
+func recordCommentNotifications(ctx context.Context, log *zap.Logger, storage *Storage, publisher NotificationPublisher, post *models.Post, comment *models.Comment) {
+	notifications := make([]*models.Notification, 0)
+	notified := map[int]bool{comment.Author.ID: true}
+	add := func(recipientID int, notificationType models.NotificationType) {
+		if notified[recipientID] {
+			return
+		}
+		notified[recipientID] = true
+		notifications = append(notifications, &models.Notification{
+			Type:        notificationType,
+			Actor:       comment.Author,
+			PostID:      comment.PostID,
+			CommentID:   comment.ID,
+			RecipientID: recipientID,
+		})
+	}
+
+	if comment.ReplyTo != nil {
+		parent, err := storage.GetCommentByID(ctx, *comment.ReplyTo)
+		if err != nil {
+			log.Error("Failed to get parent comment", zap.Error(err))
+		} else if parent.Author != nil {
+			add(parent.Author.ID, models.NotificationTypeReply)
+		}
+	}
+	for _, user := range comment.Mentions {
+		add(user.ID, models.NotificationTypeMention)
+	}
+	if post.Author != nil {
+		add(post.Author.ID, models.NotificationTypePostComment)
+	}
+	if len(notifications) == 0 {
+		return
+	}
+
+	created, err := storage.CreateNotifications(ctx, notifications)
+	if err != nil {
+		log.Error("Failed to save notifications", zap.Error(err))
+		return
+	}
+	publisher.PublishNotifications(ctx, created)
+}
+
+// resolveMentions находит в тексте упоминания существующих пользователей.
+// Неизвестные имена остаются обычным текстом. Ошибка поиска не отменяет создание комментария
+func (c *CommentService) resolveMentions(ctx context.Context, payload string) []*models.User {
+	usernames := utils.ParseMentions(payload, consts.MaxMentionsCount)
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	users, err := c.storage.GetUsersByUsernames(ctx, usernames)
+	if err != nil {
+		c.log.Error("Failed to resolve mentions", zap.String("Layer", "CommentService.resolveMentions"), zap.Error(err))
+		return nil
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	return users
+}
+
+func (c *CommentService) GetCommentMentions(ctx context.Context, comment *models.Comment) ([]*models.User, error) {
+	if comment.Mentions != nil {
+		return comment.Mentions, nil
+	}
+
+	users, err := c.storage.GetCommentMentions(ctx, comment.ID)
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+	return users, nil
+}
+
 func (c *CommentService) GetCommentsByPostID(ctx context.Context, limit *int, offset *int, postID int) ([]*models.Comment, error) {
 	limitValue, offsetValue := utils.ParseLimitOffset(limit, offset)
 
-	comments, err := c.storage.GetCommentsByPostID(ctx, limitValue, offsetValue, postID)
+	viewerID, _ := auth.UserIDFromContext(ctx)
+
+	comments, err := c.storage.GetCommentsByPostID(ctx, limitValue, offsetValue, postID, viewerID)
 	if err != nil {
 		return nil, errdefs.InternalServerError()
 	}
 	return comments, nil
 }
 
+// Replies возвращает ответы на комментарий. Ответы под постом, который зритель не видит, отдаются
+// так же, как ответы на несуществующий комментарий
 func (c *CommentService) Replies(ctx context.Context, commentID int, limit *int, offset *int) ([]*models.Comment, error) {
 	limitValue, offsetValue := utils.ParseLimitOffset(limit, offset)
 
-	comments, err := c.storage.Replies(ctx, commentID, limitValue, offsetValue)
+	viewerID, _ := auth.UserIDFromContext(ctx)
+	if err := c.checkCommentVisible(ctx, commentID, viewerID); err != nil {
+		return nil, err
+	}
+
+	comments, err := c.storage.Replies(ctx, commentID, limitValue, offsetValue, viewerID)
 	if err != nil {
-		return nil, errdefs.InternalServerError()
+		return nil, storageError(err, errdefs.CommentDoesNotExistError(commentID), nil)
 	}
 	return comments, nil
 }
+
+// checkCommentVisible проверяет, что зритель видит комментарий и пост, к которому он оставлен.
+// Скрытый комментарий видит только его автор
+func (c *CommentService) checkCommentVisible(ctx context.Context, commentID int, viewerID int) error {
+	comment, err := c.storage.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return storageError(err, errdefs.CommentDoesNotExistError(commentID), nil)
+	}
+	if comment.IsHidden && (comment.Author == nil || comment.Author.ID != viewerID) {
+		return errdefs.CommentDoesNotExistError(commentID)
+	}
+
+	post, err := c.storage.GetPostByID(ctx, comment.PostID)
+	if err != nil {
+		return storageError(err, errdefs.CommentDoesNotExistError(commentID), nil)
+	}
+	if !post.IsVisibleTo(viewerID, time.Now()) {
+		return errdefs.CommentDoesNotExistError(commentID)
+	}
+	return nil
+}
+
+func (c *CommentService) PinComment(ctx context.Context, commentID int) (*models.Comment, error) {
+	comment, err := c.commentForPostAuthor(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ReplyTo != nil {
+		return nil, errdefs.CannotPinReplyError(commentID)
+	}
+
+	comment, err = c.storage.SetCommentPinned(ctx, commentID, true)
+	if err != nil {
+		return nil, storageError(err, errdefs.CommentDoesNotExistError(commentID), nil)
+	}
+	return comment, nil
+}
+
+func (c *CommentService) UnpinComment(ctx context.Context, commentID int) (*models.Comment, error) {
+	if _, err := c.commentForPostAuthor(ctx, commentID); err != nil {
+		return nil, err
+	}
+
+	comment, err := c.storage.SetCommentPinned(ctx, commentID, false)
+	if err != nil {
+		return nil, storageError(err, errdefs.CommentDoesNotExistError(commentID), nil)
+	}
+	return comment, nil
+}
+
+func (c *CommentService) LockThread(ctx context.Context, commentID int) (*models.Comment, error) {
+	if _, err := c.commentForPostAuthor(ctx, commentID); err != nil {
+		return nil, err
+	}
+
+	comment, err := c.storage.LockThread(ctx, commentID)
+	if err != nil {
+		return nil, storageError(err, errdefs.CommentDoesNotExistError(commentID), nil)
+	}
+	return comment, nil
+}
+
+// commentForPostAuthor возвращает комментарий, если текущий пользователь - автор поста, к которому он оставлен
+func (c *CommentService) commentForPostAuthor(ctx context.Context, commentID int) (*models.Comment, error) {
+	viewerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, errdefs.UnauthenticatedError()
+	}
+
+	comment, err := c.storage.GetCommentByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, errdefs.CommentDoesNotExistError(commentID)
+		}
+		return nil, errdefs.InternalServerError()
+	}
+
+	post, err := c.storage.GetPostByID(ctx, comment.PostID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, errdefs.PostDoesNotExistError(comment.PostID)
+		}
+		return nil, errdefs.InternalServerError()
+	}
+	if post.Author == nil || post.Author.ID != viewerID {
+		return nil, errdefs.ForbiddenError(viewerID)
+	}
+	return comment, nil
+}
+
+func (c *CommentService) EditComment(ctx context.Context, commentID int, payload string) (*models.Comment, error) {
+	if _, ok := auth.UserIDFromContext(ctx); !ok {
+		return nil, errdefs.UnauthenticatedError()
+	}
+
+	comment, err := c.storage.GetCommentByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, errdefs.CommentDoesNotExistError(commentID)
+		}
+		return nil, errdefs.InternalServerError()
+	}
+
+	editorID, err := authorizeOwnerOrModerator(ctx, c.storage, comment.Author.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) > consts.MaxPayloadSize {
+		return nil, errdefs.CommentTooLongError(consts.MaxPayloadSize, len(payload))
+	}
+	if payload == comment.Payload {
+		return comment, nil
+	}
+
+	decision, err := moderate(c.moderator, moderation.Content{
+		Type:     moderation.ContentTypeComment,
+		AuthorID: comment.Author.ID,
+		Payload:  payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Отложенная правка скрывает комментарий до решения модератора. Правка, скрытие и постановка в очередь
+	// сохраняются одной транзакцией, чтобы непроверенный текст ни на момент не попал в выдачу
+	hold := heldItem(decision, &models.ModerationItem{
+		TargetType: models.TargetTypeComment,
+		TargetID:   commentID,
+		Payload:    payload,
+		Author:     comment.Author,
+	})
+	comment, err = c.storage.UpdateComment(ctx, commentID, payload, editorID, hold)
+	if err != nil {
+		return nil, storageError(err, errdefs.CommentDoesNotExistError(commentID), nil)
+	}
+	return comment, nil
+}
+
+// GetCommentRevisions возвращает прошлые версии комментария. Они доступны автору и модераторам
+func (c *CommentService) GetCommentRevisions(ctx context.Context, comment *models.Comment) ([]*models.Revision, error) {
+	authorID := 0
+	if comment.Author != nil {
+		authorID = comment.Author.ID
+	}
+	if _, err := authorizeOwnerOrModerator(ctx, c.storage, authorID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := c.storage.GetCommentRevisions(ctx, comment.ID)
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+	return revisions, nil
+}
@@ -3,12 +3,15 @@ package service
 import (
 	"context"
 	"errors"
+	"github.com/Quizert/PostCommentService/internal/auth"
 	"github.com/Quizert/PostCommentService/internal/consts"
 	"github.com/Quizert/PostCommentService/internal/errdefs"
 	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/moderation"
+	"github.com/Quizert/PostCommentService/internal/ratelimit"
 	"github.com/Quizert/PostCommentService/internal/service/mocks"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
 	"github.com/golang/mock/gomock"
-	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -18,6 +21,7 @@ import (
 
 func TestCommentService_CreateComment(t *testing.T) {
 	now := time.Now().UTC()
+	replyTo := 50
 	mockUser := &models.User{
 		ID:       1,
 		Username: "testuser",
@@ -37,6 +41,7 @@ func TestCommentService_CreateComment(t *testing.T) {
 		mockUserErr     error
 		mockPost        *models.Post
 		mockPostErr     error
+		mockLocked      bool
 		mockComment     *models.Comment
 		mockCommentErr  error
 		expectedComment *models.Comment
@@ -68,7 +73,7 @@ func TestCommentService_CreateComment(t *testing.T) {
 				AuthorID: 999,
 				Payload:  "Any payload",
 			},
-			mockUserErr:   pgx.ErrNoRows,
+			mockUserErr:   storageerr.ErrNotFound,
 			expectedError: errdefs.UserDoesNotExistError(999),
 		},
 		{
@@ -98,7 +103,7 @@ func TestCommentService_CreateComment(t *testing.T) {
 				Payload:  "Some comment",
 			},
 			mockUser:      mockUser,
-			mockPostErr:   pgx.ErrNoRows,
+			mockPostErr:   storageerr.ErrNotFound,
 			expectedError: errdefs.PostDoesNotExistError(2),
 		},
 		{
@@ -128,6 +133,40 @@ func TestCommentService_CreateComment(t *testing.T) {
 			},
 			expectedError: errdefs.CommentsNotAllowed(3),
 		},
+		{
+			name: "reply to unlocked thread",
+			input: models.NewComment{
+				PostID:   1,
+				AuthorID: 1,
+				Payload:  "Reply",
+				ReplyTo:  &replyTo,
+			},
+			mockUser: mockUser,
+			mockPost: mockPost,
+			mockComment: &models.Comment{
+				ID:      101,
+				Payload: "Reply",
+				ReplyTo: &replyTo,
+			},
+			expectedComment: &models.Comment{
+				ID:      101,
+				Payload: "Reply",
+				Author:  mockUser,
+			},
+		},
+		{
+			name: "reply to locked thread",
+			input: models.NewComment{
+				PostID:   1,
+				AuthorID: 1,
+				Payload:  "Reply",
+				ReplyTo:  &replyTo,
+			},
+			mockUser:      mockUser,
+			mockPost:      mockPost,
+			mockLocked:    true,
+			expectedError: errdefs.ThreadLockedError(replyTo),
+		},
 		{
 			name: "db error on create comment",
 			input: models.NewComment{
@@ -140,6 +179,55 @@ func TestCommentService_CreateComment(t *testing.T) {
 			mockCommentErr: errors.New("insert failed"),
 			expectedError:  errdefs.InternalServerError(),
 		},
+		{
+			name: "post deleted before insert",
+			input: models.NewComment{
+				PostID:   1,
+				AuthorID: 1,
+				Payload:  "Late comment",
+			},
+			mockUser:       mockUser,
+			mockPost:       mockPost,
+			mockCommentErr: &storageerr.ConstraintError{Kind: storageerr.ErrForeignKey, Field: "postid"},
+			expectedError:  errdefs.PostDoesNotExistError(1),
+		},
+		{
+			name: "parent comment deleted before insert",
+			input: models.NewComment{
+				PostID:   1,
+				AuthorID: 1,
+				Payload:  "Late reply",
+				ReplyTo:  &replyTo,
+			},
+			mockUser:       mockUser,
+			mockPost:       mockPost,
+			mockCommentErr: &storageerr.ConstraintError{Kind: storageerr.ErrForeignKey, Field: "replyto"},
+			expectedError:  errdefs.CommentDoesNotExistError(replyTo),
+		},
+		{
+			name: "unknown foreign key",
+			input: models.NewComment{
+				PostID:   1,
+				AuthorID: 1,
+				Payload:  "Comment",
+			},
+			mockUser:       mockUser,
+			mockPost:       mockPost,
+			mockCommentErr: &storageerr.ConstraintError{Kind: storageerr.ErrForeignKey},
+			expectedError:  errdefs.ReferenceDoesNotExistError(""),
+		},
+		{
+			name: "unique violation on create comment",
+			input: models.NewComment{
+				PostID:   1,
+				AuthorID: 1,
+				Payload:  "Comment",
+			},
+			mockUser:       mockUser,
+			mockPost:       mockPost,
+			mockCommentErr: &storageerr.ConstraintError{Kind: storageerr.ErrConflict, Field: "id"},
+			expectedError:  errdefs.ConflictError("id"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -158,6 +246,13 @@ func TestCommentService_CreateComment(t *testing.T) {
 					Times(1)
 			}
 
+			if tt.mockUserErr == nil {
+				userProvider.EXPECT().
+					GetUserBan(gomock.Any(), tt.input.AuthorID).
+					Return(nil, storageerr.ErrNotFound).
+					Times(1)
+			}
+
 			if tt.mockUserErr == nil && len(tt.input.Payload) <= consts.MaxPayloadSize {
 				postProvider.EXPECT().
 					GetPostByID(gomock.Any(), tt.input.PostID).
@@ -165,24 +260,51 @@ func TestCommentService_CreateComment(t *testing.T) {
 					Times(1)
 			}
 
+			if tt.mockUserErr == nil && len(tt.input.Payload) <= consts.MaxPayloadSize && tt.mockPostErr == nil && tt.mockPost != nil {
+				userProvider.EXPECT().
+					GetPostMute(gomock.Any(), tt.input.PostID, tt.input.AuthorID).
+					Return(nil, storageerr.ErrNotFound).
+					Times(1)
+			}
+
 			canCreate := tt.mockUserErr == nil &&
 				len(tt.input.Payload) <= consts.MaxPayloadSize &&
 				tt.mockPostErr == nil &&
 				tt.mockPost != nil &&
 				tt.mockPost.IsCommentsAllowed
 
-			if canCreate {
+			if canCreate && tt.input.ReplyTo != nil {
+				commentProvider.EXPECT().
+					IsThreadLocked(gomock.Any(), *tt.input.ReplyTo).
+					Return(tt.mockLocked, nil).
+					Times(1)
+			}
+
+			if canCreate && !tt.mockLocked {
 				commentProvider.EXPECT().
-					CreateComment(gomock.Any(), tt.input).
+					CreateComment(gomock.Any(), tt.input, false, nil).
 					Return(tt.mockComment, tt.mockCommentErr).
 					Times(1)
 			}
 
-			storage := NewStorage(postProvider, commentProvider, userProvider)
+			if canCreate && !tt.mockLocked && tt.input.ReplyTo != nil {
+				// Родительский комментарий читается для проверки блокировки и, после создания, для уведомлений.
+				// Ответ на собственный комментарий уведомлений не создаёт
+				times := 1
+				if tt.mockCommentErr == nil {
+					times = 2
+				}
+				commentProvider.EXPECT().
+					GetCommentByID(gomock.Any(), *tt.input.ReplyTo).
+					Return(&models.Comment{ID: *tt.input.ReplyTo, PostID: tt.input.PostID, Author: tt.mockUser}, nil).
+					Times(times)
+			}
+
+			storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
 			logger := zap.NewNop()
-			commentService := NewCommentService(logger, storage)
+			commentService := NewCommentService(logger, storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
 
-			ctx := context.Background()
+			ctx := auth.WithUserID(context.Background(), tt.input.AuthorID)
 			result, err := commentService.CreateComment(ctx, tt.input)
 
 			if tt.expectedError != nil {
@@ -254,13 +376,13 @@ func TestCommentService_GetCommentsByPostID(t *testing.T) {
 			userProvider := mocks.NewMockUserProvider(ctl)
 
 			commentProvider.EXPECT().
-				GetCommentsByPostID(gomock.Any(), tt.limitValue, tt.offsetValue, tt.postID).
+				GetCommentsByPostID(gomock.Any(), tt.limitValue, tt.offsetValue, tt.postID, 0).
 				Return(tt.mockComments, tt.mockCommentsErr).
 				Times(1)
 
-			storage := NewStorage(postProvider, commentProvider, userProvider)
+			storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
 			logger := zap.NewNop()
-			commentService := NewCommentService(logger, storage)
+			commentService := NewCommentService(logger, storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
 
 			ctx := context.Background()
 			result, err := commentService.GetCommentsByPostID(ctx, tt.limit, tt.offset, tt.postID)
@@ -278,6 +400,9 @@ func TestCommentService_GetCommentsByPostID(t *testing.T) {
 }
 
 func TestCommentService_Replies(t *testing.T) {
+	published := &models.Post{ID: 1, Author: &models.User{ID: 5}, Status: models.PostStatusPublished}
+	parent := &models.Comment{ID: 10, PostID: 1, Author: &models.User{ID: 6}}
+
 	tests := []struct {
 		name             string
 		commentID        int
@@ -285,6 +410,9 @@ func TestCommentService_Replies(t *testing.T) {
 		offset           *int
 		limitValue       int
 		offsetValue      int
+		mockParent       *models.Comment
+		mockParentErr    error
+		mockPost         *models.Post
 		mockComments     []*models.Comment
 		mockCommentsErr  error
 		expectedComments []*models.Comment
@@ -297,6 +425,8 @@ func TestCommentService_Replies(t *testing.T) {
 			offset:      nil,
 			limitValue:  consts.DefaultLimit,
 			offsetValue: consts.DefaultOffset,
+			mockParent:  parent,
+			mockPost:    published,
 			mockComments: []*models.Comment{
 				{ID: 101, Payload: "Reply #1"},
 				{ID: 102, Payload: "Reply #2"},
@@ -306,13 +436,51 @@ func TestCommentService_Replies(t *testing.T) {
 				{ID: 102, Payload: "Reply #2"},
 			},
 		},
+		{
+			name:          "comment not found",
+			commentID:     11,
+			mockParentErr: storageerr.ErrNotFound,
+			expectedError: errdefs.CommentDoesNotExistError(11),
+		},
+		{
+			name:          "draft post",
+			commentID:     10,
+			mockParent:    parent,
+			mockPost:      &models.Post{ID: 1, Author: &models.User{ID: 5}, Status: models.PostStatusDraft},
+			expectedError: errdefs.CommentDoesNotExistError(10),
+		},
+		{
+			name:          "hidden post",
+			commentID:     10,
+			mockParent:    parent,
+			mockPost:      &models.Post{ID: 1, Author: &models.User{ID: 5}, Status: models.PostStatusPublished, IsHidden: true},
+			expectedError: errdefs.CommentDoesNotExistError(10),
+		},
+		{
+			name:          "hidden comment",
+			commentID:     10,
+			mockParent:    &models.Comment{ID: 10, PostID: 1, Author: &models.User{ID: 6}, IsHidden: true},
+			expectedError: errdefs.CommentDoesNotExistError(10),
+		},
+		{
+			name:            "comment removed concurrently",
+			commentID:       10,
+			limitValue:      consts.DefaultLimit,
+			offsetValue:     consts.DefaultOffset,
+			mockParent:      parent,
+			mockPost:        published,
+			mockCommentsErr: storageerr.ErrNotFound,
+			expectedError:   errdefs.CommentDoesNotExistError(10),
+		},
 		{
 			name:            "db error",
-			commentID:       123,
+			commentID:       10,
 			limit:           nil,
 			offset:          nil,
 			limitValue:      consts.DefaultLimit,
 			offsetValue:     consts.DefaultOffset,
+			mockParent:      parent,
+			mockPost:        published,
 			mockCommentsErr: errors.New("some db error"),
 			expectedError:   errdefs.InternalServerError(),
 		},
@@ -327,14 +495,20 @@ func TestCommentService_Replies(t *testing.T) {
 			commentProvider := mocks.NewMockCommentProvider(ctl)
 			userProvider := mocks.NewMockUserProvider(ctl)
 
-			commentProvider.EXPECT().
-				Replies(gomock.Any(), tt.commentID, tt.limitValue, tt.offsetValue).
-				Return(tt.mockComments, tt.mockCommentsErr).
-				Times(1)
+			commentProvider.EXPECT().GetCommentByID(gomock.Any(), tt.commentID).Return(tt.mockParent, tt.mockParentErr)
+			if tt.mockPost != nil {
+				postProvider.EXPECT().GetPostByID(gomock.Any(), tt.mockPost.ID).Return(tt.mockPost, nil)
+			}
+			if tt.mockComments != nil || tt.mockCommentsErr != nil {
+				commentProvider.EXPECT().
+					Replies(gomock.Any(), tt.commentID, tt.limitValue, tt.offsetValue, 0).
+					Return(tt.mockComments, tt.mockCommentsErr).
+					Times(1)
+			}
 
-			storage := NewStorage(postProvider, commentProvider, userProvider)
+			storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
 			logger := zap.NewNop()
-			commentService := NewCommentService(logger, storage)
+			commentService := NewCommentService(logger, storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
 
 			ctx := context.Background()
 			result, err := commentService.Replies(ctx, tt.commentID, tt.limit, tt.offset)
@@ -350,3 +524,600 @@ func TestCommentService_Replies(t *testing.T) {
 		})
 	}
 }
+
+func TestCommentService_PinComment(t *testing.T) {
+	replyTo := 1
+	postAuthor := &models.User{ID: 5}
+
+	tests := []struct {
+		name           string
+		viewer         int
+		mockComment    *models.Comment
+		mockCommentErr error
+		mockPost       *models.Post
+		expectPin      bool
+		mockPinErr     error
+		expectedError  error
+	}{
+		{
+			name:          "anonymous user",
+			expectedError: errdefs.UnauthenticatedError(),
+		},
+		{
+			name:           "comment not found",
+			viewer:         5,
+			mockCommentErr: storageerr.ErrNotFound,
+			expectedError:  errdefs.CommentDoesNotExistError(10),
+		},
+		{
+			name:          "not a post author",
+			viewer:        6,
+			mockComment:   &models.Comment{ID: 10, PostID: 1},
+			mockPost:      &models.Post{ID: 1, Author: postAuthor},
+			expectedError: errdefs.ForbiddenError(6),
+		},
+		{
+			name:          "reply cannot be pinned",
+			viewer:        5,
+			mockComment:   &models.Comment{ID: 10, PostID: 1, ReplyTo: &replyTo},
+			mockPost:      &models.Post{ID: 1, Author: postAuthor},
+			expectedError: errdefs.CannotPinReplyError(10),
+		},
+		{
+			name:        "success",
+			viewer:      5,
+			mockComment: &models.Comment{ID: 10, PostID: 1},
+			mockPost:    &models.Post{ID: 1, Author: postAuthor},
+			expectPin:   true,
+		},
+		{
+			name:          "comment deleted before pin",
+			viewer:        5,
+			mockComment:   &models.Comment{ID: 10, PostID: 1},
+			mockPost:      &models.Post{ID: 1, Author: postAuthor},
+			expectPin:     true,
+			mockPinErr:    storageerr.ErrNotFound,
+			expectedError: errdefs.CommentDoesNotExistError(10),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postProvider := mocks.NewMockPostProvider(ctl)
+			commentProvider := mocks.NewMockCommentProvider(ctl)
+			userProvider := mocks.NewMockUserProvider(ctl)
+
+			if tt.viewer != 0 {
+				commentProvider.EXPECT().
+					GetCommentByID(gomock.Any(), 10).
+					Return(tt.mockComment, tt.mockCommentErr).
+					Times(1)
+			}
+			if tt.mockPost != nil {
+				postProvider.EXPECT().
+					GetPostByID(gomock.Any(), tt.mockPost.ID).
+					Return(tt.mockPost, nil).
+					Times(1)
+			}
+			if tt.expectPin {
+				commentProvider.EXPECT().
+					SetCommentPinned(gomock.Any(), 10, true).
+					Return(&models.Comment{ID: 10, PostID: 1, IsPinned: true}, tt.mockPinErr).
+					Times(1)
+			}
+
+			storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+			commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+			ctx := context.Background()
+			if tt.viewer != 0 {
+				ctx = auth.WithUserID(ctx, tt.viewer)
+			}
+			result, err := commentService.PinComment(ctx, 10)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, result.IsPinned)
+		})
+	}
+}
+
+func TestCommentService_EditComment(t *testing.T) {
+	author := &models.User{ID: 5, Username: "author"}
+	moderator := &models.User{ID: 7, Username: "moderator", Role: models.UserRoleModerator}
+	stranger := &models.User{ID: 8, Username: "stranger", Role: models.UserRoleUser}
+	original := &models.Comment{ID: 10, PostID: 1, Payload: "old", Author: author}
+
+	tests := []struct {
+		name          string
+		viewer        int
+		payload       string
+		mockViewer    *models.User
+		expectUpdate  bool
+		expectedError error
+	}{
+		{
+			name:          "anonymous user",
+			payload:       "new",
+			expectedError: errdefs.UnauthenticatedError(),
+		},
+		{
+			name:         "author edits own comment",
+			viewer:       5,
+			payload:      "new",
+			expectUpdate: true,
+		},
+		{
+			name:         "moderator edits comment",
+			viewer:       7,
+			payload:      "new",
+			mockViewer:   moderator,
+			expectUpdate: true,
+		},
+		{
+			name:          "other user cannot edit",
+			viewer:        8,
+			payload:       "new",
+			mockViewer:    stranger,
+			expectedError: errdefs.ForbiddenError(8),
+		},
+		{
+			name:          "payload too long",
+			viewer:        5,
+			payload:       string(make([]byte, consts.MaxPayloadSize+1)),
+			expectedError: errdefs.CommentTooLongError(consts.MaxPayloadSize, consts.MaxPayloadSize+1),
+		},
+		{
+			name:    "unchanged payload does not create revision",
+			viewer:  5,
+			payload: "old",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postProvider := mocks.NewMockPostProvider(ctl)
+			commentProvider := mocks.NewMockCommentProvider(ctl)
+			userProvider := mocks.NewMockUserProvider(ctl)
+
+			if tt.viewer != 0 {
+				commentProvider.EXPECT().
+					GetCommentByID(gomock.Any(), 10).
+					Return(original, nil).
+					Times(1)
+			}
+			if tt.mockViewer != nil {
+				userProvider.EXPECT().
+					GetUserByID(gomock.Any(), tt.viewer).
+					Return(tt.mockViewer, nil).
+					Times(1)
+			}
+			if tt.expectUpdate {
+				commentProvider.EXPECT().
+					UpdateComment(gomock.Any(), 10, tt.payload, tt.viewer, nil).
+					Return(&models.Comment{ID: 10, Payload: tt.payload, Author: author}, nil).
+					Times(1)
+			}
+
+			storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+			commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+			ctx := context.Background()
+			if tt.viewer != 0 {
+				ctx = auth.WithUserID(ctx, tt.viewer)
+			}
+			result, err := commentService.EditComment(ctx, 10, tt.payload)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.payload, result.Payload)
+		})
+	}
+}
+
+func TestCommentService_GetCommentRevisions(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	commentProvider := mocks.NewMockCommentProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+
+	storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+	commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+	comment := &models.Comment{ID: 10, Author: &models.User{ID: 5}}
+	revisions := []*models.Revision{{ID: 1, Version: 1, Payload: "first"}, {ID: 2, Version: 2, Payload: "second"}}
+
+	t.Run("moderator sees earlier revisions", func(t *testing.T) {
+		userProvider.EXPECT().
+			GetUserByID(gomock.Any(), 7).
+			Return(&models.User{ID: 7, Role: models.UserRoleModerator}, nil).
+			Times(1)
+		commentProvider.EXPECT().
+			GetCommentRevisions(gomock.Any(), 10).
+			Return(revisions, nil).
+			Times(1)
+
+		got, err := commentService.GetCommentRevisions(auth.WithUserID(context.Background(), 7), comment)
+		require.NoError(t, err)
+		assert.Equal(t, revisions, got)
+	})
+
+	t.Run("other users are forbidden", func(t *testing.T) {
+		userProvider.EXPECT().
+			GetUserByID(gomock.Any(), 8).
+			Return(&models.User{ID: 8, Role: models.UserRoleUser}, nil).
+			Times(1)
+
+		got, err := commentService.GetCommentRevisions(auth.WithUserID(context.Background(), 8), comment)
+		assert.Nil(t, got)
+		assert.Equal(t, errdefs.ForbiddenError(8), err)
+	})
+}
+
+func TestCommentService_CreateComment_Mentions(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	commentProvider := mocks.NewMockCommentProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+	notificationProvider := mocks.NewMockNotificationProvider(ctl)
+
+	author := &models.User{ID: 1, Username: "author"}
+	alice := &models.User{ID: 2, Username: "Alice"}
+	input := models.NewComment{
+		PostID:   1,
+		AuthorID: 1,
+		Payload:  "hi @Alice and @ghost, write to mail@example.com, @Alice",
+	}
+
+	userProvider.EXPECT().GetUserByID(gomock.Any(), 1).Return(author, nil)
+	userProvider.EXPECT().GetUserBan(gomock.Any(), 1).Return(nil, storageerr.ErrNotFound)
+	postProvider.EXPECT().GetPostByID(gomock.Any(), 1).Return(&models.Post{ID: 1, IsCommentsAllowed: true}, nil)
+	userProvider.EXPECT().GetPostMute(gomock.Any(), 1, 1).Return(nil, storageerr.ErrNotFound)
+	userProvider.EXPECT().
+		GetUsersByUsernames(gomock.Any(), []string{"Alice", "ghost"}).
+		Return([]*models.User{alice}, nil)
+	commentProvider.EXPECT().CreateComment(gomock.Any(), input, false, []int{2}).Return(&models.Comment{ID: 10, PostID: 1, Payload: input.Payload}, nil)
+	notificationProvider.EXPECT().
+		CreateNotifications(gomock.Any(), gomock.Len(1)).
+		DoAndReturn(func(ctx context.Context, notifications []*models.Notification) ([]*models.Notification, error) {
+			return notifications, nil
+		})
+
+	storage := NewStorage(postProvider, commentProvider, userProvider, notificationProvider, nil)
+	commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+	comment, err := commentService.CreateComment(auth.WithUserID(context.Background(), input.AuthorID), input)
+	require.NoError(t, err)
+	assert.Equal(t, []*models.User{alice}, comment.Mentions)
+	assert.Equal(t, input.Payload, comment.Payload, "неизвестные имена остаются обычным текстом")
+}
+
+func TestCommentService_CreateComment_Notifications(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	commentProvider := mocks.NewMockCommentProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+	notificationProvider := mocks.NewMockNotificationProvider(ctl)
+
+	commenter := &models.User{ID: 3, Username: "Alen"}
+	parentAuthor := &models.User{ID: 1, Username: "Alice"}
+	postAuthor := &models.User{ID: 2, Username: "Quizert"}
+	replyTo := 5
+	input := models.NewComment{
+		PostID:   1,
+		AuthorID: 3,
+		Payload:  "@Alice agreed",
+		ReplyTo:  &replyTo,
+	}
+
+	userProvider.EXPECT().GetUserByID(gomock.Any(), 3).Return(commenter, nil)
+	userProvider.EXPECT().GetUserBan(gomock.Any(), 3).Return(nil, storageerr.ErrNotFound)
+	postProvider.EXPECT().GetPostByID(gomock.Any(), 1).Return(&models.Post{ID: 1, Author: postAuthor, IsCommentsAllowed: true}, nil)
+	userProvider.EXPECT().GetPostMute(gomock.Any(), 1, 3).Return(nil, storageerr.ErrNotFound)
+	commentProvider.EXPECT().IsThreadLocked(gomock.Any(), replyTo).Return(false, nil)
+	userProvider.EXPECT().GetUsersByUsernames(gomock.Any(), []string{"Alice"}).Return([]*models.User{parentAuthor}, nil)
+	commentProvider.EXPECT().CreateComment(gomock.Any(), input, false, []int{1}).Return(&models.Comment{ID: 10, PostID: 1, ReplyTo: &replyTo, Payload: input.Payload}, nil)
+	commentProvider.EXPECT().GetCommentByID(gomock.Any(), replyTo).Return(&models.Comment{ID: replyTo, PostID: 1, Author: parentAuthor}, nil).Times(2)
+	userProvider.EXPECT().IsBlocked(gomock.Any(), 1, 3).Return(false, nil)
+
+	// Упомянутый автор родительского комментария получает одно уведомление об ответе
+	expected := []*models.Notification{
+		{Type: models.NotificationTypeReply, Actor: commenter, PostID: 1, CommentID: 10, RecipientID: 1},
+		{Type: models.NotificationTypePostComment, Actor: commenter, PostID: 1, CommentID: 10, RecipientID: 2},
+	}
+	notificationProvider.EXPECT().
+		CreateNotifications(gomock.Any(), expected).
+		DoAndReturn(func(ctx context.Context, notifications []*models.Notification) ([]*models.Notification, error) {
+			for i, notification := range notifications {
+				notification.ID = i + 1
+			}
+			return notifications, nil
+		})
+
+	subscriptions := NewSubscriptionService()
+	ch, err := subscriptions.CreateNotificationsSubscription(context.Background(), 2)
+	require.NoError(t, err)
+
+	received := make(chan *models.Notification, 1)
+	go func() {
+		received <- <-ch
+	}()
+
+	storage := NewStorage(postProvider, commentProvider, userProvider, notificationProvider, nil)
+	commentService := NewCommentService(zap.NewNop(), storage, subscriptions, moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+	_, err = commentService.CreateComment(auth.WithUserID(context.Background(), input.AuthorID), input)
+	require.NoError(t, err)
+
+	select {
+	case notification := <-received:
+		assert.Equal(t, models.NotificationTypePostComment, notification.Type)
+		assert.Equal(t, 2, notification.ID)
+	case <-time.After(time.Second):
+		t.Fatal("timeout: post author did not receive the notification")
+	}
+}
+
+func TestCommentService_EditComment_Held(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	commentProvider := mocks.NewMockCommentProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+
+	author := &models.User{ID: 5}
+	payload := "wooooooow"
+	filter := "repeated_chars"
+
+	commentProvider.EXPECT().
+		GetCommentByID(gomock.Any(), 10).
+		Return(&models.Comment{ID: 10, PostID: 1, Payload: "old", Author: author}, nil)
+	// Правка и элемент очереди модерации передаются в хранилище одним вызовом,
+	// EnqueueModeration отдельно не вызывается
+	commentProvider.EXPECT().
+		UpdateComment(gomock.Any(), 10, payload, 5, &models.ModerationItem{
+			TargetType: models.TargetTypeComment,
+			TargetID:   10,
+			Payload:    payload,
+			Author:     author,
+			Filter:     &filter,
+			Reason:     "content contains too many repeated characters",
+		}).
+		Return(&models.Comment{ID: 10, Payload: payload, Author: author, IsHidden: true}, nil)
+
+	storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+	commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(),
+		moderation.NewChain(moderation.NewRepeatedCharsFilter(5)), ratelimit.NewActionLimiter(nil))
+
+	comment, err := commentService.EditComment(auth.WithUserID(context.Background(), 5), 10, payload)
+	require.NoError(t, err)
+	assert.True(t, comment.IsHidden)
+}
+
+func TestCommentService_CreateComment_Moderation(t *testing.T) {
+	chain := moderation.NewChain(
+		moderation.NewBannedWordsFilter([]string{"casino"}),
+		moderation.NewRepeatedCharsFilter(5),
+	)
+	author := &models.User{ID: 1}
+	post := &models.Post{ID: 1, Author: &models.User{ID: 2}, IsCommentsAllowed: true}
+
+	t.Run("rejected", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		postProvider := mocks.NewMockPostProvider(ctl)
+		commentProvider := mocks.NewMockCommentProvider(ctl)
+		userProvider := mocks.NewMockUserProvider(ctl)
+
+		userProvider.EXPECT().GetUserByID(gomock.Any(), 1).Return(author, nil)
+		userProvider.EXPECT().GetUserBan(gomock.Any(), 1).Return(nil, storageerr.ErrNotFound)
+		postProvider.EXPECT().GetPostByID(gomock.Any(), 1).Return(post, nil)
+		userProvider.EXPECT().GetPostMute(gomock.Any(), 1, 1).Return(nil, storageerr.ErrNotFound)
+		// CreateComment не должен вызываться: контент не прошёл модерацию
+
+		storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+		commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), chain, ratelimit.NewActionLimiter(nil))
+
+		_, err := commentService.CreateComment(auth.WithUserID(context.Background(), 1), models.NewComment{
+			PostID:   1,
+			AuthorID: 1,
+			Payload:  "visit my casino",
+		})
+		assert.Equal(t, errdefs.ContentRejectedError("banned_words", "content contains a banned word"), err)
+	})
+
+	t.Run("held", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		postProvider := mocks.NewMockPostProvider(ctl)
+		commentProvider := mocks.NewMockCommentProvider(ctl)
+		userProvider := mocks.NewMockUserProvider(ctl)
+		moderationProvider := mocks.NewMockModerationProvider(ctl)
+
+		input := models.NewComment{PostID: 1, AuthorID: 1, Payload: "wooooooow"}
+		filter := "repeated_chars"
+
+		userProvider.EXPECT().GetUserByID(gomock.Any(), 1).Return(author, nil)
+		userProvider.EXPECT().GetUserBan(gomock.Any(), 1).Return(nil, storageerr.ErrNotFound)
+		postProvider.EXPECT().GetPostByID(gomock.Any(), 1).Return(post, nil)
+		userProvider.EXPECT().GetPostMute(gomock.Any(), 1, 1).Return(nil, storageerr.ErrNotFound)
+		commentProvider.EXPECT().
+			CreateComment(gomock.Any(), input, true, nil).
+			Return(&models.Comment{ID: 10, PostID: 1, Payload: input.Payload, IsHidden: true}, nil)
+		moderationProvider.EXPECT().
+			EnqueueModeration(gomock.Any(), &models.ModerationItem{
+				TargetType: models.TargetTypeComment,
+				TargetID:   10,
+				Payload:    input.Payload,
+				Author:     author,
+				Filter:     &filter,
+				Reason:     "content contains too many repeated characters",
+			}).
+			Return(&models.ModerationItem{ID: 1}, nil)
+		// Уведомления об отложенном комментарии не создаются: NotificationProvider не передан
+
+		storage := NewStorage(postProvider, commentProvider, userProvider, nil, moderationProvider)
+		commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), chain, ratelimit.NewActionLimiter(nil))
+
+		comment, err := commentService.CreateComment(auth.WithUserID(context.Background(), input.AuthorID), input)
+		require.NoError(t, err)
+		assert.True(t, comment.IsHidden)
+	})
+}
+
+func TestCommentService_CreateComment_Muted(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+
+	until := time.Now().Add(time.Hour)
+	userProvider.EXPECT().GetUserByID(gomock.Any(), 3).Return(&models.User{ID: 3}, nil)
+	userProvider.EXPECT().GetUserBan(gomock.Any(), 3).Return(nil, storageerr.ErrNotFound)
+	postProvider.EXPECT().GetPostByID(gomock.Any(), 1).Return(&models.Post{ID: 1, IsCommentsAllowed: true}, nil)
+	userProvider.EXPECT().GetPostMute(gomock.Any(), 1, 3).Return(&models.PostMute{PostID: 1, Until: &until}, nil)
+	// CreateComment не должен вызываться: пользователю запрещено комментировать пост
+
+	storage := NewStorage(postProvider, nil, userProvider, nil, nil)
+	commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+	_, err := commentService.CreateComment(auth.WithUserID(context.Background(), 3), models.NewComment{PostID: 1, AuthorID: 3, Payload: "hi"})
+	assert.Equal(t, errdefs.UserMutedError(1, 3, &until), err)
+}
+
+func TestCommentService_CreateComment_BlockedByParentAuthor(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	commentProvider := mocks.NewMockCommentProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+
+	replyTo := 5
+	userProvider.EXPECT().GetUserByID(gomock.Any(), 3).Return(&models.User{ID: 3}, nil)
+	userProvider.EXPECT().GetUserBan(gomock.Any(), 3).Return(nil, storageerr.ErrNotFound)
+	postProvider.EXPECT().GetPostByID(gomock.Any(), 1).Return(&models.Post{ID: 1, IsCommentsAllowed: true}, nil)
+	userProvider.EXPECT().GetPostMute(gomock.Any(), 1, 3).Return(nil, storageerr.ErrNotFound)
+	commentProvider.EXPECT().IsThreadLocked(gomock.Any(), replyTo).Return(false, nil)
+	commentProvider.EXPECT().GetCommentByID(gomock.Any(), replyTo).Return(&models.Comment{ID: replyTo, PostID: 1, Author: &models.User{ID: 1}}, nil)
+	userProvider.EXPECT().IsBlocked(gomock.Any(), 1, 3).Return(true, nil)
+	// CreateComment не должен вызываться: автор родительского комментария заблокировал отвечающего
+
+	storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+	commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+	_, err := commentService.CreateComment(auth.WithUserID(context.Background(), 3), models.NewComment{PostID: 1, AuthorID: 3, Payload: "hi", ReplyTo: &replyTo})
+	assert.Equal(t, errdefs.BlockedByUserError(1), err)
+}
+
+func TestCommentService_CreateComment_ReplyToOtherPost(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	commentProvider := mocks.NewMockCommentProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+
+	replyTo := 5
+	userProvider.EXPECT().GetUserByID(gomock.Any(), 3).Return(&models.User{ID: 3}, nil)
+	userProvider.EXPECT().GetUserBan(gomock.Any(), 3).Return(nil, storageerr.ErrNotFound)
+	postProvider.EXPECT().GetPostByID(gomock.Any(), 1).Return(&models.Post{ID: 1, IsCommentsAllowed: true}, nil)
+	userProvider.EXPECT().GetPostMute(gomock.Any(), 1, 3).Return(nil, storageerr.ErrNotFound)
+	commentProvider.EXPECT().IsThreadLocked(gomock.Any(), replyTo).Return(false, nil)
+	commentProvider.EXPECT().GetCommentByID(gomock.Any(), replyTo).Return(&models.Comment{ID: replyTo, PostID: 2, Author: &models.User{ID: 1}}, nil)
+	// CreateComment не должен вызываться: родительский комментарий относится к другому посту
+
+	storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+	commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+	_, err := commentService.CreateComment(auth.WithUserID(context.Background(), 3), models.NewComment{PostID: 1, AuthorID: 3, Payload: "hi", ReplyTo: &replyTo})
+	assert.Equal(t, errdefs.ReplyToOtherPostError(replyTo, 1), err)
+}
+
+func TestCommentService_CreateComment_RateLimited(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	commentProvider := mocks.NewMockCommentProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+
+	input := models.NewComment{PostID: 1, AuthorID: 1, Payload: "hi"}
+	userProvider.EXPECT().GetUserByID(gomock.Any(), 1).Return(&models.User{ID: 1}, nil).Times(2)
+	userProvider.EXPECT().GetUserBan(gomock.Any(), 1).Return(nil, storageerr.ErrNotFound).Times(2)
+	postProvider.EXPECT().GetPostByID(gomock.Any(), 1).Return(&models.Post{ID: 1, IsCommentsAllowed: true}, nil)
+	userProvider.EXPECT().GetPostMute(gomock.Any(), 1, 1).Return(nil, storageerr.ErrNotFound)
+	commentProvider.EXPECT().CreateComment(gomock.Any(), input, false, nil).Return(&models.Comment{ID: 10, PostID: 1, Payload: input.Payload}, nil)
+	// Второй комментарий отклоняется до обращения к посту
+
+	limiter := ratelimit.NewActionLimiter(map[string]ratelimit.Limit{
+		ratelimit.ActionCreateComment: {Count: 1, Per: time.Hour},
+	})
+	storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+	commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), moderation.NewChain(), limiter)
+
+	_, err := commentService.CreateComment(auth.WithUserID(context.Background(), input.AuthorID), input)
+	require.NoError(t, err)
+
+	_, err = commentService.CreateComment(auth.WithUserID(context.Background(), input.AuthorID), input)
+	assert.Equal(t, errdefs.RateLimitedError(ratelimit.ActionCreateComment, 3600), err)
+}
+
+func TestCommentService_CreateComment_Author(t *testing.T) {
+	tests := []struct {
+		name          string
+		viewer        int
+		expectedError error
+	}{
+		{
+			name:          "anonymous",
+			expectedError: errdefs.UnauthenticatedError(),
+		},
+		{
+			name:          "author mismatch",
+			viewer:        2,
+			expectedError: errdefs.ForbiddenError(2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			storage := NewStorage(mocks.NewMockPostProvider(ctl), mocks.NewMockCommentProvider(ctl), mocks.NewMockUserProvider(ctl), nil, nil)
+			commentService := NewCommentService(zap.NewNop(), storage, NewSubscriptionService(), moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+			ctx := context.Background()
+			if tt.viewer != 0 {
+				ctx = auth.WithUserID(ctx, tt.viewer)
+			}
+			_, err := commentService.CreateComment(ctx, models.NewComment{PostID: 1, AuthorID: 1, Payload: "hi"})
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
+)
+
+// storageError переводит ошибку записи в хранилище в ошибку приложения.
+// notFound возвращается, если записи нет, references - ошибки для нарушенных внешних ключей по имени столбца.
+// Остальные ошибки считаются внутренними
+func storageError(err error, notFound error, references map[string]error) error {
+	var field string
+	var constraint *storageerr.ConstraintError
+	if errors.As(err, &constraint) {
+		field = constraint.Field
+	}
+
+	switch {
+	case notFound != nil && errors.Is(err, storageerr.ErrNotFound):
+		return notFound
+	case errors.Is(err, storageerr.ErrForeignKey):
+		if reference, ok := references[field]; ok {
+			return reference
+		}
+		return errdefs.ReferenceDoesNotExistError(field)
+	case errors.Is(err, storageerr.ErrConflict):
+		return errdefs.ConflictError(field)
+	}
+	return errdefs.InternalServerError()
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/Quizert/PostCommentService/internal/models"
 	gomock "github.com/golang/mock/gomock"
@@ -36,33 +37,33 @@ func (m *MockPostProvider) EXPECT() *MockPostProviderMockRecorder {
 }
 
 // CreatePost mocks base method.
-func (m *MockPostProvider) CreatePost(ctx context.Context, input models.NewPost) (*models.Post, error) {
+func (m *MockPostProvider) CreatePost(ctx context.Context, input models.NewPost, hidden bool) (*models.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreatePost", ctx, input)
+	ret := m.ctrl.Call(m, "CreatePost", ctx, input, hidden)
 	ret0, _ := ret[0].(*models.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreatePost indicates an expected call of CreatePost.
-func (mr *MockPostProviderMockRecorder) CreatePost(ctx, input interface{}) *gomock.Call {
+func (mr *MockPostProviderMockRecorder) CreatePost(ctx, input, hidden interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockPostProvider)(nil).CreatePost), ctx, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockPostProvider)(nil).CreatePost), ctx, input, hidden)
 }
 
 // GetAllPosts mocks base method.
-func (m *MockPostProvider) GetAllPosts(ctx context.Context, limit, offset int) ([]*models.Post, error) {
+func (m *MockPostProvider) GetAllPosts(ctx context.Context, limit, offset, viewerID int) ([]*models.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllPosts", ctx, limit, offset)
+	ret := m.ctrl.Call(m, "GetAllPosts", ctx, limit, offset, viewerID)
 	ret0, _ := ret[0].([]*models.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllPosts indicates an expected call of GetAllPosts.
-func (mr *MockPostProviderMockRecorder) GetAllPosts(ctx, limit, offset interface{}) *gomock.Call {
+func (mr *MockPostProviderMockRecorder) GetAllPosts(ctx, limit, offset, viewerID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPosts", reflect.TypeOf((*MockPostProvider)(nil).GetAllPosts), ctx, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPosts", reflect.TypeOf((*MockPostProvider)(nil).GetAllPosts), ctx, limit, offset, viewerID)
 }
 
 // GetPostByID mocks base method.
@@ -80,6 +81,96 @@ func (mr *MockPostProviderMockRecorder) GetPostByID(ctx, id interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostByID", reflect.TypeOf((*MockPostProvider)(nil).GetPostByID), ctx, id)
 }
 
+// GetPostRevisions mocks base method.
+func (m *MockPostProvider) GetPostRevisions(ctx context.Context, postID int) ([]*models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostRevisions", ctx, postID)
+	ret0, _ := ret[0].([]*models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostRevisions indicates an expected call of GetPostRevisions.
+func (mr *MockPostProviderMockRecorder) GetPostRevisions(ctx, postID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostRevisions", reflect.TypeOf((*MockPostProvider)(nil).GetPostRevisions), ctx, postID)
+}
+
+// GetPostsByTag mocks base method.
+func (m *MockPostProvider) GetPostsByTag(ctx context.Context, tag string, limit, offset, viewerID int) ([]*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostsByTag", ctx, tag, limit, offset, viewerID)
+	ret0, _ := ret[0].([]*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostsByTag indicates an expected call of GetPostsByTag.
+func (mr *MockPostProviderMockRecorder) GetPostsByTag(ctx, tag, limit, offset, viewerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByTag", reflect.TypeOf((*MockPostProvider)(nil).GetPostsByTag), ctx, tag, limit, offset, viewerID)
+}
+
+// GetTags mocks base method.
+func (m *MockPostProvider) GetTags(ctx context.Context, prefix string, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", ctx, prefix, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTags indicates an expected call of GetTags.
+func (mr *MockPostProviderMockRecorder) GetTags(ctx, prefix, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockPostProvider)(nil).GetTags), ctx, prefix, limit)
+}
+
+// PublishScheduledPosts mocks base method.
+func (m *MockPostProvider) PublishScheduledPosts(ctx context.Context, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishScheduledPosts", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishScheduledPosts indicates an expected call of PublishScheduledPosts.
+func (mr *MockPostProviderMockRecorder) PublishScheduledPosts(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishScheduledPosts", reflect.TypeOf((*MockPostProvider)(nil).PublishScheduledPosts), ctx, now)
+}
+
+// UpdatePost mocks base method.
+func (m *MockPostProvider) UpdatePost(ctx context.Context, postID int, input models.EditPost, editorID int, hold *models.ModerationItem) (*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePost", ctx, postID, input, editorID, hold)
+	ret0, _ := ret[0].(*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePost indicates an expected call of UpdatePost.
+func (mr *MockPostProviderMockRecorder) UpdatePost(ctx, postID, input, editorID, hold interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePost", reflect.TypeOf((*MockPostProvider)(nil).UpdatePost), ctx, postID, input, editorID, hold)
+}
+
+// UpdatePostStatus mocks base method.
+func (m *MockPostProvider) UpdatePostStatus(ctx context.Context, postID int, status models.PostStatus, publishAt *time.Time) (*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePostStatus", ctx, postID, status, publishAt)
+	ret0, _ := ret[0].(*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePostStatus indicates an expected call of UpdatePostStatus.
+func (mr *MockPostProviderMockRecorder) UpdatePostStatus(ctx, postID, status, publishAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostStatus", reflect.TypeOf((*MockPostProvider)(nil).UpdatePostStatus), ctx, postID, status, publishAt)
+}
+
 // MockCommentProvider is a mock of CommentProvider interface.
 type MockCommentProvider struct {
 	ctrl     *gomock.Controller
@@ -104,48 +195,167 @@ func (m *MockCommentProvider) EXPECT() *MockCommentProviderMockRecorder {
 }
 
 // CreateComment mocks base method.
-func (m *MockCommentProvider) CreateComment(ctx context.Context, input models.NewComment) (*models.Comment, error) {
+func (m *MockCommentProvider) CreateComment(ctx context.Context, input models.NewComment, hidden bool, mentionIDs []int) (*models.Comment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateComment", ctx, input)
+	ret := m.ctrl.Call(m, "CreateComment", ctx, input, hidden, mentionIDs)
 	ret0, _ := ret[0].(*models.Comment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateComment indicates an expected call of CreateComment.
-func (mr *MockCommentProviderMockRecorder) CreateComment(ctx, input interface{}) *gomock.Call {
+func (mr *MockCommentProviderMockRecorder) CreateComment(ctx, input, hidden, mentionIDs interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockCommentProvider)(nil).CreateComment), ctx, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockCommentProvider)(nil).CreateComment), ctx, input, hidden, mentionIDs)
+}
+
+// GetCommentByID mocks base method.
+func (m *MockCommentProvider) GetCommentByID(ctx context.Context, commentID int) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentByID", ctx, commentID)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentByID indicates an expected call of GetCommentByID.
+func (mr *MockCommentProviderMockRecorder) GetCommentByID(ctx, commentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentByID", reflect.TypeOf((*MockCommentProvider)(nil).GetCommentByID), ctx, commentID)
+}
+
+// GetCommentMentions mocks base method.
+func (m *MockCommentProvider) GetCommentMentions(ctx context.Context, commentID int) ([]*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentMentions", ctx, commentID)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentMentions indicates an expected call of GetCommentMentions.
+func (mr *MockCommentProviderMockRecorder) GetCommentMentions(ctx, commentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentMentions", reflect.TypeOf((*MockCommentProvider)(nil).GetCommentMentions), ctx, commentID)
+}
+
+// GetCommentRevisions mocks base method.
+func (m *MockCommentProvider) GetCommentRevisions(ctx context.Context, commentID int) ([]*models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentRevisions", ctx, commentID)
+	ret0, _ := ret[0].([]*models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentRevisions indicates an expected call of GetCommentRevisions.
+func (mr *MockCommentProviderMockRecorder) GetCommentRevisions(ctx, commentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentRevisions", reflect.TypeOf((*MockCommentProvider)(nil).GetCommentRevisions), ctx, commentID)
 }
 
 // GetCommentsByPostID mocks base method.
-func (m *MockCommentProvider) GetCommentsByPostID(ctx context.Context, limit, offset, postID int) ([]*models.Comment, error) {
+func (m *MockCommentProvider) GetCommentsByPostID(ctx context.Context, limit, offset, postID, viewerID int) ([]*models.Comment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommentsByPostID", ctx, limit, offset, postID)
+	ret := m.ctrl.Call(m, "GetCommentsByPostID", ctx, limit, offset, postID, viewerID)
 	ret0, _ := ret[0].([]*models.Comment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCommentsByPostID indicates an expected call of GetCommentsByPostID.
-func (mr *MockCommentProviderMockRecorder) GetCommentsByPostID(ctx, limit, offset, postID interface{}) *gomock.Call {
+func (mr *MockCommentProviderMockRecorder) GetCommentsByPostID(ctx, limit, offset, postID, viewerID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByPostID", reflect.TypeOf((*MockCommentProvider)(nil).GetCommentsByPostID), ctx, limit, offset, postID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByPostID", reflect.TypeOf((*MockCommentProvider)(nil).GetCommentsByPostID), ctx, limit, offset, postID, viewerID)
+}
+
+// IsThreadLocked mocks base method.
+func (m *MockCommentProvider) IsThreadLocked(ctx context.Context, commentID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsThreadLocked", ctx, commentID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsThreadLocked indicates an expected call of IsThreadLocked.
+func (mr *MockCommentProviderMockRecorder) IsThreadLocked(ctx, commentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsThreadLocked", reflect.TypeOf((*MockCommentProvider)(nil).IsThreadLocked), ctx, commentID)
+}
+
+// LockThread mocks base method.
+func (m *MockCommentProvider) LockThread(ctx context.Context, commentID int) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockThread", ctx, commentID)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockThread indicates an expected call of LockThread.
+func (mr *MockCommentProviderMockRecorder) LockThread(ctx, commentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockThread", reflect.TypeOf((*MockCommentProvider)(nil).LockThread), ctx, commentID)
 }
 
 // Replies mocks base method.
-func (m *MockCommentProvider) Replies(ctx context.Context, commentID, limit, offset int) ([]*models.Comment, error) {
+func (m *MockCommentProvider) Replies(ctx context.Context, commentID, limit, offset, viewerID int) ([]*models.Comment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Replies", ctx, commentID, limit, offset)
+	ret := m.ctrl.Call(m, "Replies", ctx, commentID, limit, offset, viewerID)
 	ret0, _ := ret[0].([]*models.Comment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Replies indicates an expected call of Replies.
-func (mr *MockCommentProviderMockRecorder) Replies(ctx, commentID, limit, offset interface{}) *gomock.Call {
+func (mr *MockCommentProviderMockRecorder) Replies(ctx, commentID, limit, offset, viewerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replies", reflect.TypeOf((*MockCommentProvider)(nil).Replies), ctx, commentID, limit, offset, viewerID)
+}
+
+// SaveMentions mocks base method.
+func (m *MockCommentProvider) SaveMentions(ctx context.Context, commentID int, userIDs []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMentions", ctx, commentID, userIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMentions indicates an expected call of SaveMentions.
+func (mr *MockCommentProviderMockRecorder) SaveMentions(ctx, commentID, userIDs interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replies", reflect.TypeOf((*MockCommentProvider)(nil).Replies), ctx, commentID, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMentions", reflect.TypeOf((*MockCommentProvider)(nil).SaveMentions), ctx, commentID, userIDs)
+}
+
+// SetCommentPinned mocks base method.
+func (m *MockCommentProvider) SetCommentPinned(ctx context.Context, commentID int, pinned bool) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCommentPinned", ctx, commentID, pinned)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCommentPinned indicates an expected call of SetCommentPinned.
+func (mr *MockCommentProviderMockRecorder) SetCommentPinned(ctx, commentID, pinned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCommentPinned", reflect.TypeOf((*MockCommentProvider)(nil).SetCommentPinned), ctx, commentID, pinned)
+}
+
+// UpdateComment mocks base method.
+func (m *MockCommentProvider) UpdateComment(ctx context.Context, commentID int, payload string, editorID int, hold *models.ModerationItem) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", ctx, commentID, payload, editorID, hold)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateComment indicates an expected call of UpdateComment.
+func (mr *MockCommentProviderMockRecorder) UpdateComment(ctx, commentID, payload, editorID, hold interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockCommentProvider)(nil).UpdateComment), ctx, commentID, payload, editorID, hold)
 }
 
 // MockUserProvider is a mock of UserProvider interface.
@@ -171,6 +381,66 @@ func (m *MockUserProvider) EXPECT() *MockUserProviderMockRecorder {
 	return m.recorder
 }
 
+// BanUser mocks base method.
+func (m *MockUserProvider) BanUser(ctx context.Context, ban *models.UserBan) (*models.UserBan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanUser", ctx, ban)
+	ret0, _ := ret[0].(*models.UserBan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BanUser indicates an expected call of BanUser.
+func (mr *MockUserProviderMockRecorder) BanUser(ctx, ban interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanUser", reflect.TypeOf((*MockUserProvider)(nil).BanUser), ctx, ban)
+}
+
+// BlockUser mocks base method.
+func (m *MockUserProvider) BlockUser(ctx context.Context, blockerID, blockedID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUser", ctx, blockerID, blockedID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockUser indicates an expected call of BlockUser.
+func (mr *MockUserProviderMockRecorder) BlockUser(ctx, blockerID, blockedID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUser", reflect.TypeOf((*MockUserProvider)(nil).BlockUser), ctx, blockerID, blockedID)
+}
+
+// GetPostMute mocks base method.
+func (m *MockUserProvider) GetPostMute(ctx context.Context, postID, userID int) (*models.PostMute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostMute", ctx, postID, userID)
+	ret0, _ := ret[0].(*models.PostMute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostMute indicates an expected call of GetPostMute.
+func (mr *MockUserProviderMockRecorder) GetPostMute(ctx, postID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostMute", reflect.TypeOf((*MockUserProvider)(nil).GetPostMute), ctx, postID, userID)
+}
+
+// GetUserBan mocks base method.
+func (m *MockUserProvider) GetUserBan(ctx context.Context, userID int) (*models.UserBan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserBan", ctx, userID)
+	ret0, _ := ret[0].(*models.UserBan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserBan indicates an expected call of GetUserBan.
+func (mr *MockUserProviderMockRecorder) GetUserBan(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserBan", reflect.TypeOf((*MockUserProvider)(nil).GetUserBan), ctx, userID)
+}
+
 // GetUserByID mocks base method.
 func (m *MockUserProvider) GetUserByID(ctx context.Context, userID int) (*models.User, error) {
 	m.ctrl.T.Helper()
@@ -185,3 +455,511 @@ func (mr *MockUserProviderMockRecorder) GetUserByID(ctx, userID interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserProvider)(nil).GetUserByID), ctx, userID)
 }
+
+// GetUsersByUsernames mocks base method.
+func (m *MockUserProvider) GetUsersByUsernames(ctx context.Context, usernames []string) ([]*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersByUsernames", ctx, usernames)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersByUsernames indicates an expected call of GetUsersByUsernames.
+func (mr *MockUserProviderMockRecorder) GetUsersByUsernames(ctx, usernames interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByUsernames", reflect.TypeOf((*MockUserProvider)(nil).GetUsersByUsernames), ctx, usernames)
+}
+
+// IsBlocked mocks base method.
+func (m *MockUserProvider) IsBlocked(ctx context.Context, blockerID, blockedID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsBlocked", ctx, blockerID, blockedID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsBlocked indicates an expected call of IsBlocked.
+func (mr *MockUserProviderMockRecorder) IsBlocked(ctx, blockerID, blockedID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBlocked", reflect.TypeOf((*MockUserProvider)(nil).IsBlocked), ctx, blockerID, blockedID)
+}
+
+// MuteUserOnPost mocks base method.
+func (m *MockUserProvider) MuteUserOnPost(ctx context.Context, mute *models.PostMute) (*models.PostMute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MuteUserOnPost", ctx, mute)
+	ret0, _ := ret[0].(*models.PostMute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MuteUserOnPost indicates an expected call of MuteUserOnPost.
+func (mr *MockUserProviderMockRecorder) MuteUserOnPost(ctx, mute interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MuteUserOnPost", reflect.TypeOf((*MockUserProvider)(nil).MuteUserOnPost), ctx, mute)
+}
+
+// SetUserRole mocks base method.
+func (m *MockUserProvider) SetUserRole(ctx context.Context, userID int, role models.UserRole) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", ctx, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockUserProviderMockRecorder) SetUserRole(ctx, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockUserProvider)(nil).SetUserRole), ctx, userID, role)
+}
+
+// UnblockUser mocks base method.
+func (m *MockUserProvider) UnblockUser(ctx context.Context, blockerID, blockedID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnblockUser", ctx, blockerID, blockedID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnblockUser indicates an expected call of UnblockUser.
+func (mr *MockUserProviderMockRecorder) UnblockUser(ctx, blockerID, blockedID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnblockUser", reflect.TypeOf((*MockUserProvider)(nil).UnblockUser), ctx, blockerID, blockedID)
+}
+
+// MockNotificationProvider is a mock of NotificationProvider interface.
+type MockNotificationProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationProviderMockRecorder
+}
+
+// MockNotificationProviderMockRecorder is the mock recorder for MockNotificationProvider.
+type MockNotificationProviderMockRecorder struct {
+	mock *MockNotificationProvider
+}
+
+// NewMockNotificationProvider creates a new mock instance.
+func NewMockNotificationProvider(ctrl *gomock.Controller) *MockNotificationProvider {
+	mock := &MockNotificationProvider{ctrl: ctrl}
+	mock.recorder = &MockNotificationProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationProvider) EXPECT() *MockNotificationProviderMockRecorder {
+	return m.recorder
+}
+
+// CreateNotifications mocks base method.
+func (m *MockNotificationProvider) CreateNotifications(ctx context.Context, notifications []*models.Notification) ([]*models.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNotifications", ctx, notifications)
+	ret0, _ := ret[0].([]*models.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNotifications indicates an expected call of CreateNotifications.
+func (mr *MockNotificationProviderMockRecorder) CreateNotifications(ctx, notifications interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNotifications", reflect.TypeOf((*MockNotificationProvider)(nil).CreateNotifications), ctx, notifications)
+}
+
+// GetNotifications mocks base method.
+func (m *MockNotificationProvider) GetNotifications(ctx context.Context, userID int, unreadOnly bool, limit, after int) ([]*models.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotifications", ctx, userID, unreadOnly, limit, after)
+	ret0, _ := ret[0].([]*models.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotifications indicates an expected call of GetNotifications.
+func (mr *MockNotificationProviderMockRecorder) GetNotifications(ctx, userID, unreadOnly, limit, after interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotifications", reflect.TypeOf((*MockNotificationProvider)(nil).GetNotifications), ctx, userID, unreadOnly, limit, after)
+}
+
+// MarkNotificationsRead mocks base method.
+func (m *MockNotificationProvider) MarkNotificationsRead(ctx context.Context, userID int, ids []int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationsRead", ctx, userID, ids)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkNotificationsRead indicates an expected call of MarkNotificationsRead.
+func (mr *MockNotificationProviderMockRecorder) MarkNotificationsRead(ctx, userID, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationsRead", reflect.TypeOf((*MockNotificationProvider)(nil).MarkNotificationsRead), ctx, userID, ids)
+}
+
+// MockModerationProvider is a mock of ModerationProvider interface.
+type MockModerationProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockModerationProviderMockRecorder
+}
+
+// MockModerationProviderMockRecorder is the mock recorder for MockModerationProvider.
+type MockModerationProviderMockRecorder struct {
+	mock *MockModerationProvider
+}
+
+// NewMockModerationProvider creates a new mock instance.
+func NewMockModerationProvider(ctrl *gomock.Controller) *MockModerationProvider {
+	mock := &MockModerationProvider{ctrl: ctrl}
+	mock.recorder = &MockModerationProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockModerationProvider) EXPECT() *MockModerationProviderMockRecorder {
+	return m.recorder
+}
+
+// CreateReport mocks base method.
+func (m *MockModerationProvider) CreateReport(ctx context.Context, report *models.Report) (*models.Report, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReport", ctx, report)
+	ret0, _ := ret[0].(*models.Report)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateReport indicates an expected call of CreateReport.
+func (mr *MockModerationProviderMockRecorder) CreateReport(ctx, report interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReport", reflect.TypeOf((*MockModerationProvider)(nil).CreateReport), ctx, report)
+}
+
+// EnqueueModeration mocks base method.
+func (m *MockModerationProvider) EnqueueModeration(ctx context.Context, item *models.ModerationItem) (*models.ModerationItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueModeration", ctx, item)
+	ret0, _ := ret[0].(*models.ModerationItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueModeration indicates an expected call of EnqueueModeration.
+func (mr *MockModerationProviderMockRecorder) EnqueueModeration(ctx, item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueModeration", reflect.TypeOf((*MockModerationProvider)(nil).EnqueueModeration), ctx, item)
+}
+
+// GetModerationItem mocks base method.
+func (m *MockModerationProvider) GetModerationItem(ctx context.Context, itemID int) (*models.ModerationItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetModerationItem", ctx, itemID)
+	ret0, _ := ret[0].(*models.ModerationItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetModerationItem indicates an expected call of GetModerationItem.
+func (mr *MockModerationProviderMockRecorder) GetModerationItem(ctx, itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetModerationItem", reflect.TypeOf((*MockModerationProvider)(nil).GetModerationItem), ctx, itemID)
+}
+
+// GetModerationQueue mocks base method.
+func (m *MockModerationProvider) GetModerationQueue(ctx context.Context, status models.ModerationStatus, limit, after int) ([]*models.ModerationItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetModerationQueue", ctx, status, limit, after)
+	ret0, _ := ret[0].([]*models.ModerationItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetModerationQueue indicates an expected call of GetModerationQueue.
+func (mr *MockModerationProviderMockRecorder) GetModerationQueue(ctx, status, limit, after interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetModerationQueue", reflect.TypeOf((*MockModerationProvider)(nil).GetModerationQueue), ctx, status, limit, after)
+}
+
+// GetReportSummaries mocks base method.
+func (m *MockModerationProvider) GetReportSummaries(ctx context.Context, limit, offset int) ([]*models.ReportSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReportSummaries", ctx, limit, offset)
+	ret0, _ := ret[0].([]*models.ReportSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReportSummaries indicates an expected call of GetReportSummaries.
+func (mr *MockModerationProviderMockRecorder) GetReportSummaries(ctx, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReportSummaries", reflect.TypeOf((*MockModerationProvider)(nil).GetReportSummaries), ctx, limit, offset)
+}
+
+// ResolveModerationItem mocks base method.
+func (m *MockModerationProvider) ResolveModerationItem(ctx context.Context, itemID int, status models.ModerationStatus, moderatorID int, reason *string) (*models.ModerationItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveModerationItem", ctx, itemID, status, moderatorID, reason)
+	ret0, _ := ret[0].(*models.ModerationItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveModerationItem indicates an expected call of ResolveModerationItem.
+func (mr *MockModerationProviderMockRecorder) ResolveModerationItem(ctx, itemID, status, moderatorID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveModerationItem", reflect.TypeOf((*MockModerationProvider)(nil).ResolveModerationItem), ctx, itemID, status, moderatorID, reason)
+}
+
+// MockOutboxProvider is a mock of OutboxProvider interface.
+type MockOutboxProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxProviderMockRecorder
+}
+
+// MockOutboxProviderMockRecorder is the mock recorder for MockOutboxProvider.
+type MockOutboxProviderMockRecorder struct {
+	mock *MockOutboxProvider
+}
+
+// NewMockOutboxProvider creates a new mock instance.
+func NewMockOutboxProvider(ctrl *gomock.Controller) *MockOutboxProvider {
+	mock := &MockOutboxProvider{ctrl: ctrl}
+	mock.recorder = &MockOutboxProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxProvider) EXPECT() *MockOutboxProviderMockRecorder {
+	return m.recorder
+}
+
+// ClaimOutboxEvents mocks base method.
+func (m *MockOutboxProvider) ClaimOutboxEvents(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimOutboxEvents", ctx, now, limit, lease)
+	ret0, _ := ret[0].([]*models.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOutboxEvents indicates an expected call of ClaimOutboxEvents.
+func (mr *MockOutboxProviderMockRecorder) ClaimOutboxEvents(ctx, now, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOutboxEvents", reflect.TypeOf((*MockOutboxProvider)(nil).ClaimOutboxEvents), ctx, now, limit, lease)
+}
+
+// DeleteDeliveredOutboxEvents mocks base method.
+func (m *MockOutboxProvider) DeleteDeliveredOutboxEvents(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeliveredOutboxEvents", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDeliveredOutboxEvents indicates an expected call of DeleteDeliveredOutboxEvents.
+func (mr *MockOutboxProviderMockRecorder) DeleteDeliveredOutboxEvents(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeliveredOutboxEvents", reflect.TypeOf((*MockOutboxProvider)(nil).DeleteDeliveredOutboxEvents), ctx, before)
+}
+
+// MarkOutboxEventDelivered mocks base method.
+func (m *MockOutboxProvider) MarkOutboxEventDelivered(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxEventDelivered", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxEventDelivered indicates an expected call of MarkOutboxEventDelivered.
+func (mr *MockOutboxProviderMockRecorder) MarkOutboxEventDelivered(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxEventDelivered", reflect.TypeOf((*MockOutboxProvider)(nil).MarkOutboxEventDelivered), ctx, id)
+}
+
+// RetryOutboxEvent mocks base method.
+func (m *MockOutboxProvider) RetryOutboxEvent(ctx context.Context, id int, nextAttemptAt time.Time, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryOutboxEvent", ctx, id, nextAttemptAt, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryOutboxEvent indicates an expected call of RetryOutboxEvent.
+func (mr *MockOutboxProviderMockRecorder) RetryOutboxEvent(ctx, id, nextAttemptAt, lastError interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryOutboxEvent", reflect.TypeOf((*MockOutboxProvider)(nil).RetryOutboxEvent), ctx, id, nextAttemptAt, lastError)
+}
+
+// MockWebhookProvider is a mock of WebhookProvider interface.
+type MockWebhookProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookProviderMockRecorder
+}
+
+// MockWebhookProviderMockRecorder is the mock recorder for MockWebhookProvider.
+type MockWebhookProviderMockRecorder struct {
+	mock *MockWebhookProvider
+}
+
+// NewMockWebhookProvider creates a new mock instance.
+func NewMockWebhookProvider(ctrl *gomock.Controller) *MockWebhookProvider {
+	mock := &MockWebhookProvider{ctrl: ctrl}
+	mock.recorder = &MockWebhookProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookProvider) EXPECT() *MockWebhookProviderMockRecorder {
+	return m.recorder
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockWebhookProvider) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, now, limit, lease)
+	ret0, _ := ret[0].([]*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockWebhookProviderMockRecorder) ClaimWebhookDeliveries(ctx, now, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockWebhookProvider)(nil).ClaimWebhookDeliveries), ctx, now, limit, lease)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockWebhookProvider) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, webhook)
+	ret0, _ := ret[0].(*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockWebhookProviderMockRecorder) CreateWebhook(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockWebhookProvider)(nil).CreateWebhook), ctx, webhook)
+}
+
+// CreateWebhookDeliveries mocks base method.
+func (m *MockWebhookProvider) CreateWebhookDeliveries(ctx context.Context, eventID int, eventType models.WebhookEventType, payload string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDeliveries", ctx, eventID, eventType, payload)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDeliveries indicates an expected call of CreateWebhookDeliveries.
+func (mr *MockWebhookProviderMockRecorder) CreateWebhookDeliveries(ctx, eventID, eventType, payload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDeliveries", reflect.TypeOf((*MockWebhookProvider)(nil).CreateWebhookDeliveries), ctx, eventID, eventType, payload)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockWebhookProvider) DeleteWebhook(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockWebhookProviderMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockWebhookProvider)(nil).DeleteWebhook), ctx, id)
+}
+
+// GetWebhookByID mocks base method.
+func (m *MockWebhookProvider) GetWebhookByID(ctx context.Context, id int) (*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookByID", ctx, id)
+	ret0, _ := ret[0].(*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookByID indicates an expected call of GetWebhookByID.
+func (mr *MockWebhookProviderMockRecorder) GetWebhookByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookByID", reflect.TypeOf((*MockWebhookProvider)(nil).GetWebhookByID), ctx, id)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockWebhookProvider) GetWebhookDeliveries(ctx context.Context, webhookID int, status *models.WebhookDeliveryStatus, limit, offset int) ([]*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", ctx, webhookID, status, limit, offset)
+	ret0, _ := ret[0].([]*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockWebhookProviderMockRecorder) GetWebhookDeliveries(ctx, webhookID, status, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockWebhookProvider)(nil).GetWebhookDeliveries), ctx, webhookID, status, limit, offset)
+}
+
+// GetWebhookDelivery mocks base method.
+func (m *MockWebhookProvider) GetWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery.
+func (mr *MockWebhookProviderMockRecorder) GetWebhookDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockWebhookProvider)(nil).GetWebhookDelivery), ctx, id)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockWebhookProvider) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks", ctx)
+	ret0, _ := ret[0].([]*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockWebhookProviderMockRecorder) GetWebhooks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockWebhookProvider)(nil).GetWebhooks), ctx)
+}
+
+// UpdateWebhook mocks base method.
+func (m *MockWebhookProvider) UpdateWebhook(ctx context.Context, id int, input models.EditWebhook) (*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhook", ctx, id, input)
+	ret0, _ := ret[0].(*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook.
+func (mr *MockWebhookProviderMockRecorder) UpdateWebhook(ctx, id, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhook", reflect.TypeOf((*MockWebhookProvider)(nil).UpdateWebhook), ctx, id, input)
+}
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockWebhookProvider) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockWebhookProviderMockRecorder) UpdateWebhookDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockWebhookProvider)(nil).UpdateWebhookDelivery), ctx, delivery)
+}
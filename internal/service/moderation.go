@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/consts"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/moderation"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/Quizert/PostCommentService/internal/utils"
+	"go.uber.org/zap"
+	"time"
+)
+
+type Moderator interface {
+	Check(content moderation.Content) moderation.Decision
+}
+
+// moderate прогоняет контент через фильтры модерации. REJECT превращается в ошибку,
+// при HOLD возвращается решение, с которым контент ставится в очередь модерации
+func moderate(moderator Moderator, content moderation.Content) (*moderation.Decision, error) {
+	decision := moderator.Check(content)
+	switch decision.Verdict {
+	case moderation.VerdictReject:
+		return nil, errdefs.ContentRejectedError(decision.Filter, decision.Reason)
+	case moderation.VerdictHold:
+		return &decision, nil
+	}
+	return nil, nil
+}
+
+// holdContent ставит скрытый контент в очередь модерации со снимком его текста
+func holdContent(ctx context.Context, storage *Storage, decision *moderation.Decision, item *models.ModerationItem) error {
+	_, err := storage.EnqueueModeration(ctx, heldItem(decision, item))
+	return err
+}
+
+// heldItem дополняет элемент очереди модерации решением фильтра. Без решения возвращает nil
+func heldItem(decision *moderation.Decision, item *models.ModerationItem) *models.ModerationItem {
+	if decision == nil {
+		return nil
+	}
+	item.Filter = &decision.Filter
+	item.Reason = decision.Reason
+	return item
+}
+
+// reportsFilter - имя, под которым в очереди модерации появляется контент, скрытый по жалобам
+const reportsFilter = "reports"
+
+type ModerationService struct {
+	log       *zap.Logger
+	storage   *Storage
+	publisher NotificationPublisher
+	// reportThreshold - число жалоб, после которого контент скрывается до решения модератора
+	reportThreshold int
+}
+
+func NewModerationService(log *zap.Logger, storage *Storage, publisher NotificationPublisher, reportThreshold int) *ModerationService {
+	return &ModerationService{
+		log,
+		storage,
+		publisher,
+		reportThreshold,
+	}
+}
+
+func (m *ModerationService) GetModerationQueue(ctx context.Context, status *models.ModerationStatus, first *int, after *int) (*models.ModerationQueueConnection, error) {
+	if _, err := requireModerator(ctx, m.storage); err != nil {
+		return nil, err
+	}
+
+	statusValue := models.ModerationStatusPending
+	if status != nil {
+		statusValue = *status
+	}
+	limit, _ := utils.ParseLimitOffset(first, nil)
+	afterValue := 0
+	if after != nil {
+		afterValue = *after
+	}
+
+	// Запрашиваем на один элемент больше, чтобы узнать, есть ли следующая страница
+	items, err := m.storage.GetModerationQueue(ctx, statusValue, limit+1, afterValue)
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+
+	connection := &models.ModerationQueueConnection{
+		Nodes:       items,
+		HasNextPage: len(items) > limit,
+	}
+	if connection.HasNextPage {
+		connection.Nodes = items[:limit]
+	}
+	if len(connection.Nodes) > 0 {
+		connection.EndCursor = &connection.Nodes[len(connection.Nodes)-1].ID
+	}
+	return connection, nil
+}
+
+// ApproveContent возвращает контент в выдачу. Впервые опубликованный комментарий уведомляет получателей так же,
+// как только что созданный, подписчикам его разошлёт диспетчер outbox. Контент, который уже публиковался
+// (например, скрытый по жалобам), возвращается в выдачу без повторных уведомлений
+func (m *ModerationService) ApproveContent(ctx context.Context, itemID int, reason *string) (*models.ModerationItem, error) {
+	item, err := m.resolve(ctx, itemID, models.ModerationStatusApproved, reason)
+	if err != nil {
+		return nil, err
+	}
+	if item.TargetType == models.TargetTypeComment && !item.WasPublished {
+		m.publishApprovedComment(ctx, item.TargetID)
+	}
+	return item, nil
+}
+
+// RejectContent оставляет контент скрытым
+func (m *ModerationService) RejectContent(ctx context.Context, itemID int, reason string) (*models.ModerationItem, error) {
+	return m.resolve(ctx, itemID, models.ModerationStatusRejected, &reason)
+}
+
+func (m *ModerationService) resolve(ctx context.Context, itemID int, status models.ModerationStatus, reason *string) (*models.ModerationItem, error) {
+	moderatorID, err := requireModerator(ctx, m.storage)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := m.storage.GetModerationItem(ctx, itemID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, errdefs.ModerationItemDoesNotExistError(itemID)
+		}
+		return nil, errdefs.InternalServerError()
+	}
+	if item.Status != models.ModerationStatusPending {
+		return nil, errdefs.ModerationItemAlreadyResolvedError(itemID, item.Status)
+	}
+
+	item, err = m.storage.ResolveModerationItem(ctx, itemID, status, moderatorID, reason)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			// Элемент успели разобрать параллельно
+			return nil, errdefs.ModerationItemAlreadyResolvedError(itemID, "")
+		}
+		return nil, errdefs.InternalServerError()
+	}
+	return item, nil
+}
+
+// publishApprovedComment создаёт уведомления об одобренном комментарии.
+// Ошибки только логируются: решение модератора уже сохранено
+func (m *ModerationService) publishApprovedComment(ctx context.Context, commentID int) {
+	log := m.log.With(
+		zap.String("Layer", "ModerationService.publishApprovedComment"),
+		zap.Int("CommentID", commentID),
+	)
+
+	comment, err := m.storage.GetCommentByID(ctx, commentID)
+	if err != nil {
+		log.Error("Failed to get comment", zap.Error(err))
+		return
+	}
+	post, err := m.storage.GetPostByID(ctx, comment.PostID)
+	if err != nil {
+		log.Error("Failed to get post", zap.Error(err))
+		return
+	}
+	comment.Mentions, err = m.storage.GetCommentMentions(ctx, commentID)
+	if err != nil {
+		log.Error("Failed to get mentions", zap.Error(err))
+	}
+
+	recordCommentNotifications(ctx, log, m.storage, m.publisher, post, comment)
+}
+
+// Report сохраняет жалобу текущего пользователя. Каждый пользователь может пожаловаться на контент один раз.
+// Когда число жалоб достигает порога, контент скрывается и попадает в очередь модерации
+func (m *ModerationService) Report(ctx context.Context, targetType models.TargetType, targetID int, reason models.ReportReason, note *string) (*models.Report, error) {
+	reporterID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, errdefs.UnauthenticatedError()
+	}
+	if note != nil && len(*note) > consts.MaxReportNoteLength {
+		return nil, errdefs.ReportNoteTooLongError(consts.MaxReportNoteLength, len(*note))
+	}
+
+	item, hidden, err := m.reportTarget(ctx, targetType, targetID, reporterID)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter, err := m.storage.GetUserByID(ctx, reporterID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, errdefs.UserDoesNotExistError(reporterID)
+		}
+		return nil, errdefs.InternalServerError()
+	}
+
+	report, count, err := m.storage.CreateReport(ctx, &models.Report{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Note:       note,
+		Reporter:   reporter,
+	})
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+	if report == nil {
+		return nil, errdefs.AlreadyReportedError(targetType, targetID)
+	}
+
+	// Контент скрывается, пока жалоб не меньше порога и он виден. Одобренный модератором контент
+	// скрывается снова при следующей жалобе
+	if count >= m.reportThreshold && !hidden {
+		filter := reportsFilter
+		item.Filter = &filter
+		item.Reason = fmt.Sprintf("reported by %d users", count)
+		if _, err = m.storage.EnqueueModeration(ctx, item); err != nil {
+			m.log.Error("Failed to enqueue reported content",
+				zap.String("Layer", "ModerationService.Report"),
+				zap.String("TargetType", string(targetType)),
+				zap.Int("TargetID", targetID),
+				zap.Error(err),
+			)
+			return nil, errdefs.InternalServerError()
+		}
+	}
+	return report, nil
+}
+
+// reportTarget находит контент, на который жалуется пользователь, и возвращает заготовку элемента очереди модерации
+// со снимком контента и признак того, что контент уже скрыт
+func (m *ModerationService) reportTarget(ctx context.Context, targetType models.TargetType, targetID int, reporterID int) (*models.ModerationItem, bool, error) {
+	if targetType == models.TargetTypePost {
+		post, err := m.storage.GetPostByID(ctx, targetID)
+		if err != nil {
+			if errors.Is(err, storageerr.ErrNotFound) {
+				return nil, false, errdefs.PostDoesNotExistError(targetID)
+			}
+			return nil, false, errdefs.InternalServerError()
+		}
+		if !post.IsVisibleTo(reporterID, time.Now()) {
+			return nil, false, errdefs.PostDoesNotExistError(targetID)
+		}
+		return &models.ModerationItem{
+			TargetType: targetType,
+			TargetID:   targetID,
+			Title:      &post.Title,
+			Payload:    post.Payload,
+			Author:     post.Author,
+		}, post.IsHidden, nil
+	}
+
+	comment, err := m.storage.GetCommentByID(ctx, targetID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, false, errdefs.CommentDoesNotExistError(targetID)
+		}
+		return nil, false, errdefs.InternalServerError()
+	}
+	if comment.IsHidden && (comment.Author == nil || comment.Author.ID != reporterID) {
+		return nil, false, errdefs.CommentDoesNotExistError(targetID)
+	}
+	return &models.ModerationItem{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Payload:    comment.Payload,
+		Author:     comment.Author,
+	}, comment.IsHidden, nil
+}
+
+// GetReports возвращает жалобы, сгруппированные по контенту. Доступно только модераторам
+func (m *ModerationService) GetReports(ctx context.Context, limit *int, offset *int) ([]*models.ReportSummary, error) {
+	if _, err := requireModerator(ctx, m.storage); err != nil {
+		return nil, err
+	}
+	limitValue, offsetValue := utils.ParseLimitOffset(limit, offset)
+
+	summaries, err := m.storage.GetReportSummaries(ctx, limitValue, offsetValue)
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+	return summaries, nil
+}
+
+// BanUser запрещает пользователю создавать посты и комментарии до until, без until - бессрочно. Доступно только модераторам
+func (m *ModerationService) BanUser(ctx context.Context, userID int, until *time.Time, reason string) (*models.UserBan, error) {
+	moderatorID, err := requireModerator(ctx, m.storage)
+	if err != nil {
+		return nil, err
+	}
+	if until != nil && !until.After(time.Now()) {
+		return nil, errdefs.InvalidUntilError(until)
+	}
+
+	user, err := m.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, errdefs.UserDoesNotExistError(userID)
+		}
+		return nil, errdefs.InternalServerError()
+	}
+
+	ban, err := m.storage.BanUser(ctx, &models.UserBan{
+		User:      user,
+		Until:     until,
+		Reason:    reason,
+		Moderator: &models.User{ID: moderatorID},
+	})
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+	return ban, nil
+}
+
+// MuteUserOnPost запрещает пользователю комментировать пост до until, без until - бессрочно.
+// Доступно автору поста и модераторам
+func (m *ModerationService) MuteUserOnPost(ctx context.Context, postID int, userID int, until *time.Time) (*models.PostMute, error) {
+	if _, ok := auth.UserIDFromContext(ctx); !ok {
+		return nil, errdefs.UnauthenticatedError()
+	}
+	if until != nil && !until.After(time.Now()) {
+		return nil, errdefs.InvalidUntilError(until)
+	}
+
+	post, err := m.storage.GetPostByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, errdefs.PostDoesNotExistError(postID)
+		}
+		return nil, errdefs.InternalServerError()
+	}
+	authorID := 0
+	if post.Author != nil {
+		authorID = post.Author.ID
+	}
+	mutedByID, err := authorizeOwnerOrModerator(ctx, m.storage, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := m.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, errdefs.UserDoesNotExistError(userID)
+		}
+		return nil, errdefs.InternalServerError()
+	}
+
+	mute, err := m.storage.MuteUserOnPost(ctx, &models.PostMute{
+		PostID:  postID,
+		User:    user,
+		Until:   until,
+		MutedBy: &models.User{ID: mutedByID},
+	})
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+	return mute, nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/service/mocks"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestModerationService_ApproveContent(t *testing.T) {
+	moderator := &models.User{ID: 2, Username: "Quizert", Role: models.UserRoleModerator}
+	user := &models.User{ID: 3, Username: "Alen", Role: models.UserRoleUser}
+	reason := "looks fine"
+
+	tests := []struct {
+		name          string
+		viewer        *models.User
+		mockItem      *models.ModerationItem
+		mockItemErr   error
+		expectResolve bool
+		expectedError error
+	}{
+		{
+			name:          "approve post",
+			viewer:        moderator,
+			mockItem:      &models.ModerationItem{ID: 1, TargetType: models.TargetTypePost, TargetID: 5, Status: models.ModerationStatusPending},
+			expectResolve: true,
+		},
+		{
+			// Комментарий уже публиковался, поэтому уведомления не создаются и хранилище комментариев не нужно
+			name:          "approve already published comment",
+			viewer:        moderator,
+			mockItem:      &models.ModerationItem{ID: 1, TargetType: models.TargetTypeComment, TargetID: 5, Status: models.ModerationStatusPending, WasPublished: true},
+			expectResolve: true,
+		},
+		{
+			name:          "not a moderator",
+			viewer:        user,
+			expectedError: errdefs.ForbiddenError(3),
+		},
+		{
+			name:          "item not found",
+			viewer:        moderator,
+			mockItemErr:   storageerr.ErrNotFound,
+			expectedError: errdefs.ModerationItemDoesNotExistError(1),
+		},
+		{
+			name:          "already resolved",
+			viewer:        moderator,
+			mockItem:      &models.ModerationItem{ID: 1, Status: models.ModerationStatusRejected},
+			expectedError: errdefs.ModerationItemAlreadyResolvedError(1, models.ModerationStatusRejected),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			userProvider := mocks.NewMockUserProvider(ctl)
+			moderationProvider := mocks.NewMockModerationProvider(ctl)
+
+			userProvider.EXPECT().GetUserByID(gomock.Any(), tt.viewer.ID).Return(tt.viewer, nil)
+			if tt.viewer.IsModerator() {
+				moderationProvider.EXPECT().GetModerationItem(gomock.Any(), 1).Return(tt.mockItem, tt.mockItemErr)
+			}
+			if tt.expectResolve {
+				resolved := *tt.mockItem
+				resolved.Status = models.ModerationStatusApproved
+				moderationProvider.EXPECT().
+					ResolveModerationItem(gomock.Any(), 1, models.ModerationStatusApproved, moderator.ID, &reason).
+					Return(&resolved, nil)
+			}
+
+			storage := NewStorage(nil, nil, userProvider, nil, moderationProvider)
+			moderationService := NewModerationService(zap.NewNop(), storage, NewSubscriptionService(), 3)
+
+			ctx := auth.WithUserID(context.Background(), tt.viewer.ID)
+			item, err := moderationService.ApproveContent(ctx, 1, &reason)
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, models.ModerationStatusApproved, item.Status)
+		})
+	}
+}
+
+func TestModerationService_ApproveComment_Notifies(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	commentProvider := mocks.NewMockCommentProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+	notificationProvider := mocks.NewMockNotificationProvider(ctl)
+	moderationProvider := mocks.NewMockModerationProvider(ctl)
+
+	moderator := &models.User{ID: 2, Role: models.UserRoleModerator}
+	author := &models.User{ID: 3}
+	comment := &models.Comment{ID: 10, PostID: 1, Author: author, Payload: "held"}
+
+	userProvider.EXPECT().GetUserByID(gomock.Any(), 2).Return(moderator, nil)
+	moderationProvider.EXPECT().
+		GetModerationItem(gomock.Any(), 7).
+		Return(&models.ModerationItem{ID: 7, TargetType: models.TargetTypeComment, TargetID: 10, Status: models.ModerationStatusPending}, nil)
+	moderationProvider.EXPECT().
+		ResolveModerationItem(gomock.Any(), 7, models.ModerationStatusApproved, 2, nil).
+		Return(&models.ModerationItem{ID: 7, TargetType: models.TargetTypeComment, TargetID: 10, Status: models.ModerationStatusApproved}, nil)
+	commentProvider.EXPECT().GetCommentByID(gomock.Any(), 10).Return(comment, nil)
+	postProvider.EXPECT().GetPostByID(gomock.Any(), 1).Return(&models.Post{ID: 1, Author: &models.User{ID: 1}}, nil)
+	commentProvider.EXPECT().GetCommentMentions(gomock.Any(), 10).Return([]*models.User{}, nil)
+	notificationProvider.EXPECT().
+		CreateNotifications(gomock.Any(), gomock.Len(1)).
+		DoAndReturn(func(ctx context.Context, notifications []*models.Notification) ([]*models.Notification, error) {
+			return notifications, nil
+		})
+
+	subscriptions := NewSubscriptionService()
+	ch, err := subscriptions.CreateNotificationsSubscription(context.Background(), 1)
+	require.NoError(t, err)
+	received := make(chan *models.Notification, 1)
+	go func() {
+		received <- <-ch
+	}()
+
+	storage := NewStorage(postProvider, commentProvider, userProvider, notificationProvider, moderationProvider)
+	moderationService := NewModerationService(zap.NewNop(), storage, subscriptions, 3)
+
+	_, err = moderationService.ApproveContent(auth.WithUserID(context.Background(), 2), 7, nil)
+	require.NoError(t, err)
+
+	select {
+	case got := <-received:
+		assert.Equal(t, models.NotificationTypePostComment, got.Type)
+		assert.Equal(t, 10, got.CommentID)
+	case <-time.After(time.Second):
+		t.Fatal("timeout: notification about approved comment was not published")
+	}
+}
+
+func TestModerationService_Report(t *testing.T) {
+	post := &models.Post{ID: 1, Title: "Title", Payload: "text", Author: &models.User{ID: 1}, Status: models.PostStatusPublished}
+	reporter := &models.User{ID: 3, Username: "Alen"}
+
+	tests := []struct {
+		name          string
+		authenticated bool
+		hidden        bool
+		reportsCount  int
+		duplicate     bool
+		expectEnqueue bool
+		enqueueErr    error
+		expectedError error
+	}{
+		{
+			name:          "below threshold",
+			authenticated: true,
+			reportsCount:  2,
+		},
+		{
+			name:          "threshold reached",
+			authenticated: true,
+			reportsCount:  3,
+			expectEnqueue: true,
+		},
+		{
+			name:          "reported again after approval",
+			authenticated: true,
+			reportsCount:  4,
+			expectEnqueue: true,
+		},
+		{
+			name:          "enqueue failed",
+			authenticated: true,
+			reportsCount:  3,
+			expectEnqueue: true,
+			enqueueErr:    errors.New("db is down"),
+			expectedError: errdefs.InternalServerError(),
+		},
+		{
+			name:          "hidden post",
+			authenticated: true,
+			hidden:        true,
+			expectedError: errdefs.PostDoesNotExistError(1),
+		},
+		{
+			name:          "duplicate report",
+			authenticated: true,
+			reportsCount:  3,
+			duplicate:     true,
+			expectedError: errdefs.AlreadyReportedError(models.TargetTypePost, 1),
+		},
+		{
+			name:          "unauthenticated",
+			expectedError: errdefs.UnauthenticatedError(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postProvider := mocks.NewMockPostProvider(ctl)
+			userProvider := mocks.NewMockUserProvider(ctl)
+			moderationProvider := mocks.NewMockModerationProvider(ctl)
+
+			ctx := context.Background()
+			if tt.authenticated {
+				ctx = auth.WithUserID(ctx, reporter.ID)
+
+				target := *post
+				target.IsHidden = tt.hidden
+				postProvider.EXPECT().GetPostByID(gomock.Any(), post.ID).Return(&target, nil)
+			}
+			if tt.authenticated && !tt.hidden {
+				userProvider.EXPECT().GetUserByID(gomock.Any(), reporter.ID).Return(reporter, nil)
+
+				moderationProvider.EXPECT().
+					CreateReport(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, report *models.Report) (*models.Report, int, error) {
+						if tt.duplicate {
+							return nil, tt.reportsCount, nil
+						}
+						stored := *report
+						stored.ID = 10
+						return &stored, tt.reportsCount, nil
+					})
+			}
+			if tt.expectEnqueue {
+				moderationProvider.EXPECT().
+					EnqueueModeration(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, item *models.ModerationItem) (*models.ModerationItem, error) {
+						assert.Equal(t, models.TargetTypePost, item.TargetType)
+						assert.Equal(t, post.ID, item.TargetID)
+						assert.Equal(t, "reports", *item.Filter)
+						assert.Equal(t, fmt.Sprintf("reported by %d users", tt.reportsCount), item.Reason)
+						return item, tt.enqueueErr
+					})
+			}
+
+			storage := NewStorage(postProvider, nil, userProvider, nil, moderationProvider)
+			moderationService := NewModerationService(zap.NewNop(), storage, NewSubscriptionService(), 3)
+
+			report, err := moderationService.Report(ctx, models.TargetTypePost, post.ID, models.ReportReasonSpam, nil)
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 10, report.ID)
+			assert.Equal(t, reporter, report.Reporter)
+		})
+	}
+}
+
+func TestModerationService_BanUser(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	userProvider := mocks.NewMockUserProvider(ctl)
+	storage := NewStorage(nil, nil, userProvider, nil, nil)
+	moderationService := NewModerationService(zap.NewNop(), storage, NewSubscriptionService(), 3)
+
+	moderator := &models.User{ID: 2, Role: models.UserRoleModerator}
+	target := &models.User{ID: 3}
+	until := time.Now().Add(24 * time.Hour)
+
+	t.Run("not a moderator", func(t *testing.T) {
+		userProvider.EXPECT().GetUserByID(gomock.Any(), 1).Return(&models.User{ID: 1}, nil)
+
+		_, err := moderationService.BanUser(auth.WithUserID(context.Background(), 1), 3, &until, "spam")
+		assert.Equal(t, errdefs.ForbiddenError(1), err)
+	})
+
+	t.Run("unverified moderator", func(t *testing.T) {
+		_, err := moderationService.BanUser(auth.WithUnverifiedUserID(context.Background(), 2), 3, &until, "spam")
+		assert.Equal(t, errdefs.ForbiddenError(2), err, "X-User-ID без токена не даёт прав модератора")
+	})
+
+	t.Run("until in the past", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		userProvider.EXPECT().GetUserByID(gomock.Any(), 2).Return(moderator, nil)
+
+		_, err := moderationService.BanUser(auth.WithUserID(context.Background(), 2), 3, &past, "spam")
+		assert.Equal(t, errdefs.InvalidUntilError(&past), err)
+	})
+
+	t.Run("banned", func(t *testing.T) {
+		userProvider.EXPECT().GetUserByID(gomock.Any(), 2).Return(moderator, nil)
+		userProvider.EXPECT().GetUserByID(gomock.Any(), 3).Return(target, nil)
+		userProvider.EXPECT().
+			BanUser(gomock.Any(), &models.UserBan{User: target, Until: &until, Reason: "spam", Moderator: &models.User{ID: 2}}).
+			Return(&models.UserBan{User: target, Until: &until, Reason: "spam", Moderator: moderator}, nil)
+
+		ban, err := moderationService.BanUser(auth.WithUserID(context.Background(), 2), 3, &until, "spam")
+		require.NoError(t, err)
+		assert.Equal(t, moderator, ban.Moderator)
+	})
+}
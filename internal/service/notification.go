@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/utils"
+	"go.uber.org/zap"
+)
+
+// NotificationPublisher доставляет уведомления подписчикам в реальном времени
+type NotificationPublisher interface {
+	PublishNotifications(ctx context.Context, notifications []*models.Notification)
+}
+
+type NotificationService struct {
+	log     *zap.Logger
+	storage *Storage
+}
+
+func NewNotificationService(log *zap.Logger, storage *Storage) *NotificationService {
+	return &NotificationService{
+		log,
+		storage,
+	}
+}
+
+func (n *NotificationService) GetNotifications(ctx context.Context, unreadOnly *bool, first *int, after *int) (*models.NotificationConnection, error) {
+	viewerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, errdefs.UnauthenticatedError()
+	}
+
+	limit, _ := utils.ParseLimitOffset(first, nil)
+	afterValue := 0
+	if after != nil {
+		afterValue = *after
+	}
+
+	// Запрашиваем на одно уведомление больше, чтобы узнать, есть ли следующая страница
+	notifications, err := n.storage.GetNotifications(ctx, viewerID, unreadOnly != nil && *unreadOnly, limit+1, afterValue)
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+
+	connection := &models.NotificationConnection{
+		Nodes:       notifications,
+		HasNextPage: len(notifications) > limit,
+	}
+	if connection.HasNextPage {
+		connection.Nodes = notifications[:limit]
+	}
+	if len(connection.Nodes) > 0 {
+		connection.EndCursor = &connection.Nodes[len(connection.Nodes)-1].ID
+	}
+	return connection, nil
+}
+
+func (n *NotificationService) MarkNotificationsRead(ctx context.Context, ids []int) (int, error) {
+	viewerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return 0, errdefs.UnauthenticatedError()
+	}
+
+	count, err := n.storage.MarkNotificationsRead(ctx, viewerID, ids)
+	if err != nil {
+		return 0, errdefs.InternalServerError()
+	}
+	return count, nil
+}
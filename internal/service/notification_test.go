@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"testing"
+)
+
+func TestNotificationService_GetNotifications(t *testing.T) {
+	first := 2
+	after := 10
+	unreadOnly := true
+	page := []*models.Notification{{ID: 9}, {ID: 8}, {ID: 7}}
+
+	tests := []struct {
+		name              string
+		ctx               context.Context
+		unreadOnly        *bool
+		first             *int
+		after             *int
+		expectStorageCall bool
+		expectedUnread    bool
+		expectedAfter     int
+		mockNotifications []*models.Notification
+		expectedIDs       []int
+		expectedCursor    *int
+		expectedNext      bool
+		expectedError     error
+	}{
+		{
+			name:              "first page with next page",
+			ctx:               auth.WithUserID(context.Background(), 1),
+			first:             &first,
+			expectStorageCall: true,
+			mockNotifications: page,
+			expectedIDs:       []int{9, 8},
+			expectedCursor:    &page[1].ID,
+			expectedNext:      true,
+		},
+		{
+			name:              "last unread page",
+			ctx:               auth.WithUserID(context.Background(), 1),
+			unreadOnly:        &unreadOnly,
+			first:             &first,
+			after:             &after,
+			expectStorageCall: true,
+			expectedUnread:    true,
+			expectedAfter:     10,
+			mockNotifications: page[2:],
+			expectedIDs:       []int{7},
+			expectedCursor:    &page[2].ID,
+		},
+		{
+			name:              "empty inbox",
+			ctx:               auth.WithUserID(context.Background(), 1),
+			first:             &first,
+			expectStorageCall: true,
+			mockNotifications: []*models.Notification{},
+			expectedIDs:       []int{},
+		},
+		{
+			name:          "anonymous",
+			ctx:           context.Background(),
+			expectedError: errdefs.UnauthenticatedError(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			notificationProvider := mocks.NewMockNotificationProvider(ctl)
+			if tt.expectStorageCall {
+				notificationProvider.EXPECT().
+					GetNotifications(gomock.Any(), 1, tt.expectedUnread, first+1, tt.expectedAfter).
+					Return(tt.mockNotifications, nil).
+					Times(1)
+			}
+
+			storage := NewStorage(nil, nil, nil, notificationProvider, nil)
+			notificationService := NewNotificationService(zap.NewNop(), storage)
+
+			connection, err := notificationService.GetNotifications(tt.ctx, tt.unreadOnly, tt.first, tt.after)
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			require.NoError(t, err)
+
+			ids := make([]int, 0, len(connection.Nodes))
+			for _, notification := range connection.Nodes {
+				ids = append(ids, notification.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Equal(t, tt.expectedCursor, connection.EndCursor)
+			assert.Equal(t, tt.expectedNext, connection.HasNextPage)
+		})
+	}
+}
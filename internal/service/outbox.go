@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/models"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	// outboxBatchSize - сколько событий диспетчер берёт за один запрос
+	outboxBatchSize = 100
+	// outboxLease - на сколько откладывается следующая попытка выданного события. Если экземпляр упадёт
+	// посреди доставки, событие после аренды возьмёт другой
+	outboxLease = time.Minute
+	// Задержка перед повтором удваивается с каждой попыткой от outboxMinBackoff до outboxMaxBackoff
+	outboxMinBackoff = time.Second
+	outboxMaxBackoff = 5 * time.Minute
+	// outboxCleanupInterval - как часто удаляются доставленные события
+	outboxCleanupInterval = 10 * time.Minute
+)
+
+// EventHandler получает события outbox. Ошибка означает, что событие нужно доставить ещё раз,
+// поэтому обработчик должен переносить повторную доставку. События чужих типов обработчик пропускает
+type EventHandler interface {
+	HandleEvent(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// OutboxDispatcher периодически забирает события из outbox и передаёт их обработчикам.
+// Событие помечается доставленным, когда его приняли все обработчики, иначе повторяется с растущей задержкой
+type OutboxDispatcher struct {
+	log       *zap.Logger
+	outbox    OutboxProvider
+	handlers  []EventHandler
+	interval  time.Duration
+	retention time.Duration
+	now       func() time.Time
+
+	lastCleanup time.Time
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+func NewOutboxDispatcher(log *zap.Logger, outbox OutboxProvider, interval time.Duration, retention time.Duration, handlers ...EventHandler) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		log:       log,
+		outbox:    outbox,
+		handlers:  handlers,
+		interval:  interval,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+func (d *OutboxDispatcher) Start(ctx context.Context) {
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			// Полная пачка значит, что в outbox могут быть ещё события
+			for d.dispatch(ctx) == outboxBatchSize && ctx.Err() == nil {
+			}
+			d.cleanup(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop останавливает диспетчер и дожидается завершения текущей итерации. Недоставленные события
+// остаются в outbox и будут выданы снова после аренды
+func (d *OutboxDispatcher) Stop() {
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	<-d.done
+}
+
+// dispatch доставляет одну пачку событий и возвращает её размер
+func (d *OutboxDispatcher) dispatch(ctx context.Context) int {
+	log := d.log.With(
+		zap.String("Layer", "OutboxDispatcher.dispatch"),
+	)
+
+	events, err := d.outbox.ClaimOutboxEvents(ctx, d.now(), outboxBatchSize, outboxLease)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error("Failed to claim outbox events", zap.Error(err))
+		}
+		return 0
+	}
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return len(events)
+		}
+		d.deliver(ctx, log.With(zap.Int("EventID", event.ID), zap.String("EventType", string(event.Type))), event)
+	}
+	return len(events)
+}
+
+func (d *OutboxDispatcher) deliver(ctx context.Context, log *zap.Logger, event *models.OutboxEvent) {
+	var errs []error
+	for _, handler := range d.handlers {
+		if err := handler.HandleEvent(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		nextAttemptAt := d.now().Add(outboxBackoff(event.Attempts))
+		log.Warn("Failed to deliver outbox event", zap.Int("Attempts", event.Attempts), zap.Time("NextAttemptAt", nextAttemptAt), zap.Error(err))
+		if err = d.outbox.RetryOutboxEvent(ctx, event.ID, nextAttemptAt, err.Error()); err != nil {
+			log.Error("Failed to reschedule outbox event", zap.Error(err))
+		}
+		return
+	}
+	if err := d.outbox.MarkOutboxEventDelivered(ctx, event.ID); err != nil {
+		log.Error("Failed to mark outbox event delivered", zap.Error(err))
+	}
+}
+
+// cleanup удаляет доставленные события старше retention, не чаще раза в outboxCleanupInterval
+func (d *OutboxDispatcher) cleanup(ctx context.Context) {
+	now := d.now()
+	if d.retention <= 0 || now.Sub(d.lastCleanup) < outboxCleanupInterval {
+		return
+	}
+	d.lastCleanup = now
+
+	deleted, err := d.outbox.DeleteDeliveredOutboxEvents(ctx, now.Add(-d.retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			d.log.Error("Failed to delete delivered outbox events", zap.String("Layer", "OutboxDispatcher.cleanup"), zap.Error(err))
+		}
+		return
+	}
+	if deleted > 0 {
+		d.log.Info("Deleted delivered outbox events", zap.String("Layer", "OutboxDispatcher.cleanup"), zap.Int("Events", deleted))
+	}
+}
+
+// outboxBackoff возвращает задержку перед следующей попыткой после attempts неудачных
+func outboxBackoff(attempts int) time.Duration {
+	return backoff(attempts, outboxMinBackoff, outboxMaxBackoff)
+}
+
+// backoff удваивает задержку с каждой неудачной попыткой, начиная с minBackoff, но не больше maxBackoff
+func backoff(attempts int, minBackoff time.Duration, maxBackoff time.Duration) time.Duration {
+	delay := minBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// CommentNotifier рассылает опубликованный комментарий подписчикам поста и упомянутым пользователям
+type CommentNotifier interface {
+	Notify(ctx context.Context, comment *models.Comment) error
+}
+
+// CommentPublishedHandler рассылает подписчикам комментарии из событий comment.published
+type CommentPublishedHandler struct {
+	log      *zap.Logger
+	comments CommentProvider
+	notifier CommentNotifier
+}
+
+func NewCommentPublishedHandler(log *zap.Logger, comments CommentProvider, notifier CommentNotifier) *CommentPublishedHandler {
+	return &CommentPublishedHandler{
+		log:      log,
+		comments: comments,
+		notifier: notifier,
+	}
+}
+
+func (h *CommentPublishedHandler) HandleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	if event.Type != models.OutboxEventCommentPublished {
+		return nil
+	}
+	log := h.log.With(
+		zap.String("Layer", "CommentPublishedHandler.HandleEvent"),
+		zap.Int("CommentID", event.AggregateID),
+	)
+
+	comment, err := h.comments.GetCommentByID(ctx, event.AggregateID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			log.Warn("Published comment not found, skipping")
+			return nil
+		}
+		return err
+	}
+	// Комментарий могли скрыть по жалобам раньше, чем событие дошло до подписчиков
+	if comment.IsHidden {
+		return nil
+	}
+
+	comment.Mentions, err = h.comments.GetCommentMentions(ctx, comment.ID)
+	if err != nil {
+		return err
+	}
+	return h.notifier.Notify(ctx, comment)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/service/mocks"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+type handlerFunc func(ctx context.Context, event *models.OutboxEvent) error
+
+func (f handlerFunc) HandleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	return f(ctx, event)
+}
+
+func TestOutboxDispatcher_Dispatch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		attempts    int
+		handlerErrs []error
+		expectRetry time.Time
+	}{
+		{
+			name:        "delivered",
+			attempts:    1,
+			handlerErrs: []error{nil, nil},
+		},
+		{
+			name:        "first failure",
+			attempts:    1,
+			handlerErrs: []error{nil, errors.New("subscriber failed")},
+			expectRetry: now.Add(time.Second),
+		},
+		{
+			name:        "backoff grows with attempts",
+			attempts:    4,
+			handlerErrs: []error{errors.New("subscriber failed"), nil},
+			expectRetry: now.Add(8 * time.Second),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			outbox := mocks.NewMockOutboxProvider(ctl)
+			event := &models.OutboxEvent{ID: 7, Type: models.OutboxEventCommentPublished, AggregateID: 10, Attempts: tt.attempts}
+			outbox.EXPECT().ClaimOutboxEvents(gomock.Any(), now, outboxBatchSize, outboxLease).Return([]*models.OutboxEvent{event}, nil)
+			if tt.expectRetry.IsZero() {
+				outbox.EXPECT().MarkOutboxEventDelivered(gomock.Any(), 7).Return(nil)
+			} else {
+				outbox.EXPECT().RetryOutboxEvent(gomock.Any(), 7, tt.expectRetry, "subscriber failed").Return(nil)
+			}
+
+			// Каждый обработчик получает событие, даже если предыдущий не справился
+			handlers := make([]EventHandler, 0, len(tt.handlerErrs))
+			calls := 0
+			for _, err := range tt.handlerErrs {
+				handlers = append(handlers, handlerFunc(func(_ context.Context, got *models.OutboxEvent) error {
+					calls++
+					assert.Equal(t, event, got)
+					return err
+				}))
+			}
+
+			dispatcher := NewOutboxDispatcher(zap.NewNop(), outbox, time.Second, 0, handlers...)
+			dispatcher.now = func() time.Time { return now }
+			assert.Equal(t, 1, dispatcher.dispatch(context.Background()))
+			assert.Equal(t, len(tt.handlerErrs), calls)
+		})
+	}
+}
+
+func TestOutboxDispatcher_Cleanup(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	outbox := mocks.NewMockOutboxProvider(ctl)
+	outbox.EXPECT().DeleteDeliveredOutboxEvents(gomock.Any(), now.Add(-time.Hour)).Return(3, nil)
+	outbox.EXPECT().DeleteDeliveredOutboxEvents(gomock.Any(), now.Add(outboxCleanupInterval-time.Hour)).Return(0, nil)
+
+	dispatcher := NewOutboxDispatcher(zap.NewNop(), outbox, time.Second, time.Hour)
+	dispatcher.now = func() time.Time { return now }
+	dispatcher.cleanup(context.Background())
+	dispatcher.cleanup(context.Background())
+
+	now = now.Add(outboxCleanupInterval)
+	dispatcher.cleanup(context.Background())
+}
+
+func TestOutboxDispatcher_Start(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	outbox := mocks.NewMockOutboxProvider(ctl)
+	event := &models.OutboxEvent{ID: 1, Type: models.OutboxEventCommentPublished, AggregateID: 10, Attempts: 1}
+	outbox.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Any(), outboxBatchSize, outboxLease).Return([]*models.OutboxEvent{event}, nil)
+	outbox.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Any(), outboxBatchSize, outboxLease).Return([]*models.OutboxEvent{}, nil).AnyTimes()
+	outbox.EXPECT().MarkOutboxEventDelivered(gomock.Any(), 1).Return(nil)
+	outbox.EXPECT().DeleteDeliveredOutboxEvents(gomock.Any(), gomock.Any()).Return(0, nil)
+
+	delivered := make(chan *models.OutboxEvent, 1)
+	dispatcher := NewOutboxDispatcher(zap.NewNop(), outbox, 10*time.Millisecond, time.Hour, handlerFunc(func(_ context.Context, event *models.OutboxEvent) error {
+		delivered <- event
+		return nil
+	}))
+
+	dispatcher.Start(context.Background())
+	select {
+	case got := <-delivered:
+		assert.Equal(t, event, got)
+	case <-time.After(time.Second):
+		t.Fatal("timeout: dispatcher did not deliver event")
+	}
+	dispatcher.Stop()
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 0, expected: time.Second},
+		{attempts: 1, expected: time.Second},
+		{attempts: 2, expected: 2 * time.Second},
+		{attempts: 5, expected: 16 * time.Second},
+		{attempts: 9, expected: 256 * time.Second},
+		{attempts: 10, expected: outboxMaxBackoff},
+		{attempts: 1000, expected: outboxMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, outboxBackoff(tt.attempts), "attempts %d", tt.attempts)
+	}
+}
+
+func TestCommentPublishedHandler(t *testing.T) {
+	mentioned := &models.User{ID: 2, Username: "Quizert"}
+
+	tests := []struct {
+		name         string
+		eventType    models.OutboxEventType
+		comment      *models.Comment
+		commentErr   error
+		expectNotify bool
+		expectErr    bool
+	}{
+		{
+			name:         "visible comment",
+			eventType:    models.OutboxEventCommentPublished,
+			comment:      &models.Comment{ID: 10, PostID: 1, Payload: "hi @Quizert"},
+			expectNotify: true,
+		},
+		{
+			name:      "hidden after publishing",
+			eventType: models.OutboxEventCommentPublished,
+			comment:   &models.Comment{ID: 10, PostID: 1, IsHidden: true},
+		},
+		{
+			name:       "comment not found",
+			eventType:  models.OutboxEventCommentPublished,
+			commentErr: storageerr.ErrNotFound,
+		},
+		{
+			name:       "storage error is retried",
+			eventType:  models.OutboxEventCommentPublished,
+			commentErr: errors.New("db error"),
+			expectErr:  true,
+		},
+		{
+			name:      "other event type",
+			eventType: "post.published",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			commentProvider := mocks.NewMockCommentProvider(ctl)
+			if tt.eventType == models.OutboxEventCommentPublished {
+				commentProvider.EXPECT().GetCommentByID(gomock.Any(), 10).Return(tt.comment, tt.commentErr)
+			}
+			if tt.expectNotify {
+				commentProvider.EXPECT().GetCommentMentions(gomock.Any(), 10).Return([]*models.User{mentioned}, nil)
+			}
+
+			subscriptions := NewSubscriptionService()
+			postCh, err := subscriptions.CreateSubscription(context.Background(), 1)
+			require.NoError(t, err)
+			mentionCh, err := subscriptions.CreateMentionsSubscription(context.Background(), mentioned.ID)
+			require.NoError(t, err)
+			received := make(chan *models.Comment, 2)
+			go func() {
+				received <- <-postCh
+				received <- <-mentionCh
+			}()
+
+			handler := NewCommentPublishedHandler(zap.NewNop(), commentProvider, subscriptions)
+			err = handler.HandleEvent(context.Background(), &models.OutboxEvent{ID: 1, Type: tt.eventType, AggregateID: 10})
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if !tt.expectNotify {
+				assert.Empty(t, received)
+				return
+			}
+			for i := 0; i < 2; i++ {
+				select {
+				case got := <-received:
+					assert.Equal(t, 10, got.ID)
+					assert.Equal(t, []*models.User{mentioned}, got.Mentions)
+				case <-time.After(time.Second):
+					t.Fatal("timeout: comment was not broadcast")
+				}
+			}
+		})
+	}
+}
@@ -3,65 +3,304 @@ package service
 import (
 	"context"
 	"errors"
+	"github.com/Quizert/PostCommentService/internal/auth"
 	"github.com/Quizert/PostCommentService/internal/consts"
 	"github.com/Quizert/PostCommentService/internal/errdefs"
 	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/moderation"
+	"github.com/Quizert/PostCommentService/internal/ratelimit"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
 	"github.com/Quizert/PostCommentService/internal/utils"
-	"github.com/jackc/pgx/v4"
 	"go.uber.org/zap"
+	"time"
 )
 
 type PostService struct {
-	log     *zap.Logger
-	storage *Storage
+	log       *zap.Logger
+	storage   *Storage
+	moderator Moderator
+	limiter   RateLimiter
 }
 
-func NewPostService(log *zap.Logger, storage *Storage) *PostService {
+func NewPostService(log *zap.Logger, storage *Storage, moderator Moderator, limiter RateLimiter) *PostService {
 	return &PostService{
-		log:     log,
-		storage: storage,
+		log:       log,
+		storage:   storage,
+		moderator: moderator,
+		limiter:   limiter,
 	}
 }
 
 func (p *PostService) CreatePost(ctx context.Context, input models.NewPost) (*models.Post, error) {
+	if err := authorizeAuthor(ctx, input.AuthorID); err != nil {
+		return nil, err
+	}
 	author, err := p.storage.GetUserByID(ctx, input.AuthorID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
+		if errors.Is(err, storageerr.ErrNotFound) {
 			return nil, errdefs.UserDoesNotExistError(input.AuthorID)
 		}
 		return nil, errdefs.InternalServerError()
 	}
+	if err = checkNotBanned(ctx, p.storage, author.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	if err = checkRateLimit(ctx, p.limiter, ratelimit.ActionCreatePost); err != nil {
+		return nil, err
+	}
 
 	if len(input.Payload) > consts.MaxPayloadSize {
 		return nil, errdefs.CommentTooLongError(consts.MaxPayloadSize, len(input.Payload))
 	}
 
-	post, err := p.storage.CreatePost(ctx, input)
+	input.Tags = utils.NormalizeTags(input.Tags)
+	if len(input.Tags) > consts.MaxTagsCount {
+		return nil, errdefs.TooManyTagsError(consts.MaxTagsCount, len(input.Tags))
+	}
+	for _, tag := range input.Tags {
+		if len(tag) > consts.MaxTagLength {
+			return nil, errdefs.TagTooLongError(tag, consts.MaxTagLength)
+		}
+	}
+
+	status, err := resolvePostStatus(input.Status, input.PublishAt, time.Now())
 	if err != nil {
-		return nil, errdefs.InternalServerError()
+		return nil, err
+	}
+	input.Status = &status
+
+	decision, err := moderate(p.moderator, moderation.Content{
+		Type:     moderation.ContentTypePost,
+		AuthorID: input.AuthorID,
+		Title:    input.Title,
+		Payload:  input.Payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := p.storage.CreatePost(ctx, input, decision != nil)
+	if err != nil {
+		return nil, storageError(err, nil, map[string]error{"authorid": errdefs.UserDoesNotExistError(input.AuthorID)})
 	}
 	post.Author = author
+
+	if decision != nil {
+		if err = p.holdPost(ctx, decision, post); err != nil {
+			return nil, err
+		}
+	}
 	return post, nil
 }
 
 func (p *PostService) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
 	post, err := p.storage.GetPostByID(ctx, id)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
+		if errors.Is(err, storageerr.ErrNotFound) {
 			return nil, errdefs.PostDoesNotExistError(id)
 		}
 		return nil, errdefs.InternalServerError()
 	}
+
+	viewerID, _ := auth.UserIDFromContext(ctx)
+	if !post.IsVisibleTo(viewerID, time.Now()) {
+		return nil, errdefs.PostDoesNotExistError(id)
+	}
 	return post, nil
 }
 
 func (p *PostService) GetAllPosts(ctx context.Context, limit *int, offset *int) ([]*models.Post, error) {
 	limitValue, offsetValue := utils.ParseLimitOffset(limit, offset)
 
-	posts, err := p.storage.GetAllPosts(ctx, limitValue, offsetValue)
+	viewerID, _ := auth.UserIDFromContext(ctx)
+
+	posts, err := p.storage.GetAllPosts(ctx, limitValue, offsetValue, viewerID)
 	if err != nil {
 		return nil, errdefs.InternalServerError()
 	}
 
 	return posts, nil
 }
+
+func (p *PostService) GetPostsByTag(ctx context.Context, tag string, limit *int, offset *int) ([]*models.Post, error) {
+	limitValue, offsetValue := utils.ParseLimitOffset(limit, offset)
+
+	tag = utils.NormalizeTag(tag)
+	if tag == "" {
+		return []*models.Post{}, nil
+	}
+
+	viewerID, _ := auth.UserIDFromContext(ctx)
+
+	posts, err := p.storage.GetPostsByTag(ctx, tag, limitValue, offsetValue, viewerID)
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+
+	return posts, nil
+}
+
+func (p *PostService) GetTags(ctx context.Context, prefix string, limit *int) ([]string, error) {
+	limitValue, _ := utils.ParseLimitOffset(limit, nil)
+
+	tags, err := p.storage.GetTags(ctx, utils.NormalizeTag(prefix), limitValue)
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+
+	return tags, nil
+}
+
+func (p *PostService) PublishPost(ctx context.Context, postID int, publishAt *time.Time) (*models.Post, error) {
+	viewerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, errdefs.UnauthenticatedError()
+	}
+
+	post, err := p.storage.GetPostByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, errdefs.PostDoesNotExistError(postID)
+		}
+		return nil, errdefs.InternalServerError()
+	}
+
+	now := time.Now()
+	if !post.IsVisibleTo(viewerID, now) {
+		return nil, errdefs.PostDoesNotExistError(postID)
+	}
+	if post.Author == nil || post.Author.ID != viewerID {
+		return nil, errdefs.ForbiddenError(viewerID)
+	}
+	if post.Status == models.PostStatusPublished {
+		return nil, errdefs.PostAlreadyPublishedError(postID)
+	}
+
+	status, err := resolvePostStatus(nil, publishAt, now)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := p.storage.UpdatePostStatus(ctx, postID, status, publishAt)
+	if err != nil {
+		return nil, storageError(err, errdefs.PostDoesNotExistError(postID), nil)
+	}
+	return updated, nil
+}
+
+func (p *PostService) EditPost(ctx context.Context, postID int, input models.EditPost) (*models.Post, error) {
+	viewerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, errdefs.UnauthenticatedError()
+	}
+
+	post, err := p.storage.GetPostByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, storageerr.ErrNotFound) {
+			return nil, errdefs.PostDoesNotExistError(postID)
+		}
+		return nil, errdefs.InternalServerError()
+	}
+	if !post.IsVisibleTo(viewerID, time.Now()) {
+		return nil, errdefs.PostDoesNotExistError(postID)
+	}
+
+	editorID, err := authorizeOwnerOrModerator(ctx, p.storage, post.Author.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Payload != nil && len(*input.Payload) > consts.MaxPayloadSize {
+		return nil, errdefs.CommentTooLongError(consts.MaxPayloadSize, len(*input.Payload))
+	}
+	if (input.Title == nil || *input.Title == post.Title) && (input.Payload == nil || *input.Payload == post.Payload) {
+		return post, nil
+	}
+
+	content := moderation.Content{
+		Type:     moderation.ContentTypePost,
+		AuthorID: post.Author.ID,
+		Title:    post.Title,
+		Payload:  post.Payload,
+	}
+	if input.Title != nil {
+		content.Title = *input.Title
+	}
+	if input.Payload != nil {
+		content.Payload = *input.Payload
+	}
+	decision, err := moderate(p.moderator, content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Отложенная правка скрывает пост до решения модератора. Правка, скрытие и постановка в очередь
+	// сохраняются одной транзакцией, как в CommentService.EditComment
+	hold := heldItem(decision, &models.ModerationItem{
+		TargetType: models.TargetTypePost,
+		TargetID:   postID,
+		Title:      &content.Title,
+		Payload:    content.Payload,
+		Author:     post.Author,
+	})
+	post, err = p.storage.UpdatePost(ctx, postID, input, editorID, hold)
+	if err != nil {
+		return nil, storageError(err, errdefs.PostDoesNotExistError(postID), nil)
+	}
+	return post, nil
+}
+
+func (p *PostService) holdPost(ctx context.Context, decision *moderation.Decision, post *models.Post) error {
+	err := holdContent(ctx, p.storage, decision, &models.ModerationItem{
+		TargetType: models.TargetTypePost,
+		TargetID:   post.ID,
+		Title:      &post.Title,
+		Payload:    post.Payload,
+		Author:     post.Author,
+	})
+	if err != nil {
+		p.log.Error("Failed to enqueue post for moderation", zap.Int("PostID", post.ID), zap.Error(err))
+		return errdefs.InternalServerError()
+	}
+	return nil
+}
+
+// GetPostRevisions возвращает прошлые версии поста. Они доступны автору и модераторам
+func (p *PostService) GetPostRevisions(ctx context.Context, post *models.Post) ([]*models.Revision, error) {
+	authorID := 0
+	if post.Author != nil {
+		authorID = post.Author.ID
+	}
+	if _, err := authorizeOwnerOrModerator(ctx, p.storage, authorID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := p.storage.GetPostRevisions(ctx, post.ID)
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+	return revisions, nil
+}
+
+// resolvePostStatus определяет статус нового поста: без publishAt пост публикуется сразу,
+// с publishAt - становится отложенным. publishAt допустим только для отложенных постов и только в будущем
+func resolvePostStatus(status *models.PostStatus, publishAt *time.Time, now time.Time) (models.PostStatus, error) {
+	resolved := models.PostStatusPublished
+	if status != nil {
+		resolved = *status
+	} else if publishAt != nil {
+		resolved = models.PostStatusScheduled
+	}
+
+	if resolved == models.PostStatusScheduled {
+		if publishAt == nil || !publishAt.After(now) {
+			return "", errdefs.InvalidPublishAtError(publishAt)
+		}
+		return resolved, nil
+	}
+
+	if publishAt != nil {
+		return "", errdefs.InvalidPublishAtError(publishAt)
+	}
+	return resolved, nil
+}
@@ -3,21 +3,29 @@ package service
 import (
 	"context"
 	"errors"
+	"github.com/Quizert/PostCommentService/internal/auth"
 	"github.com/Quizert/PostCommentService/internal/consts"
 	"github.com/Quizert/PostCommentService/internal/errdefs"
 	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/moderation"
+	"github.com/Quizert/PostCommentService/internal/ratelimit"
 	"github.com/Quizert/PostCommentService/internal/service/mocks"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
 	"github.com/golang/mock/gomock"
-	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"strings"
 	"testing"
 	"time"
 )
 
 func TestPostService_CreatePost(t *testing.T) {
 	now := time.Now().UTC()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	scheduled := models.PostStatusScheduled
+	draft := models.PostStatusDraft
 	mockUser := &models.User{
 		ID:       1,
 		Username: "testuser",
@@ -26,6 +34,7 @@ func TestPostService_CreatePost(t *testing.T) {
 	tests := []struct {
 		name          string
 		input         models.NewPost
+		storageInput  *models.NewPost
 		mockUser      *models.User
 		mockUserErr   error
 		mockPost      *models.Post
@@ -65,7 +74,7 @@ func TestPostService_CreatePost(t *testing.T) {
 			input: models.NewPost{
 				AuthorID: 999,
 			},
-			mockUserErr:   pgx.ErrNoRows,
+			mockUserErr:   storageerr.ErrNotFound,
 			expectedError: errdefs.UserDoesNotExistError(999),
 			expectDBCalls: false,
 		},
@@ -80,6 +89,122 @@ func TestPostService_CreatePost(t *testing.T) {
 			expectedError: errdefs.CommentTooLongError(consts.MaxPayloadSize, consts.MaxPayloadSize+1),
 			expectDBCalls: false,
 		},
+		{
+			name: "tags are normalized and deduplicated",
+			input: models.NewPost{
+				Title:    "Tagged Post",
+				Payload:  "Valid content",
+				AuthorID: 1,
+				Tags:     []string{"Go", "#go", "  Web   Dev ", ""},
+			},
+			storageInput: &models.NewPost{
+				Title:    "Tagged Post",
+				Payload:  "Valid content",
+				AuthorID: 1,
+				Tags:     []string{"go", "web-dev"},
+			},
+			mockUser: mockUser,
+			mockPost: &models.Post{
+				ID:        2,
+				Title:     "Tagged Post",
+				Payload:   "Valid content",
+				Tags:      []string{"go", "web-dev"},
+				CreatedAt: now,
+			},
+			expectedPost: &models.Post{
+				ID:        2,
+				Title:     "Tagged Post",
+				Payload:   "Valid content",
+				Tags:      []string{"go", "web-dev"},
+				CreatedAt: now,
+				Author:    mockUser,
+			},
+			expectDBCalls: true,
+		},
+		{
+			name: "too many tags",
+			input: models.NewPost{
+				Title:    "Tagged Post",
+				Payload:  "Valid content",
+				AuthorID: 1,
+				Tags:     []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+			},
+			mockUser:      mockUser,
+			expectedError: errdefs.TooManyTagsError(consts.MaxTagsCount, consts.MaxTagsCount+1),
+			expectDBCalls: false,
+		},
+		{
+			name: "tag too long",
+			input: models.NewPost{
+				Title:    "Tagged Post",
+				Payload:  "Valid content",
+				AuthorID: 1,
+				Tags:     []string{strings.Repeat("x", consts.MaxTagLength+1)},
+			},
+			mockUser:      mockUser,
+			expectedError: errdefs.TagTooLongError(strings.Repeat("x", consts.MaxTagLength+1), consts.MaxTagLength),
+			expectDBCalls: false,
+		},
+		{
+			name: "scheduled post in the past",
+			input: models.NewPost{
+				Title:     "Late Post",
+				Payload:   "Valid content",
+				AuthorID:  1,
+				PublishAt: &past,
+			},
+			mockUser:      mockUser,
+			expectedError: errdefs.InvalidPublishAtError(&past),
+			expectDBCalls: false,
+		},
+		{
+			name: "scheduled post",
+			input: models.NewPost{
+				Title:     "Future Post",
+				Payload:   "Valid content",
+				AuthorID:  1,
+				PublishAt: &future,
+			},
+			storageInput: &models.NewPost{
+				Title:     "Future Post",
+				Payload:   "Valid content",
+				AuthorID:  1,
+				Status:    &scheduled,
+				PublishAt: &future,
+			},
+			mockUser: mockUser,
+			mockPost: &models.Post{
+				ID:        3,
+				Title:     "Future Post",
+				Payload:   "Valid content",
+				Status:    models.PostStatusScheduled,
+				PublishAt: &future,
+				CreatedAt: now,
+			},
+			expectedPost: &models.Post{
+				ID:        3,
+				Title:     "Future Post",
+				Payload:   "Valid content",
+				Status:    models.PostStatusScheduled,
+				PublishAt: &future,
+				CreatedAt: now,
+				Author:    mockUser,
+			},
+			expectDBCalls: true,
+		},
+		{
+			name: "draft with publishAt",
+			input: models.NewPost{
+				Title:     "Draft",
+				Payload:   "Valid content",
+				AuthorID:  1,
+				Status:    &draft,
+				PublishAt: &future,
+			},
+			mockUser:      mockUser,
+			expectedError: errdefs.InvalidPublishAtError(&future),
+			expectDBCalls: false,
+		},
 		{
 			name: "database error on create",
 			input: models.NewPost{
@@ -93,6 +218,18 @@ func TestPostService_CreatePost(t *testing.T) {
 			expectedError: errdefs.InternalServerError(),
 			expectDBCalls: true,
 		},
+		{
+			name: "author deleted before insert",
+			input: models.NewPost{
+				Title:    "Orphan Post",
+				Payload:  "Valid content",
+				AuthorID: 1,
+			},
+			mockUser:      mockUser,
+			mockPostErr:   &storageerr.ConstraintError{Kind: storageerr.ErrForeignKey, Field: "authorid"},
+			expectedError: errdefs.UserDoesNotExistError(1),
+			expectDBCalls: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -107,19 +244,33 @@ func TestPostService_CreatePost(t *testing.T) {
 				GetUserByID(gomock.Any(), tt.input.AuthorID).
 				Return(tt.mockUser, tt.mockUserErr).
 				Times(1)
+			if tt.mockUserErr == nil {
+				userProvider.EXPECT().
+					GetUserBan(gomock.Any(), tt.input.AuthorID).
+					Return(nil, storageerr.ErrNotFound).
+					Times(1)
+			}
 
+			storageInput := tt.input
+			if tt.storageInput != nil {
+				storageInput = *tt.storageInput
+			}
+			if storageInput.Status == nil {
+				status := models.PostStatusPublished
+				storageInput.Status = &status
+			}
 			if tt.expectDBCalls {
 				postProvider.EXPECT().
-					CreatePost(gomock.Any(), tt.input).
+					CreatePost(gomock.Any(), storageInput, false).
 					Return(tt.mockPost, tt.mockPostErr).
 					Times(1)
 			}
 
-			storage := NewStorage(postProvider, commentProvider, userProvider)
+			storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
 			logger := zap.NewNop()
-			postService := NewPostService(logger, storage)
+			postService := NewPostService(logger, storage, moderation.NewChain(), ratelimit.NewActionLimiter(nil))
 
-			ctx := context.Background()
+			ctx := auth.WithUserID(context.Background(), tt.input.AuthorID)
 			result, err := postService.CreatePost(ctx, tt.input)
 
 			if tt.expectedError != nil {
@@ -135,6 +286,8 @@ func TestPostService_CreatePost(t *testing.T) {
 				assert.Equal(t, tt.expectedPost.Title, result.Title)
 				assert.Equal(t, tt.expectedPost.Payload, result.Payload)
 				assert.Equal(t, tt.expectedPost.IsCommentsAllowed, result.IsCommentsAllowed)
+				assert.Equal(t, tt.expectedPost.Tags, result.Tags)
+				assert.Equal(t, tt.expectedPost.Status, result.Status)
 				assert.True(t, tt.expectedPost.CreatedAt.Equal(result.CreatedAt))
 				if tt.expectedPost.Author != nil {
 					assert.Equal(t, tt.expectedPost.Author.ID, result.Author.ID)
@@ -149,6 +302,7 @@ func TestPostService_GetPostByID(t *testing.T) {
 	tests := []struct {
 		name          string
 		postID        int
+		viewer        int
 		mockPost      *models.Post
 		mockPostErr   error
 		expectedPost  *models.Post
@@ -169,11 +323,34 @@ func TestPostService_GetPostByID(t *testing.T) {
 			},
 		},
 		{
-			name:          "post does not exist (pgx.ErrNoRows)",
+			name:          "post does not exist (storageerr.ErrNotFound)",
 			postID:        999,
-			mockPostErr:   pgx.ErrNoRows,
+			mockPostErr:   storageerr.ErrNotFound,
 			expectedError: errdefs.PostDoesNotExistError(999),
 		},
+		{
+			name:   "draft is hidden from other users",
+			postID: 2,
+			mockPost: &models.Post{
+				ID:     2,
+				Status: models.PostStatusDraft,
+				Author: &models.User{ID: 5},
+			},
+			expectedError: errdefs.PostDoesNotExistError(2),
+		},
+		{
+			name:   "draft is visible to author",
+			postID: 3,
+			viewer: 5,
+			mockPost: &models.Post{
+				ID:     3,
+				Status: models.PostStatusDraft,
+				Author: &models.User{ID: 5},
+			},
+			expectedPost: &models.Post{
+				ID: 3,
+			},
+		},
 		{
 			name:          "internal server error",
 			postID:        10,
@@ -195,11 +372,14 @@ func TestPostService_GetPostByID(t *testing.T) {
 				Return(tt.mockPost, tt.mockPostErr).
 				Times(1)
 
-			storage := NewStorage(postProvider, commentProvider, userProvider)
+			storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
 			logger := zap.NewNop()
-			postService := NewPostService(logger, storage)
+			postService := NewPostService(logger, storage, moderation.NewChain(), ratelimit.NewActionLimiter(nil))
 
 			ctx := context.Background()
+			if tt.viewer != 0 {
+				ctx = auth.WithUserID(ctx, tt.viewer)
+			}
 			result, err := postService.GetPostByID(ctx, tt.postID)
 
 			if tt.expectedError != nil {
@@ -265,13 +445,13 @@ func TestPostService_GetAllPosts(t *testing.T) {
 			commentProvider := mocks.NewMockCommentProvider(ctl)
 
 			postProvider.EXPECT().
-				GetAllPosts(gomock.Any(), tt.limitValue, tt.offsetValue).
+				GetAllPosts(gomock.Any(), tt.limitValue, tt.offsetValue, 0).
 				Return(tt.mockPosts, tt.mockPostsErr).
 				Times(1)
 
-			storage := NewStorage(postProvider, commentProvider, userProvider)
+			storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
 			logger := zap.NewNop()
-			postService := NewPostService(logger, storage)
+			postService := NewPostService(logger, storage, moderation.NewChain(), ratelimit.NewActionLimiter(nil))
 
 			ctx := context.Background()
 			result, err := postService.GetAllPosts(ctx, tt.limit, tt.offset)
@@ -287,3 +467,284 @@ func TestPostService_GetAllPosts(t *testing.T) {
 		})
 	}
 }
+
+func TestPostService_GetPostsByTag(t *testing.T) {
+	tests := []struct {
+		name          string
+		tag           string
+		storageTag    string
+		mockPosts     []*models.Post
+		mockPostsErr  error
+		expectDBCalls bool
+		expectedPosts []*models.Post
+		expectedError error
+	}{
+		{
+			name:          "tag is normalized before lookup",
+			tag:           "#GoLang",
+			storageTag:    "golang",
+			mockPosts:     []*models.Post{{ID: 1, Tags: []string{"golang"}}},
+			expectDBCalls: true,
+			expectedPosts: []*models.Post{{ID: 1, Tags: []string{"golang"}}},
+		},
+		{
+			name:          "empty tag",
+			tag:           " # ",
+			expectDBCalls: false,
+			expectedPosts: []*models.Post{},
+		},
+		{
+			name:          "internal error",
+			tag:           "go",
+			storageTag:    "go",
+			mockPostsErr:  errors.New("database failure"),
+			expectDBCalls: true,
+			expectedError: errdefs.InternalServerError(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postProvider := mocks.NewMockPostProvider(ctl)
+			userProvider := mocks.NewMockUserProvider(ctl)
+			commentProvider := mocks.NewMockCommentProvider(ctl)
+
+			if tt.expectDBCalls {
+				postProvider.EXPECT().
+					GetPostsByTag(gomock.Any(), tt.storageTag, consts.DefaultLimit, consts.DefaultOffset, 0).
+					Return(tt.mockPosts, tt.mockPostsErr).
+					Times(1)
+			}
+
+			storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+			logger := zap.NewNop()
+			postService := NewPostService(logger, storage, moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+			result, err := postService.GetPostsByTag(context.Background(), tt.tag, nil, nil)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedPosts, result)
+		})
+	}
+}
+
+func TestPostService_PublishPost(t *testing.T) {
+	author := &models.User{ID: 5, Username: "author"}
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		viewer        int
+		publishAt     *time.Time
+		mockPost      *models.Post
+		mockPostErr   error
+		updateStatus  models.PostStatus
+		expectUpdate  bool
+		expectedError error
+	}{
+		{
+			name:          "anonymous user",
+			expectedError: errdefs.UnauthenticatedError(),
+		},
+		{
+			name:          "post not found",
+			viewer:        5,
+			mockPostErr:   storageerr.ErrNotFound,
+			expectedError: errdefs.PostDoesNotExistError(1),
+		},
+		{
+			name:          "draft of another user",
+			viewer:        6,
+			mockPost:      &models.Post{ID: 1, Status: models.PostStatusDraft, Author: author},
+			expectedError: errdefs.PostDoesNotExistError(1),
+		},
+		{
+			name:          "published post of another user",
+			viewer:        6,
+			mockPost:      &models.Post{ID: 1, Status: models.PostStatusPublished, Author: author},
+			expectedError: errdefs.ForbiddenError(6),
+		},
+		{
+			name:          "already published",
+			viewer:        5,
+			mockPost:      &models.Post{ID: 1, Status: models.PostStatusPublished, Author: author},
+			expectedError: errdefs.PostAlreadyPublishedError(1),
+		},
+		{
+			name:          "publishAt in the past",
+			viewer:        5,
+			publishAt:     &past,
+			mockPost:      &models.Post{ID: 1, Status: models.PostStatusDraft, Author: author},
+			expectedError: errdefs.InvalidPublishAtError(&past),
+		},
+		{
+			name:         "publish draft now",
+			viewer:       5,
+			mockPost:     &models.Post{ID: 1, Status: models.PostStatusDraft, Author: author},
+			updateStatus: models.PostStatusPublished,
+			expectUpdate: true,
+		},
+		{
+			name:         "schedule draft",
+			viewer:       5,
+			publishAt:    &future,
+			mockPost:     &models.Post{ID: 1, Status: models.PostStatusDraft, Author: author},
+			updateStatus: models.PostStatusScheduled,
+			expectUpdate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postProvider := mocks.NewMockPostProvider(ctl)
+			userProvider := mocks.NewMockUserProvider(ctl)
+			commentProvider := mocks.NewMockCommentProvider(ctl)
+
+			if tt.viewer != 0 {
+				postProvider.EXPECT().
+					GetPostByID(gomock.Any(), 1).
+					Return(tt.mockPost, tt.mockPostErr).
+					Times(1)
+			}
+			if tt.expectUpdate {
+				postProvider.EXPECT().
+					UpdatePostStatus(gomock.Any(), 1, tt.updateStatus, tt.publishAt).
+					Return(&models.Post{ID: 1, Status: tt.updateStatus}, nil).
+					Times(1)
+			}
+
+			storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+			postService := NewPostService(zap.NewNop(), storage, moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+			ctx := context.Background()
+			if tt.viewer != 0 {
+				ctx = auth.WithUserID(ctx, tt.viewer)
+			}
+			result, err := postService.PublishPost(ctx, 1, tt.publishAt)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.updateStatus, result.Status)
+		})
+	}
+}
+
+func TestPostService_CreatePost_Moderation(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+	userProvider.EXPECT().GetUserByID(gomock.Any(), 1).Return(&models.User{ID: 1}, nil)
+	userProvider.EXPECT().GetUserBan(gomock.Any(), 1).Return(nil, storageerr.ErrNotFound)
+
+	storage := NewStorage(postProvider, nil, userProvider, nil, nil)
+	postService := NewPostService(zap.NewNop(), storage, moderation.NewChain(moderation.NewLinkLimitFilter(1)), ratelimit.NewActionLimiter(nil))
+
+	_, err := postService.CreatePost(auth.WithUserID(context.Background(), 1), models.NewPost{
+		Title:    "Links",
+		Payload:  "https://a.com https://b.com",
+		AuthorID: 1,
+	})
+	assert.Equal(t, errdefs.ContentRejectedError("link_limit", "content contains 2 links, at most 1 allowed"), err)
+}
+
+func TestPostService_CreatePost_Banned(t *testing.T) {
+	until := time.Now().Add(time.Hour)
+	expired := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		ban           *models.UserBan
+		expectedError error
+	}{
+		{
+			name:          "temporary ban",
+			ban:           &models.UserBan{Until: &until, Reason: "spam"},
+			expectedError: errdefs.UserBannedError(1, &until, "spam"),
+		},
+		{
+			name:          "permanent ban",
+			ban:           &models.UserBan{Reason: "abuse"},
+			expectedError: errdefs.UserBannedError(1, (*time.Time)(nil), "abuse"),
+		},
+		{
+			name: "expired ban",
+			ban:  &models.UserBan{Until: &expired, Reason: "spam"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postProvider := mocks.NewMockPostProvider(ctl)
+			userProvider := mocks.NewMockUserProvider(ctl)
+			userProvider.EXPECT().GetUserByID(gomock.Any(), 1).Return(&models.User{ID: 1}, nil)
+			userProvider.EXPECT().GetUserBan(gomock.Any(), 1).Return(tt.ban, nil)
+			if tt.expectedError == nil {
+				postProvider.EXPECT().CreatePost(gomock.Any(), gomock.Any(), false).Return(&models.Post{ID: 1}, nil)
+			}
+
+			storage := NewStorage(postProvider, nil, userProvider, nil, nil)
+			postService := NewPostService(zap.NewNop(), storage, moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+			_, err := postService.CreatePost(auth.WithUserID(context.Background(), 1), models.NewPost{Title: "Title", Payload: "text", AuthorID: 1})
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
+func TestPostService_CreatePost_Author(t *testing.T) {
+	tests := []struct {
+		name          string
+		viewer        int
+		expectedError error
+	}{
+		{
+			name:          "anonymous",
+			expectedError: errdefs.UnauthenticatedError(),
+		},
+		{
+			name:          "author mismatch",
+			viewer:        2,
+			expectedError: errdefs.ForbiddenError(2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			storage := NewStorage(mocks.NewMockPostProvider(ctl), nil, mocks.NewMockUserProvider(ctl), nil, nil)
+			postService := NewPostService(zap.NewNop(), storage, moderation.NewChain(), ratelimit.NewActionLimiter(nil))
+
+			ctx := context.Background()
+			if tt.viewer != 0 {
+				ctx = auth.WithUserID(ctx, tt.viewer)
+			}
+			_, err := postService.CreatePost(ctx, models.NewPost{Title: "Title", Payload: "text", AuthorID: 1})
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/ratelimit"
+	"time"
+)
+
+// RateLimiter ограничивает частоту действий пользователя
+type RateLimiter interface {
+	Allow(userID int, action string) (bool, time.Duration)
+}
+
+// checkRateLimit списывает действие текущего пользователя из его лимита и возвращает RATE_LIMITED, если лимит исчерпан.
+// Лимит ведётся по пользователю из контекста, а не по id из входных данных
+func checkRateLimit(ctx context.Context, limiter RateLimiter, action string) error {
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return errdefs.UnauthenticatedError()
+	}
+	allowed, retryAfter := limiter.Allow(userID, action)
+	if !allowed {
+		return errdefs.RateLimitedError(action, ratelimit.RetryAfterSeconds(retryAfter))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+// PublishScheduler периодически публикует отложенные посты, у которых наступило время публикации
+type PublishScheduler struct {
+	log      *zap.Logger
+	storage  *Storage
+	interval time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewPublishScheduler(log *zap.Logger, storage *Storage, interval time.Duration) *PublishScheduler {
+	return &PublishScheduler{
+		log:      log,
+		storage:  storage,
+		interval: interval,
+	}
+}
+
+func (s *PublishScheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.publishDuePosts(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop останавливает планировщик и дожидается завершения текущей итерации
+func (s *PublishScheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+func (s *PublishScheduler) publishDuePosts(ctx context.Context) {
+	log := s.log.With(
+		zap.String("Layer", "PublishScheduler.publishDuePosts"),
+	)
+
+	published, err := s.storage.PublishScheduledPosts(ctx, time.Now())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error("Failed to publish scheduled posts", zap.Error(err))
+		}
+		return
+	}
+	if published > 0 {
+		log.With(zap.Int("Posts", published)).Info("Published scheduled posts")
+	}
+}
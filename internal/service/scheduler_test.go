@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/service/mocks"
+	"github.com/golang/mock/gomock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestPublishScheduler(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	postProvider := mocks.NewMockPostProvider(ctl)
+	commentProvider := mocks.NewMockCommentProvider(ctl)
+	userProvider := mocks.NewMockUserProvider(ctl)
+
+	called := make(chan struct{}, 10)
+	postProvider.EXPECT().
+		PublishScheduledPosts(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ time.Time) (int, error) {
+			called <- struct{}{}
+			return 1, nil
+		}).
+		MinTimes(2)
+
+	storage := NewStorage(postProvider, commentProvider, userProvider, nil, nil)
+	scheduler := NewPublishScheduler(zap.NewNop(), storage, 10*time.Millisecond)
+
+	scheduler.Start(context.Background())
+	for i := 0; i < 2; i++ {
+		select {
+		case <-called:
+		case <-time.After(time.Second):
+			t.Fatal("timeout: scheduler did not publish posts")
+		}
+	}
+	scheduler.Stop()
+
+	// после Stop планировщик больше не обращается к хранилищу
+	for len(called) > 0 {
+		<-called
+	}
+	time.Sleep(30 * time.Millisecond)
+	if len(called) != 0 {
+		t.Fatal("scheduler kept running after Stop")
+	}
+}
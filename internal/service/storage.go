@@ -3,35 +3,129 @@ package service
 import (
 	"context"
 	"github.com/Quizert/PostCommentService/internal/models"
+	"time"
 )
 
 type Storage struct {
 	PostProvider
 	CommentProvider
 	UserProvider
+	NotificationProvider
+	ModerationProvider
 }
 
-func NewStorage(postProvider PostProvider, commentProvider CommentProvider, userProvider UserProvider) *Storage {
+func NewStorage(postProvider PostProvider, commentProvider CommentProvider, userProvider UserProvider, notificationProvider NotificationProvider, moderationProvider ModerationProvider) *Storage {
 	return &Storage{
 		postProvider,
 		commentProvider,
 		userProvider,
+		notificationProvider,
+		moderationProvider,
 	}
 }
 
 //go:generate mockgen -source=storage.go -destination=mocks/providers-mock.go -package=mocks PostProvider
 type PostProvider interface {
-	CreatePost(ctx context.Context, input models.NewPost) (*models.Post, error)
-	GetAllPosts(ctx context.Context, limit int, offset int) ([]*models.Post, error)
+	// CreatePost сохраняет пост. Скрытый пост (hidden) видит только автор, пока его не одобрит модератор
+	CreatePost(ctx context.Context, input models.NewPost, hidden bool) (*models.Post, error)
+	GetAllPosts(ctx context.Context, limit int, offset int, viewerID int) ([]*models.Post, error)
 	GetPostByID(ctx context.Context, id int) (*models.Post, error)
+	GetPostsByTag(ctx context.Context, tag string, limit int, offset int, viewerID int) ([]*models.Post, error)
+	GetTags(ctx context.Context, prefix string, limit int) ([]string, error)
+	UpdatePostStatus(ctx context.Context, postID int, status models.PostStatus, publishAt *time.Time) (*models.Post, error)
+	PublishScheduledPosts(ctx context.Context, now time.Time) (int, error)
+	// UpdatePost сохраняет правку. Непустой hold в той же транзакции скрывает пост и ставит его в очередь модерации
+	UpdatePost(ctx context.Context, postID int, input models.EditPost, editorID int, hold *models.ModerationItem) (*models.Post, error)
+	GetPostRevisions(ctx context.Context, postID int) ([]*models.Revision, error)
 }
 
 type CommentProvider interface {
-	CreateComment(ctx context.Context, input models.NewComment) (*models.Comment, error)
-	GetCommentsByPostID(ctx context.Context, limit int, offset int, postID int) ([]*models.Comment, error)
-	Replies(ctx context.Context, commentID int, limit int, offset int) ([]*models.Comment, error)
+	// CreateComment сохраняет комментарий вместе с упоминаниями mentionIDs. Скрытый комментарий (hidden) не попадает
+	// в списки комментариев и ответов. Для видимого комментария в той же транзакции в outbox пишется событие comment.published
+	CreateComment(ctx context.Context, input models.NewComment, hidden bool, mentionIDs []int) (*models.Comment, error)
+	// GetCommentsByPostID и Replies не возвращают комментарии авторов, заблокированных зрителем viewerID
+	GetCommentsByPostID(ctx context.Context, limit int, offset int, postID int, viewerID int) ([]*models.Comment, error)
+	Replies(ctx context.Context, commentID int, limit int, offset int, viewerID int) ([]*models.Comment, error)
+	GetCommentByID(ctx context.Context, commentID int) (*models.Comment, error)
+	SetCommentPinned(ctx context.Context, commentID int, pinned bool) (*models.Comment, error)
+	LockThread(ctx context.Context, commentID int) (*models.Comment, error)
+	IsThreadLocked(ctx context.Context, commentID int) (bool, error)
+	// UpdateComment работает как UpdatePost
+	UpdateComment(ctx context.Context, commentID int, payload string, editorID int, hold *models.ModerationItem) (*models.Comment, error)
+	GetCommentRevisions(ctx context.Context, commentID int) ([]*models.Revision, error)
+	SaveMentions(ctx context.Context, commentID int, userIDs []int) error
+	GetCommentMentions(ctx context.Context, commentID int) ([]*models.User, error)
 }
 
 type UserProvider interface {
 	GetUserByID(ctx context.Context, userID int) (*models.User, error)
+	GetUsersByUsernames(ctx context.Context, usernames []string) ([]*models.User, error)
+	// SetUserRole меняет роль пользователя, несуществующий пользователь - storage.ErrNotFound
+	SetUserRole(ctx context.Context, userID int, role models.UserRole) error
+	// BanUser сохраняет бан пользователя, заменяя предыдущий
+	BanUser(ctx context.Context, ban *models.UserBan) (*models.UserBan, error)
+	GetUserBan(ctx context.Context, userID int) (*models.UserBan, error)
+	// MuteUserOnPost запрещает пользователю комментировать пост, заменяя предыдущий запрет
+	MuteUserOnPost(ctx context.Context, mute *models.PostMute) (*models.PostMute, error)
+	GetPostMute(ctx context.Context, postID int, userID int) (*models.PostMute, error)
+	// BlockUser и UnblockUser возвращают false, если блокировка уже была в нужном состоянии
+	BlockUser(ctx context.Context, blockerID int, blockedID int) (bool, error)
+	UnblockUser(ctx context.Context, blockerID int, blockedID int) (bool, error)
+	IsBlocked(ctx context.Context, blockerID int, blockedID int) (bool, error)
+}
+
+type NotificationProvider interface {
+	CreateNotifications(ctx context.Context, notifications []*models.Notification) ([]*models.Notification, error)
+	// GetNotifications возвращает уведомления пользователя от новых к старым, начиная с id меньше after (0 - с начала)
+	GetNotifications(ctx context.Context, userID int, unreadOnly bool, limit int, after int) ([]*models.Notification, error)
+	// MarkNotificationsRead помечает прочитанными уведомления пользователя, при пустом ids - все
+	MarkNotificationsRead(ctx context.Context, userID int, ids []int) (int, error)
+}
+
+type ModerationProvider interface {
+	EnqueueModeration(ctx context.Context, item *models.ModerationItem) (*models.ModerationItem, error)
+	// GetModerationQueue возвращает элементы очереди со статусом status от старых к новым, начиная с id больше after
+	GetModerationQueue(ctx context.Context, status models.ModerationStatus, limit int, after int) ([]*models.ModerationItem, error)
+	GetModerationItem(ctx context.Context, itemID int) (*models.ModerationItem, error)
+	// ResolveModerationItem фиксирует решение модератора. При APPROVED контент снова становится видимым,
+	// для комментария в той же транзакции в outbox пишется событие comment.published
+	ResolveModerationItem(ctx context.Context, itemID int, status models.ModerationStatus, moderatorID int, reason *string) (*models.ModerationItem, error)
+	// CreateReport сохраняет жалобу и возвращает число жалоб на контент. Повторная жалоба того же пользователя
+	// не сохраняется, в этом случае возвращается nil вместо жалобы
+	CreateReport(ctx context.Context, report *models.Report) (*models.Report, int, error)
+	// GetReportSummaries возвращает жалобы, сгруппированные по контенту: сначала контент с наибольшим числом жалоб
+	GetReportSummaries(ctx context.Context, limit int, offset int) ([]*models.ReportSummary, error)
+}
+
+type OutboxProvider interface {
+	// ClaimOutboxEvents возвращает до limit недоставленных событий, время попытки которых наступило, от старых к новым.
+	// Следующая попытка откладывается на lease, чтобы событие одновременно не взял другой экземпляр сервиса
+	ClaimOutboxEvents(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.OutboxEvent, error)
+	MarkOutboxEventDelivered(ctx context.Context, id int) error
+	// RetryOutboxEvent сохраняет ошибку доставки и назначает следующую попытку
+	RetryOutboxEvent(ctx context.Context, id int, nextAttemptAt time.Time, lastError string) error
+	// DeleteDeliveredOutboxEvents удаляет события, доставленные раньше before
+	DeleteDeliveredOutboxEvents(ctx context.Context, before time.Time) (int, error)
+}
+
+type WebhookProvider interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	// GetWebhooks возвращает все вебхуки в порядке создания
+	GetWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	GetWebhookByID(ctx context.Context, id int) (*models.Webhook, error)
+	// UpdateWebhook меняет только переданные поля
+	UpdateWebhook(ctx context.Context, id int, input models.EditWebhook) (*models.Webhook, error)
+	// DeleteWebhook удаляет вебхук вместе с журналом его доставок
+	DeleteWebhook(ctx context.Context, id int) error
+	// CreateWebhookDeliveries ставит событие в очередь всем активным вебхукам, подписанным на eventType, и возвращает
+	// число новых доставок. Повторный вызов для того же события доставки не дублирует
+	CreateWebhookDeliveries(ctx context.Context, eventID int, eventType models.WebhookEventType, payload string) (int, error)
+	// ClaimWebhookDeliveries возвращает до limit ожидающих доставок активных вебхуков, время попытки которых наступило,
+	// от старых к новым. Счётчик попыток увеличивается, следующая попытка откладывается на lease
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	// UpdateWebhookDelivery сохраняет статус, попытки, итог последней попытки и время следующей
+	UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error)
+	// GetWebhookDeliveries возвращает журнал доставок вебхука, сначала новые. Без status - доставки в любом статусе
+	GetWebhookDeliveries(ctx context.Context, webhookID int, status *models.WebhookDeliveryStatus, limit int, offset int) ([]*models.WebhookDelivery, error)
 }
@@ -96,3 +96,62 @@ func TestSubscriptionService_Notify(t *testing.T) {
 		t.Fatal("timeout: channels did not receive the message in time")
 	}
 }
+
+func TestSubscriptionService_NotifyMentions(t *testing.T) {
+	s := service.NewSubscriptionService()
+	ctx := context.Background()
+
+	ch, err := s.CreateMentionsSubscription(ctx, 2)
+	require.NoError(t, err)
+
+	comment := &models.Comment{ID: 10, PostID: 1, Payload: "hi @Alice", Mentions: []*models.User{{ID: 2, Username: "Alice"}}}
+	go func() {
+		_ = s.Notify(ctx, comment)
+	}()
+
+	select {
+	case c := <-ch:
+		assert.Equal(t, comment, c)
+	case <-time.After(time.Second):
+		t.Fatal("timeout: mentioned user did not receive the comment")
+	}
+
+	err = s.DeleteMentionsSubscription(ctx, 2, ch)
+	require.NoError(t, err)
+	_, ok := <-ch
+	assert.False(t, ok, "channel should be closed after DeleteMentionsSubscription")
+}
+
+func TestSubscriptionService_SlowSubscriber(t *testing.T) {
+	s := service.NewSubscriptionService()
+	ctx := context.Background()
+
+	slow, err := s.CreateSubscription(ctx, 1)
+	require.NoError(t, err)
+	notifications, err := s.CreateNotificationsSubscription(ctx, 2)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 100; i++ {
+			_ = s.Notify(ctx, &models.Comment{ID: i, PostID: 1})
+			s.PublishNotifications(ctx, []*models.Notification{{ID: i, RecipientID: 2}})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timeout: slow subscriber blocked publishing")
+	}
+
+	c := <-slow
+	assert.Equal(t, 1, c.ID, "события сверх буфера отбрасываются, первые доставляются")
+	n := <-notifications
+	assert.Equal(t, 1, n.ID)
+
+	// Рассылка после удаления подписки не пишет в закрытый канал
+	require.NoError(t, s.DeleteSubscription(ctx, 1, slow))
+	require.NoError(t, s.Notify(ctx, &models.Comment{ID: 101, PostID: 1}))
+}
@@ -6,62 +6,160 @@ import (
 	"sync"
 )
 
+// subscriptionBufferSize - сколько событий копится в канале подписчика. Если клиент не успевает читать,
+// новые события для него отбрасываются, чтобы медленный подписчик не задерживал рассылку
+const subscriptionBufferSize = 16
+
 type SubscriptionService struct {
-	commentChannels map[int][]chan *models.Comment
-	mu              sync.Mutex
+	commentChannels map[int][]*subscriber[*models.Comment]
+	mentionChannels map[int][]*subscriber[*models.Comment]
+	// notificationChannels - живые уведомления по id получателя
+	notificationChannels map[int][]*subscriber[*models.Notification]
+	mu                   sync.Mutex
 }
 
 func NewSubscriptionService() *SubscriptionService {
-	return &SubscriptionService{commentChannels: map[int][]chan *models.Comment{}}
-}
+	return &SubscriptionService{
+		commentChannels: map[int][]*subscriber[*models.Comment]{},
+		mentionChannels: map[int][]*subscriber[*models.Comment]{},
 
+		notificationChannels: map[int][]*subscriber[*models.Notification]{},
+	}
+}
 func (s *SubscriptionService) CreateSubscription(ctx context.Context, postID int) (chan *models.Comment, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	ch := make(chan *models.Comment)
-	s.commentChannels[postID] = append(s.commentChannels[postID], ch)
-
-	return ch, nil
+	return subscribe(s.commentChannels, postID), nil
 }
 
 func (s *SubscriptionService) DeleteSubscription(ctx context.Context, postID int, ch chan *models.Comment) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	chans, ok := s.commentChannels[postID]
-	if !ok {
-		return nil
+	unsubscribe(s.commentChannels, postID, ch)
+	return nil
+}
+
+func (s *SubscriptionService) CreateMentionsSubscription(ctx context.Context, userID int) (chan *models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return subscribe(s.mentionChannels, userID), nil
+}
+
+func (s *SubscriptionService) DeleteMentionsSubscription(ctx context.Context, userID int, ch chan *models.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unsubscribe(s.mentionChannels, userID, ch)
+	return nil
+}
+
+func (s *SubscriptionService) CreateNotificationsSubscription(ctx context.Context, userID int) (chan *models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return subscribe(s.notificationChannels, userID), nil
+}
+
+func (s *SubscriptionService) DeleteNotificationsSubscription(ctx context.Context, userID int, ch chan *models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unsubscribe(s.notificationChannels, userID, ch)
+	return nil
+}
+
+// PublishNotifications доставляет уведомления получателям с открытой подпиской
+func (s *SubscriptionService) PublishNotifications(ctx context.Context, notifications []*models.Notification) {
+	s.mu.Lock()
+	recipients := make([][]*subscriber[*models.Notification], len(notifications))
+	for i, notification := range notifications {
+		recipients[i] = subscribers(s.notificationChannels, notification.RecipientID)
 	}
-	for i, c := range chans {
-		if c == ch {
-			close(ch)
-			n := len(chans) - 1
-			s.commentChannels[postID][i] = chans[n]
-			s.commentChannels[postID] = s.commentChannels[postID][:n]
-
-			if len(s.commentChannels[postID]) == 0 {
-				delete(s.commentChannels, postID)
-			}
+	s.mu.Unlock()
 
-			return nil
+	for i, notification := range notifications {
+		for _, sub := range recipients[i] {
+			sub.send(notification)
 		}
 	}
-	return nil
 }
 
+// Notify рассылает комментарий подписчикам поста и упомянутым в нём пользователям
 func (s *SubscriptionService) Notify(ctx context.Context, comment *models.Comment) error {
+	s.mu.Lock()
+	recipients := subscribers(s.commentChannels, comment.PostID)
+	for _, user := range comment.Mentions {
+		recipients = append(recipients, s.mentionChannels[user.ID]...)
+	}
+	s.mu.Unlock()
+
+	for _, sub := range recipients {
+		sub.send(comment)
+	}
+	return nil
+}
+
+// subscriber - канал подписки. Отправка и закрытие идут под его собственной блокировкой,
+// поэтому рассылка не держит блокировку сервиса и не пишет в закрытый канал
+type subscriber[T any] struct {
+	mu     sync.Mutex
+	ch     chan T
+	closed bool
+}
+
+// send не блокируется: если буфер подписчика заполнен, событие отбрасывается
+func (s *subscriber[T]) send(value T) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	chans, ok := s.commentChannels[comment.PostID]
-	if !ok {
-		return nil
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- value:
+	default:
 	}
+}
 
-	for _, ch := range chans {
-		ch <- comment
+func (s *subscriber[T]) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	close(s.ch)
+}
+
+// subscribers копирует список подписчиков, чтобы отправлять им после снятия блокировки
+func subscribers[T any](channels map[int][]*subscriber[T], key int) []*subscriber[T] {
+	return append([]*subscriber[T](nil), channels[key]...)
+}
+
+func subscribe[T any](channels map[int][]*subscriber[T], key int) chan T {
+	sub := &subscriber[T]{ch: make(chan T, subscriptionBufferSize)}
+	channels[key] = append(channels[key], sub)
+	return sub.ch
+}
+
+func unsubscribe[T any](channels map[int][]*subscriber[T], key int, ch chan T) {
+	subs, ok := channels[key]
+	if !ok {
+		return
 	}
+	for i, sub := range subs {
+		if sub.ch == ch {
+			sub.close()
+			n := len(subs) - 1
+			channels[key][i] = subs[n]
+			channels[key] = channels[key][:n]
 
-	return nil
+			if len(channels[key]) == 0 {
+				delete(channels, key)
+			}
+
+			return
+		}
+	}
 }
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/Quizert/PostCommentService/internal/utils"
+	"github.com/Quizert/PostCommentService/internal/webhook"
+	"go.uber.org/zap"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// webhookBatchSize - сколько доставок диспетчер берёт за один запрос
+	webhookBatchSize = 10
+	// Задержка перед повтором доставки удваивается с каждой попыткой от webhookMinBackoff до webhookMaxBackoff
+	webhookMinBackoff = 10 * time.Second
+	webhookMaxBackoff = time.Hour
+)
+
+// webhookEventTypes - события outbox, которые рассылаются вебхукам
+var webhookEventTypes = map[models.OutboxEventType]models.WebhookEventType{
+	models.OutboxEventPostPublished:    models.WebhookEventTypePostPublished,
+	models.OutboxEventCommentPublished: models.WebhookEventTypeCommentPublished,
+}
+
+type WebhookService struct {
+	log      *zap.Logger
+	storage  *Storage
+	webhooks WebhookProvider
+	now      func() time.Time
+}
+
+func NewWebhookService(log *zap.Logger, storage *Storage, webhooks WebhookProvider) *WebhookService {
+	return &WebhookService{
+		log:      log,
+		storage:  storage,
+		webhooks: webhooks,
+		now:      time.Now,
+	}
+}
+
+func (w *WebhookService) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	if _, err := requireModerator(ctx, w.storage); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := w.webhooks.GetWebhooks(ctx)
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+	return webhooks, nil
+}
+
+func (w *WebhookService) CreateWebhook(ctx context.Context, input models.NewWebhook) (*models.Webhook, error) {
+	if _, err := requireModerator(ctx, w.storage); err != nil {
+		return nil, err
+	}
+	// uniqueEventTypes не возвращает nil, поэтому пропущенный список тоже не пройдёт проверку
+	eventTypes := uniqueEventTypes(input.EventTypes)
+	if err := validateWebhook(&input.URL, &input.Secret, eventTypes); err != nil {
+		return nil, err
+	}
+
+	created, err := w.webhooks.CreateWebhook(ctx, &models.Webhook{
+		URL:        input.URL,
+		Secret:     input.Secret,
+		EventTypes: eventTypes,
+		IsActive:   true,
+	})
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+	w.log.Info("Webhook created",
+		zap.String("Layer", "WebhookService.CreateWebhook"),
+		zap.Int("WebhookID", created.ID),
+		zap.String("URL", created.URL),
+	)
+	return created, nil
+}
+
+func (w *WebhookService) UpdateWebhook(ctx context.Context, webhookID int, input models.EditWebhook) (*models.Webhook, error) {
+	if _, err := requireModerator(ctx, w.storage); err != nil {
+		return nil, err
+	}
+	if input.EventTypes != nil {
+		input.EventTypes = uniqueEventTypes(input.EventTypes)
+	}
+	if err := validateWebhook(input.URL, input.Secret, input.EventTypes); err != nil {
+		return nil, err
+	}
+
+	updated, err := w.webhooks.UpdateWebhook(ctx, webhookID, input)
+	if err != nil {
+		return nil, storageError(err, errdefs.WebhookDoesNotExistError(webhookID), nil)
+	}
+	return updated, nil
+}
+
+func (w *WebhookService) DeleteWebhook(ctx context.Context, webhookID int) (bool, error) {
+	if _, err := requireModerator(ctx, w.storage); err != nil {
+		return false, err
+	}
+
+	if err := w.webhooks.DeleteWebhook(ctx, webhookID); err != nil {
+		return false, storageError(err, errdefs.WebhookDoesNotExistError(webhookID), nil)
+	}
+	return true, nil
+}
+
+func (w *WebhookService) GetWebhookDeliveries(ctx context.Context, webhookID int, status *models.WebhookDeliveryStatus, limit *int, offset *int) ([]*models.WebhookDelivery, error) {
+	if _, err := requireModerator(ctx, w.storage); err != nil {
+		return nil, err
+	}
+
+	if _, err := w.webhooks.GetWebhookByID(ctx, webhookID); err != nil {
+		return nil, storageError(err, errdefs.WebhookDoesNotExistError(webhookID), nil)
+	}
+	limitValue, offsetValue := utils.ParseLimitOffset(limit, offset)
+	deliveries, err := w.webhooks.GetWebhookDeliveries(ctx, webhookID, status, limitValue, offsetValue)
+	if err != nil {
+		return nil, errdefs.InternalServerError()
+	}
+	return deliveries, nil
+}
+
+// ReplayWebhookDelivery возвращает доставку в очередь с обнулённым счётчиком попыток. Тело не меняется,
+// поэтому получатель увидит тот же id события и сможет отбросить дубликат
+func (w *WebhookService) ReplayWebhookDelivery(ctx context.Context, deliveryID int) (*models.WebhookDelivery, error) {
+	moderatorID, err := requireModerator(ctx, w.storage)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := w.webhooks.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, storageError(err, errdefs.WebhookDeliveryDoesNotExistError(deliveryID), nil)
+	}
+	delivery.Status = models.WebhookDeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = w.now()
+	delivery.DeliveredAt = nil
+	if err = w.webhooks.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		return nil, storageError(err, errdefs.WebhookDeliveryDoesNotExistError(deliveryID), nil)
+	}
+
+	w.log.Info("Webhook delivery replayed",
+		zap.String("Layer", "WebhookService.ReplayWebhookDelivery"),
+		zap.Int("DeliveryID", deliveryID),
+		zap.Int("ModeratorID", moderatorID),
+	)
+	return delivery, nil
+}
+
+// validateWebhook проверяет переданные поля вебхука, nil - поле не меняется. Адрес должен быть абсолютным http(s),
+// секрет - непустым, а вебхук - подписан хотя бы на одно событие
+func validateWebhook(rawURL *string, secret *string, eventTypes []models.WebhookEventType) error {
+	if rawURL != nil {
+		parsed, err := url.Parse(*rawURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errdefs.InvalidWebhookError("url", "must be an absolute http or https URL")
+		}
+	}
+	if secret != nil && strings.TrimSpace(*secret) == "" {
+		return errdefs.InvalidWebhookError("secret", "must not be empty")
+	}
+	if eventTypes != nil && len(eventTypes) == 0 {
+		return errdefs.InvalidWebhookError("eventTypes", "at least one event type is required")
+	}
+	for _, eventType := range eventTypes {
+		if !eventType.IsValid() {
+			return errdefs.InvalidWebhookError("eventTypes", "unknown event type "+string(eventType))
+		}
+	}
+	return nil
+}
+
+func uniqueEventTypes(eventTypes []models.WebhookEventType) []models.WebhookEventType {
+	unique := make([]models.WebhookEventType, 0, len(eventTypes))
+	seen := make(map[models.WebhookEventType]bool, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+	return unique
+}
+
+// webhookPayload - тело доставки. ID - id события, он одинаков во всех доставках и повторах одного события
+type webhookPayload struct {
+	ID        int                     `json:"id"`
+	Type      models.WebhookEventType `json:"type"`
+	CreatedAt time.Time               `json:"createdAt"`
+	Data      interface{}             `json:"data"`
+}
+
+type webhookPost struct {
+	ID        int                  `json:"id"`
+	Title     string               `json:"title"`
+	Payload   string               `json:"payload"`
+	Format    models.ContentFormat `json:"format"`
+	Author    *models.User         `json:"author"`
+	Tags      []string             `json:"tags"`
+	PublishAt *time.Time           `json:"publishAt"`
+	CreatedAt time.Time            `json:"createdAt"`
+}
+
+type webhookComment struct {
+	ID        int                  `json:"id"`
+	PostID    int                  `json:"postID"`
+	ReplyTo   *int                 `json:"replyTo"`
+	Payload   string               `json:"payload"`
+	Format    models.ContentFormat `json:"format"`
+	Author    *models.User         `json:"author"`
+	CreatedAt time.Time            `json:"createdAt"`
+}
+
+// WebhookEventHandler ставит события outbox в очередь доставки вебхукам, подписанным на них
+type WebhookEventHandler struct {
+	log      *zap.Logger
+	posts    PostProvider
+	comments CommentProvider
+	webhooks WebhookProvider
+}
+
+func NewWebhookEventHandler(log *zap.Logger, posts PostProvider, comments CommentProvider, webhooks WebhookProvider) *WebhookEventHandler {
+	return &WebhookEventHandler{
+		log:      log,
+		posts:    posts,
+		comments: comments,
+		webhooks: webhooks,
+	}
+}
+
+func (h *WebhookEventHandler) HandleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	eventType, ok := webhookEventTypes[event.Type]
+	if !ok {
+		return nil
+	}
+	log := h.log.With(
+		zap.String("Layer", "WebhookEventHandler.HandleEvent"),
+		zap.Int("EventID", event.ID),
+		zap.String("EventType", string(eventType)),
+	)
+
+	// Ненайденный контент не пропускается: событие могло обогнать реплику, с которой идёт чтение
+	var data interface{}
+	switch eventType {
+	case models.WebhookEventTypePostPublished:
+		post, err := h.posts.GetPostByID(ctx, event.AggregateID)
+		if err != nil {
+			return err
+		}
+		if post.IsHidden {
+			return nil
+		}
+		data = &webhookPost{
+			ID:        post.ID,
+			Title:     post.Title,
+			Payload:   post.Payload,
+			Format:    post.Format,
+			Author:    post.Author,
+			Tags:      post.Tags,
+			PublishAt: post.PublishAt,
+			CreatedAt: post.CreatedAt,
+		}
+	case models.WebhookEventTypeCommentPublished:
+		comment, err := h.comments.GetCommentByID(ctx, event.AggregateID)
+		if err != nil {
+			return err
+		}
+		// Комментарий могли скрыть по жалобам раньше, чем событие дошло до вебхуков
+		if comment.IsHidden {
+			return nil
+		}
+		data = &webhookComment{
+			ID:        comment.ID,
+			PostID:    comment.PostID,
+			ReplyTo:   comment.ReplyTo,
+			Payload:   comment.Payload,
+			Format:    comment.Format,
+			Author:    comment.Author,
+			CreatedAt: comment.CreatedAt,
+		}
+	}
+
+	payload, err := json.Marshal(&webhookPayload{ID: event.ID, Type: eventType, CreatedAt: event.CreatedAt, Data: data})
+	if err != nil {
+		return err
+	}
+	created, err := h.webhooks.CreateWebhookDeliveries(ctx, event.ID, eventType, string(payload))
+	if err != nil {
+		return err
+	}
+	if created > 0 {
+		log.Debug("Webhook deliveries queued", zap.Int("Deliveries", created))
+	}
+	return nil
+}
+
+// WebhookSender отправляет одну доставку и возвращает код ответа получателя, 0 - если ответа не было
+type WebhookSender interface {
+	Send(ctx context.Context, request webhook.Request) (int, error)
+}
+
+// WebhookDispatcher периодически забирает доставки вебхуков и отправляет их. Неудачная доставка повторяется
+// с растущей задержкой, после maxAttempts попыток она помечается FAILED и ждёт ручного повтора
+type WebhookDispatcher struct {
+	log         *zap.Logger
+	webhooks    WebhookProvider
+	sender      WebhookSender
+	interval    time.Duration
+	maxAttempts int
+	// lease - на сколько откладывается следующая попытка выданных доставок: пачка должна успеть уйти целиком
+	lease time.Duration
+	now   func() time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWebhookDispatcher создаёт диспетчер. timeout - ограничение одной попытки, по нему считается аренда пачки
+func NewWebhookDispatcher(log *zap.Logger, webhooks WebhookProvider, sender WebhookSender, interval time.Duration, timeout time.Duration, maxAttempts int) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		log:         log,
+		webhooks:    webhooks,
+		sender:      sender,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+		lease:       webhookBatchSize*timeout + time.Minute,
+		now:         time.Now,
+	}
+}
+
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			// Полная пачка значит, что в очереди могут быть ещё доставки
+			for d.dispatch(ctx) == webhookBatchSize && ctx.Err() == nil {
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop останавливает диспетчер и дожидается завершения текущей попытки. Неотправленные доставки
+// будут выданы снова после аренды
+func (d *WebhookDispatcher) Stop() {
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	<-d.done
+}
+
+// dispatch отправляет одну пачку доставок и возвращает её размер
+func (d *WebhookDispatcher) dispatch(ctx context.Context) int {
+	log := d.log.With(
+		zap.String("Layer", "WebhookDispatcher.dispatch"),
+	)
+
+	deliveries, err := d.webhooks.ClaimWebhookDeliveries(ctx, d.now(), webhookBatchSize, d.lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error("Failed to claim webhook deliveries", zap.Error(err))
+		}
+		return 0
+	}
+
+	hooks := make(map[int]*models.Webhook)
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return len(deliveries)
+		}
+		d.deliver(ctx, log.With(zap.Int("DeliveryID", delivery.ID), zap.Int("WebhookID", delivery.WebhookID)), delivery, hooks)
+	}
+	return len(deliveries)
+}
+
+// deliver отправляет доставку и сохраняет итог. hooks - вебхуки, уже загруженные для этой пачки
+func (d *WebhookDispatcher) deliver(ctx context.Context, log *zap.Logger, delivery *models.WebhookDelivery, hooks map[int]*models.Webhook) {
+	hook, ok := hooks[delivery.WebhookID]
+	if !ok {
+		var err error
+		hook, err = d.webhooks.GetWebhookByID(ctx, delivery.WebhookID)
+		if err != nil {
+			// Удалённый вебхук забирает доставки с собой, остальные ошибки повторятся после аренды
+			if !errors.Is(err, storageerr.ErrNotFound) {
+				log.Error("Failed to get webhook", zap.Error(err))
+			}
+			return
+		}
+		hooks[hook.ID] = hook
+	}
+
+	status, err := d.sender.Send(ctx, webhook.Request{
+		URL:        hook.URL,
+		Secret:     hook.Secret,
+		Event:      string(delivery.EventType),
+		DeliveryID: delivery.ID,
+		Body:       []byte(delivery.Payload),
+	})
+	if ctx.Err() != nil {
+		// Прерванная остановкой попытка не считается неудачной, доставка повторится после аренды
+		return
+	}
+
+	now := d.now()
+	delivery.ResponseStatus = nil
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliveryStatusSucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = nil
+	case delivery.Attempts >= d.maxAttempts:
+		lastError := err.Error()
+		delivery.Status = models.WebhookDeliveryStatusFailed
+		delivery.LastError = &lastError
+		log.Warn("Webhook delivery failed, attempts exhausted", zap.Int("Attempts", delivery.Attempts), zap.Error(err))
+	default:
+		lastError := err.Error()
+		delivery.LastError = &lastError
+		delivery.NextAttemptAt = now.Add(backoff(delivery.Attempts, webhookMinBackoff, webhookMaxBackoff))
+		log.Warn("Failed to deliver webhook", zap.Int("Attempts", delivery.Attempts), zap.Time("NextAttemptAt", delivery.NextAttemptAt), zap.Error(err))
+	}
+
+	if err = d.webhooks.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		log.Error("Failed to save webhook delivery result", zap.Error(err))
+	}
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/Quizert/PostCommentService/internal/errdefs"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/service/mocks"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/Quizert/PostCommentService/internal/webhook"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type senderFunc func(ctx context.Context, request webhook.Request) (int, error)
+
+func (f senderFunc) Send(ctx context.Context, request webhook.Request) (int, error) {
+	return f(ctx, request)
+}
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	moderator := &models.User{ID: 2, Username: "Quizert", Role: models.UserRoleModerator}
+	user := &models.User{ID: 3, Username: "Alen", Role: models.UserRoleUser}
+	posts := models.WebhookEventTypePostPublished
+
+	tests := []struct {
+		name          string
+		viewer        *models.User
+		input         models.NewWebhook
+		expected      *models.Webhook
+		expectedError error
+	}{
+		{
+			name:     "created active with unique event types",
+			viewer:   moderator,
+			input:    models.NewWebhook{URL: "https://example.com/hook", Secret: "secret", EventTypes: []models.WebhookEventType{posts, posts}},
+			expected: &models.Webhook{URL: "https://example.com/hook", Secret: "secret", EventTypes: []models.WebhookEventType{posts}, IsActive: true},
+		},
+		{
+			name:          "not a moderator",
+			viewer:        user,
+			input:         models.NewWebhook{URL: "https://example.com/hook", Secret: "secret", EventTypes: []models.WebhookEventType{posts}},
+			expectedError: errdefs.ForbiddenError(3),
+		},
+		{
+			name:          "relative url",
+			viewer:        moderator,
+			input:         models.NewWebhook{URL: "/hook", Secret: "secret", EventTypes: []models.WebhookEventType{posts}},
+			expectedError: errdefs.InvalidWebhookError("url", "must be an absolute http or https URL"),
+		},
+		{
+			name:          "unsupported scheme",
+			viewer:        moderator,
+			input:         models.NewWebhook{URL: "ftp://example.com/hook", Secret: "secret", EventTypes: []models.WebhookEventType{posts}},
+			expectedError: errdefs.InvalidWebhookError("url", "must be an absolute http or https URL"),
+		},
+		{
+			name:          "blank secret",
+			viewer:        moderator,
+			input:         models.NewWebhook{URL: "https://example.com/hook", Secret: "  ", EventTypes: []models.WebhookEventType{posts}},
+			expectedError: errdefs.InvalidWebhookError("secret", "must not be empty"),
+		},
+		{
+			name:          "no event types",
+			viewer:        moderator,
+			input:         models.NewWebhook{URL: "https://example.com/hook", Secret: "secret"},
+			expectedError: errdefs.InvalidWebhookError("eventTypes", "at least one event type is required"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			userProvider := mocks.NewMockUserProvider(ctl)
+			webhookProvider := mocks.NewMockWebhookProvider(ctl)
+			userProvider.EXPECT().GetUserByID(gomock.Any(), tt.viewer.ID).Return(tt.viewer, nil)
+			if tt.expected != nil {
+				created := *tt.expected
+				created.ID = 1
+				webhookProvider.EXPECT().CreateWebhook(gomock.Any(), tt.expected).Return(&created, nil)
+			}
+
+			webhookService := NewWebhookService(zap.NewNop(), NewStorage(nil, nil, userProvider, nil, nil), webhookProvider)
+			ctx := auth.WithUserID(context.Background(), tt.viewer.ID)
+			created, err := webhookService.CreateWebhook(ctx, tt.input)
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				assert.Nil(t, created)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, created.ID)
+		})
+	}
+}
+
+func TestWebhookService_UpdateWebhook(t *testing.T) {
+	moderator := &models.User{ID: 2, Username: "Quizert", Role: models.UserRoleModerator}
+	inactive := false
+
+	tests := []struct {
+		name          string
+		input         models.EditWebhook
+		storageErr    error
+		expectUpdate  bool
+		expectedError error
+	}{
+		{
+			name:         "deactivate keeps event types",
+			input:        models.EditWebhook{IsActive: &inactive},
+			expectUpdate: true,
+		},
+		{
+			name:          "empty event types",
+			input:         models.EditWebhook{EventTypes: []models.WebhookEventType{}},
+			expectedError: errdefs.InvalidWebhookError("eventTypes", "at least one event type is required"),
+		},
+		{
+			name:          "webhook not found",
+			input:         models.EditWebhook{IsActive: &inactive},
+			storageErr:    storageerr.ErrNotFound,
+			expectUpdate:  true,
+			expectedError: errdefs.WebhookDoesNotExistError(1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			userProvider := mocks.NewMockUserProvider(ctl)
+			webhookProvider := mocks.NewMockWebhookProvider(ctl)
+			userProvider.EXPECT().GetUserByID(gomock.Any(), moderator.ID).Return(moderator, nil)
+			if tt.expectUpdate {
+				var updated *models.Webhook
+				if tt.storageErr == nil {
+					updated = &models.Webhook{ID: 1}
+				}
+				webhookProvider.EXPECT().UpdateWebhook(gomock.Any(), 1, tt.input).Return(updated, tt.storageErr)
+			}
+
+			webhookService := NewWebhookService(zap.NewNop(), NewStorage(nil, nil, userProvider, nil, nil), webhookProvider)
+			ctx := auth.WithUserID(context.Background(), moderator.ID)
+			_, err := webhookService.UpdateWebhook(ctx, 1, tt.input)
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
+func TestWebhookService_ReplayWebhookDelivery(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	moderator := &models.User{ID: 2, Username: "Quizert", Role: models.UserRoleModerator}
+	status := http.StatusInternalServerError
+	lastError := "receiver responded with 500"
+	failed := &models.WebhookDelivery{
+		ID:             7,
+		WebhookID:      1,
+		Status:         models.WebhookDeliveryStatusFailed,
+		Attempts:       8,
+		ResponseStatus: &status,
+		LastError:      &lastError,
+		NextAttemptAt:  now.Add(-time.Hour),
+	}
+
+	userProvider := mocks.NewMockUserProvider(ctl)
+	webhookProvider := mocks.NewMockWebhookProvider(ctl)
+	userProvider.EXPECT().GetUserByID(gomock.Any(), moderator.ID).Return(moderator, nil).Times(2)
+	webhookProvider.EXPECT().GetWebhookDelivery(gomock.Any(), 7).Return(failed, nil)
+	webhookProvider.EXPECT().UpdateWebhookDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, delivery *models.WebhookDelivery) error {
+		assert.Equal(t, models.WebhookDeliveryStatusPending, delivery.Status)
+		assert.Zero(t, delivery.Attempts)
+		assert.Equal(t, now, delivery.NextAttemptAt)
+		assert.Equal(t, &lastError, delivery.LastError, "итог прошлой попытки остаётся в журнале до следующей")
+		return nil
+	})
+	webhookProvider.EXPECT().GetWebhookDelivery(gomock.Any(), 8).Return(nil, storageerr.ErrNotFound)
+
+	webhookService := NewWebhookService(zap.NewNop(), NewStorage(nil, nil, userProvider, nil, nil), webhookProvider)
+	webhookService.now = func() time.Time { return now }
+	ctx := auth.WithUserID(context.Background(), moderator.ID)
+
+	replayed, err := webhookService.ReplayWebhookDelivery(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryStatusPending, replayed.Status)
+
+	_, err = webhookService.ReplayWebhookDelivery(ctx, 8)
+	assert.Equal(t, errdefs.WebhookDeliveryDoesNotExistError(8), err)
+}
+
+func TestWebhookEventHandler(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	author := &models.User{ID: 1, Username: "Alice"}
+
+	tests := []struct {
+		name          string
+		eventType     models.OutboxEventType
+		post          *models.Post
+		comment       *models.Comment
+		contentErr    error
+		expectedType  models.WebhookEventType
+		expectedData  string
+		expectErr     bool
+		expectNothing bool
+	}{
+		{
+			name:         "published post",
+			eventType:    models.OutboxEventPostPublished,
+			post:         &models.Post{ID: 10, Title: "title", Payload: "payload", Format: models.ContentFormatPlain, Author: author, Tags: []string{"go"}, CreatedAt: createdAt},
+			expectedType: models.WebhookEventTypePostPublished,
+			expectedData: `{"id":10,"title":"title","payload":"payload","format":"PLAIN","author":{"id":1,"username":"Alice"},"tags":["go"],"publishAt":null,"createdAt":"2024-05-01T12:00:00Z"}`,
+		},
+		{
+			name:         "published comment",
+			eventType:    models.OutboxEventCommentPublished,
+			comment:      &models.Comment{ID: 10, PostID: 3, Payload: "hi", Format: models.ContentFormatMarkdown, Author: author, CreatedAt: createdAt},
+			expectedType: models.WebhookEventTypeCommentPublished,
+			expectedData: `{"id":10,"postID":3,"replyTo":null,"payload":"hi","format":"MARKDOWN","author":{"id":1,"username":"Alice"},"createdAt":"2024-05-01T12:00:00Z"}`,
+		},
+		{
+			name:          "comment hidden after publishing",
+			eventType:     models.OutboxEventCommentPublished,
+			comment:       &models.Comment{ID: 10, IsHidden: true},
+			expectNothing: true,
+		},
+		{
+			name:       "post not yet on replica is retried",
+			eventType:  models.OutboxEventPostPublished,
+			contentErr: storageerr.ErrNotFound,
+			expectErr:  true,
+		},
+		{
+			name:          "unknown event type",
+			eventType:     "post.deleted",
+			expectNothing: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			postProvider := mocks.NewMockPostProvider(ctl)
+			commentProvider := mocks.NewMockCommentProvider(ctl)
+			webhookProvider := mocks.NewMockWebhookProvider(ctl)
+			switch tt.eventType {
+			case models.OutboxEventPostPublished:
+				postProvider.EXPECT().GetPostByID(gomock.Any(), 10).Return(tt.post, tt.contentErr)
+			case models.OutboxEventCommentPublished:
+				commentProvider.EXPECT().GetCommentByID(gomock.Any(), 10).Return(tt.comment, tt.contentErr)
+			}
+			if !tt.expectErr && !tt.expectNothing {
+				webhookProvider.EXPECT().CreateWebhookDeliveries(gomock.Any(), 5, tt.expectedType, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int, _ models.WebhookEventType, payload string) (int, error) {
+						var body struct {
+							ID        int             `json:"id"`
+							Type      string          `json:"type"`
+							CreatedAt time.Time       `json:"createdAt"`
+							Data      json.RawMessage `json:"data"`
+						}
+						require.NoError(t, json.Unmarshal([]byte(payload), &body))
+						assert.Equal(t, 5, body.ID, "id события одинаков во всех доставках")
+						assert.Equal(t, string(tt.expectedType), body.Type)
+						assert.Equal(t, createdAt, body.CreatedAt)
+						assert.JSONEq(t, tt.expectedData, string(body.Data))
+						return 1, nil
+					})
+			}
+
+			handler := NewWebhookEventHandler(zap.NewNop(), postProvider, commentProvider, webhookProvider)
+			err := handler.HandleEvent(context.Background(), &models.OutboxEvent{ID: 5, Type: tt.eventType, AggregateID: 10, CreatedAt: createdAt})
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWebhookDispatcher_Dispatch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	payload := `{"id":5,"type":"POST_PUBLISHED"}`
+
+	tests := []struct {
+		name           string
+		responseStatus int
+		attempts       int
+		maxAttempts    int
+		expected       models.WebhookDelivery
+	}{
+		{
+			name:           "delivered",
+			responseStatus: http.StatusOK,
+			attempts:       1,
+			maxAttempts:    8,
+			expected:       models.WebhookDelivery{Status: models.WebhookDeliveryStatusSucceeded, DeliveredAt: &now},
+		},
+		{
+			name:           "receiver error is retried with backoff",
+			responseStatus: http.StatusServiceUnavailable,
+			attempts:       3,
+			maxAttempts:    8,
+			expected:       models.WebhookDelivery{Status: models.WebhookDeliveryStatusPending, NextAttemptAt: now.Add(40 * time.Second)},
+		},
+		{
+			name:           "attempts exhausted",
+			responseStatus: http.StatusInternalServerError,
+			attempts:       3,
+			maxAttempts:    3,
+			expected:       models.WebhookDelivery{Status: models.WebhookDeliveryStatusFailed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			var requests atomic.Int32
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, payload, string(body))
+				assert.Equal(t, "POST_PUBLISHED", r.Header.Get(webhook.HeaderEvent))
+				assert.Equal(t, "7", r.Header.Get(webhook.HeaderDelivery))
+				timestamp, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+				assert.NoError(t, err)
+				assert.True(t, webhook.Verify("secret", timestamp, body, r.Header.Get(webhook.HeaderSignature)), "доставка подписана секретом вебхука")
+				w.WriteHeader(tt.responseStatus)
+			}))
+			defer receiver.Close()
+
+			webhookProvider := mocks.NewMockWebhookProvider(ctl)
+			delivery := &models.WebhookDelivery{
+				ID:            7,
+				WebhookID:     1,
+				EventID:       5,
+				EventType:     models.WebhookEventTypePostPublished,
+				Payload:       payload,
+				Status:        models.WebhookDeliveryStatusPending,
+				Attempts:      tt.attempts,
+				NextAttemptAt: now.Add(time.Hour),
+			}
+			dispatcher := NewWebhookDispatcher(zap.NewNop(), webhookProvider, webhook.NewSender(time.Second), time.Second, time.Second, tt.maxAttempts)
+			dispatcher.now = func() time.Time { return now }
+
+			webhookProvider.EXPECT().ClaimWebhookDeliveries(gomock.Any(), now, webhookBatchSize, dispatcher.lease).Return([]*models.WebhookDelivery{delivery}, nil)
+			webhookProvider.EXPECT().GetWebhookByID(gomock.Any(), 1).Return(&models.Webhook{ID: 1, URL: receiver.URL, Secret: "secret", IsActive: true}, nil)
+			webhookProvider.EXPECT().UpdateWebhookDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, got *models.WebhookDelivery) error {
+				assert.Equal(t, tt.expected.Status, got.Status)
+				assert.Equal(t, tt.attempts, got.Attempts)
+				if assert.NotNil(t, got.ResponseStatus) {
+					assert.Equal(t, tt.responseStatus, *got.ResponseStatus)
+				}
+				assert.Equal(t, tt.expected.DeliveredAt, got.DeliveredAt)
+				if tt.expected.Status == models.WebhookDeliveryStatusSucceeded {
+					assert.Nil(t, got.LastError)
+				} else {
+					assert.NotNil(t, got.LastError)
+				}
+				if !tt.expected.NextAttemptAt.IsZero() {
+					assert.Equal(t, tt.expected.NextAttemptAt, got.NextAttemptAt)
+				}
+				return nil
+			})
+
+			assert.Equal(t, 1, dispatcher.dispatch(context.Background()))
+			assert.Equal(t, int32(1), requests.Load())
+		})
+	}
+}
+
+func TestWebhookDispatcher_DeletedWebhook(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	webhookProvider := mocks.NewMockWebhookProvider(ctl)
+	sends := 0
+	sender := senderFunc(func(_ context.Context, request webhook.Request) (int, error) {
+		sends++
+		assert.Equal(t, "http://receiver", request.URL)
+		return 0, errors.New("connection refused")
+	})
+	deliveries := []*models.WebhookDelivery{{ID: 1, WebhookID: 1, Attempts: 1}, {ID: 2, WebhookID: 2, Attempts: 1}, {ID: 3, WebhookID: 2, Attempts: 1}}
+	webhookProvider.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any(), webhookBatchSize, gomock.Any()).Return(deliveries, nil)
+	webhookProvider.EXPECT().GetWebhookByID(gomock.Any(), 1).Return(nil, storageerr.ErrNotFound)
+	// Вебхук загружается один раз на пачку
+	webhookProvider.EXPECT().GetWebhookByID(gomock.Any(), 2).Return(&models.Webhook{ID: 2, URL: "http://receiver", Secret: "secret"}, nil)
+	webhookProvider.EXPECT().UpdateWebhookDelivery(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	dispatcher := NewWebhookDispatcher(zap.NewNop(), webhookProvider, sender, time.Second, time.Second, 8)
+	assert.Equal(t, 3, dispatcher.dispatch(context.Background()))
+	assert.Equal(t, 2, sends)
+}
+
+func TestWebhookDispatcher_Start(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1", r.Header.Get(webhook.HeaderDelivery))
+	}))
+	defer receiver.Close()
+
+	saved := make(chan *models.WebhookDelivery, 1)
+	webhookProvider := mocks.NewMockWebhookProvider(ctl)
+	delivery := &models.WebhookDelivery{ID: 1, WebhookID: 1, EventType: models.WebhookEventTypeCommentPublished, Payload: "{}", Attempts: 1}
+	webhookProvider.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any(), webhookBatchSize, gomock.Any()).Return([]*models.WebhookDelivery{delivery}, nil)
+	webhookProvider.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any(), webhookBatchSize, gomock.Any()).Return([]*models.WebhookDelivery{}, nil).AnyTimes()
+	webhookProvider.EXPECT().GetWebhookByID(gomock.Any(), 1).Return(&models.Webhook{ID: 1, URL: receiver.URL, Secret: "secret"}, nil)
+	webhookProvider.EXPECT().UpdateWebhookDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, got *models.WebhookDelivery) error {
+		saved <- got
+		return nil
+	})
+
+	dispatcher := NewWebhookDispatcher(zap.NewNop(), webhookProvider, webhook.NewSender(time.Second), 10*time.Millisecond, time.Second, 8)
+	dispatcher.Start(context.Background())
+	select {
+	case got := <-saved:
+		assert.Equal(t, models.WebhookDeliveryStatusSucceeded, got.Status)
+	case <-time.After(time.Second):
+		t.Fatal("timeout: dispatcher did not deliver")
+	}
+	dispatcher.Stop()
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 1, expected: webhookMinBackoff},
+		{attempts: 2, expected: 20 * time.Second},
+		{attempts: 5, expected: 160 * time.Second},
+		{attempts: 9, expected: 2560 * time.Second},
+		{attempts: 10, expected: webhookMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, backoff(tt.attempts, webhookMinBackoff, webhookMaxBackoff), "attempts %d", tt.attempts)
+	}
+}
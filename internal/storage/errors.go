@@ -0,0 +1,51 @@
+// Package storage содержит ошибки, общие для всех реализаций хранилища. Каждый бэкенд переводит в них
+// ошибки своего драйвера, поэтому сервисный слой не зависит от конкретной базы
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound - запись не найдена
+	ErrNotFound = errors.New("storage: not found")
+	// ErrConflict - запись нарушает ограничение уникальности
+	ErrConflict = errors.New("storage: conflict")
+	// ErrForeignKey - запись ссылается на несуществующую сущность
+	ErrForeignKey = errors.New("storage: foreign key violation")
+)
+
+// ConstraintError - нарушение ограничения базы. errors.Is(err, Kind) выполняется для любого ConstraintError
+type ConstraintError struct {
+	// Kind - ErrConflict или ErrForeignKey
+	Kind error
+	// Field - столбцы ограничения в нижнем регистре через "_", например "postid".
+	// Пустой, если бэкенд не сообщает, какое ограничение нарушено
+	Field string
+	// Err - исходная ошибка драйвера, может быть nil
+	Err error
+}
+
+func (e *ConstraintError) Error() string {
+	message := e.Kind.Error()
+	if e.Field != "" {
+		message = fmt.Sprintf("%s on %s", message, e.Field)
+	}
+	if e.Err != nil {
+		message = fmt.Sprintf("%s: %v", message, e.Err)
+	}
+	return message
+}
+
+func (e *ConstraintError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// IsStorageError сообщает, что ошибка уже переведена в ошибку пакета storage
+func IsStorageError(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) || errors.Is(err, ErrForeignKey)
+}
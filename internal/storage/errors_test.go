@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstraintError(t *testing.T) {
+	driverErr := errors.New("duplicate key value violates unique constraint")
+	err := error(&ConstraintError{Kind: ErrConflict, Field: "username", Err: driverErr})
+
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, driverErr)
+	assert.NotErrorIs(t, err, ErrForeignKey)
+	assert.True(t, IsStorageError(err))
+	assert.Equal(t, "storage: conflict on username: duplicate key value violates unique constraint", err.Error())
+
+	bare := error(&ConstraintError{Kind: ErrForeignKey})
+	assert.ErrorIs(t, bare, ErrForeignKey)
+	assert.Equal(t, "storage: foreign key violation", bare.Error())
+
+	assert.False(t, IsStorageError(driverErr))
+}
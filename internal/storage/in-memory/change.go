@@ -0,0 +1,231 @@
+package in_memory
+
+import (
+	"github.com/Quizert/PostCommentService/internal/models"
+	"sort"
+)
+
+// change - запись журнала: новые версии сущностей, которые изменила одна операция.
+// Сохранённые сущности после записи не меняются, любое изменение кладёт в хранилище новую копию.
+// Поэтому снапшот может сериализовать их без блокировки
+type change struct {
+	Posts         []*models.Post           `json:"posts,omitempty"`
+	Comments      []*models.Comment        `json:"comments,omitempty"`
+	Revisions     []*revisionRecord        `json:"revisions,omitempty"`
+	Mentions      []*mentionRecord         `json:"mentions,omitempty"`
+	Notifications []*notificationRecord    `json:"notifications,omitempty"`
+	Moderation    []*models.ModerationItem `json:"moderation,omitempty"`
+	Reports       []*models.Report         `json:"reports,omitempty"`
+	Bans          []*models.UserBan        `json:"bans,omitempty"`
+	Mutes         []*models.PostMute       `json:"mutes,omitempty"`
+	Blocks        []*blockRecord           `json:"blocks,omitempty"`
+	Outbox        []*models.OutboxEvent    `json:"outbox,omitempty"`
+	// OutboxDeleted - id удалённых событий outbox
+	OutboxDeleted []int `json:"outboxDeleted,omitempty"`
+
+	Webhooks          []*webhookRecord          `json:"webhooks,omitempty"`
+	WebhookDeliveries []*models.WebhookDelivery `json:"webhookDeliveries,omitempty"`
+	// WebhooksDeleted - id удалённых вебхуков, их доставки удаляются вместе с ними
+	WebhooksDeleted []int `json:"webhooksDeleted,omitempty"`
+}
+
+type mentionRecord struct {
+	CommentID int   `json:"commentID"`
+	UserIDs   []int `json:"userIDs"`
+}
+
+// notificationRecord нужен, потому что получатель уведомления не сериализуется в models.Notification
+type notificationRecord struct {
+	*models.Notification
+	RecipientID int `json:"recipientID"`
+}
+
+// webhookRecord нужен, потому что секрет вебхука не сериализуется в models.Webhook
+type webhookRecord struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+type blockRecord struct {
+	BlockerID int  `json:"blockerID"`
+	BlockedID int  `json:"blockedID"`
+	Blocked   bool `json:"blocked"`
+}
+
+// apply кладёт изменение в хранилище. Вызывается под блокировкой на запись
+func (s *InMemoryStorage) apply(c *change) {
+	for _, post := range c.Posts {
+		post.Author = s.userRef(post.Author)
+		s.posts[post.ID] = post
+		s.nextPostID = max(s.nextPostID, post.ID+1)
+	}
+	for _, comment := range c.Comments {
+		comment.Author = s.userRef(comment.Author)
+		s.comments[comment.ID] = comment
+		s.nextCommentID = max(s.nextCommentID, comment.ID+1)
+	}
+	for _, record := range c.Revisions {
+		record.Revision.Editor = s.userRef(record.Revision.Editor)
+		s.revisions = append(s.revisions, record)
+		s.nextRevisionID = max(s.nextRevisionID, record.Revision.ID+1)
+	}
+	for _, record := range c.Mentions {
+		s.mentions[record.CommentID] = record.UserIDs
+	}
+	for _, record := range c.Notifications {
+		notification := record.Notification
+		notification.RecipientID = record.RecipientID
+		notification.Actor = s.userRef(notification.Actor)
+		s.notifications = upsertByID(s.notifications, notification, func(n *models.Notification) int { return n.ID })
+		s.nextNotificationID = max(s.nextNotificationID, notification.ID+1)
+	}
+	for _, item := range c.Moderation {
+		item.Author = s.userRef(item.Author)
+		item.Moderator = s.userRef(item.Moderator)
+		s.moderation = upsertByID(s.moderation, item, func(i *models.ModerationItem) int { return i.ID })
+		s.nextModerationID = max(s.nextModerationID, item.ID+1)
+	}
+	for _, report := range c.Reports {
+		report.Reporter = s.userRef(report.Reporter)
+		s.reports = append(s.reports, report)
+		s.nextReportID = max(s.nextReportID, report.ID+1)
+	}
+	for _, ban := range c.Bans {
+		ban.User = s.userRef(ban.User)
+		ban.Moderator = s.userRef(ban.Moderator)
+		s.bans[ban.User.ID] = ban
+	}
+	for _, mute := range c.Mutes {
+		mute.User = s.userRef(mute.User)
+		mute.MutedBy = s.userRef(mute.MutedBy)
+		s.mutes[postMuteKey{mute.PostID, mute.User.ID}] = mute
+	}
+	for _, record := range c.Blocks {
+		blocked, ok := s.blocks[record.BlockerID]
+		if !ok {
+			blocked = make(map[int]bool)
+			s.blocks[record.BlockerID] = blocked
+		}
+		if record.Blocked {
+			blocked[record.BlockedID] = true
+		} else {
+			delete(blocked, record.BlockedID)
+		}
+	}
+	for _, event := range c.Outbox {
+		s.outbox = upsertByID(s.outbox, event, func(e *models.OutboxEvent) int { return e.ID })
+		s.nextOutboxID = max(s.nextOutboxID, event.ID+1)
+	}
+	if len(c.OutboxDeleted) > 0 {
+		deleted := make(map[int]bool, len(c.OutboxDeleted))
+		for _, id := range c.OutboxDeleted {
+			deleted[id] = true
+		}
+		kept := s.outbox[:0]
+		for _, event := range s.outbox {
+			if !deleted[event.ID] {
+				kept = append(kept, event)
+			}
+		}
+		s.outbox = kept
+	}
+	for _, record := range c.Webhooks {
+		webhook := record.Webhook
+		webhook.Secret = record.Secret
+		s.webhooks = upsertByID(s.webhooks, webhook, func(w *models.Webhook) int { return w.ID })
+		s.nextWebhookID = max(s.nextWebhookID, webhook.ID+1)
+	}
+	for _, delivery := range c.WebhookDeliveries {
+		s.webhookDeliveries = upsertByID(s.webhookDeliveries, delivery, func(d *models.WebhookDelivery) int { return d.ID })
+		s.nextWebhookDeliveryID = max(s.nextWebhookDeliveryID, delivery.ID+1)
+	}
+	if len(c.WebhooksDeleted) > 0 {
+		deleted := make(map[int]bool, len(c.WebhooksDeleted))
+		for _, id := range c.WebhooksDeleted {
+			deleted[id] = true
+		}
+		keptWebhooks := s.webhooks[:0]
+		for _, webhook := range s.webhooks {
+			if !deleted[webhook.ID] {
+				keptWebhooks = append(keptWebhooks, webhook)
+			}
+		}
+		s.webhooks = keptWebhooks
+		keptDeliveries := s.webhookDeliveries[:0]
+		for _, delivery := range s.webhookDeliveries {
+			if !deleted[delivery.WebhookID] {
+				keptDeliveries = append(keptDeliveries, delivery)
+			}
+		}
+		s.webhookDeliveries = keptDeliveries
+	}
+}
+
+// state собирает всё содержимое хранилища в одно изменение. Копируются только ссылки на сущности.
+// Вызывается под блокировкой
+func (s *InMemoryStorage) state() *change {
+	c := &change{
+		Posts:         make([]*models.Post, 0, len(s.posts)),
+		Comments:      make([]*models.Comment, 0, len(s.comments)),
+		Revisions:     append([]*revisionRecord(nil), s.revisions...),
+		Mentions:      make([]*mentionRecord, 0, len(s.mentions)),
+		Notifications: make([]*notificationRecord, 0, len(s.notifications)),
+		Moderation:    append([]*models.ModerationItem(nil), s.moderation...),
+		Reports:       append([]*models.Report(nil), s.reports...),
+		Bans:          make([]*models.UserBan, 0, len(s.bans)),
+		Mutes:         make([]*models.PostMute, 0, len(s.mutes)),
+		Blocks:        make([]*blockRecord, 0),
+		Outbox:        append([]*models.OutboxEvent(nil), s.outbox...),
+
+		Webhooks:          make([]*webhookRecord, 0, len(s.webhooks)),
+		WebhookDeliveries: append([]*models.WebhookDelivery(nil), s.webhookDeliveries...),
+	}
+	for _, webhook := range s.webhooks {
+		c.Webhooks = append(c.Webhooks, &webhookRecord{Webhook: webhook, Secret: webhook.Secret})
+	}
+	for _, post := range s.posts {
+		c.Posts = append(c.Posts, post)
+	}
+	for _, comment := range s.comments {
+		c.Comments = append(c.Comments, comment)
+	}
+	for commentID, userIDs := range s.mentions {
+		c.Mentions = append(c.Mentions, &mentionRecord{CommentID: commentID, UserIDs: userIDs})
+	}
+	for _, notification := range s.notifications {
+		c.Notifications = append(c.Notifications, &notificationRecord{Notification: notification, RecipientID: notification.RecipientID})
+	}
+	for _, ban := range s.bans {
+		c.Bans = append(c.Bans, ban)
+	}
+	for _, mute := range s.mutes {
+		c.Mutes = append(c.Mutes, mute)
+	}
+	for blockerID, blocked := range s.blocks {
+		for blockedID := range blocked {
+			c.Blocks = append(c.Blocks, &blockRecord{BlockerID: blockerID, BlockedID: blockedID, Blocked: true})
+		}
+	}
+	return c
+}
+
+// userRef заменяет пользователя из записи на пользователя хранилища: роль в журнал не пишется
+func (s *InMemoryStorage) userRef(user *models.User) *models.User {
+	if user == nil {
+		return nil
+	}
+	if stored, ok := s.users[user.ID]; ok {
+		return stored
+	}
+	return user
+}
+
+// upsertByID заменяет элемент с тем же id или добавляет новый. Срез упорядочен по id
+func upsertByID[T any](items []*T, item *T, id func(*T) int) []*T {
+	i := sort.Search(len(items), func(i int) bool { return id(items[i]) >= id(item) })
+	if i < len(items) && id(items[i]) == id(item) {
+		items[i] = item
+		return items
+	}
+	return append(items, item)
+}
@@ -3,6 +3,7 @@ package in_memory
 import (
 	"context"
 	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
 	"go.uber.org/zap"
 	"log"
 	"sort"
@@ -21,34 +22,67 @@ func NewCommentMemoryStorage(log *zap.Logger, storage *InMemoryStorage) *Comment
 	}
 }
 
-func (c *CommentMemoryStorage) CreateComment(ctx context.Context, input models.NewComment) (*models.Comment, error) {
+func (c *CommentMemoryStorage) CreateComment(ctx context.Context, input models.NewComment, hidden bool, mentionIDs []int) (*models.Comment, error) {
 	c.storage.mu.Lock()
 	defer c.storage.mu.Unlock()
 
-	newID := c.storage.nextCommentID
-	c.storage.nextCommentID++
+	// Проверяем те же внешние ключи, что и база
+	if _, ok := c.storage.posts[input.PostID]; !ok {
+		return nil, &storage.ConstraintError{Kind: storage.ErrForeignKey, Field: "postid"}
+	}
+	if _, ok := c.storage.users[input.AuthorID]; !ok {
+		return nil, &storage.ConstraintError{Kind: storage.ErrForeignKey, Field: "authorid"}
+	}
+	if input.ReplyTo != nil {
+		if _, ok := c.storage.comments[*input.ReplyTo]; !ok {
+			return nil, &storage.ConstraintError{Kind: storage.ErrForeignKey, Field: "replyto"}
+		}
+	}
+	for _, userID := range mentionIDs {
+		if _, ok := c.storage.users[userID]; !ok {
+			return nil, &storage.ConstraintError{Kind: storage.ErrForeignKey, Field: "userid"}
+		}
+	}
 
 	comment := &models.Comment{
-		ID:        newID,
+		ID:        c.storage.nextCommentID,
 		Payload:   input.Payload,
+		Format:    models.ContentFormatPlain,
 		PostID:    input.PostID,
+		Author:    c.storage.users[input.AuthorID],
 		ReplyTo:   input.ReplyTo,
+		IsHidden:  hidden,
 		CreatedAt: time.Now(),
 	}
+	if input.Format != nil {
+		comment.Format = *input.Format
+	}
 
-	c.storage.comments[newID] = comment
-	return comment, nil
+	ch := &change{Comments: []*models.Comment{comment}}
+	if len(mentionIDs) > 0 {
+		ch.Mentions = []*mentionRecord{{CommentID: comment.ID, UserIDs: append([]int(nil), mentionIDs...)}}
+	}
+	if !hidden {
+		c.storage.addOutboxEvent(ch, models.OutboxEventCommentPublished, comment.ID, comment.CreatedAt)
+	}
+	if err := c.storage.commit(ch); err != nil {
+		c.log.Error("Failed to save comment", zap.String("Layer", "CommentMemoryStorage.CreateComment"), zap.Error(err))
+		return nil, err
+	}
+	result := *comment
+	return &result, nil
 }
 
-func (c *CommentMemoryStorage) GetCommentsByPostID(ctx context.Context, limit, offset, postID int) ([]*models.Comment, error) {
+func (c *CommentMemoryStorage) GetCommentsByPostID(ctx context.Context, limit, offset, postID, viewerID int) ([]*models.Comment, error) {
 	log.Println("AKOLFAKOLJFKLAWFHLJIKAWFHJIK:LAWHFIUJLAWHFLJIAWHNFJKLAWHNJF:KLWAHNFLJKAS:KHNFKSANF:ASKJFh")
 	c.storage.mu.RLock()
 	defer c.storage.mu.RUnlock()
 
-	// Собираем комментарии, у которых comment.PostID == postID и comment.ReplyTo == nil
+	// Собираем комментарии, у которых comment.PostID == postID и comment.ReplyTo == nil.
+	// Скрытые модерацией и комментарии заблокированных зрителем авторов пропускаем
 	filtered := make([]*models.Comment, 0)
 	for _, comment := range c.storage.comments {
-		if comment.PostID == postID && comment.ReplyTo == nil {
+		if comment.PostID == postID && comment.ReplyTo == nil && !comment.IsHidden && !c.storage.isBlockedAuthor(viewerID, comment) {
 			filtered = append(filtered, comment)
 		}
 	}
@@ -56,9 +90,9 @@ func (c *CommentMemoryStorage) GetCommentsByPostID(ctx context.Context, limit, o
 		return []*models.Comment{}, nil
 	}
 
-	// Аналог order by
+	// Аналог order by: закреплённые комментарии всегда идут первыми
 	sort.Slice(filtered, func(i, j int) bool {
-		return filtered[i].CreatedAt.After(filtered[j].CreatedAt)
+		return commentLess(filtered[i], filtered[j])
 	})
 
 	filtered = filtered[offset:]
@@ -72,13 +106,13 @@ func (c *CommentMemoryStorage) GetCommentsByPostID(ctx context.Context, limit, o
 	return result, nil
 }
 
-func (c *CommentMemoryStorage) Replies(ctx context.Context, commentID, limit, offset int) ([]*models.Comment, error) {
+func (c *CommentMemoryStorage) Replies(ctx context.Context, commentID, limit, offset, viewerID int) ([]*models.Comment, error) {
 	c.storage.mu.RLock()
 	defer c.storage.mu.RUnlock()
 
 	filtered := make([]*models.Comment, 0)
 	for _, comment := range c.storage.comments {
-		if comment.ReplyTo != nil && *comment.ReplyTo == commentID {
+		if comment.ReplyTo != nil && *comment.ReplyTo == commentID && !comment.IsHidden && !c.storage.isBlockedAuthor(viewerID, comment) {
 			filtered = append(filtered, comment)
 		}
 	}
@@ -87,7 +121,7 @@ func (c *CommentMemoryStorage) Replies(ctx context.Context, commentID, limit, of
 	}
 
 	sort.Slice(filtered, func(i, j int) bool {
-		return filtered[i].CreatedAt.After(filtered[j].CreatedAt)
+		return commentLess(filtered[i], filtered[j])
 	})
 
 	filtered = filtered[offset:]
@@ -99,3 +133,128 @@ func (c *CommentMemoryStorage) Replies(ctx context.Context, commentID, limit, of
 
 	return result, nil
 }
+
+func (c *CommentMemoryStorage) GetCommentByID(ctx context.Context, commentID int) (*models.Comment, error) {
+	c.storage.mu.RLock()
+	defer c.storage.mu.RUnlock()
+
+	comment, ok := c.storage.comments[commentID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return comment, nil
+}
+
+func (c *CommentMemoryStorage) SetCommentPinned(ctx context.Context, commentID int, pinned bool) (*models.Comment, error) {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+
+	comment, ok := c.storage.comments[commentID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	updated := *comment
+	updated.IsPinned = pinned
+	return c.saveComment(&change{Comments: []*models.Comment{&updated}})
+}
+
+func (c *CommentMemoryStorage) LockThread(ctx context.Context, commentID int) (*models.Comment, error) {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+
+	comment, ok := c.storage.comments[commentID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	updated := *comment
+	updated.IsLocked = true
+	return c.saveComment(&change{Comments: []*models.Comment{&updated}})
+}
+
+func (c *CommentMemoryStorage) IsThreadLocked(ctx context.Context, commentID int) (bool, error) {
+	c.storage.mu.RLock()
+	defer c.storage.mu.RUnlock()
+
+	// Поднимаемся по цепочке ответов до корневого комментария
+	for {
+		comment, ok := c.storage.comments[commentID]
+		if !ok {
+			return false, nil
+		}
+		if comment.IsLocked {
+			return true, nil
+		}
+		if comment.ReplyTo == nil {
+			return false, nil
+		}
+		commentID = *comment.ReplyTo
+	}
+}
+
+// UpdateComment сохраняет правку. Если задан hold, комментарий в том же изменении скрывается и ставится в очередь модерации
+func (c *CommentMemoryStorage) UpdateComment(ctx context.Context, commentID int, payload string, editorID int, hold *models.ModerationItem) (*models.Comment, error) {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+
+	comment, ok := c.storage.comments[commentID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	now := time.Now()
+	revision := c.storage.newRevision(revisionTargetComment, commentID, nil, comment.Payload, editorID, now)
+
+	updated := *comment
+	updated.Payload = payload
+	updated.EditedAt = &now
+
+	ch := &change{Comments: []*models.Comment{&updated}, Revisions: []*revisionRecord{revision}}
+	if hold != nil {
+		updated.IsHidden = true
+		c.storage.addModerationItem(ch, hold, !comment.IsHidden, now)
+	}
+	return c.saveComment(ch)
+}
+
+func (c *CommentMemoryStorage) GetCommentRevisions(ctx context.Context, commentID int) ([]*models.Revision, error) {
+	c.storage.mu.RLock()
+	defer c.storage.mu.RUnlock()
+
+	return c.storage.revisionsOf(revisionTargetComment, commentID), nil
+}
+
+func (c *CommentMemoryStorage) SaveMentions(ctx context.Context, commentID int, userIDs []int) error {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+
+	return c.storage.commit(&change{Mentions: []*mentionRecord{{CommentID: commentID, UserIDs: append([]int(nil), userIDs...)}}})
+}
+
+func (c *CommentMemoryStorage) GetCommentMentions(ctx context.Context, commentID int) ([]*models.User, error) {
+	c.storage.mu.RLock()
+	defer c.storage.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(c.storage.mentions[commentID]))
+	for _, userID := range c.storage.mentions[commentID] {
+		if user, ok := c.storage.users[userID]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// saveComment применяет изменение с одним комментарием и возвращает его копию. Вызывается под блокировкой на запись
+func (c *CommentMemoryStorage) saveComment(ch *change) (*models.Comment, error) {
+	if err := c.storage.commit(ch); err != nil {
+		return nil, err
+	}
+	result := *ch.Comments[0]
+	return &result, nil
+}
+
+func commentLess(a, b *models.Comment) bool {
+	if a.IsPinned != b.IsPinned {
+		return a.IsPinned
+	}
+	return a.CreatedAt.After(b.CreatedAt)
+}
@@ -0,0 +1,132 @@
+package in_memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCommentMemoryStorage_PinnedFirst(t *testing.T) {
+	storage := NewInMemoryStorage()
+	commentStorage := NewCommentMemoryStorage(zap.NewNop(), storage)
+
+	now := time.Now()
+	storage.comments[1] = &models.Comment{ID: 1, PostID: 1, CreatedAt: now.Add(-3 * time.Hour)}
+	storage.comments[2] = &models.Comment{ID: 2, PostID: 1, CreatedAt: now.Add(-2 * time.Hour)}
+	storage.comments[3] = &models.Comment{ID: 3, PostID: 1, CreatedAt: now.Add(-1 * time.Hour)}
+
+	_, err := commentStorage.SetCommentPinned(context.Background(), 1, true)
+	require.NoError(t, err)
+
+	comments, err := commentStorage.GetCommentsByPostID(context.Background(), 10, 0, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, comments, 3)
+	assert.Equal(t, 1, comments[0].ID, "закреплённый комментарий должен быть первым")
+	assert.Equal(t, 3, comments[1].ID)
+	assert.Equal(t, 2, comments[2].ID)
+}
+
+func TestCommentMemoryStorage_IsThreadLocked(t *testing.T) {
+	storage := NewInMemoryStorage()
+	commentStorage := NewCommentMemoryStorage(zap.NewNop(), storage)
+
+	root, child := 1, 2
+	storage.comments[1] = &models.Comment{ID: 1, PostID: 1}
+	storage.comments[2] = &models.Comment{ID: 2, PostID: 1, ReplyTo: &root}
+	storage.comments[3] = &models.Comment{ID: 3, PostID: 1, ReplyTo: &child}
+	storage.comments[4] = &models.Comment{ID: 4, PostID: 1}
+
+	locked, err := commentStorage.IsThreadLocked(context.Background(), 3)
+	require.NoError(t, err)
+	assert.False(t, locked)
+
+	_, err = commentStorage.LockThread(context.Background(), 1)
+	require.NoError(t, err)
+
+	locked, err = commentStorage.IsThreadLocked(context.Background(), 3)
+	require.NoError(t, err)
+	assert.True(t, locked, "блокировка предка распространяется на всю ветку")
+
+	locked, err = commentStorage.IsThreadLocked(context.Background(), 4)
+	require.NoError(t, err)
+	assert.False(t, locked)
+}
+
+func TestCommentMemoryStorage_UpdateComment(t *testing.T) {
+	storage := NewInMemoryStorage()
+	commentStorage := NewCommentMemoryStorage(zap.NewNop(), storage)
+	storage.posts[1] = &models.Post{ID: 1}
+
+	comment, err := commentStorage.CreateComment(context.Background(), models.NewComment{PostID: 1, AuthorID: 1, Payload: "v1"}, false, nil)
+	require.NoError(t, err)
+	require.NotNil(t, comment.Author)
+	assert.Nil(t, comment.EditedAt)
+
+	_, err = commentStorage.UpdateComment(context.Background(), comment.ID, "v2", 1, nil)
+	require.NoError(t, err)
+	updated, err := commentStorage.UpdateComment(context.Background(), comment.ID, "v3", 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "v3", updated.Payload)
+	assert.NotNil(t, updated.EditedAt)
+
+	revisions, err := commentStorage.GetCommentRevisions(context.Background(), comment.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 1, revisions[0].Version)
+	assert.Equal(t, "v1", revisions[0].Payload)
+	assert.Equal(t, 1, revisions[0].Editor.ID)
+	assert.Equal(t, 2, revisions[1].Version)
+	assert.Equal(t, "v2", revisions[1].Payload)
+	assert.Equal(t, 2, revisions[1].Editor.ID, "вторую правку сделал модератор")
+
+	_, err = commentStorage.UpdateComment(context.Background(), 999, "x", 1, nil)
+	assert.Error(t, err)
+}
+
+func TestCommentMemoryStorage_BlockedAuthors(t *testing.T) {
+	storage := NewInMemoryStorage()
+	commentStorage := NewCommentMemoryStorage(zap.NewNop(), storage)
+	userStorage := NewUserMemoryStorage(zap.NewNop(), storage)
+	ctx := context.Background()
+	storage.posts[1] = &models.Post{ID: 1}
+
+	parent, err := commentStorage.CreateComment(ctx, models.NewComment{PostID: 1, AuthorID: 1, Payload: "parent"}, false, nil)
+	require.NoError(t, err)
+	_, err = commentStorage.CreateComment(ctx, models.NewComment{PostID: 1, AuthorID: 3, Payload: "top"}, false, nil)
+	require.NoError(t, err)
+	_, err = commentStorage.CreateComment(ctx, models.NewComment{PostID: 1, AuthorID: 3, Payload: "reply", ReplyTo: &parent.ID}, false, nil)
+	require.NoError(t, err)
+
+	changed, err := userStorage.BlockUser(ctx, 1, 3)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = userStorage.BlockUser(ctx, 1, 3)
+	require.NoError(t, err)
+	assert.False(t, changed, "повторная блокировка ничего не меняет")
+
+	comments, err := commentStorage.GetCommentsByPostID(ctx, 10, 0, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, parent.ID, comments[0].ID)
+
+	replies, err := commentStorage.Replies(ctx, parent.ID, 10, 0, 1)
+	require.NoError(t, err)
+	assert.Empty(t, replies)
+
+	// Другие зрители видят все комментарии
+	comments, err = commentStorage.GetCommentsByPostID(ctx, 10, 0, 1, 2)
+	require.NoError(t, err)
+	assert.Len(t, comments, 2)
+
+	changed, err = userStorage.UnblockUser(ctx, 1, 3)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	replies, err = commentStorage.Replies(ctx, parent.ID, 10, 0, 1)
+	require.NoError(t, err)
+	assert.Len(t, replies, 1)
+}
@@ -0,0 +1,22 @@
+package in_memory
+
+import (
+	"testing"
+
+	"github.com/Quizert/PostCommentService/internal/storage/storagetest"
+	"go.uber.org/zap"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Providers {
+		storage := NewInMemoryStorage()
+		return storagetest.Providers{
+			Posts:      NewPostMemoryStorage(zap.NewNop(), storage),
+			Comments:   NewCommentMemoryStorage(zap.NewNop(), storage),
+			Users:      NewUserMemoryStorage(zap.NewNop(), storage),
+			Outbox:     NewOutboxMemoryStorage(zap.NewNop(), storage),
+			Webhooks:   NewWebhookMemoryStorage(zap.NewNop(), storage),
+			Moderation: NewModerationMemoryStorage(zap.NewNop(), storage),
+		}
+	})
+}
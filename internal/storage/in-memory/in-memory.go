@@ -3,16 +3,61 @@ package in_memory
 import (
 	"github.com/Quizert/PostCommentService/internal/models"
 	"sync"
+	"time"
 )
 
+const (
+	revisionTargetPost    = "POST"
+	revisionTargetComment = "COMMENT"
+)
+
+// revisionRecord - прошлая версия поста или комментария
+type revisionRecord struct {
+	TargetType string           `json:"targetType"`
+	TargetID   int              `json:"targetID"`
+	Revision   *models.Revision `json:"revision"`
+}
+
+// postMuteKey - пользователь, которому запрещено комментировать пост
+type postMuteKey struct {
+	postID int
+	userID int
+}
+
 type InMemoryStorage struct {
-	posts    map[int]*models.Post
-	comments map[int]*models.Comment
-	users    map[int]*models.User
+	posts     map[int]*models.Post
+	comments  map[int]*models.Comment
+	users     map[int]*models.User
+	revisions []*revisionRecord
+	// mentions - id упомянутых пользователей по id комментария
+	mentions      map[int][]int
+	notifications []*models.Notification
+	moderation    []*models.ModerationItem
+	reports       []*models.Report
+	bans          map[int]*models.UserBan
+	mutes         map[postMuteKey]*models.PostMute
+	// blocks - id заблокированных пользователей по id заблокировавшего
+	blocks map[int]map[int]bool
+	// outbox - события, упорядоченные по id
+	outbox []*models.OutboxEvent
+	// webhooks и webhookDeliveries упорядочены по id
+	webhooks          []*models.Webhook
+	webhookDeliveries []*models.WebhookDelivery
+
+	nextPostID         int
+	nextCommentID      int
+	nextUserID         int
+	nextRevisionID     int
+	nextNotificationID int
+	nextModerationID   int
+	nextReportID       int
+	nextOutboxID       int
 
-	nextPostID    int
-	nextCommentID int
-	nextUserID    int
+	nextWebhookID         int
+	nextWebhookDeliveryID int
+
+	// persist - журнал и снапшоты, nil если хранилище живёт только в памяти
+	persist *persistence
 
 	mu sync.RWMutex
 }
@@ -22,25 +67,126 @@ func NewInMemoryStorage() *InMemoryStorage {
 		posts:         make(map[int]*models.Post),
 		comments:      make(map[int]*models.Comment),
 		users:         make(map[int]*models.User),
+		mentions:      make(map[int][]int),
+		bans:          make(map[int]*models.UserBan),
+		mutes:         make(map[postMuteKey]*models.PostMute),
+		blocks:        make(map[int]map[int]bool),
 		nextPostID:    1,
 		nextCommentID: 1,
 		nextUserID:    4,
+
+		nextRevisionID:     1,
+		nextNotificationID: 1,
+		nextModerationID:   1,
+		nextReportID:       1,
+		nextOutboxID:       1,
+
+		nextWebhookID:         1,
+		nextWebhookDeliveryID: 1,
 	}
 
 	user1 := &models.User{
 		ID:       1,
 		Username: "Alice",
+		Role:     models.UserRoleUser,
 	}
 	user2 := &models.User{
 		ID:       2,
 		Username: "Quizert",
+		Role:     models.UserRoleUser,
 	}
 	user3 := &models.User{
 		ID:       3,
 		Username: "Alen",
+		Role:     models.UserRoleUser,
 	}
 	storage.users[user1.ID] = user1
 	storage.users[user2.ID] = user2
 	storage.users[user3.ID] = user3
 	return storage
 }
+
+// newRevision создаёт версию контента до правки. Вызывается под блокировкой на запись
+func (s *InMemoryStorage) newRevision(targetType string, targetID int, title *string, payload string, editorID int, editedAt time.Time) *revisionRecord {
+	version := 1
+	for _, record := range s.revisions {
+		if record.TargetType == targetType && record.TargetID == targetID {
+			version++
+		}
+	}
+
+	return &revisionRecord{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Revision: &models.Revision{
+			ID:       s.nextRevisionID,
+			Version:  version,
+			Title:    title,
+			Payload:  payload,
+			Editor:   s.users[editorID],
+			EditedAt: editedAt,
+		},
+	}
+}
+
+// revisionsOf возвращает версии контента в порядке их создания. Вызывается под блокировкой на чтение
+func (s *InMemoryStorage) revisionsOf(targetType string, targetID int) []*models.Revision {
+	revisions := make([]*models.Revision, 0)
+	for _, record := range s.revisions {
+		if record.TargetType == targetType && record.TargetID == targetID {
+			revisions = append(revisions, record.Revision)
+		}
+	}
+	return revisions
+}
+
+// setHidden добавляет в изменение скрытый или возвращённый в выдачу пост или комментарий. Вызывается под блокировкой на запись
+// published сообщает, был ли контент опубликован до изменения: не скрыт, а пост ещё и в статусе PUBLISHED
+func (s *InMemoryStorage) setHidden(c *change, targetType models.TargetType, targetID int, hidden bool) (published bool, ok bool) {
+	switch targetType {
+	case models.TargetTypePost:
+		if post, ok := s.posts[targetID]; ok {
+			updated := *post
+			updated.IsHidden = hidden
+			c.Posts = append(c.Posts, &updated)
+			return !post.IsHidden && post.Status == models.PostStatusPublished, true
+		}
+	case models.TargetTypeComment:
+		if comment, ok := s.comments[targetID]; ok {
+			updated := *comment
+			updated.IsHidden = hidden
+			c.Comments = append(c.Comments, &updated)
+			return !comment.IsHidden, true
+		}
+	}
+	return false, false
+}
+
+// addModerationItem добавляет в изменение элемент очереди модерации. published - был ли контент
+// опубликован до того, как его скрыли. Вызывается под блокировкой на запись
+func (s *InMemoryStorage) addModerationItem(c *change, item *models.ModerationItem, published bool, createdAt time.Time) *models.ModerationItem {
+	stored := *item
+	stored.ID = s.nextModerationID + len(c.Moderation)
+	stored.Status = models.ModerationStatusPending
+	stored.WasPublished = published
+	stored.CreatedAt = createdAt
+	c.Moderation = append(c.Moderation, &stored)
+	return &stored
+}
+
+// addOutboxEvent добавляет в изменение событие outbox. События одного изменения получают
+// последовательные id. Вызывается под блокировкой на запись
+func (s *InMemoryStorage) addOutboxEvent(c *change, eventType models.OutboxEventType, aggregateID int, createdAt time.Time) {
+	c.Outbox = append(c.Outbox, &models.OutboxEvent{
+		ID:            s.nextOutboxID + len(c.Outbox),
+		Type:          eventType,
+		AggregateID:   aggregateID,
+		NextAttemptAt: createdAt,
+		CreatedAt:     createdAt,
+	})
+}
+
+// isBlockedAuthor сообщает, заблокировал ли зритель автора комментария. Вызывается под блокировкой на чтение
+func (s *InMemoryStorage) isBlockedAuthor(viewerID int, comment *models.Comment) bool {
+	return comment.Author != nil && s.blocks[viewerID][comment.Author.ID]
+}
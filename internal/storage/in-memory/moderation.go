@@ -0,0 +1,224 @@
+package in_memory
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+type ModerationMemoryStorage struct {
+	log     *zap.Logger
+	storage *InMemoryStorage
+}
+
+func NewModerationMemoryStorage(log *zap.Logger, storage *InMemoryStorage) *ModerationMemoryStorage {
+	return &ModerationMemoryStorage{
+		log:     log,
+		storage: storage,
+	}
+}
+
+func (m *ModerationMemoryStorage) EnqueueModeration(ctx context.Context, item *models.ModerationItem) (*models.ModerationItem, error) {
+	m.storage.mu.Lock()
+	defer m.storage.mu.Unlock()
+
+	c := &change{}
+	published, ok := m.storage.setHidden(c, item.TargetType, item.TargetID, true)
+	if !ok {
+		m.log.Warn("Failed to enqueue moderation: content not found",
+			zap.String("Layer", "ModerationMemoryStorage.EnqueueModeration"),
+			zap.String("TargetType", string(item.TargetType)),
+			zap.Int("TargetID", item.TargetID),
+		)
+		return nil, storage.ErrNotFound
+	}
+
+	stored := m.storage.addModerationItem(c, item, published, time.Now())
+	if err := m.storage.commit(c); err != nil {
+		return nil, err
+	}
+	result := *stored
+	return &result, nil
+}
+
+func (m *ModerationMemoryStorage) GetModerationQueue(ctx context.Context, status models.ModerationStatus, limit int, after int) ([]*models.ModerationItem, error) {
+	m.storage.mu.RLock()
+	defer m.storage.mu.RUnlock()
+
+	// Элементы хранятся в порядке создания, id растут
+	items := make([]*models.ModerationItem, 0, limit)
+	for _, item := range m.storage.moderation {
+		if len(items) == limit {
+			break
+		}
+		if item.Status != status || item.ID <= after {
+			continue
+		}
+		result := *item
+		items = append(items, &result)
+	}
+	return items, nil
+}
+
+func (m *ModerationMemoryStorage) GetModerationItem(ctx context.Context, itemID int) (*models.ModerationItem, error) {
+	m.storage.mu.RLock()
+	defer m.storage.mu.RUnlock()
+
+	item := m.findItem(itemID)
+	if item == nil {
+		return nil, storage.ErrNotFound
+	}
+	result := *item
+	return &result, nil
+}
+
+func (m *ModerationMemoryStorage) ResolveModerationItem(ctx context.Context, itemID int, status models.ModerationStatus, moderatorID int, reason *string) (*models.ModerationItem, error) {
+	m.storage.mu.Lock()
+	defer m.storage.mu.Unlock()
+
+	item := m.findItem(itemID)
+	if item == nil || item.Status != models.ModerationStatusPending {
+		return nil, storage.ErrNotFound
+	}
+
+	now := time.Now()
+	updated := *item
+	updated.Status = status
+	updated.Moderator = m.storage.users[moderatorID]
+	updated.DecisionReason = reason
+	updated.DecidedAt = &now
+
+	c := &change{Moderation: []*models.ModerationItem{&updated}}
+	if status == models.ModerationStatusApproved {
+		// Событие о публикации пишется, только если контент публикуется впервые: повторно одобренный после жалоб
+		// или правки контент и контент, уже показанный по другому элементу очереди, подписчикам не рассылается
+		published, ok := m.storage.setHidden(c, updated.TargetType, updated.TargetID, false)
+		if published {
+			updated.WasPublished = true
+		}
+		if ok && !updated.WasPublished {
+			switch updated.TargetType {
+			case models.TargetTypeComment:
+				m.storage.addOutboxEvent(c, models.OutboxEventCommentPublished, updated.TargetID, now)
+			case models.TargetTypePost:
+				// Пост становится виден всем, только если он уже опубликован
+				if m.storage.posts[updated.TargetID].Status == models.PostStatusPublished {
+					m.storage.addOutboxEvent(c, models.OutboxEventPostPublished, updated.TargetID, now)
+				}
+			}
+		}
+	}
+
+	if err := m.storage.commit(c); err != nil {
+		return nil, err
+	}
+	result := updated
+	return &result, nil
+}
+
+func (m *ModerationMemoryStorage) CreateReport(ctx context.Context, report *models.Report) (*models.Report, int, error) {
+	m.storage.mu.Lock()
+	defer m.storage.mu.Unlock()
+
+	count := 0
+	duplicate := false
+	for _, stored := range m.storage.reports {
+		if stored.TargetType != report.TargetType || stored.TargetID != report.TargetID {
+			continue
+		}
+		count++
+		if stored.Reporter.ID == report.Reporter.ID {
+			duplicate = true
+		}
+	}
+	if duplicate {
+		return nil, count, nil
+	}
+
+	stored := *report
+	stored.ID = m.storage.nextReportID
+	stored.CreatedAt = time.Now()
+
+	if err := m.storage.commit(&change{Reports: []*models.Report{&stored}}); err != nil {
+		return nil, 0, err
+	}
+	result := stored
+	return &result, count + 1, nil
+}
+
+func (m *ModerationMemoryStorage) GetReportSummaries(ctx context.Context, limit int, offset int) ([]*models.ReportSummary, error) {
+	m.storage.mu.RLock()
+	defer m.storage.mu.RUnlock()
+
+	type target struct {
+		targetType models.TargetType
+		targetID   int
+	}
+	summaries := make([]*models.ReportSummary, 0)
+	byTarget := make(map[target]*models.ReportSummary)
+	byReason := make(map[target]map[models.ReportReason]*models.ReportReasonCount)
+	for _, report := range m.storage.reports {
+		key := target{report.TargetType, report.TargetID}
+		summary, ok := byTarget[key]
+		if !ok {
+			summary = &models.ReportSummary{
+				TargetType: report.TargetType,
+				TargetID:   report.TargetID,
+				Reasons:    make([]*models.ReportReasonCount, 0),
+			}
+			byTarget[key] = summary
+			byReason[key] = make(map[models.ReportReason]*models.ReportReasonCount)
+			summaries = append(summaries, summary)
+		}
+		summary.Count++
+		if report.CreatedAt.After(summary.LastReportedAt) {
+			summary.LastReportedAt = report.CreatedAt
+		}
+
+		reason, ok := byReason[key][report.Reason]
+		if !ok {
+			reason = &models.ReportReasonCount{Reason: report.Reason}
+			byReason[key][report.Reason] = reason
+			summary.Reasons = append(summary.Reasons, reason)
+		}
+		reason.Count++
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].Count != summaries[j].Count {
+			return summaries[i].Count > summaries[j].Count
+		}
+		return summaries[i].LastReportedAt.After(summaries[j].LastReportedAt)
+	})
+	for _, summary := range summaries {
+		reasons := summary.Reasons
+		sort.SliceStable(reasons, func(i, j int) bool {
+			if reasons[i].Count != reasons[j].Count {
+				return reasons[i].Count > reasons[j].Count
+			}
+			return reasons[i].Reason < reasons[j].Reason
+		})
+	}
+
+	if offset >= len(summaries) {
+		return []*models.ReportSummary{}, nil
+	}
+	end := offset + limit
+	if end > len(summaries) {
+		end = len(summaries)
+	}
+	return summaries[offset:end], nil
+}
+
+// findItem ищет элемент очереди по id. Вызывается под блокировкой
+func (m *ModerationMemoryStorage) findItem(itemID int) *models.ModerationItem {
+	for _, item := range m.storage.moderation {
+		if item.ID == itemID {
+			return item
+		}
+	}
+	return nil
+}
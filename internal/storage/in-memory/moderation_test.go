@@ -0,0 +1,220 @@
+package in_memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestModerationMemoryStorage_HoldAndApprove(t *testing.T) {
+	storage := NewInMemoryStorage()
+	commentStorage := NewCommentMemoryStorage(zap.NewNop(), storage)
+	moderationStorage := NewModerationMemoryStorage(zap.NewNop(), storage)
+	ctx := context.Background()
+	storage.posts[1] = &models.Post{ID: 1}
+
+	visible, err := commentStorage.CreateComment(ctx, models.NewComment{PostID: 1, AuthorID: 1, Payload: "ok"}, false, nil)
+	require.NoError(t, err)
+	held, err := commentStorage.CreateComment(ctx, models.NewComment{PostID: 1, AuthorID: 3, Payload: "held"}, true, nil)
+	require.NoError(t, err)
+
+	item, err := moderationStorage.EnqueueModeration(ctx, &models.ModerationItem{
+		TargetType: models.TargetTypeComment,
+		TargetID:   held.ID,
+		Payload:    held.Payload,
+		Author:     storage.users[3],
+		Reason:     "spam",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.ModerationStatusPending, item.Status)
+
+	comments, err := commentStorage.GetCommentsByPostID(ctx, 10, 0, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, visible.ID, comments[0].ID, "отложенный комментарий не виден")
+
+	queue, err := moderationStorage.GetModerationQueue(ctx, models.ModerationStatusPending, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+
+	resolved, err := moderationStorage.ResolveModerationItem(ctx, item.ID, models.ModerationStatusApproved, 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Quizert", resolved.Moderator.Username)
+	assert.NotNil(t, resolved.DecidedAt)
+
+	comments, err = commentStorage.GetCommentsByPostID(ctx, 10, 0, 1, 0)
+	require.NoError(t, err)
+	assert.Len(t, comments, 2, "одобренный комментарий снова виден")
+
+	events, err := NewOutboxMemoryStorage(zap.NewNop(), storage).ClaimOutboxEvents(ctx, time.Now(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, []int{visible.ID, held.ID}, []int{events[0].AggregateID, events[1].AggregateID}, "одобренный комментарий публикуется через outbox")
+
+	_, err = moderationStorage.ResolveModerationItem(ctx, item.ID, models.ModerationStatusRejected, 2, nil)
+	assert.Error(t, err, "разобранный элемент нельзя разобрать повторно")
+
+	queue, err = moderationStorage.GetModerationQueue(ctx, models.ModerationStatusPending, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, queue)
+}
+
+func TestModerationMemoryStorage_Reports(t *testing.T) {
+	storage := NewInMemoryStorage()
+	moderationStorage := NewModerationMemoryStorage(zap.NewNop(), storage)
+	ctx := context.Background()
+
+	report := func(targetType models.TargetType, targetID, reporterID int, reason models.ReportReason) (*models.Report, int) {
+		created, count, err := moderationStorage.CreateReport(ctx, &models.Report{
+			TargetType: targetType,
+			TargetID:   targetID,
+			Reason:     reason,
+			Reporter:   storage.users[reporterID],
+		})
+		require.NoError(t, err)
+		return created, count
+	}
+
+	created, count := report(models.TargetTypeComment, 1, 1, models.ReportReasonSpam)
+	require.NotNil(t, created)
+	assert.Equal(t, 1, count)
+	_, count = report(models.TargetTypeComment, 1, 2, models.ReportReasonAbuse)
+	assert.Equal(t, 2, count)
+	_, count = report(models.TargetTypeComment, 1, 3, models.ReportReasonSpam)
+	assert.Equal(t, 3, count)
+
+	duplicate, count := report(models.TargetTypeComment, 1, 1, models.ReportReasonOther)
+	assert.Nil(t, duplicate, "повторная жалоба не сохраняется")
+	assert.Equal(t, 3, count)
+
+	_, count = report(models.TargetTypePost, 1, 1, models.ReportReasonSpam)
+	assert.Equal(t, 1, count, "жалобы на пост и комментарий с одним id считаются отдельно")
+
+	summaries, err := moderationStorage.GetReportSummaries(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+
+	assert.Equal(t, models.TargetTypeComment, summaries[0].TargetType)
+	assert.Equal(t, 3, summaries[0].Count)
+	assert.Equal(t, []*models.ReportReasonCount{
+		{Reason: models.ReportReasonSpam, Count: 2},
+		{Reason: models.ReportReasonAbuse, Count: 1},
+	}, summaries[0].Reasons)
+	assert.Equal(t, models.TargetTypePost, summaries[1].TargetType)
+
+	summaries, err = moderationStorage.GetReportSummaries(ctx, 10, 1)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, models.TargetTypePost, summaries[0].TargetType)
+}
+
+func TestModerationMemoryStorage_ApprovePost(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      models.PostStatus
+		expectEvent bool
+	}{
+		{
+			name:        "published post is announced",
+			status:      models.PostStatusPublished,
+			expectEvent: true,
+		},
+		{
+			name:   "draft stays unannounced",
+			status: models.PostStatusDraft,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewInMemoryStorage()
+			moderationStorage := NewModerationMemoryStorage(zap.NewNop(), storage)
+			ctx := context.Background()
+
+			post, err := NewPostMemoryStorage(zap.NewNop(), storage).CreatePost(ctx, models.NewPost{Title: "held", AuthorID: 3, Status: &tt.status}, true)
+			require.NoError(t, err)
+			item, err := moderationStorage.EnqueueModeration(ctx, &models.ModerationItem{
+				TargetType: models.TargetTypePost,
+				TargetID:   post.ID,
+				Author:     storage.users[3],
+				Reason:     "spam",
+			})
+			require.NoError(t, err)
+
+			_, err = moderationStorage.ResolveModerationItem(ctx, item.ID, models.ModerationStatusApproved, 2, nil)
+			require.NoError(t, err)
+
+			events, err := NewOutboxMemoryStorage(zap.NewNop(), storage).ClaimOutboxEvents(ctx, time.Now(), 10, time.Minute)
+			require.NoError(t, err)
+			if !tt.expectEvent {
+				assert.Empty(t, events)
+				return
+			}
+			require.Len(t, events, 1)
+			assert.Equal(t, models.OutboxEventPostPublished, events[0].Type)
+			assert.Equal(t, post.ID, events[0].AggregateID)
+		})
+	}
+}
+
+func TestModerationMemoryStorage_ApproveAlreadyPublished(t *testing.T) {
+	storage := NewInMemoryStorage()
+	commentStorage := NewCommentMemoryStorage(zap.NewNop(), storage)
+	moderationStorage := NewModerationMemoryStorage(zap.NewNop(), storage)
+	outboxStorage := NewOutboxMemoryStorage(zap.NewNop(), storage)
+	ctx := context.Background()
+	storage.posts[1] = &models.Post{ID: 1}
+
+	claim := func() []int {
+		events, err := outboxStorage.ClaimOutboxEvents(ctx, time.Now(), 10, time.Minute)
+		require.NoError(t, err)
+		ids := make([]int, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.AggregateID)
+			require.NoError(t, outboxStorage.MarkOutboxEventDelivered(ctx, event.ID))
+		}
+		return ids
+	}
+	enqueue := func(comment *models.Comment) *models.ModerationItem {
+		item, err := moderationStorage.EnqueueModeration(ctx, &models.ModerationItem{
+			TargetType: models.TargetTypeComment,
+			TargetID:   comment.ID,
+			Payload:    comment.Payload,
+			Author:     storage.users[3],
+			Reason:     "reported",
+		})
+		require.NoError(t, err)
+		return item
+	}
+	approve := func(item *models.ModerationItem) *models.ModerationItem {
+		resolved, err := moderationStorage.ResolveModerationItem(ctx, item.ID, models.ModerationStatusApproved, 2, nil)
+		require.NoError(t, err)
+		return resolved
+	}
+
+	visible, err := commentStorage.CreateComment(ctx, models.NewComment{PostID: 1, AuthorID: 3, Payload: "ok"}, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{visible.ID}, claim())
+
+	reported := enqueue(visible)
+	assert.True(t, reported.WasPublished, "скрытый по жалобам контент уже был опубликован")
+	assert.True(t, approve(reported).WasPublished)
+	assert.Empty(t, claim(), "повторное одобрение не публикует контент заново")
+
+	held, err := commentStorage.CreateComment(ctx, models.NewComment{PostID: 1, AuthorID: 3, Payload: "held"}, true, nil)
+	require.NoError(t, err)
+	first := enqueue(held)
+	second := enqueue(held)
+	assert.False(t, first.WasPublished)
+	assert.False(t, second.WasPublished)
+
+	assert.False(t, approve(first).WasPublished)
+	assert.Equal(t, []int{held.ID}, claim())
+	assert.True(t, approve(second).WasPublished, "контент уже показан по первому элементу")
+	assert.Empty(t, claim())
+}
@@ -0,0 +1,96 @@
+package in_memory
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"go.uber.org/zap"
+	"time"
+)
+
+type NotificationMemoryStorage struct {
+	log     *zap.Logger
+	storage *InMemoryStorage
+}
+
+func NewNotificationMemoryStorage(log *zap.Logger, storage *InMemoryStorage) *NotificationMemoryStorage {
+	return &NotificationMemoryStorage{
+		log:     log,
+		storage: storage,
+	}
+}
+
+func (n *NotificationMemoryStorage) CreateNotifications(ctx context.Context, notifications []*models.Notification) ([]*models.Notification, error) {
+	n.storage.mu.Lock()
+	defer n.storage.mu.Unlock()
+
+	now := time.Now()
+	c := &change{}
+	created := make([]*models.Notification, 0, len(notifications))
+	for i, notification := range notifications {
+		stored := *notification
+		stored.ID = n.storage.nextNotificationID + i
+		stored.IsRead = false
+		stored.CreatedAt = now
+
+		c.Notifications = append(c.Notifications, &notificationRecord{Notification: &stored, RecipientID: stored.RecipientID})
+		result := stored
+		created = append(created, &result)
+	}
+
+	if err := n.storage.commit(c); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (n *NotificationMemoryStorage) GetNotifications(ctx context.Context, userID int, unreadOnly bool, limit int, after int) ([]*models.Notification, error) {
+	n.storage.mu.RLock()
+	defer n.storage.mu.RUnlock()
+
+	// Уведомления хранятся в порядке создания, поэтому идём с конца
+	notifications := make([]*models.Notification, 0, limit)
+	for i := len(n.storage.notifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+		notification := n.storage.notifications[i]
+		if notification.RecipientID != userID || (unreadOnly && notification.IsRead) {
+			continue
+		}
+		if after > 0 && notification.ID >= after {
+			continue
+		}
+		// Отдаём копию, чтобы последующие изменения не затрагивали уже выданные данные
+		result := *notification
+		notifications = append(notifications, &result)
+	}
+	return notifications, nil
+}
+
+func (n *NotificationMemoryStorage) MarkNotificationsRead(ctx context.Context, userID int, ids []int) (int, error) {
+	n.storage.mu.Lock()
+	defer n.storage.mu.Unlock()
+
+	selected := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	c := &change{}
+	for _, notification := range n.storage.notifications {
+		if notification.RecipientID != userID || notification.IsRead {
+			continue
+		}
+		if len(ids) > 0 && !selected[notification.ID] {
+			continue
+		}
+		updated := *notification
+		updated.IsRead = true
+		c.Notifications = append(c.Notifications, &notificationRecord{Notification: &updated, RecipientID: updated.RecipientID})
+	}
+	if len(c.Notifications) == 0 {
+		return 0, nil
+	}
+
+	if err := n.storage.commit(c); err != nil {
+		return 0, err
+	}
+	return len(c.Notifications), nil
+}
@@ -0,0 +1,68 @@
+package in_memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNotificationMemoryStorage_Pagination(t *testing.T) {
+	storage := NewInMemoryStorage()
+	notificationStorage := NewNotificationMemoryStorage(zap.NewNop(), storage)
+	ctx := context.Background()
+
+	actor := storage.users[3]
+	notifications := make([]*models.Notification, 0)
+	for i := 1; i <= 5; i++ {
+		notifications = append(notifications, &models.Notification{
+			Type:        models.NotificationTypePostComment,
+			Actor:       actor,
+			PostID:      1,
+			CommentID:   i,
+			RecipientID: 1,
+		})
+	}
+	notifications = append(notifications, &models.Notification{
+		Type:        models.NotificationTypeReply,
+		Actor:       actor,
+		PostID:      1,
+		CommentID:   6,
+		RecipientID: 2,
+	})
+	created, err := notificationStorage.CreateNotifications(ctx, notifications)
+	require.NoError(t, err)
+	require.Len(t, created, 6)
+	assert.Equal(t, 1, created[0].ID)
+
+	page, err := notificationStorage.GetNotifications(ctx, 1, false, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, 5, page[0].ID, "новые уведомления идут первыми")
+	assert.Equal(t, 4, page[1].ID)
+
+	page, err = notificationStorage.GetNotifications(ctx, 1, false, 10, page[1].ID)
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	assert.Equal(t, 3, page[0].ID)
+
+	count, err := notificationStorage.MarkNotificationsRead(ctx, 1, []int{5, 4, 6})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "чужие уведомления не помечаются")
+
+	unread, err := notificationStorage.GetNotifications(ctx, 1, true, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, unread, 3)
+	assert.Equal(t, 3, unread[0].ID)
+
+	count, err = notificationStorage.MarkNotificationsRead(ctx, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	unread, err = notificationStorage.GetNotifications(ctx, 1, true, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, unread)
+}
@@ -0,0 +1,119 @@
+package in_memory
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+type OutboxMemoryStorage struct {
+	log     *zap.Logger
+	storage *InMemoryStorage
+}
+
+func NewOutboxMemoryStorage(log *zap.Logger, storage *InMemoryStorage) *OutboxMemoryStorage {
+	return &OutboxMemoryStorage{
+		log:     log,
+		storage: storage,
+	}
+}
+
+func (o *OutboxMemoryStorage) ClaimOutboxEvents(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	o.storage.mu.Lock()
+	defer o.storage.mu.Unlock()
+
+	due := make([]*models.OutboxEvent, 0)
+	for _, event := range o.storage.outbox {
+		if event.DeliveredAt == nil && !event.NextAttemptAt.After(now) {
+			due = append(due, event)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	if len(due) == 0 {
+		return []*models.OutboxEvent{}, nil
+	}
+
+	c := &change{Outbox: make([]*models.OutboxEvent, 0, len(due))}
+	for _, event := range due {
+		claimed := *event
+		claimed.Attempts++
+		claimed.NextAttemptAt = now.Add(lease)
+		c.Outbox = append(c.Outbox, &claimed)
+	}
+	if err := o.storage.commit(c); err != nil {
+		o.log.Error("Failed to claim outbox events", zap.String("Layer", "OutboxMemoryStorage.ClaimOutboxEvents"), zap.Error(err))
+		return nil, err
+	}
+
+	sort.Slice(c.Outbox, func(i, j int) bool { return c.Outbox[i].ID < c.Outbox[j].ID })
+	events := make([]*models.OutboxEvent, 0, len(c.Outbox))
+	for _, event := range c.Outbox {
+		result := *event
+		events = append(events, &result)
+	}
+	return events, nil
+}
+
+func (o *OutboxMemoryStorage) MarkOutboxEventDelivered(ctx context.Context, id int) error {
+	o.storage.mu.Lock()
+	defer o.storage.mu.Unlock()
+
+	event := o.findEvent(id)
+	if event == nil {
+		return storage.ErrNotFound
+	}
+	now := time.Now()
+	updated := *event
+	updated.DeliveredAt = &now
+	updated.LastError = nil
+	return o.storage.commit(&change{Outbox: []*models.OutboxEvent{&updated}})
+}
+
+func (o *OutboxMemoryStorage) RetryOutboxEvent(ctx context.Context, id int, nextAttemptAt time.Time, lastError string) error {
+	o.storage.mu.Lock()
+	defer o.storage.mu.Unlock()
+
+	event := o.findEvent(id)
+	if event == nil || event.DeliveredAt != nil {
+		return storage.ErrNotFound
+	}
+	updated := *event
+	updated.NextAttemptAt = nextAttemptAt
+	updated.LastError = &lastError
+	return o.storage.commit(&change{Outbox: []*models.OutboxEvent{&updated}})
+}
+
+func (o *OutboxMemoryStorage) DeleteDeliveredOutboxEvents(ctx context.Context, before time.Time) (int, error) {
+	o.storage.mu.Lock()
+	defer o.storage.mu.Unlock()
+
+	deleted := make([]int, 0)
+	for _, event := range o.storage.outbox {
+		if event.DeliveredAt != nil && event.DeliveredAt.Before(before) {
+			deleted = append(deleted, event.ID)
+		}
+	}
+	if len(deleted) == 0 {
+		return 0, nil
+	}
+	if err := o.storage.commit(&change{OutboxDeleted: deleted}); err != nil {
+		o.log.Error("Failed to delete delivered outbox events", zap.String("Layer", "OutboxMemoryStorage.DeleteDeliveredOutboxEvents"), zap.Error(err))
+		return 0, err
+	}
+	return len(deleted), nil
+}
+
+// findEvent ищет событие по id. Вызывается под блокировкой
+func (o *OutboxMemoryStorage) findEvent(id int) *models.OutboxEvent {
+	i := sort.Search(len(o.storage.outbox), func(i int) bool { return o.storage.outbox[i].ID >= id })
+	if i < len(o.storage.outbox) && o.storage.outbox[i].ID == id {
+		return o.storage.outbox[i]
+	}
+	return nil
+}
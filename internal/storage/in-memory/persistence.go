@@ -0,0 +1,435 @@
+package in_memory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FsyncPolicy определяет, когда записи журнала сбрасываются на диск
+type FsyncPolicy string
+
+const (
+	// FsyncAlways - fsync после каждой записи, изменения не теряются даже при отключении питания
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval - fsync раз в fsyncInterval, при сбое теряется не больше последней секунды
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever - сброс на диск остаётся на усмотрение ОС
+	FsyncNever FsyncPolicy = "never"
+)
+
+const (
+	fsyncInterval = time.Second
+
+	snapshotFile  = "snapshot.json"
+	segmentPrefix = "wal-"
+	segmentSuffix = ".log"
+)
+
+func ParseFsyncPolicy(value string) (FsyncPolicy, error) {
+	switch policy := FsyncPolicy(value); policy {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown fsync policy %q, expected always, interval or never", value)
+}
+
+type PersistenceConfig struct {
+	// Dir - каталог для снапшота и сегментов журнала
+	Dir   string
+	Fsync FsyncPolicy
+	// SnapshotInterval - как часто писать снапшот, 0 отключает периодические снапшоты
+	SnapshotInterval time.Duration
+}
+
+// snapshot - состояние хранилища на момент начала сегмента Seq. Все более ранние сегменты уже учтены в State
+type snapshot struct {
+	Seq   int     `json:"seq"`
+	State *change `json:"state"`
+}
+
+// persistence пишет журнал изменений и снапшоты хранилища
+type persistence struct {
+	log    *zap.Logger
+	dir    string
+	policy FsyncPolicy
+
+	// mu защищает текущий сегмент: его пишут под блокировкой хранилища, а сбрасывает на диск фоновая горутина
+	mu      sync.Mutex
+	segment *os.File
+	seq     int
+	dirty   bool
+
+	// snapshotMu не даёт двум снапшотам писаться одновременно
+	snapshotMu sync.Mutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// OpenInMemoryStorage восстанавливает хранилище из последнего снапшота и журнала и продолжает писать журнал в cfg.Dir
+func OpenInMemoryStorage(log *zap.Logger, cfg PersistenceConfig) (*InMemoryStorage, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	storage := NewInMemoryStorage()
+	p := &persistence{
+		log:    log,
+		dir:    cfg.Dir,
+		policy: cfg.Fsync,
+		stop:   make(chan struct{}),
+	}
+
+	seq, err := p.loadSnapshot(storage)
+	if err != nil {
+		return nil, err
+	}
+	last, err := p.replay(storage, seq)
+	if err != nil {
+		return nil, err
+	}
+	// Продолжаем с нового сегмента, уже записанные не трогаем
+	if last >= seq {
+		seq = last + 1
+	}
+	if err := p.openSegment(seq); err != nil {
+		return nil, err
+	}
+	storage.persist = p
+
+	if p.policy == FsyncInterval {
+		p.wg.Add(1)
+		go p.syncLoop()
+	}
+	if cfg.SnapshotInterval > 0 {
+		p.wg.Add(1)
+		go p.snapshotLoop(storage, cfg.SnapshotInterval)
+	}
+
+	log.Info("In-memory storage restored",
+		zap.String("Dir", cfg.Dir),
+		zap.Int("Posts", len(storage.posts)),
+		zap.Int("Comments", len(storage.comments)),
+	)
+	return storage, nil
+}
+
+// Snapshot сохраняет состояние хранилища и удаляет сегменты журнала, которые в него вошли.
+// Под блокировкой только копируются ссылки на сущности и переключается сегмент, запись на диск идёт без неё
+func (s *InMemoryStorage) Snapshot() error {
+	if s.persist == nil {
+		return nil
+	}
+	p := s.persist
+	p.snapshotMu.Lock()
+	defer p.snapshotMu.Unlock()
+
+	s.mu.Lock()
+	state := s.state()
+	err := p.openSegment(p.seq + 1)
+	seq := p.seq
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return p.writeSnapshot(&snapshot{Seq: seq, State: state})
+}
+
+// Close останавливает фоновые задачи, пишет финальный снапшот и закрывает журнал
+func (s *InMemoryStorage) Close() error {
+	if s.persist == nil {
+		return nil
+	}
+	p := s.persist
+	close(p.stop)
+	p.wg.Wait()
+
+	snapshotErr := s.Snapshot()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.segment.Sync(); err != nil {
+		return err
+	}
+	if err := p.segment.Close(); err != nil {
+		return err
+	}
+	return snapshotErr
+}
+
+// commit записывает изменение в журнал и применяет его. Вызывается под блокировкой на запись
+func (s *InMemoryStorage) commit(c *change) error {
+	if s.persist != nil {
+		if err := s.persist.append(c); err != nil {
+			return err
+		}
+	}
+	s.apply(c)
+	return nil
+}
+
+func (p *persistence) append(c *change) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("encode wal record: %w", err)
+	}
+	data = append(data, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.segment.Write(data); err != nil {
+		p.log.Error("Failed to write wal record", zap.String("Layer", "persistence.append"), zap.Error(err))
+		return fmt.Errorf("write wal record: %w", err)
+	}
+	if p.policy == FsyncAlways {
+		return p.segment.Sync()
+	}
+	p.dirty = true
+	return nil
+}
+
+// openSegment переключает журнал на сегмент seq. Вызывается под блокировкой хранилища на запись
+func (p *persistence) openSegment(seq int) error {
+	file, err := os.OpenFile(p.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open wal segment: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.segment != nil {
+		// Закрытый сегмент должен оказаться на диске раньше снапшота, который его заменит
+		if p.policy != FsyncNever {
+			if err := p.segment.Sync(); err != nil {
+				file.Close()
+				return fmt.Errorf("sync wal segment: %w", err)
+			}
+		}
+		p.segment.Close()
+	}
+	p.segment = file
+	p.seq = seq
+	p.dirty = false
+	return nil
+}
+
+func (p *persistence) writeSnapshot(snap *snapshot) error {
+	log := p.log.With(
+		zap.String("Layer", "persistence.writeSnapshot"),
+		zap.Int("Seq", snap.Seq),
+	)
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы не оставить на диске половину снапшота
+	tmp := filepath.Join(p.dir, snapshotFile+".tmp")
+	if err := writeFileSync(tmp, data); err != nil {
+		log.Error("Failed to write snapshot", zap.Error(err))
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(p.dir, snapshotFile)); err != nil {
+		log.Error("Failed to rename snapshot", zap.Error(err))
+		return err
+	}
+	if err := syncDir(p.dir); err != nil {
+		return err
+	}
+
+	segments, err := p.segments()
+	if err != nil {
+		return err
+	}
+	for _, seq := range segments {
+		if seq >= snap.Seq {
+			break
+		}
+		if err := os.Remove(p.segmentPath(seq)); err != nil {
+			log.Warn("Failed to remove wal segment", zap.Int("Segment", seq), zap.Error(err))
+		}
+	}
+
+	log.Info("Snapshot written", zap.Int("Bytes", len(data)))
+	return nil
+}
+
+// loadSnapshot применяет снапшот к хранилищу и возвращает номер сегмента, с которого нужно читать журнал
+func (p *persistence) loadSnapshot(storage *InMemoryStorage) (int, error) {
+	data, err := os.ReadFile(filepath.Join(p.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return 0, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snap.State != nil {
+		storage.apply(snap.State)
+	}
+	return snap.Seq, nil
+}
+
+// replay применяет сегменты журнала начиная с from и возвращает номер последнего из них или -1, если их нет
+func (p *persistence) replay(storage *InMemoryStorage, from int) (int, error) {
+	segments, err := p.segments()
+	if err != nil {
+		return 0, err
+	}
+
+	last := -1
+	for i, seq := range segments {
+		if seq < from {
+			continue
+		}
+		if err := p.replaySegment(storage, seq, i == len(segments)-1); err != nil {
+			return 0, err
+		}
+		last = seq
+	}
+	return last, nil
+}
+
+// replaySegment применяет записи сегмента. Недописанная последняя запись в последнем сегменте -
+// след аварийной остановки, её отрезаем. Повреждение в любом другом месте - ошибка
+func (p *persistence) replaySegment(storage *InMemoryStorage, seq int, isLast bool) error {
+	path := p.segmentPath(seq)
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open wal segment: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			return nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("read wal segment: %w", err)
+		}
+
+		var c change
+		if decodeErr := json.Unmarshal(bytes.TrimSpace(line), &c); decodeErr != nil || err != nil {
+			if _, tailErr := reader.Peek(1); !isLast || !errors.Is(tailErr, io.EOF) {
+				return fmt.Errorf("corrupted wal segment %s at offset %d", path, offset)
+			}
+			p.log.Warn("Truncating torn wal record",
+				zap.String("Layer", "persistence.replaySegment"),
+				zap.String("Segment", path),
+				zap.Int64("Offset", offset),
+			)
+			return os.Truncate(path, offset)
+		}
+
+		storage.apply(&c)
+		offset += int64(len(line))
+	}
+}
+
+// segments возвращает номера сегментов журнала по возрастанию
+func (p *persistence) segments() ([]int, error) {
+	paths, err := filepath.Glob(filepath.Join(p.dir, segmentPrefix+"*"+segmentSuffix))
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]int, 0, len(paths))
+	for _, path := range paths {
+		var seq int
+		if _, err := fmt.Sscanf(filepath.Base(path), segmentPrefix+"%d"+segmentSuffix, &seq); err != nil {
+			continue
+		}
+		segments = append(segments, seq)
+	}
+	sort.Ints(segments)
+	return segments, nil
+}
+
+func (p *persistence) segmentPath(seq int) string {
+	return filepath.Join(p.dir, fmt.Sprintf("%s%08d%s", segmentPrefix, seq, segmentSuffix))
+}
+
+func (p *persistence) syncLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(fsyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			if p.dirty {
+				if err := p.segment.Sync(); err != nil {
+					p.log.Error("Failed to sync wal segment", zap.String("Layer", "persistence.syncLoop"), zap.Error(err))
+				}
+				p.dirty = false
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+func (p *persistence) snapshotLoop(storage *InMemoryStorage, interval time.Duration) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if err := storage.Snapshot(); err != nil {
+				p.log.Error("Failed to write snapshot", zap.String("Layer", "persistence.snapshotLoop"), zap.Error(err))
+			}
+		}
+	}
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// syncDir фиксирует на диске переименование файла в каталоге
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
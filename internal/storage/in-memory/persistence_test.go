@@ -0,0 +1,218 @@
+package in_memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func openTestStorage(t *testing.T, dir string) *InMemoryStorage {
+	t.Helper()
+	storage, err := OpenInMemoryStorage(zap.NewNop(), PersistenceConfig{Dir: dir, Fsync: FsyncAlways})
+	require.NoError(t, err)
+	return storage
+}
+
+// crash бросает хранилище без финального снапшота, как при аварийной остановке
+func crash(t *testing.T, storage *InMemoryStorage) {
+	t.Helper()
+	require.NoError(t, storage.persist.segment.Close())
+}
+
+// fillStorage создаёт по одной сущности каждого вида
+func fillStorage(t *testing.T, storage *InMemoryStorage) (*models.Post, *models.Comment) {
+	t.Helper()
+	ctx := context.Background()
+	logger := zap.NewNop()
+	posts := NewPostMemoryStorage(logger, storage)
+	comments := NewCommentMemoryStorage(logger, storage)
+	users := NewUserMemoryStorage(logger, storage)
+	notifications := NewNotificationMemoryStorage(logger, storage)
+
+	post, err := posts.CreatePost(ctx, models.NewPost{Title: "title", Payload: "payload", AuthorID: 1, Tags: []string{"go"}}, false)
+	require.NoError(t, err)
+	newTitle := "edited"
+	post, err = posts.UpdatePost(ctx, post.ID, models.EditPost{Title: &newTitle}, 1, nil)
+	require.NoError(t, err)
+
+	comment, err := comments.CreateComment(ctx, models.NewComment{PostID: post.ID, AuthorID: 3, Payload: "hi @Alice"}, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, comments.SaveMentions(ctx, comment.ID, []int{1}))
+	comment, err = comments.SetCommentPinned(ctx, comment.ID, true)
+	require.NoError(t, err)
+
+	_, err = notifications.CreateNotifications(ctx, []*models.Notification{
+		{Type: models.NotificationTypeMention, Actor: storage.users[3], PostID: post.ID, CommentID: comment.ID, RecipientID: 1},
+	})
+	require.NoError(t, err)
+	_, err = users.BlockUser(ctx, 1, 3)
+	require.NoError(t, err)
+
+	webhooks := NewWebhookMemoryStorage(logger, storage)
+	_, err = webhooks.CreateWebhook(ctx, &models.Webhook{
+		URL:        "https://example.com/hook",
+		Secret:     "secret",
+		EventTypes: []models.WebhookEventType{models.WebhookEventTypeCommentPublished},
+		IsActive:   true,
+	})
+	require.NoError(t, err)
+	_, err = webhooks.CreateWebhookDeliveries(ctx, 1, models.WebhookEventTypeCommentPublished, "{}")
+	require.NoError(t, err)
+	return post, comment
+}
+
+func assertRestored(t *testing.T, storage *InMemoryStorage, post *models.Post, comment *models.Comment) {
+	t.Helper()
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	gotPost, err := NewPostMemoryStorage(logger, storage).GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", gotPost.Title)
+	assert.Equal(t, []string{"go"}, gotPost.Tags)
+	assert.Same(t, storage.users[1], gotPost.Author, "автор должен ссылаться на пользователя хранилища")
+
+	revisions, err := NewPostMemoryStorage(logger, storage).GetPostRevisions(ctx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "title", *revisions[0].Title)
+
+	gotComment, err := NewCommentMemoryStorage(logger, storage).GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	assert.True(t, gotComment.IsPinned)
+
+	mentions, err := NewCommentMemoryStorage(logger, storage).GetCommentMentions(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Len(t, mentions, 1)
+	assert.Equal(t, 1, mentions[0].ID)
+
+	notifications, err := NewNotificationMemoryStorage(logger, storage).GetNotifications(ctx, 1, false, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, 1, notifications[0].RecipientID)
+
+	blocked, err := NewUserMemoryStorage(logger, storage).IsBlocked(ctx, 1, 3)
+	require.NoError(t, err)
+	assert.True(t, blocked)
+
+	webhooks, err := NewWebhookMemoryStorage(logger, storage).GetWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, "secret", webhooks[0].Secret, "секрет вебхука сохраняется в журнал")
+	deliveries, err := NewWebhookMemoryStorage(logger, storage).GetWebhookDeliveries(ctx, webhooks[0].ID, nil, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	// Счётчики id продолжаются, а не начинаются заново
+	next, err := NewPostMemoryStorage(logger, storage).CreatePost(ctx, models.NewPost{Title: "next", AuthorID: 1}, false)
+	require.NoError(t, err)
+	assert.Equal(t, post.ID+1, next.ID)
+}
+
+func TestPersistence_ReplayWAL(t *testing.T) {
+	dir := t.TempDir()
+
+	storage := openTestStorage(t, dir)
+	post, comment := fillStorage(t, storage)
+	crash(t, storage)
+
+	_, err := os.Stat(filepath.Join(dir, snapshotFile))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	restored := openTestStorage(t, dir)
+	assertRestored(t, restored, post, comment)
+	require.NoError(t, restored.Close())
+}
+
+func TestPersistence_SnapshotAndWAL(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	storage := openTestStorage(t, dir)
+	post, comment := fillStorage(t, storage)
+	require.NoError(t, storage.Snapshot())
+
+	// Изменения после снапшота попадают только в журнал
+	_, err := NewUserMemoryStorage(zap.NewNop(), storage).UnblockUser(ctx, 1, 3)
+	require.NoError(t, err)
+	_, err = NewUserMemoryStorage(zap.NewNop(), storage).BlockUser(ctx, 1, 3)
+	require.NoError(t, err)
+	crash(t, storage)
+
+	segments, err := storage.persist.segments()
+	require.NoError(t, err)
+	assert.Len(t, segments, 1, "сегменты, вошедшие в снапшот, удаляются")
+
+	restored := openTestStorage(t, dir)
+	assertRestored(t, restored, post, comment)
+	require.NoError(t, restored.Close())
+}
+
+func TestPersistence_Close(t *testing.T) {
+	dir := t.TempDir()
+
+	storage := openTestStorage(t, dir)
+	post, comment := fillStorage(t, storage)
+	require.NoError(t, storage.Close())
+
+	restored := openTestStorage(t, dir)
+	assertRestored(t, restored, post, comment)
+	require.NoError(t, restored.Close())
+}
+
+func TestPersistence_TornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	storage := openTestStorage(t, dir)
+	post, comment := fillStorage(t, storage)
+	path := storage.persist.segmentPath(storage.persist.seq)
+	crash(t, storage)
+
+	// Запись оборвалась посередине
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"posts":[{"id":`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	restored := openTestStorage(t, dir)
+	assertRestored(t, restored, post, comment)
+	require.NoError(t, restored.Close())
+
+	// Хвост отрезан, повторный запуск тоже проходит
+	again := openTestStorage(t, dir)
+	require.NoError(t, again.Close())
+}
+
+func TestPersistence_CorruptedSegment(t *testing.T) {
+	dir := t.TempDir()
+
+	storage := openTestStorage(t, dir)
+	fillStorage(t, storage)
+	path := storage.persist.segmentPath(storage.persist.seq)
+	crash(t, storage)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, append([]byte("garbage\n"), data...), 0o644))
+
+	_, err = OpenInMemoryStorage(zap.NewNop(), PersistenceConfig{Dir: dir, Fsync: FsyncAlways})
+	assert.Error(t, err)
+}
+
+func TestParseFsyncPolicy(t *testing.T) {
+	for _, value := range []string{"always", "interval", "never"} {
+		policy, err := ParseFsyncPolicy(value)
+		require.NoError(t, err)
+		assert.Equal(t, FsyncPolicy(value), policy)
+	}
+
+	_, err := ParseFsyncPolicy("sometimes")
+	assert.Error(t, err)
+}
@@ -2,11 +2,12 @@ package in_memory
 
 import (
 	"context"
-	"github.com/Quizert/PostCommentService/internal/errdefs"
 	"github.com/Quizert/PostCommentService/internal/models"
-	"github.com/jackc/pgx/v4"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/Quizert/PostCommentService/internal/utils"
 	"go.uber.org/zap"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -22,7 +23,7 @@ func NewPostMemoryStorage(log *zap.Logger, storage *InMemoryStorage) *PostMemory
 	}
 }
 
-func (p *PostMemoryStorage) CreatePost(ctx context.Context, input models.NewPost) (*models.Post, error) {
+func (p *PostMemoryStorage) CreatePost(ctx context.Context, input models.NewPost, hidden bool) (*models.Post, error) {
 	p.storage.mu.Lock()
 	defer p.storage.mu.Unlock()
 
@@ -32,26 +33,46 @@ func (p *PostMemoryStorage) CreatePost(ctx context.Context, input models.NewPost
 		zap.Int("AuthorID", input.AuthorID),
 	)
 
-	newID := p.storage.nextPostID
-	p.storage.nextPostID++
-
 	author, ok := p.storage.users[input.AuthorID]
 	if !ok {
 		log.Error("Failed to get user")
-		return nil, errdefs.UserDoesNotExistError(input.AuthorID) // Не может быть так как на уровне сервиса уже проверили
+		return nil, &storage.ConstraintError{Kind: storage.ErrForeignKey, Field: "authorid"} // Не может быть так как на уровне сервиса уже проверили
 	}
 
+	now := time.Now()
 	post := &models.Post{
-		ID:                newID,
+		ID:                p.storage.nextPostID,
 		Title:             input.Title,
 		Payload:           input.Payload,
 		Author:            author,
 		IsCommentsAllowed: input.IsCommentsAllowed,
-		CreatedAt:         time.Now(),
+		Tags:              utils.SortTags(input.Tags),
+		Status:            models.PostStatusPublished,
+		Format:            models.ContentFormatPlain,
+		IsHidden:          hidden,
+		PublishAt:         input.PublishAt,
+		CreatedAt:         now,
+	}
+	if input.Status != nil {
+		post.Status = *input.Status
+	}
+	if input.Format != nil {
+		post.Format = *input.Format
+	}
+	if post.Status == models.PostStatusPublished && post.PublishAt == nil {
+		post.PublishAt = &now
 	}
 
-	p.storage.posts[newID] = post
-	return post, nil
+	c := &change{Posts: []*models.Post{post}}
+	if post.Status == models.PostStatusPublished && !hidden {
+		p.storage.addOutboxEvent(c, models.OutboxEventPostPublished, post.ID, now)
+	}
+	if err := p.storage.commit(c); err != nil {
+		log.Error("Failed to save post", zap.Error(err))
+		return nil, err
+	}
+	result := *post
+	return &result, nil
 }
 
 func (p *PostMemoryStorage) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
@@ -66,19 +87,22 @@ func (p *PostMemoryStorage) GetPostByID(ctx context.Context, id int) (*models.Po
 	post, ok := p.storage.posts[id]
 	if !ok {
 		log.Warn("Failed to get post: not found in memory")
-		return nil, pgx.ErrNoRows
+		return nil, storage.ErrNotFound
 	}
 
 	return post, nil
 }
 
-func (p *PostMemoryStorage) GetAllPosts(ctx context.Context, limit, offset int) ([]*models.Post, error) {
+func (p *PostMemoryStorage) GetAllPosts(ctx context.Context, limit, offset, viewerID int) ([]*models.Post, error) {
 	p.storage.mu.RLock()
 	defer p.storage.mu.RUnlock()
 
+	now := time.Now()
 	postsSlice := make([]*models.Post, 0, len(p.storage.posts))
 	for _, post := range p.storage.posts {
-		postsSlice = append(postsSlice, post)
+		if post.IsVisibleTo(viewerID, now) {
+			postsSlice = append(postsSlice, post)
+		}
 	}
 
 	if offset >= len(postsSlice) {
@@ -87,7 +111,43 @@ func (p *PostMemoryStorage) GetAllPosts(ctx context.Context, limit, offset int)
 
 	// Сортируем, аналог order by
 	sort.Slice(postsSlice, func(i, j int) bool {
-		return postsSlice[i].CreatedAt.After(postsSlice[j].CreatedAt)
+		return postsSlice[i].SortTime().After(postsSlice[j].SortTime())
+	})
+
+	postsSlice = postsSlice[offset:]
+
+	if limit > len(postsSlice) {
+		limit = len(postsSlice)
+	}
+	postsSlice = postsSlice[:limit]
+
+	return postsSlice, nil
+}
+
+func (p *PostMemoryStorage) GetPostsByTag(ctx context.Context, tag string, limit, offset, viewerID int) ([]*models.Post, error) {
+	p.storage.mu.RLock()
+	defer p.storage.mu.RUnlock()
+
+	now := time.Now()
+	postsSlice := make([]*models.Post, 0)
+	for _, post := range p.storage.posts {
+		if !post.IsVisibleTo(viewerID, now) {
+			continue
+		}
+		for _, postTag := range post.Tags {
+			if postTag == tag {
+				postsSlice = append(postsSlice, post)
+				break
+			}
+		}
+	}
+
+	if offset >= len(postsSlice) {
+		return []*models.Post{}, nil
+	}
+
+	sort.Slice(postsSlice, func(i, j int) bool {
+		return postsSlice[i].SortTime().After(postsSlice[j].SortTime())
 	})
 
 	postsSlice = postsSlice[offset:]
@@ -99,3 +159,133 @@ func (p *PostMemoryStorage) GetAllPosts(ctx context.Context, limit, offset int)
 
 	return postsSlice, nil
 }
+
+func (p *PostMemoryStorage) GetTags(ctx context.Context, prefix string, limit int) ([]string, error) {
+	p.storage.mu.RLock()
+	defer p.storage.mu.RUnlock()
+
+	// Считаем популярность тегов, чтобы в подсказках сначала шли самые используемые
+	counts := make(map[string]int)
+	for _, post := range p.storage.posts {
+		for _, tag := range post.Tags {
+			if strings.HasPrefix(tag, prefix) {
+				counts[tag]++
+			}
+		}
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+
+	if limit < len(tags) {
+		tags = tags[:limit]
+	}
+	return tags, nil
+}
+
+func (p *PostMemoryStorage) UpdatePostStatus(ctx context.Context, postID int, status models.PostStatus, publishAt *time.Time) (*models.Post, error) {
+	p.storage.mu.Lock()
+	defer p.storage.mu.Unlock()
+
+	post, ok := p.storage.posts[postID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	now := time.Now()
+	if status == models.PostStatusPublished && publishAt == nil {
+		publishAt = &now
+	}
+	updated := *post
+	updated.Status = status
+	updated.PublishAt = publishAt
+
+	c := &change{Posts: []*models.Post{&updated}}
+	if status == models.PostStatusPublished && post.Status != models.PostStatusPublished && !post.IsHidden {
+		p.storage.addOutboxEvent(c, models.OutboxEventPostPublished, postID, now)
+	}
+	if err := p.storage.commit(c); err != nil {
+		return nil, err
+	}
+	result := updated
+	return &result, nil
+}
+
+func (p *PostMemoryStorage) PublishScheduledPosts(ctx context.Context, now time.Time) (int, error) {
+	p.storage.mu.Lock()
+	defer p.storage.mu.Unlock()
+
+	c := &change{}
+	for _, post := range p.storage.posts {
+		if post.Status == models.PostStatusScheduled && post.PublishAt != nil && !post.PublishAt.After(now) {
+			updated := *post
+			updated.Status = models.PostStatusPublished
+			c.Posts = append(c.Posts, &updated)
+		}
+	}
+	// Посты в map лежат в случайном порядке, события пишутся по возрастанию id
+	sort.Slice(c.Posts, func(i, j int) bool { return c.Posts[i].ID < c.Posts[j].ID })
+	for _, post := range c.Posts {
+		if !post.IsHidden {
+			p.storage.addOutboxEvent(c, models.OutboxEventPostPublished, post.ID, now)
+		}
+	}
+	if len(c.Posts) == 0 {
+		return 0, nil
+	}
+	if err := p.storage.commit(c); err != nil {
+		return 0, err
+	}
+	return len(c.Posts), nil
+}
+
+// UpdatePost сохраняет правку. Если задан hold, пост в том же изменении скрывается и ставится в очередь модерации
+func (p *PostMemoryStorage) UpdatePost(ctx context.Context, postID int, input models.EditPost, editorID int, hold *models.ModerationItem) (*models.Post, error) {
+	p.storage.mu.Lock()
+	defer p.storage.mu.Unlock()
+
+	post, ok := p.storage.posts[postID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	now := time.Now()
+	title := post.Title
+	revision := p.storage.newRevision(revisionTargetPost, postID, &title, post.Payload, editorID, now)
+
+	updated := *post
+	if input.Title != nil {
+		updated.Title = *input.Title
+	}
+	if input.Payload != nil {
+		updated.Payload = *input.Payload
+	}
+	updated.EditedAt = &now
+
+	c := &change{Posts: []*models.Post{&updated}, Revisions: []*revisionRecord{revision}}
+	if hold != nil {
+		updated.IsHidden = true
+		p.storage.addModerationItem(c, hold, !post.IsHidden && post.Status == models.PostStatusPublished, now)
+	}
+	if err := p.storage.commit(c); err != nil {
+		return nil, err
+	}
+	result := updated
+	return &result, nil
+}
+
+func (p *PostMemoryStorage) GetPostRevisions(ctx context.Context, postID int) ([]*models.Revision, error) {
+	p.storage.mu.RLock()
+	defer p.storage.mu.RUnlock()
+
+	return p.storage.revisionsOf(revisionTargetPost, postID), nil
+}
@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"github.com/Quizert/PostCommentService/internal/models"
-	"github.com/jackc/pgx/v4"
+	storageerr "github.com/Quizert/PostCommentService/internal/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -32,7 +32,7 @@ func TestPostMemoryStorage_CreatePost(t *testing.T) {
 			IsCommentsAllowed: true,
 		}
 
-		post, err := postStorage.CreatePost(context.Background(), input)
+		post, err := postStorage.CreatePost(context.Background(), input, false)
 		require.NoError(t, err)
 		require.NotNil(t, post)
 
@@ -59,8 +59,8 @@ func TestPostMemoryStorage_CreatePost(t *testing.T) {
 			IsCommentsAllowed: false,
 		}
 
-		post, err := postStorage.CreatePost(context.Background(), input)
-		require.Error(t, err)
+		post, err := postStorage.CreatePost(context.Background(), input, false)
+		assert.ErrorIs(t, err, storageerr.ErrForeignKey)
 		assert.Nil(t, post)
 
 		assert.Empty(t, storage.posts)
@@ -100,7 +100,7 @@ func TestPostMemoryStorage_GetPostByID(t *testing.T) {
 		got, err := postStorage.GetPostByID(context.Background(), 999)
 		require.Error(t, err)
 		assert.Nil(t, got)
-		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.ErrorIs(t, err, storageerr.ErrNotFound)
 	})
 }
 
@@ -111,7 +111,7 @@ func TestPostMemoryStorage_GetAllPosts(t *testing.T) {
 		storage := NewInMemoryStorage()
 		postStorage := NewPostMemoryStorage(logger, storage)
 
-		posts, err := postStorage.GetAllPosts(context.Background(), 10, 0)
+		posts, err := postStorage.GetAllPosts(context.Background(), 10, 0, 0)
 		require.NoError(t, err)
 		assert.Empty(t, posts)
 	})
@@ -143,7 +143,7 @@ func TestPostMemoryStorage_GetAllPosts(t *testing.T) {
 		storage.posts[2] = post2
 		storage.posts[3] = post3
 
-		posts, err := postStorage.GetAllPosts(context.Background(), 10, 0)
+		posts, err := postStorage.GetAllPosts(context.Background(), 10, 0, 0)
 		require.NoError(t, err)
 		require.Len(t, posts, 3)
 
@@ -165,15 +165,94 @@ func TestPostMemoryStorage_GetAllPosts(t *testing.T) {
 			}
 		}
 
-		posts, err := postStorage.GetAllPosts(context.Background(), 2, 1)
+		posts, err := postStorage.GetAllPosts(context.Background(), 2, 1, 0)
 		require.NoError(t, err)
 
 		require.Len(t, posts, 2)
 		assert.Equal(t, 2, posts[0].ID)
 		assert.Equal(t, 3, posts[1].ID)
 
-		posts2, err2 := postStorage.GetAllPosts(context.Background(), 10, 10)
+		posts2, err2 := postStorage.GetAllPosts(context.Background(), 10, 10, 0)
 		require.NoError(t, err2)
 		assert.Empty(t, posts2, "offset = 10 больше, чем число постов = 5 (я устал придумывать приколы на англ)")
 	})
 }
+
+func TestPostMemoryStorage_GetPostsByTag(t *testing.T) {
+	logger := zap.NewNop()
+	storage := NewInMemoryStorage()
+	postStorage := NewPostMemoryStorage(logger, storage)
+
+	now := time.Now()
+	storage.posts[1] = &models.Post{ID: 1, Tags: []string{"go", "web"}, CreatedAt: now.Add(-2 * time.Hour)}
+	storage.posts[2] = &models.Post{ID: 2, Tags: []string{"rust"}, CreatedAt: now.Add(-1 * time.Hour)}
+	storage.posts[3] = &models.Post{ID: 3, Tags: []string{"go"}, CreatedAt: now}
+
+	posts, err := postStorage.GetPostsByTag(context.Background(), "go", 10, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	assert.Equal(t, 3, posts[0].ID)
+	assert.Equal(t, 1, posts[1].ID)
+
+	posts, err = postStorage.GetPostsByTag(context.Background(), "go", 10, 2, 0)
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+}
+
+func TestPostMemoryStorage_GetTags(t *testing.T) {
+	logger := zap.NewNop()
+	storage := NewInMemoryStorage()
+	postStorage := NewPostMemoryStorage(logger, storage)
+
+	storage.posts[1] = &models.Post{ID: 1, Tags: []string{"go", "golang", "web"}}
+	storage.posts[2] = &models.Post{ID: 2, Tags: []string{"golang"}}
+	storage.posts[3] = &models.Post{ID: 3, Tags: []string{"gopher"}}
+
+	tags, err := postStorage.GetTags(context.Background(), "go", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"golang", "go", "gopher"}, tags, "сначала популярные, затем по алфавиту")
+
+	tags, err = postStorage.GetTags(context.Background(), "go", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"golang"}, tags)
+}
+
+func TestPostMemoryStorage_Drafts(t *testing.T) {
+	logger := zap.NewNop()
+	storage := NewInMemoryStorage()
+	postStorage := NewPostMemoryStorage(logger, storage)
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	author := &models.User{ID: 1, Username: "Alice"}
+
+	storage.posts[1] = &models.Post{ID: 1, Author: author, Status: models.PostStatusPublished, CreatedAt: now}
+	storage.posts[2] = &models.Post{ID: 2, Author: author, Status: models.PostStatusDraft, CreatedAt: now}
+	storage.posts[3] = &models.Post{ID: 3, Author: author, Status: models.PostStatusScheduled, PublishAt: &future, CreatedAt: now}
+	storage.posts[4] = &models.Post{ID: 4, Author: author, Status: models.PostStatusScheduled, PublishAt: &past, CreatedAt: now}
+
+	t.Run("other users see only published posts", func(t *testing.T) {
+		posts, err := postStorage.GetAllPosts(context.Background(), 10, 0, 2)
+		require.NoError(t, err)
+		ids := make([]int, 0, len(posts))
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		assert.ElementsMatch(t, []int{1, 4}, ids)
+	})
+
+	t.Run("author sees everything", func(t *testing.T) {
+		posts, err := postStorage.GetAllPosts(context.Background(), 10, 0, 1)
+		require.NoError(t, err)
+		assert.Len(t, posts, 4)
+	})
+
+	t.Run("publish due posts", func(t *testing.T) {
+		published, err := postStorage.PublishScheduledPosts(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, published)
+		assert.Equal(t, models.PostStatusPublished, storage.posts[4].Status)
+		assert.Equal(t, models.PostStatusScheduled, storage.posts[3].Status)
+	})
+}
@@ -3,8 +3,10 @@ package in_memory
 import (
 	"context"
 	"github.com/Quizert/PostCommentService/internal/models"
-	"github.com/jackc/pgx/v4"
+	"github.com/Quizert/PostCommentService/internal/storage"
 	"go.uber.org/zap"
+	"strings"
+	"time"
 )
 
 type UserMemoryStorage struct {
@@ -25,10 +27,134 @@ func (u *UserMemoryStorage) GetUserByID(ctx context.Context, userID int) (*model
 		zap.Int("UserID", userID),
 	)
 
+	u.storage.mu.RLock()
+	defer u.storage.mu.RUnlock()
+
 	user, ok := u.storage.users[userID]
 	if !ok {
 		log.Warn("User does not exist")
-		return nil, pgx.ErrNoRows
+		return nil, storage.ErrNotFound
 	}
 	return user, nil
 }
+
+func (u *UserMemoryStorage) GetUsersByUsernames(ctx context.Context, usernames []string) ([]*models.User, error) {
+	u.storage.mu.RLock()
+	defer u.storage.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(usernames))
+	for _, username := range usernames {
+		for _, user := range u.storage.users {
+			if strings.EqualFold(user.Username, username) {
+				users = append(users, user)
+				break
+			}
+		}
+	}
+	return users, nil
+}
+
+// SetUserRole меняет роль на месте, чтобы её видели все ссылки на пользователя. Пользователи не пишутся в журнал,
+// роль назначается заново при каждом старте
+func (u *UserMemoryStorage) SetUserRole(ctx context.Context, userID int, role models.UserRole) error {
+	u.storage.mu.Lock()
+	defer u.storage.mu.Unlock()
+
+	user, ok := u.storage.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	user.Role = role
+	return nil
+}
+
+func (u *UserMemoryStorage) BanUser(ctx context.Context, ban *models.UserBan) (*models.UserBan, error) {
+	u.storage.mu.Lock()
+	defer u.storage.mu.Unlock()
+
+	stored := *ban
+	stored.User = u.storage.users[ban.User.ID]
+	stored.Moderator = u.storage.users[ban.Moderator.ID]
+	stored.CreatedAt = time.Now()
+
+	if err := u.storage.commit(&change{Bans: []*models.UserBan{&stored}}); err != nil {
+		return nil, err
+	}
+
+	result := stored
+	return &result, nil
+}
+
+func (u *UserMemoryStorage) GetUserBan(ctx context.Context, userID int) (*models.UserBan, error) {
+	u.storage.mu.RLock()
+	defer u.storage.mu.RUnlock()
+
+	ban, ok := u.storage.bans[userID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	result := *ban
+	return &result, nil
+}
+
+func (u *UserMemoryStorage) MuteUserOnPost(ctx context.Context, mute *models.PostMute) (*models.PostMute, error) {
+	u.storage.mu.Lock()
+	defer u.storage.mu.Unlock()
+
+	stored := *mute
+	stored.User = u.storage.users[mute.User.ID]
+	stored.MutedBy = u.storage.users[mute.MutedBy.ID]
+	stored.CreatedAt = time.Now()
+
+	if err := u.storage.commit(&change{Mutes: []*models.PostMute{&stored}}); err != nil {
+		return nil, err
+	}
+
+	result := stored
+	return &result, nil
+}
+
+func (u *UserMemoryStorage) GetPostMute(ctx context.Context, postID int, userID int) (*models.PostMute, error) {
+	u.storage.mu.RLock()
+	defer u.storage.mu.RUnlock()
+
+	mute, ok := u.storage.mutes[postMuteKey{postID, userID}]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	result := *mute
+	return &result, nil
+}
+
+func (u *UserMemoryStorage) BlockUser(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	u.storage.mu.Lock()
+	defer u.storage.mu.Unlock()
+
+	if u.storage.blocks[blockerID][blockedID] {
+		return false, nil
+	}
+	if err := u.storage.commit(&change{Blocks: []*blockRecord{{BlockerID: blockerID, BlockedID: blockedID, Blocked: true}}}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (u *UserMemoryStorage) UnblockUser(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	u.storage.mu.Lock()
+	defer u.storage.mu.Unlock()
+
+	if !u.storage.blocks[blockerID][blockedID] {
+		return false, nil
+	}
+	if err := u.storage.commit(&change{Blocks: []*blockRecord{{BlockerID: blockerID, BlockedID: blockedID}}}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (u *UserMemoryStorage) IsBlocked(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	u.storage.mu.RLock()
+	defer u.storage.mu.RUnlock()
+
+	return u.storage.blocks[blockerID][blockedID], nil
+}
@@ -0,0 +1,256 @@
+package in_memory
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+type WebhookMemoryStorage struct {
+	log     *zap.Logger
+	storage *InMemoryStorage
+}
+
+func NewWebhookMemoryStorage(log *zap.Logger, storage *InMemoryStorage) *WebhookMemoryStorage {
+	return &WebhookMemoryStorage{
+		log:     log,
+		storage: storage,
+	}
+}
+
+func (w *WebhookMemoryStorage) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	w.storage.mu.Lock()
+	defer w.storage.mu.Unlock()
+
+	created := *webhook
+	created.ID = w.storage.nextWebhookID
+	created.EventTypes = append([]models.WebhookEventType(nil), webhook.EventTypes...)
+	created.CreatedAt = time.Now()
+
+	if err := w.storage.commit(&change{Webhooks: []*webhookRecord{{Webhook: &created, Secret: created.Secret}}}); err != nil {
+		w.log.Error("Failed to save webhook", zap.String("Layer", "WebhookMemoryStorage.CreateWebhook"), zap.Error(err))
+		return nil, err
+	}
+	result := created
+	return &result, nil
+}
+
+func (w *WebhookMemoryStorage) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	w.storage.mu.RLock()
+	defer w.storage.mu.RUnlock()
+
+	webhooks := make([]*models.Webhook, 0, len(w.storage.webhooks))
+	for _, webhook := range w.storage.webhooks {
+		result := *webhook
+		webhooks = append(webhooks, &result)
+	}
+	return webhooks, nil
+}
+
+func (w *WebhookMemoryStorage) GetWebhookByID(ctx context.Context, id int) (*models.Webhook, error) {
+	w.storage.mu.RLock()
+	defer w.storage.mu.RUnlock()
+
+	webhook := w.findWebhook(id)
+	if webhook == nil {
+		return nil, storage.ErrNotFound
+	}
+	result := *webhook
+	return &result, nil
+}
+
+func (w *WebhookMemoryStorage) UpdateWebhook(ctx context.Context, id int, input models.EditWebhook) (*models.Webhook, error) {
+	w.storage.mu.Lock()
+	defer w.storage.mu.Unlock()
+
+	webhook := w.findWebhook(id)
+	if webhook == nil {
+		return nil, storage.ErrNotFound
+	}
+	updated := *webhook
+	if input.URL != nil {
+		updated.URL = *input.URL
+	}
+	if input.Secret != nil {
+		updated.Secret = *input.Secret
+	}
+	if input.EventTypes != nil {
+		updated.EventTypes = append([]models.WebhookEventType(nil), input.EventTypes...)
+	}
+	if input.IsActive != nil {
+		updated.IsActive = *input.IsActive
+	}
+
+	if err := w.storage.commit(&change{Webhooks: []*webhookRecord{{Webhook: &updated, Secret: updated.Secret}}}); err != nil {
+		w.log.Error("Failed to update webhook", zap.String("Layer", "WebhookMemoryStorage.UpdateWebhook"), zap.Error(err))
+		return nil, err
+	}
+	result := updated
+	return &result, nil
+}
+
+func (w *WebhookMemoryStorage) DeleteWebhook(ctx context.Context, id int) error {
+	w.storage.mu.Lock()
+	defer w.storage.mu.Unlock()
+
+	if w.findWebhook(id) == nil {
+		return storage.ErrNotFound
+	}
+	return w.storage.commit(&change{WebhooksDeleted: []int{id}})
+}
+
+func (w *WebhookMemoryStorage) CreateWebhookDeliveries(ctx context.Context, eventID int, eventType models.WebhookEventType, payload string) (int, error) {
+	w.storage.mu.Lock()
+	defer w.storage.mu.Unlock()
+
+	delivered := make(map[int]bool)
+	for _, delivery := range w.storage.webhookDeliveries {
+		if delivery.EventID == eventID {
+			delivered[delivery.WebhookID] = true
+		}
+	}
+
+	now := time.Now()
+	c := &change{}
+	for _, webhook := range w.storage.webhooks {
+		if !webhook.IsActive || !webhook.Subscribed(eventType) || delivered[webhook.ID] {
+			continue
+		}
+		c.WebhookDeliveries = append(c.WebhookDeliveries, &models.WebhookDelivery{
+			ID:            w.storage.nextWebhookDeliveryID + len(c.WebhookDeliveries),
+			WebhookID:     webhook.ID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryStatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	if len(c.WebhookDeliveries) == 0 {
+		return 0, nil
+	}
+	if err := w.storage.commit(c); err != nil {
+		w.log.Error("Failed to save webhook deliveries", zap.String("Layer", "WebhookMemoryStorage.CreateWebhookDeliveries"), zap.Error(err))
+		return 0, err
+	}
+	return len(c.WebhookDeliveries), nil
+}
+
+func (w *WebhookMemoryStorage) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	w.storage.mu.Lock()
+	defer w.storage.mu.Unlock()
+
+	due := make([]*models.WebhookDelivery, 0)
+	for _, delivery := range w.storage.webhookDeliveries {
+		if delivery.Status != models.WebhookDeliveryStatusPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if webhook := w.findWebhook(delivery.WebhookID); webhook != nil && webhook.IsActive {
+			due = append(due, delivery)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	if len(due) == 0 {
+		return []*models.WebhookDelivery{}, nil
+	}
+
+	c := &change{WebhookDeliveries: make([]*models.WebhookDelivery, 0, len(due))}
+	for _, delivery := range due {
+		claimed := *delivery
+		claimed.Attempts++
+		claimed.NextAttemptAt = now.Add(lease)
+		c.WebhookDeliveries = append(c.WebhookDeliveries, &claimed)
+	}
+	if err := w.storage.commit(c); err != nil {
+		w.log.Error("Failed to claim webhook deliveries", zap.String("Layer", "WebhookMemoryStorage.ClaimWebhookDeliveries"), zap.Error(err))
+		return nil, err
+	}
+
+	sort.Slice(c.WebhookDeliveries, func(i, j int) bool { return c.WebhookDeliveries[i].ID < c.WebhookDeliveries[j].ID })
+	deliveries := make([]*models.WebhookDelivery, 0, len(c.WebhookDeliveries))
+	for _, delivery := range c.WebhookDeliveries {
+		result := *delivery
+		deliveries = append(deliveries, &result)
+	}
+	return deliveries, nil
+}
+
+func (w *WebhookMemoryStorage) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	w.storage.mu.Lock()
+	defer w.storage.mu.Unlock()
+
+	stored := w.findDelivery(delivery.ID)
+	if stored == nil {
+		return storage.ErrNotFound
+	}
+	updated := *stored
+	updated.Status = delivery.Status
+	updated.Attempts = delivery.Attempts
+	updated.ResponseStatus = delivery.ResponseStatus
+	updated.LastError = delivery.LastError
+	updated.NextAttemptAt = delivery.NextAttemptAt
+	updated.DeliveredAt = delivery.DeliveredAt
+	return w.storage.commit(&change{WebhookDeliveries: []*models.WebhookDelivery{&updated}})
+}
+
+func (w *WebhookMemoryStorage) GetWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	w.storage.mu.RLock()
+	defer w.storage.mu.RUnlock()
+
+	delivery := w.findDelivery(id)
+	if delivery == nil {
+		return nil, storage.ErrNotFound
+	}
+	result := *delivery
+	return &result, nil
+}
+
+func (w *WebhookMemoryStorage) GetWebhookDeliveries(ctx context.Context, webhookID int, status *models.WebhookDeliveryStatus, limit int, offset int) ([]*models.WebhookDelivery, error) {
+	w.storage.mu.RLock()
+	defer w.storage.mu.RUnlock()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for i := len(w.storage.webhookDeliveries) - 1; i >= 0; i-- {
+		delivery := w.storage.webhookDeliveries[i]
+		if delivery.WebhookID != webhookID || (status != nil && delivery.Status != *status) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if len(deliveries) == limit {
+			break
+		}
+		result := *delivery
+		deliveries = append(deliveries, &result)
+	}
+	return deliveries, nil
+}
+
+// findWebhook ищет вебхук по id. Вызывается под блокировкой
+func (w *WebhookMemoryStorage) findWebhook(id int) *models.Webhook {
+	webhooks := w.storage.webhooks
+	i := sort.Search(len(webhooks), func(i int) bool { return webhooks[i].ID >= id })
+	if i < len(webhooks) && webhooks[i].ID == id {
+		return webhooks[i]
+	}
+	return nil
+}
+
+// findDelivery ищет доставку по id. Вызывается под блокировкой
+func (w *WebhookMemoryStorage) findDelivery(id int) *models.WebhookDelivery {
+	deliveries := w.storage.webhookDeliveries
+	i := sort.Search(len(deliveries), func(i int) bool { return deliveries[i].ID >= id })
+	if i < len(deliveries) && deliveries[i].ID == id {
+		return deliveries[i]
+	}
+	return nil
+}
@@ -2,141 +2,393 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"github.com/Quizert/PostCommentService/internal/models"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/jackc/pgx/v4"
 	"go.uber.org/zap"
 	"time"
 )
 
+// notBlockedCondition отбрасывает комментарии авторов, заблокированных зрителем ($4)
+const notBlockedCondition = `NOT EXISTS (SELECT 1 FROM user_blocks b WHERE b.blockerID = $4 AND b.blockedID = c.authorID)`
+
 type CommentPostgresRepository struct {
-	db  *pgxpool.Pool
+	db  *DB
 	log *zap.Logger
 }
 
-func NewCommentPostgresRepository(db *pgxpool.Pool, log *zap.Logger) *CommentPostgresRepository {
+func NewCommentPostgresRepository(db *DB, log *zap.Logger) *CommentPostgresRepository {
 	return &CommentPostgresRepository{
 		db:  db,
 		log: log,
 	}
 }
 
-func (c *CommentPostgresRepository) CreateComment(ctx context.Context, input models.NewComment) (*models.Comment, error) {
+func (c *CommentPostgresRepository) CreateComment(ctx context.Context, input models.NewComment, hidden bool, mentionIDs []int) (*models.Comment, error) {
 	log := c.log.With(
 		zap.String("Layer", "CommentPostgresRepository.CreateComment"),
 		zap.Int("PostID", input.PostID),
 		zap.Int("AuthorID", input.AuthorID),
 	)
 
+	format := models.ContentFormatPlain
+	if input.Format != nil {
+		format = *input.Format
+	}
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO comments (payload, postID, authorID, replyTo, createdAt)
-		VALUES ($1, $2, $3, $4, NOW())
-		RETURNING id, createdAt
+		INSERT INTO comments (payload, format, postID, authorID, replyTo, isHidden, createdAt)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id, createdAt, (SELECT username FROM users WHERE id = $4)
 	`
 
 	var commentID int
 	var createdAt time.Time
+	author := &models.User{ID: input.AuthorID}
 
-	err := c.db.QueryRow(ctx, query, input.Payload, input.PostID, input.AuthorID, input.ReplyTo).Scan(&commentID, &createdAt)
-
+	err = tx.QueryRow(ctx, query, input.Payload, string(format), input.PostID, input.AuthorID, input.ReplyTo, hidden).Scan(&commentID, &createdAt, &author.Username)
 	if err != nil {
 		log.Error("Failed to create comment", zap.Error(err))
-		return nil, err
+		return nil, mapError(err)
+	}
+
+	if len(mentionIDs) > 0 {
+		if err = insertMentions(ctx, tx, commentID, mentionIDs); err != nil {
+			log.Error("Failed to save mentions", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+	if !hidden {
+		if err = insertOutboxEvent(ctx, tx, models.OutboxEventCommentPublished, commentID); err != nil {
+			log.Error("Failed to write outbox event", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
 	}
 	comment := &models.Comment{
 		ID:        commentID,
 		Payload:   input.Payload,
+		Format:    format,
 		PostID:    input.PostID,
+		Author:    author,
 		ReplyTo:   input.ReplyTo,
+		IsHidden:  hidden,
 		CreatedAt: createdAt,
 	}
 	return comment, nil
 }
 
-func (c *CommentPostgresRepository) GetCommentsByPostID(ctx context.Context, limit int, offset int, postID int) ([]*models.Comment, error) {
+func (c *CommentPostgresRepository) GetCommentsByPostID(ctx context.Context, limit int, offset int, postID int, viewerID int) ([]*models.Comment, error) {
 	log := c.log.With(
 		zap.String("Layer", "CommentPostgresRepository.GetCommentsByPostID"),
 		zap.Int("PostID", postID),
 	)
 
+	// Закреплённые комментарии всегда идут первыми
 	query := `
-		SELECT c.id, c.payload, c.postID, c.createdAt, u.id, u.username 
+		SELECT c.id, c.payload, c.format, c.postID, c.replyTo, c.isPinned, c.isLocked, c.isHidden, c.editedAt, c.createdAt, u.id, u.username
 		FROM comments c join users u on c.authorID = u.id
 		WHERE c.postID = $1
-		AND c.replyto IS NULL 
-		ORDER BY c.createdAt 
-		DESC LIMIT $2 OFFSET $3
+		AND c.replyto IS NULL
+		AND NOT c.isHidden
+		AND ` + notBlockedCondition + `
+		ORDER BY c.isPinned DESC, c.createdAt DESC
+		LIMIT $2 OFFSET $3
 	`
 
-	comments := make([]*models.Comment, 0, limit)
-	rows, err := c.db.Query(ctx, query, postID, limit, offset)
+	rows, err := c.db.Reader(ctx).Query(ctx, query, postID, limit, offset, viewerID)
 	if err != nil {
 		log.Error("Error getting comments", zap.Error(err))
-		return nil, err
+		return nil, mapError(err)
 	}
 	defer rows.Close()
 
+	return c.scanComments(rows, make([]*models.Comment, 0, limit), log)
+}
+
+func (c *CommentPostgresRepository) Replies(ctx context.Context, commentID int, limit int, offset int, viewerID int) ([]*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentPostgresRepository.Replies"),
+		zap.Int("CommentID", commentID),
+	)
+
+	query := `
+		SELECT c.id, c.payload, c.format, c.postID, c.replyTo, c.isPinned, c.isLocked, c.isHidden, c.editedAt, c.createdAt, u.id, u.username
+		FROM comments c join users u on c.authorID = u.id
+		WHERE c.replyTo = $1 AND NOT c.isHidden AND ` + notBlockedCondition + `
+		order by c.isPinned DESC, c.createdAt DESC LIMIT $2 OFFSET $3
+	`
+	rows, err := c.db.Reader(ctx).Query(ctx, query, commentID, limit, offset, viewerID)
+	if err != nil {
+		log.Error("Error getting comments", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	return c.scanComments(rows, make([]*models.Comment, 0, limit), log)
+}
+
+func (c *CommentPostgresRepository) GetCommentByID(ctx context.Context, commentID int) (*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentPostgresRepository.GetCommentByID"),
+		zap.Int("CommentID", commentID),
+	)
+
+	query := `
+		SELECT c.id, c.payload, c.format, c.postID, c.replyTo, c.isPinned, c.isLocked, c.isHidden, c.editedAt, c.createdAt, u.id, u.username
+		FROM comments c join users u on c.authorID = u.id
+		WHERE c.id = $1
+	`
+
+	var comment models.Comment
+	comment.Author = &models.User{}
+	err := c.db.QueryRow(ctx, query, commentID).Scan(
+		&comment.ID,
+		&comment.Payload,
+		&comment.Format,
+		&comment.PostID,
+		&comment.ReplyTo,
+		&comment.IsPinned,
+		&comment.IsLocked,
+		&comment.IsHidden,
+		&comment.EditedAt,
+		&comment.CreatedAt,
+		&comment.Author.ID,
+		&comment.Author.Username,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Warn("Failed to get comment", zap.Error(err))
+			return nil, mapError(err)
+		}
+		log.Error("Failed to get comment", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return &comment, nil
+}
+
+func (c *CommentPostgresRepository) SetCommentPinned(ctx context.Context, commentID int, pinned bool) (*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentPostgresRepository.SetCommentPinned"),
+		zap.Int("CommentID", commentID),
+		zap.Bool("Pinned", pinned),
+	)
+
+	tag, err := c.db.Exec(ctx, `UPDATE comments SET isPinned = $2 WHERE id = $1`, commentID, pinned)
+	if err != nil {
+		log.Error("Failed to update comment", zap.Error(err))
+		return nil, mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		log.Warn("Failed to update comment: not found")
+		return nil, storage.ErrNotFound
+	}
+	return c.GetCommentByID(ctx, commentID)
+}
+
+func (c *CommentPostgresRepository) LockThread(ctx context.Context, commentID int) (*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentPostgresRepository.LockThread"),
+		zap.Int("CommentID", commentID),
+	)
+
+	tag, err := c.db.Exec(ctx, `UPDATE comments SET isLocked = true WHERE id = $1`, commentID)
+	if err != nil {
+		log.Error("Failed to lock thread", zap.Error(err))
+		return nil, mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		log.Warn("Failed to lock thread: comment not found")
+		return nil, storage.ErrNotFound
+	}
+	return c.GetCommentByID(ctx, commentID)
+}
+
+func (c *CommentPostgresRepository) IsThreadLocked(ctx context.Context, commentID int) (bool, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentPostgresRepository.IsThreadLocked"),
+		zap.Int("CommentID", commentID),
+	)
+
+	// Проверяем сам комментарий и всех его предков
+	query := `
+		WITH RECURSIVE thread AS (
+			SELECT id, replyTo, isLocked FROM comments WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.replyTo, c.isLocked FROM comments c JOIN thread t ON c.id = t.replyTo
+		)
+		SELECT COALESCE(bool_or(isLocked), false) FROM thread
+	`
+
+	var locked bool
+	if err := c.db.QueryRow(ctx, query, commentID).Scan(&locked); err != nil {
+		log.Error("Failed to check thread lock", zap.Error(err))
+		return false, mapError(err)
+	}
+	return locked, nil
+}
+
+func (c *CommentPostgresRepository) scanComments(rows pgx.Rows, comments []*models.Comment, log *zap.Logger) ([]*models.Comment, error) {
 	for rows.Next() {
 		var comment models.Comment
 		comment.Author = &models.User{}
-		err = rows.Scan(
+		err := rows.Scan(
 			&comment.ID,
 			&comment.Payload,
+			&comment.Format,
 			&comment.PostID,
+			&comment.ReplyTo,
+			&comment.IsPinned,
+			&comment.IsLocked,
+			&comment.IsHidden,
+			&comment.EditedAt,
 			&comment.CreatedAt,
 			&comment.Author.ID,
 			&comment.Author.Username,
 		)
 		if err != nil {
 			log.Error("Failed to scan row", zap.Error(err))
-			return nil, err
+			return nil, mapError(err)
 		}
 		comments = append(comments, &comment)
 	}
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		log.Error("Error after reading rows", zap.Error(err))
-		return nil, err
+		return nil, mapError(err)
 	}
 	return comments, nil
 }
 
-func (c *CommentPostgresRepository) Replies(ctx context.Context, commentID int, limit int, offset int) ([]*models.Comment, error) {
+func (c *CommentPostgresRepository) UpdateComment(ctx context.Context, commentID int, payload string, editorID int, hold *models.ModerationItem) (*models.Comment, error) {
 	log := c.log.With(
-		zap.String("Layer", "CommentPostgresRepository.Replies"),
+		zap.String("Layer", "CommentPostgresRepository.UpdateComment"),
+		zap.Int("CommentID", commentID),
+		zap.Int("EditorID", editorID),
+	)
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	var oldPayload string
+	err = tx.QueryRow(ctx, `SELECT payload FROM comments WHERE id = $1 FOR UPDATE`, commentID).Scan(&oldPayload)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Warn("Failed to update comment: not found")
+			return nil, mapError(err)
+		}
+		log.Error("Failed to get comment", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	if err = insertRevision(ctx, tx, revisionTargetComment, commentID, nil, oldPayload, editorID); err != nil {
+		log.Error("Failed to save revision", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE comments SET payload = $2, editedAt = NOW() WHERE id = $1`, commentID, payload)
+	if err != nil {
+		log.Error("Failed to update comment", zap.Error(err))
+		return nil, mapError(err)
+	}
+	if hold != nil {
+		if _, err = enqueueModeration(ctx, tx, hold); err != nil {
+			log.Error("Failed to enqueue comment", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	return c.GetCommentByID(ctx, commentID)
+}
+
+func (c *CommentPostgresRepository) GetCommentRevisions(ctx context.Context, commentID int) ([]*models.Revision, error) {
+	revisions, err := getRevisions(ctx, c.db.Pool, revisionTargetComment, commentID)
+	if err != nil {
+		c.log.Error("Failed to get comment revisions",
+			zap.String("Layer", "CommentPostgresRepository.GetCommentRevisions"),
+			zap.Int("CommentID", commentID),
+			zap.Error(err),
+		)
+		return nil, mapError(err)
+	}
+	return revisions, nil
+}
+
+func (c *CommentPostgresRepository) SaveMentions(ctx context.Context, commentID int, userIDs []int) error {
+	log := c.log.With(
+		zap.String("Layer", "CommentPostgresRepository.SaveMentions"),
 		zap.Int("CommentID", commentID),
 	)
 
-	comments := make([]*models.Comment, 0, limit)
+	if err := insertMentions(ctx, c.db, commentID, userIDs); err != nil {
+		log.Error("Failed to save mentions", zap.Error(err))
+		return mapError(err)
+	}
+	return nil
+}
+
+// insertMentions сохраняет упоминания пользователей в комментарии, повторные упоминания пропускаются
+func insertMentions(ctx context.Context, db execer, commentID int, userIDs []int) error {
 	query := `
-		SELECT c.id, c.payload, c.postID, c.createdAt, u.id, u.username
-		FROM comments c join users u on c.authorID = u.id
-		WHERE c.replyTo = $1 order by c.createdAt DESC LIMIT $2 OFFSET $3
+		INSERT INTO comment_mentions (commentID, userID)
+		SELECT $1, unnest($2::int[])
+		ON CONFLICT DO NOTHING
+	`
+	_, err := db.Exec(ctx, query, commentID, userIDs)
+	return err
+}
+
+func (c *CommentPostgresRepository) GetCommentMentions(ctx context.Context, commentID int) ([]*models.User, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentPostgresRepository.GetCommentMentions"),
+		zap.Int("CommentID", commentID),
+	)
+
+	query := `
+		SELECT u.id, u.username
+		FROM comment_mentions m JOIN users u ON m.userID = u.id
+		WHERE m.commentID = $1
+		ORDER BY u.username
 	`
-	rows, err := c.db.Query(ctx, query, commentID, limit, offset)
+	rows, err := c.db.Query(ctx, query, commentID)
 	if err != nil {
-		log.Error("Error getting comments", zap.Error(err))
-		return nil, err
+		log.Error("Failed to get mentions", zap.Error(err))
+		return nil, mapError(err)
 	}
 	defer rows.Close()
+
+	users := make([]*models.User, 0)
 	for rows.Next() {
-		var comment models.Comment
-		comment.Author = &models.User{}
-		err = rows.Scan(
-			&comment.ID,
-			&comment.Payload,
-			&comment.PostID,
-			&comment.CreatedAt,
-			&comment.Author.ID,
-			&comment.Author.Username,
-		)
-		if err != nil {
+		var user models.User
+		if err = rows.Scan(&user.ID, &user.Username); err != nil {
 			log.Error("Failed to scan row", zap.Error(err))
-			return nil, err
+			return nil, mapError(err)
 		}
-		comments = append(comments, &comment)
+		users = append(users, &user)
 	}
 	if err = rows.Err(); err != nil {
 		log.Error("Error after reading rows", zap.Error(err))
-		return nil, err
+		return nil, mapError(err)
 	}
-	return comments, nil
+	return users, nil
 }
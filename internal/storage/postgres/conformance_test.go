@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Quizert/PostCommentService/internal/migrator"
+	"github.com/Quizert/PostCommentService/internal/storage/storagetest"
+	"github.com/Quizert/PostCommentService/migrations"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// dsnEnv - строка подключения к отдельной тестовой базе. Перед каждой проверкой все таблицы, кроме users, очищаются
+const dsnEnv = "TEST_POSTGRES_DSN"
+
+const truncateQuery = `
+	TRUNCATE posts, comments, tags, post_tags, revisions, comment_mentions, notifications,
+	         moderation_queue, reports, user_bans, post_mutes, user_blocks, outbox,
+	         webhooks, webhook_deliveries
+	RESTART IDENTITY CASCADE
+`
+
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+	ctx := context.Background()
+
+	pool, err := pgxpool.Connect(ctx, dsn)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	migrationList, err := migrator.Load(migrations.FS)
+	require.NoError(t, err)
+	conn, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	_, err = migrator.New(zap.NewNop(), conn, migrationList).Up(ctx)
+	conn.Release()
+	require.NoError(t, err)
+
+	// Тот же пул служит репликой, чтобы чтения проходили через Reader
+	db := NewDB(pool, []*pgxpool.Pool{pool}, time.Second)
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Providers {
+		_, err := pool.Exec(ctx, truncateQuery)
+		require.NoError(t, err)
+		return storagetest.Providers{
+			Posts:      NewPostPostgresRepository(db, zap.NewNop()),
+			Comments:   NewCommentPostgresRepository(db, zap.NewNop()),
+			Users:      NewUserPostgresRepository(db, zap.NewNop()),
+			Outbox:     NewOutboxPostgresRepository(db, zap.NewNop()),
+			Webhooks:   NewWebhookPostgresRepository(db, zap.NewNop()),
+			Moderation: NewModerationPostgresRepository(db, zap.NewNop()),
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DB - основная база и её реплики для чтения. Запросы через встроенный пул идут в основную базу,
+// Reader выбирает реплику. Begin, Exec и Writer считаются записью: после них чтения того же пользователя
+// в течение окна sticky идут в основную базу, чтобы он сразу видел свои изменения.
+// Запись через встроенный пул напрямую не отмечается, поэтому любая запись должна идти через эти методы
+type DB struct {
+	*pgxpool.Pool
+	replicas []*pgxpool.Pool
+	sticky   time.Duration
+	next     atomic.Uint64
+	now      func() time.Time
+
+	mu sync.Mutex
+	// writes - время последней записи пользователя
+	writes    map[int]time.Time
+	nextSweep time.Time
+}
+
+// NewDB создаёт DB. Без реплик все запросы идут в primary
+func NewDB(primary *pgxpool.Pool, replicas []*pgxpool.Pool, sticky time.Duration) *DB {
+	return &DB{
+		Pool:     primary,
+		replicas: replicas,
+		sticky:   sticky,
+		now:      time.Now,
+		writes:   make(map[int]time.Time),
+	}
+}
+
+func (db *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	db.markWrite(ctx)
+	return db.Pool.Begin(ctx)
+}
+
+func (db *DB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	db.markWrite(ctx)
+	return db.Pool.Exec(ctx, sql, args...)
+}
+
+// Writer отмечает запись и возвращает основную базу. Нужен для записи через QueryRow, например INSERT ... RETURNING
+func (db *DB) Writer(ctx context.Context) *pgxpool.Pool {
+	db.markWrite(ctx)
+	return db.Pool
+}
+
+// Reader возвращает реплику по кругу. Основная база возвращается, если реплик нет,
+// текущий пользователь недавно что-то записал или пользователь неизвестен:
+// без идентификатора нельзя понять, не читает ли вызывающий свою же запись
+func (db *DB) Reader(ctx context.Context) *pgxpool.Pool {
+	if len(db.replicas) == 0 || db.recentlyWrote(ctx) {
+		return db.Pool
+	}
+	return db.replicas[(db.next.Add(1)-1)%uint64(len(db.replicas))]
+}
+
+// Close закрывает основную базу и реплики
+func (db *DB) Close() {
+	db.Pool.Close()
+	for _, replica := range db.replicas {
+		replica.Close()
+	}
+}
+
+func (db *DB) markWrite(ctx context.Context) {
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok || len(db.replicas) == 0 || db.sticky <= 0 {
+		return
+	}
+	now := db.now()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.writes[userID] = now
+	// Раз в окно удаляем истёкшие отметки, чтобы карта не росла
+	if now.After(db.nextSweep) {
+		for id, writtenAt := range db.writes {
+			if now.Sub(writtenAt) >= db.sticky {
+				delete(db.writes, id)
+			}
+		}
+		db.nextSweep = now.Add(db.sticky)
+	}
+}
+
+func (db *DB) recentlyWrote(ctx context.Context) bool {
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return true
+	}
+	if db.sticky <= 0 {
+		return false
+	}
+
+	db.mu.Lock()
+	writtenAt, ok := db.writes[userID]
+	db.mu.Unlock()
+	return ok && db.now().Sub(writtenAt) < db.sticky
+}
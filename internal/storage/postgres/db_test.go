@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quizert/PostCommentService/internal/auth"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lazyPool создаёт пул без подключения: для проверки маршрутизации база не нужна
+func lazyPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	cfg, err := pgxpool.ParseConfig("postgres://user@127.0.0.1:1/db")
+	require.NoError(t, err)
+	cfg.LazyConnect = true
+	pool, err := pgxpool.ConnectConfig(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestDB_Reader(t *testing.T) {
+	primary, first, second := lazyPool(t), lazyPool(t), lazyPool(t)
+	alice := auth.WithUserID(context.Background(), 1)
+	bob := auth.WithUserID(context.Background(), 2)
+
+	t.Run("without replicas", func(t *testing.T) {
+		db := NewDB(primary, nil, time.Second)
+		assert.Same(t, primary, db.Reader(alice))
+	})
+
+	t.Run("round robin", func(t *testing.T) {
+		db := NewDB(primary, []*pgxpool.Pool{first, second}, time.Second)
+		assert.Same(t, first, db.Reader(alice))
+		assert.Same(t, second, db.Reader(bob))
+		assert.Same(t, first, db.Reader(alice))
+	})
+
+	t.Run("read your writes", func(t *testing.T) {
+		now := time.Now()
+		db := NewDB(primary, []*pgxpool.Pool{first}, time.Second)
+		db.now = func() time.Time { return now }
+
+		assert.Same(t, primary, db.Writer(alice))
+		assert.Same(t, primary, db.Reader(alice), "автор записи читает из основной базы")
+		assert.Same(t, first, db.Reader(bob), "остальные читают из реплики")
+
+		now = now.Add(time.Second)
+		assert.Same(t, first, db.Reader(alice), "после окна автор снова читает из реплики")
+	})
+
+	t.Run("anonymous write", func(t *testing.T) {
+		db := NewDB(primary, []*pgxpool.Pool{first}, time.Second)
+		db.Writer(context.Background())
+		assert.Same(t, first, db.Reader(alice))
+		assert.Empty(t, db.writes)
+	})
+
+	t.Run("anonymous read", func(t *testing.T) {
+		db := NewDB(primary, []*pgxpool.Pool{first}, time.Second)
+		assert.Same(t, primary, db.Reader(context.Background()), "без пользователя читаем из основной базы")
+	})
+
+	t.Run("expired marks are removed", func(t *testing.T) {
+		now := time.Now()
+		db := NewDB(primary, []*pgxpool.Pool{first}, time.Second)
+		db.now = func() time.Time { return now }
+
+		db.Writer(alice)
+		now = now.Add(2 * time.Second)
+		db.Writer(bob)
+		assert.Equal(t, map[int]time.Time{2: now}, db.writes)
+	})
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"strings"
+)
+
+// Коды ошибок Postgres, которые переводятся в ошибки пакета storage
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// mapError переводит ошибки pgx в ошибки пакета storage. Остальные ошибки возвращаются как есть
+func mapError(err error) error {
+	if err == nil || storage.IsStorageError(err) {
+		return err
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case foreignKeyViolation:
+		return &storage.ConstraintError{Kind: storage.ErrForeignKey, Field: constraintField(pgErr), Err: err}
+	case uniqueViolation:
+		return &storage.ConstraintError{Kind: storage.ErrConflict, Field: constraintField(pgErr), Err: err}
+	}
+	return err
+}
+
+// constraintField достаёт столбцы из имени ограничения. Все ограничения в миграциях названы по умолчанию:
+// <таблица>_<столбцы>_fkey, <таблица>_<столбцы>_key или <таблица>_pkey
+func constraintField(pgErr *pgconn.PgError) string {
+	name := strings.TrimPrefix(pgErr.ConstraintName, pgErr.TableName+"_")
+	for _, suffix := range []string{"_fkey", "_key"} {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok {
+			return trimmed
+		}
+	}
+	return ""
+}
@@ -0,0 +1,359 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+const moderationItemColumns = `
+	m.id, m.targetType, m.targetID, m.title, m.payload, m.filter, m.reason, m.status,
+	m.decisionReason, m.createdAt, m.decidedAt, m.wasPublished, a.id, a.username, mu.id, mu.username
+`
+
+const moderationItemFrom = `
+	FROM moderation_queue m
+	JOIN users a ON m.authorID = a.id
+	LEFT JOIN users mu ON m.moderatorID = mu.id
+`
+
+type ModerationPostgresRepository struct {
+	db  *DB
+	log *zap.Logger
+}
+
+func NewModerationPostgresRepository(db *DB, log *zap.Logger) *ModerationPostgresRepository {
+	return &ModerationPostgresRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (m *ModerationPostgresRepository) EnqueueModeration(ctx context.Context, item *models.ModerationItem) (*models.ModerationItem, error) {
+	log := m.log.With(
+		zap.String("Layer", "ModerationPostgresRepository.EnqueueModeration"),
+		zap.String("TargetType", string(item.TargetType)),
+		zap.Int("TargetID", item.TargetID),
+	)
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	stored, err := enqueueModeration(ctx, tx, item)
+	if err != nil {
+		log.Error("Failed to enqueue content", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return stored, nil
+}
+
+// enqueueModeration скрывает контент и ставит его в очередь модерации внутри транзакции
+func enqueueModeration(ctx context.Context, tx pgx.Tx, item *models.ModerationItem) (*models.ModerationItem, error) {
+	published, err := setHidden(ctx, tx, item.TargetType, item.TargetID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO moderation_queue (targetType, targetID, title, payload, authorID, filter, reason, status, wasPublished, createdAt)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'PENDING', $8, NOW())
+		RETURNING id, status, createdAt
+	`
+	stored := *item
+	stored.WasPublished = published
+	err = tx.QueryRow(ctx, query,
+		string(item.TargetType),
+		item.TargetID,
+		item.Title,
+		item.Payload,
+		item.Author.ID,
+		item.Filter,
+		item.Reason,
+		published,
+	).Scan(&stored.ID, &stored.Status, &stored.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (m *ModerationPostgresRepository) GetModerationQueue(ctx context.Context, status models.ModerationStatus, limit int, after int) ([]*models.ModerationItem, error) {
+	log := m.log.With(
+		zap.String("Layer", "ModerationPostgresRepository.GetModerationQueue"),
+		zap.String("Status", string(status)),
+	)
+
+	query := `SELECT ` + moderationItemColumns + moderationItemFrom + `
+		WHERE m.status = $1 AND m.id > $2
+		ORDER BY m.id
+		LIMIT $3
+	`
+	rows, err := m.db.Query(ctx, query, string(status), after, limit)
+	if err != nil {
+		log.Error("Error getting moderation queue", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	items := make([]*models.ModerationItem, 0, limit)
+	for rows.Next() {
+		item, err := scanModerationItem(rows)
+		if err != nil {
+			log.Error("Failed to scan row", zap.Error(err))
+			return nil, mapError(err)
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Error after reading rows", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return items, nil
+}
+
+func (m *ModerationPostgresRepository) GetModerationItem(ctx context.Context, itemID int) (*models.ModerationItem, error) {
+	log := m.log.With(
+		zap.String("Layer", "ModerationPostgresRepository.GetModerationItem"),
+		zap.Int("ItemID", itemID),
+	)
+
+	query := `SELECT ` + moderationItemColumns + moderationItemFrom + `WHERE m.id = $1`
+	item, err := scanModerationItem(m.db.QueryRow(ctx, query, itemID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Warn("Moderation item not found")
+			return nil, mapError(err)
+		}
+		log.Error("Failed to get moderation item", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return item, nil
+}
+
+func (m *ModerationPostgresRepository) ResolveModerationItem(ctx context.Context, itemID int, status models.ModerationStatus, moderatorID int, reason *string) (*models.ModerationItem, error) {
+	log := m.log.With(
+		zap.String("Layer", "ModerationPostgresRepository.ResolveModerationItem"),
+		zap.Int("ItemID", itemID),
+		zap.String("Status", string(status)),
+	)
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Условие на PENDING не даёт двум модераторам разобрать один элемент
+	var targetType models.TargetType
+	var targetID int
+	var wasPublished bool
+	err = tx.QueryRow(ctx, `
+		UPDATE moderation_queue
+		SET status = $2, moderatorID = $3, decisionReason = $4, decidedAt = NOW()
+		WHERE id = $1 AND status = 'PENDING'
+		RETURNING targetType, targetID, wasPublished
+	`, itemID, string(status), moderatorID, reason).Scan(&targetType, &targetID, &wasPublished)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Warn("Moderation item not found or already resolved")
+			return nil, mapError(err)
+		}
+		log.Error("Failed to resolve moderation item", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	if status == models.ModerationStatusApproved {
+		// Событие о публикации пишется, только если контент публикуется впервые: повторно одобренный после жалоб
+		// или правки контент и контент, уже показанный по другому элементу очереди, подписчикам не рассылается
+		published, err := setHidden(ctx, tx, targetType, targetID, false)
+		if err != nil {
+			log.Error("Failed to show content", zap.Error(err))
+			return nil, mapError(err)
+		}
+		if published && !wasPublished {
+			if _, err = tx.Exec(ctx, `UPDATE moderation_queue SET wasPublished = true WHERE id = $1`, itemID); err != nil {
+				log.Error("Failed to mark moderation item", zap.Error(err))
+				return nil, mapError(err)
+			}
+		}
+		if !published && !wasPublished {
+			if err = insertPublishedEvent(ctx, tx, targetType, targetID); err != nil {
+				log.Error("Failed to write outbox event", zap.Error(err))
+				return nil, mapError(err)
+			}
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return m.GetModerationItem(ctx, itemID)
+}
+
+func (m *ModerationPostgresRepository) CreateReport(ctx context.Context, report *models.Report) (*models.Report, int, error) {
+	log := m.log.With(
+		zap.String("Layer", "ModerationPostgresRepository.CreateReport"),
+		zap.String("TargetType", string(report.TargetType)),
+		zap.Int("TargetID", report.TargetID),
+		zap.Int("ReporterID", report.Reporter.ID),
+	)
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, 0, mapError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Уникальный индекс (targetType, targetID, reporterID) отсекает повторную жалобу
+	stored := *report
+	created := true
+	err = tx.QueryRow(ctx, `
+		INSERT INTO reports (targetType, targetID, reporterID, reason, note, createdAt)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (targetType, targetID, reporterID) DO NOTHING
+		RETURNING id, createdAt
+	`, string(report.TargetType), report.TargetID, report.Reporter.ID, string(report.Reason), report.Note).Scan(&stored.ID, &stored.CreatedAt)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Error("Failed to create report", zap.Error(err))
+			return nil, 0, mapError(err)
+		}
+		created = false
+	}
+
+	var count int
+	err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM reports WHERE targetType = $1 AND targetID = $2`,
+		string(report.TargetType), report.TargetID).Scan(&count)
+	if err != nil {
+		log.Error("Failed to count reports", zap.Error(err))
+		return nil, 0, mapError(err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, 0, mapError(err)
+	}
+	if !created {
+		log.Info("Duplicate report ignored")
+		return nil, count, nil
+	}
+	return &stored, count, nil
+}
+
+func (m *ModerationPostgresRepository) GetReportSummaries(ctx context.Context, limit int, offset int) ([]*models.ReportSummary, error) {
+	log := m.log.With(
+		zap.String("Layer", "ModerationPostgresRepository.GetReportSummaries"),
+	)
+
+	// Сначала выбираем страницу контента, затем считаем жалобы на него по причинам
+	query := `
+		WITH targets AS (
+			SELECT targetType, targetID, COUNT(*) AS total, MAX(createdAt) AS lastReportedAt
+			FROM reports
+			GROUP BY targetType, targetID
+			ORDER BY total DESC, lastReportedAt DESC, targetType, targetID
+			LIMIT $1 OFFSET $2
+		)
+		SELECT t.targetType, t.targetID, t.total, t.lastReportedAt, r.reason, COUNT(*) AS reasonCount
+		FROM targets t
+		JOIN reports r ON r.targetType = t.targetType AND r.targetID = t.targetID
+		GROUP BY t.targetType, t.targetID, t.total, t.lastReportedAt, r.reason
+		ORDER BY t.total DESC, t.lastReportedAt DESC, t.targetType, t.targetID, reasonCount DESC, r.reason
+	`
+	rows, err := m.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		log.Error("Error getting report summaries", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	summaries := make([]*models.ReportSummary, 0, limit)
+	var current *models.ReportSummary
+	for rows.Next() {
+		var summary models.ReportSummary
+		var reason models.ReportReasonCount
+		err = rows.Scan(&summary.TargetType, &summary.TargetID, &summary.Count, &summary.LastReportedAt, &reason.Reason, &reason.Count)
+		if err != nil {
+			log.Error("Failed to scan row", zap.Error(err))
+			return nil, mapError(err)
+		}
+		if current == nil || current.TargetType != summary.TargetType || current.TargetID != summary.TargetID {
+			current = &summary
+			current.Reasons = make([]*models.ReportReasonCount, 0)
+			summaries = append(summaries, current)
+		}
+		current.Reasons = append(current.Reasons, &reason)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Error after reading rows", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return summaries, nil
+}
+
+// setHidden скрывает или возвращает в выдачу пост или комментарий внутри транзакции.
+// Возвращает, был ли контент опубликован до изменения: не скрыт, а пост ещё и в статусе PUBLISHED
+func setHidden(ctx context.Context, tx pgx.Tx, targetType models.TargetType, targetID int, hidden bool) (bool, error) {
+	query := `SELECT NOT isHidden AND status = 'PUBLISHED' FROM posts WHERE id = $1 FOR UPDATE`
+	update := `UPDATE posts SET isHidden = $2 WHERE id = $1`
+	if targetType == models.TargetTypeComment {
+		query = `SELECT NOT isHidden FROM comments WHERE id = $1 FOR UPDATE`
+		update = `UPDATE comments SET isHidden = $2 WHERE id = $1`
+	}
+
+	var published bool
+	if err := tx.QueryRow(ctx, query, targetID).Scan(&published); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, update, targetID, hidden); err != nil {
+		return false, err
+	}
+	return published, nil
+}
+
+func scanModerationItem(row pgx.Row) (*models.ModerationItem, error) {
+	var item models.ModerationItem
+	var moderatorID *int
+	var moderatorUsername *string
+	item.Author = &models.User{}
+
+	err := row.Scan(
+		&item.ID,
+		&item.TargetType,
+		&item.TargetID,
+		&item.Title,
+		&item.Payload,
+		&item.Filter,
+		&item.Reason,
+		&item.Status,
+		&item.DecisionReason,
+		&item.CreatedAt,
+		&item.DecidedAt,
+		&item.WasPublished,
+		&item.Author.ID,
+		&item.Author.Username,
+		&moderatorID,
+		&moderatorUsername,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if moderatorID != nil {
+		item.Moderator = &models.User{ID: *moderatorID, Username: *moderatorUsername}
+	}
+	return &item, nil
+}
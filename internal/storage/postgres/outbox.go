@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+// execer - общее у пула и транзакции, чтобы вспомогательные запросы работали и там, и там
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+// insertOutboxEvent записывает событие в outbox. Вызывается в транзакции изменения, которое породило событие
+func insertOutboxEvent(ctx context.Context, tx pgx.Tx, eventType models.OutboxEventType, aggregateID int) error {
+	query := `
+		INSERT INTO outbox (eventType, aggregateID, nextAttemptAt, createdAt)
+		VALUES ($1, $2, NOW(), NOW())
+	`
+	_, err := tx.Exec(ctx, query, string(eventType), aggregateID)
+	return err
+}
+
+// insertPublishedEvent записывает событие о том, что модератор вернул контент в выдачу.
+// Пост становится виден всем, только если он уже опубликован
+func insertPublishedEvent(ctx context.Context, tx pgx.Tx, targetType models.TargetType, targetID int) error {
+	if targetType == models.TargetTypeComment {
+		return insertOutboxEvent(ctx, tx, models.OutboxEventCommentPublished, targetID)
+	}
+	query := `
+		INSERT INTO outbox (eventType, aggregateID, nextAttemptAt, createdAt)
+		SELECT $1, id, NOW(), NOW() FROM posts WHERE id = $2 AND status = 'PUBLISHED'
+	`
+	_, err := tx.Exec(ctx, query, string(models.OutboxEventPostPublished), targetID)
+	return err
+}
+
+type OutboxPostgresRepository struct {
+	db  *DB
+	log *zap.Logger
+}
+
+func NewOutboxPostgresRepository(db *DB, log *zap.Logger) *OutboxPostgresRepository {
+	return &OutboxPostgresRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// ClaimOutboxEvents выдаёт события, срок попытки которых наступил. SKIP LOCKED позволяет нескольким
+// экземплярам сервиса разбирать outbox одновременно, не получая одни и те же события
+func (o *OutboxPostgresRepository) ClaimOutboxEvents(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	log := o.log.With(zap.String("Layer", "OutboxPostgresRepository.ClaimOutboxEvents"))
+
+	query := `
+		UPDATE outbox SET nextAttemptAt = $2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE deliveredAt IS NULL AND nextAttemptAt <= $1
+			ORDER BY nextAttemptAt, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, eventType, aggregateID, attempts, lastError, nextAttemptAt, deliveredAt, createdAt
+	`
+	rows, err := o.db.Writer(ctx).Query(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		log.Error("Failed to claim outbox events", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	events := make([]*models.OutboxEvent, 0)
+	for rows.Next() {
+		var event models.OutboxEvent
+		var eventType string
+		err = rows.Scan(
+			&event.ID,
+			&eventType,
+			&event.AggregateID,
+			&event.Attempts,
+			&event.LastError,
+			&event.NextAttemptAt,
+			&event.DeliveredAt,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			log.Error("Failed to scan outbox event", zap.Error(err))
+			return nil, mapError(err)
+		}
+		event.Type = models.OutboxEventType(eventType)
+		events = append(events, &event)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Failed to read outbox events", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	// RETURNING не сохраняет порядок подзапроса
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+func (o *OutboxPostgresRepository) MarkOutboxEventDelivered(ctx context.Context, id int) error {
+	log := o.log.With(zap.String("Layer", "OutboxPostgresRepository.MarkOutboxEventDelivered"), zap.Int("EventID", id))
+
+	tag, err := o.db.Exec(ctx, `UPDATE outbox SET deliveredAt = NOW(), lastError = NULL WHERE id = $1`, id)
+	if err != nil {
+		log.Error("Failed to mark outbox event delivered", zap.Error(err))
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		log.Warn("Failed to mark outbox event delivered: not found")
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (o *OutboxPostgresRepository) RetryOutboxEvent(ctx context.Context, id int, nextAttemptAt time.Time, lastError string) error {
+	log := o.log.With(zap.String("Layer", "OutboxPostgresRepository.RetryOutboxEvent"), zap.Int("EventID", id))
+
+	query := `UPDATE outbox SET nextAttemptAt = $2, lastError = $3 WHERE id = $1 AND deliveredAt IS NULL`
+	tag, err := o.db.Exec(ctx, query, id, nextAttemptAt, lastError)
+	if err != nil {
+		log.Error("Failed to reschedule outbox event", zap.Error(err))
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		log.Warn("Failed to reschedule outbox event: not found or delivered")
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (o *OutboxPostgresRepository) DeleteDeliveredOutboxEvents(ctx context.Context, before time.Time) (int, error) {
+	log := o.log.With(zap.String("Layer", "OutboxPostgresRepository.DeleteDeliveredOutboxEvents"))
+
+	tag, err := o.db.Exec(ctx, `DELETE FROM outbox WHERE deliveredAt IS NOT NULL AND deliveredAt < $1`, before)
+	if err != nil {
+		log.Error("Failed to delete delivered outbox events", zap.Error(err))
+		return 0, mapError(err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -4,46 +4,107 @@ import (
 	"context"
 	"errors"
 	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/Quizert/PostCommentService/internal/utils"
 	"github.com/jackc/pgx/v4"
-	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/zap"
+	"strings"
+	"time"
 )
 
+// visiblePostsCondition отбирает посты, которые видит пользователь $3: опубликованные и
+// отложенные с наступившим временем публикации, если они не скрыты модерацией, и все собственные посты
+const visiblePostsCondition = `(((p.status = 'PUBLISHED' OR (p.status = 'SCHEDULED' AND p.publishAt <= NOW())) AND NOT p.isHidden) OR p.authorID = $3)`
+
 type PostPostgresRepository struct {
-	db  *pgxpool.Pool
+	db  *DB
 	log *zap.Logger
 }
 
-func NewPostPostgresRepository(db *pgxpool.Pool, log *zap.Logger) *PostPostgresRepository {
+func NewPostPostgresRepository(db *DB, log *zap.Logger) *PostPostgresRepository {
 	return &PostPostgresRepository{
 		db:  db,
 		log: log,
 	}
 }
 
-func (p *PostPostgresRepository) CreatePost(ctx context.Context, input models.NewPost) (*models.Post, error) {
+func (p *PostPostgresRepository) CreatePost(ctx context.Context, input models.NewPost, hidden bool) (*models.Post, error) {
 	log := p.log.With(
 		zap.String("Layer", "PostPostgresRepository.CreatePost"),
 		zap.String("Title", input.Title),
 		zap.Int("AuthorID", input.AuthorID),
 	)
 
-	query := `INSERT INTO posts (title, payload, authorID, isCommentsAllowed, createdAt)
-              VALUES ($1, $2, $3, $4, NOW())
-              RETURNING id, createdAt`
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	status := models.PostStatusPublished
+	if input.Status != nil {
+		status = *input.Status
+	}
+	format := models.ContentFormatPlain
+	if input.Format != nil {
+		format = *input.Format
+	}
+
+	// Опубликованный сразу пост получает publishAt = createdAt
+	query := `INSERT INTO posts (title, payload, authorID, isCommentsAllowed, status, publishAt, format, isHidden, createdAt)
+              VALUES ($1, $2, $3, $4, $5, CASE WHEN $5 = 'PUBLISHED' THEN COALESCE($6, NOW()) ELSE $6 END, $7, $8, NOW())
+              RETURNING id, publishAt, createdAt, (SELECT username FROM users WHERE id = $3)`
 
 	var post models.Post
-	err := p.db.QueryRow(ctx, query, input.Title, input.Payload, input.AuthorID, input.IsCommentsAllowed).
-		Scan(&post.ID, &post.CreatedAt)
+	post.Author = &models.User{ID: input.AuthorID}
+	err = tx.QueryRow(ctx, query, input.Title, input.Payload, input.AuthorID, input.IsCommentsAllowed, string(status), input.PublishAt, string(format), hidden).
+		Scan(&post.ID, &post.PublishAt, &post.CreatedAt, &post.Author.Username)
 
 	if err != nil {
 		log.Error("Failed to create post", zap.Error(err))
-		return nil, err
+		return nil, mapError(err)
+	}
+
+	for _, tag := range input.Tags {
+		// DO UPDATE нужен, чтобы RETURNING вернул id и для уже существующего тега
+		var tagID int
+		err = tx.QueryRow(ctx, `
+			INSERT INTO tags (name) VALUES ($1)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		`, tag).Scan(&tagID)
+		if err != nil {
+			log.Error("Failed to create tag", zap.String("Tag", tag), zap.Error(err))
+			return nil, mapError(err)
+		}
+
+		_, err = tx.Exec(ctx, `INSERT INTO post_tags (postID, tagID) VALUES ($1, $2) ON CONFLICT DO NOTHING`, post.ID, tagID)
+		if err != nil {
+			log.Error("Failed to attach tag to post", zap.String("Tag", tag), zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if status == models.PostStatusPublished && !hidden {
+		if err = insertOutboxEvent(ctx, tx, models.OutboxEventPostPublished, post.ID); err != nil {
+			log.Error("Failed to write outbox event", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
 	}
 
 	post.Title = input.Title
 	post.Payload = input.Payload
 	post.IsCommentsAllowed = input.IsCommentsAllowed
+	post.Tags = utils.SortTags(input.Tags)
+	post.Status = status
+	post.Format = format
+	post.IsHidden = hidden
 
 	return &post, nil
 }
@@ -57,33 +118,40 @@ func (p *PostPostgresRepository) GetPostByID(ctx context.Context, id int) (*mode
 	post.Author = &models.User{}
 
 	query := `
-		SELECT p.id, p.title, p.payload, p.isCommentsAllowed, p.createdAt, u.id as author_id, u.username as author_username
+		SELECT p.id, p.title, p.payload, p.format, p.isCommentsAllowed, p.status, p.isHidden, p.publishAt, p.editedAt, p.createdAt, u.id as author_id, u.username as author_username,
+		       ARRAY(SELECT t.name FROM post_tags pt JOIN tags t ON pt.tagID = t.id WHERE pt.postID = p.id ORDER BY t.name) as tags
 		FROM posts p JOIN users u ON p.authorID = u.id
 		WHERE p.id = $1
 	`
 
 	// Выполняем запрос и сканируем результат в структуру Post
-	err := p.db.QueryRow(ctx, query, id).Scan(
+	err := p.db.Reader(ctx).QueryRow(ctx, query, id).Scan(
 		&post.ID,
 		&post.Title,
 		&post.Payload,
+		&post.Format,
 		&post.IsCommentsAllowed,
+		&post.Status,
+		&post.IsHidden,
+		&post.PublishAt,
+		&post.EditedAt,
 		&post.CreatedAt,
 		&post.Author.ID,
 		&post.Author.Username,
+		&post.Tags,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			log.Warn("Failed to get post", zap.Error(err))
-			return nil, err
+			return nil, mapError(err)
 		}
 		log.Error("Failed to get post", zap.Error(err))
-		return nil, err
+		return nil, mapError(err)
 	}
 	return &post, nil
 }
 
-func (p *PostPostgresRepository) GetAllPosts(ctx context.Context, limit int, offset int) ([]*models.Post, error) {
+func (p *PostPostgresRepository) GetAllPosts(ctx context.Context, limit int, offset int, viewerID int) ([]*models.Post, error) {
 	log := p.log.With(
 		zap.String("Layer", "PostPostgresRepository.GetAllPosts"),
 	)
@@ -91,41 +159,266 @@ func (p *PostPostgresRepository) GetAllPosts(ctx context.Context, limit int, off
 	posts := make([]*models.Post, 0, limit)
 
 	query := `
-		SELECT p.id, p.title, p.payload, p.isCommentsAllowed, p.createdAt, u.id, u.username
+		SELECT p.id, p.title, p.payload, p.format, p.isCommentsAllowed, p.status, p.isHidden, p.publishAt, p.editedAt, p.createdAt, u.id, u.username,
+		       ARRAY(SELECT t.name FROM post_tags pt JOIN tags t ON pt.tagID = t.id WHERE pt.postID = p.id ORDER BY t.name)
 		FROM posts p join users u on p.authorID = u.id 
-		ORDER BY p.createdAt DESC LIMIT $1 OFFSET $2
+		WHERE ` + visiblePostsCondition + `
+		ORDER BY COALESCE(p.publishAt, p.createdAt) DESC LIMIT $1 OFFSET $2
+	`
+
+	rows, err := p.db.Reader(ctx).Query(ctx, query, limit, offset, viewerID)
+	if err != nil {
+		log.Error("Failed to get posts", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	return p.scanPosts(rows, posts, log)
+}
+
+func (p *PostPostgresRepository) GetPostsByTag(ctx context.Context, tag string, limit int, offset int, viewerID int) ([]*models.Post, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostPostgresRepository.GetPostsByTag"),
+		zap.String("Tag", tag),
+	)
+
+	posts := make([]*models.Post, 0, limit)
+
+	query := `
+		SELECT p.id, p.title, p.payload, p.format, p.isCommentsAllowed, p.status, p.isHidden, p.publishAt, p.editedAt, p.createdAt, u.id, u.username,
+		       ARRAY(SELECT t.name FROM post_tags pt JOIN tags t ON pt.tagID = t.id WHERE pt.postID = p.id ORDER BY t.name)
+		FROM posts p
+		JOIN users u ON p.authorID = u.id
+		JOIN post_tags pt ON pt.postID = p.id
+		JOIN tags t ON pt.tagID = t.id
+		WHERE t.name = $4 AND ` + visiblePostsCondition + `
+		ORDER BY COALESCE(p.publishAt, p.createdAt) DESC LIMIT $1 OFFSET $2
 	`
 
-	rows, err := p.db.Query(ctx, query, limit, offset)
+	rows, err := p.db.Query(ctx, query, limit, offset, viewerID, tag)
 	if err != nil {
 		log.Error("Failed to get posts", zap.Error(err))
-		return nil, err
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	return p.scanPosts(rows, posts, log)
+}
+
+func (p *PostPostgresRepository) GetTags(ctx context.Context, prefix string, limit int) ([]string, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostPostgresRepository.GetTags"),
+		zap.String("Prefix", prefix),
+	)
+
+	// Экранируем спецсимволы LIKE, чтобы префикс искался буквально
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)
+
+	query := `
+		SELECT t.name
+		FROM tags t LEFT JOIN post_tags pt ON pt.tagID = t.id
+		WHERE t.name LIKE $1 || '%'
+		GROUP BY t.name
+		ORDER BY count(pt.postID) DESC, t.name
+		LIMIT $2
+	`
+
+	rows, err := p.db.Query(ctx, query, escaped, limit)
+	if err != nil {
+		log.Error("Failed to get tags", zap.Error(err))
+		return nil, mapError(err)
 	}
 	defer rows.Close()
+
+	tags := make([]string, 0, limit)
+	for rows.Next() {
+		var tag string
+		if err = rows.Scan(&tag); err != nil {
+			log.Error("Failed to scan row", zap.Error(err))
+			return nil, mapError(err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Error after reading rows", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return tags, nil
+}
+
+func (p *PostPostgresRepository) scanPosts(rows pgx.Rows, posts []*models.Post, log *zap.Logger) ([]*models.Post, error) {
 	for rows.Next() {
 		var post models.Post
 		post.Author = &models.User{}
-		err = rows.Scan(
+		err := rows.Scan(
 			&post.ID,
 			&post.Title,
 			&post.Payload,
+			&post.Format,
 			&post.IsCommentsAllowed,
+			&post.Status,
+			&post.IsHidden,
+			&post.PublishAt,
+			&post.EditedAt,
 			&post.CreatedAt,
 			&post.Author.ID,
 			&post.Author.Username,
+			&post.Tags,
 		)
 		if err != nil {
 			log.Error("Failed to get posts", zap.Error(err))
-			return nil, err
+			return nil, mapError(err)
 		}
 		posts = append(posts, &post)
-
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		log.Error("Error after reading rows", zap.Error(err))
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	return posts, nil
 }
+
+func (p *PostPostgresRepository) UpdatePostStatus(ctx context.Context, postID int, status models.PostStatus, publishAt *time.Time) (*models.Post, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostPostgresRepository.UpdatePostStatus"),
+		zap.Int("PostID", postID),
+		zap.String("Status", string(status)),
+	)
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	var oldStatus models.PostStatus
+	var hidden bool
+	err = tx.QueryRow(ctx, `SELECT status, isHidden FROM posts WHERE id = $1 FOR UPDATE`, postID).Scan(&oldStatus, &hidden)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Warn("Failed to update post status: post not found")
+			return nil, storage.ErrNotFound
+		}
+		log.Error("Failed to get post", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	query := `
+		UPDATE posts
+		SET status = $2, publishAt = CASE WHEN $2 = 'PUBLISHED' THEN COALESCE($3, NOW()) ELSE $3 END
+		WHERE id = $1
+	`
+	if _, err = tx.Exec(ctx, query, postID, string(status), publishAt); err != nil {
+		log.Error("Failed to update post status", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	if status == models.PostStatusPublished && oldStatus != models.PostStatusPublished && !hidden {
+		if err = insertOutboxEvent(ctx, tx, models.OutboxEventPostPublished, postID); err != nil {
+			log.Error("Failed to write outbox event", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	return p.GetPostByID(ctx, postID)
+}
+
+func (p *PostPostgresRepository) PublishScheduledPosts(ctx context.Context, now time.Time) (int, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostPostgresRepository.PublishScheduledPosts"),
+	)
+
+	// События о публикации пишутся тем же запросом, скрытые модерацией посты их не получают
+	query := `
+		WITH published AS (
+			UPDATE posts SET status = 'PUBLISHED' WHERE status = 'SCHEDULED' AND publishAt <= $1
+			RETURNING id, isHidden
+		), events AS (
+			INSERT INTO outbox (eventType, aggregateID, nextAttemptAt, createdAt)
+			SELECT $2, id, NOW(), NOW() FROM published WHERE NOT isHidden
+		)
+		SELECT COUNT(*) FROM published
+	`
+
+	var published int
+	if err := p.db.Writer(ctx).QueryRow(ctx, query, now, string(models.OutboxEventPostPublished)).Scan(&published); err != nil {
+		log.Error("Failed to publish scheduled posts", zap.Error(err))
+		return 0, mapError(err)
+	}
+	return published, nil
+}
+
+func (p *PostPostgresRepository) UpdatePost(ctx context.Context, postID int, input models.EditPost, editorID int, hold *models.ModerationItem) (*models.Post, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostPostgresRepository.UpdatePost"),
+		zap.Int("PostID", postID),
+		zap.Int("EditorID", editorID),
+	)
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	var oldTitle, oldPayload string
+	err = tx.QueryRow(ctx, `SELECT title, payload FROM posts WHERE id = $1 FOR UPDATE`, postID).Scan(&oldTitle, &oldPayload)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Warn("Failed to update post: not found")
+			return nil, mapError(err)
+		}
+		log.Error("Failed to get post", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	if err = insertRevision(ctx, tx, revisionTargetPost, postID, &oldTitle, oldPayload, editorID); err != nil {
+		log.Error("Failed to save revision", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	query := `
+		UPDATE posts
+		SET title = COALESCE($2, title), payload = COALESCE($3, payload), editedAt = NOW()
+		WHERE id = $1
+	`
+	if _, err = tx.Exec(ctx, query, postID, input.Title, input.Payload); err != nil {
+		log.Error("Failed to update post", zap.Error(err))
+		return nil, mapError(err)
+	}
+	if hold != nil {
+		if _, err = enqueueModeration(ctx, tx, hold); err != nil {
+			log.Error("Failed to enqueue post", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	return p.GetPostByID(ctx, postID)
+}
+
+func (p *PostPostgresRepository) GetPostRevisions(ctx context.Context, postID int) ([]*models.Revision, error) {
+	revisions, err := getRevisions(ctx, p.db.Pool, revisionTargetPost, postID)
+	if err != nil {
+		p.log.Error("Failed to get post revisions",
+			zap.String("Layer", "PostPostgresRepository.GetPostRevisions"),
+			zap.Int("PostID", postID),
+			zap.Error(err),
+		)
+		return nil, mapError(err)
+	}
+	return revisions, nil
+}
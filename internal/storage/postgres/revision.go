@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	revisionTargetPost    = "POST"
+	revisionTargetComment = "COMMENT"
+)
+
+// insertRevision сохраняет версию контента до правки. Номер версии считается внутри той же транзакции,
+// строка контента к этому моменту уже заблокирована через SELECT ... FOR UPDATE
+func insertRevision(ctx context.Context, tx pgx.Tx, targetType string, targetID int, title *string, payload string, editorID int) error {
+	query := `
+		INSERT INTO revisions (targetType, targetID, version, title, payload, editorID, editedAt)
+		VALUES ($1, $2,
+		        (SELECT COALESCE(MAX(version), 0) + 1 FROM revisions WHERE targetType = $1 AND targetID = $2),
+		        $3, $4, $5, NOW())
+	`
+	_, err := tx.Exec(ctx, query, targetType, targetID, title, payload, editorID)
+	return err
+}
+
+func getRevisions(ctx context.Context, db *pgxpool.Pool, targetType string, targetID int) ([]*models.Revision, error) {
+	query := `
+		SELECT r.id, r.version, r.title, r.payload, r.editedAt, u.id, u.username
+		FROM revisions r JOIN users u ON r.editorID = u.id
+		WHERE r.targetType = $1 AND r.targetID = $2
+		ORDER BY r.version
+	`
+
+	rows, err := db.Query(ctx, query, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]*models.Revision, 0)
+	for rows.Next() {
+		var revision models.Revision
+		revision.Editor = &models.User{}
+		err = rows.Scan(
+			&revision.ID,
+			&revision.Version,
+			&revision.Title,
+			&revision.Payload,
+			&revision.EditedAt,
+			&revision.Editor.ID,
+			&revision.Editor.Username,
+		)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, &revision)
+	}
+	return revisions, rows.Err()
+}
@@ -4,17 +4,17 @@ import (
 	"context"
 	"errors"
 	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
 	"github.com/jackc/pgx/v4"
-	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/zap"
 )
 
 type UserPostgresRepository struct {
-	db  *pgxpool.Pool
+	db  *DB
 	log *zap.Logger
 }
 
-func NewUserPostgresRepository(db *pgxpool.Pool, log *zap.Logger) *UserPostgresRepository {
+func NewUserPostgresRepository(db *DB, log *zap.Logger) *UserPostgresRepository {
 	return &UserPostgresRepository{
 		db:  db,
 		log: log,
@@ -28,16 +28,222 @@ func (p *UserPostgresRepository) GetUserByID(ctx context.Context, userID int) (*
 	)
 
 	var user models.User
-	query := `SELECT id, username FROM users WHERE id = $1`
-	err := p.db.QueryRow(ctx, query, userID).Scan(&user.ID, &user.Username)
+	query := `SELECT id, username, role FROM users WHERE id = $1`
+	err := p.db.Reader(ctx).QueryRow(ctx, query, userID).Scan(&user.ID, &user.Username, &user.Role)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			log.Warn("User does not exist")
-			return nil, err
+			return nil, mapError(err)
 		}
 		log.Error("Error getting user", zap.Error(err))
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	return &user, nil
 }
+
+func (p *UserPostgresRepository) GetUsersByUsernames(ctx context.Context, usernames []string) ([]*models.User, error) {
+	log := p.log.With(
+		zap.String("Layer", "UserPostgresRepository.GetUsersByUsernames"),
+		zap.Strings("Usernames", usernames),
+	)
+
+	query := `SELECT id, username, role FROM users WHERE lower(username) = ANY(SELECT lower(unnest($1::text[])))`
+	rows, err := p.db.Query(ctx, query, usernames)
+	if err != nil {
+		log.Error("Error getting users", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0, len(usernames))
+	for rows.Next() {
+		var user models.User
+		if err = rows.Scan(&user.ID, &user.Username, &user.Role); err != nil {
+			log.Error("Failed to scan row", zap.Error(err))
+			return nil, mapError(err)
+		}
+		users = append(users, &user)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Error after reading rows", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return users, nil
+}
+
+func (p *UserPostgresRepository) SetUserRole(ctx context.Context, userID int, role models.UserRole) error {
+	log := p.log.With(
+		zap.String("Layer", "UserPostgresRepository.SetUserRole"),
+		zap.Int("UserID", userID),
+		zap.String("Role", string(role)),
+	)
+
+	tag, err := p.db.Exec(ctx, `UPDATE users SET role = $2 WHERE id = $1`, userID, role)
+	if err != nil {
+		log.Error("Failed to update user role", zap.Error(err))
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		log.Warn("Failed to update user role: user not found")
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (p *UserPostgresRepository) BanUser(ctx context.Context, ban *models.UserBan) (*models.UserBan, error) {
+	log := p.log.With(
+		zap.String("Layer", "UserPostgresRepository.BanUser"),
+		zap.Int("UserID", ban.User.ID),
+	)
+
+	query := `
+		INSERT INTO user_bans (userID, until, reason, moderatorID, createdAt)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (userID) DO UPDATE
+		SET until = EXCLUDED.until, reason = EXCLUDED.reason, moderatorID = EXCLUDED.moderatorID, createdAt = EXCLUDED.createdAt
+	`
+	_, err := p.db.Exec(ctx, query, ban.User.ID, ban.Until, ban.Reason, ban.Moderator.ID)
+	if err != nil {
+		log.Error("Failed to ban user", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return p.GetUserBan(ctx, ban.User.ID)
+}
+
+func (p *UserPostgresRepository) GetUserBan(ctx context.Context, userID int) (*models.UserBan, error) {
+	log := p.log.With(
+		zap.String("Layer", "UserPostgresRepository.GetUserBan"),
+		zap.Int("UserID", userID),
+	)
+
+	query := `
+		SELECT b.until, b.reason, b.createdAt, u.id, u.username, m.id, m.username
+		FROM user_bans b
+		JOIN users u ON b.userID = u.id
+		JOIN users m ON b.moderatorID = m.id
+		WHERE b.userID = $1
+	`
+	ban := models.UserBan{User: &models.User{}, Moderator: &models.User{}}
+	err := p.db.QueryRow(ctx, query, userID).Scan(
+		&ban.Until,
+		&ban.Reason,
+		&ban.CreatedAt,
+		&ban.User.ID,
+		&ban.User.Username,
+		&ban.Moderator.ID,
+		&ban.Moderator.Username,
+	)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Error("Error getting user ban", zap.Error(err))
+		}
+		return nil, mapError(err)
+	}
+	return &ban, nil
+}
+
+func (p *UserPostgresRepository) MuteUserOnPost(ctx context.Context, mute *models.PostMute) (*models.PostMute, error) {
+	log := p.log.With(
+		zap.String("Layer", "UserPostgresRepository.MuteUserOnPost"),
+		zap.Int("PostID", mute.PostID),
+		zap.Int("UserID", mute.User.ID),
+	)
+
+	query := `
+		INSERT INTO post_mutes (postID, userID, until, mutedBy, createdAt)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (postID, userID) DO UPDATE
+		SET until = EXCLUDED.until, mutedBy = EXCLUDED.mutedBy, createdAt = EXCLUDED.createdAt
+	`
+	_, err := p.db.Exec(ctx, query, mute.PostID, mute.User.ID, mute.Until, mute.MutedBy.ID)
+	if err != nil {
+		log.Error("Failed to mute user on post", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return p.GetPostMute(ctx, mute.PostID, mute.User.ID)
+}
+
+func (p *UserPostgresRepository) GetPostMute(ctx context.Context, postID int, userID int) (*models.PostMute, error) {
+	log := p.log.With(
+		zap.String("Layer", "UserPostgresRepository.GetPostMute"),
+		zap.Int("PostID", postID),
+		zap.Int("UserID", userID),
+	)
+
+	query := `
+		SELECT pm.postID, pm.until, pm.createdAt, u.id, u.username, m.id, m.username
+		FROM post_mutes pm
+		JOIN users u ON pm.userID = u.id
+		JOIN users m ON pm.mutedBy = m.id
+		WHERE pm.postID = $1 AND pm.userID = $2
+	`
+	mute := models.PostMute{User: &models.User{}, MutedBy: &models.User{}}
+	err := p.db.QueryRow(ctx, query, postID, userID).Scan(
+		&mute.PostID,
+		&mute.Until,
+		&mute.CreatedAt,
+		&mute.User.ID,
+		&mute.User.Username,
+		&mute.MutedBy.ID,
+		&mute.MutedBy.Username,
+	)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Error("Error getting post mute", zap.Error(err))
+		}
+		return nil, mapError(err)
+	}
+	return &mute, nil
+}
+
+func (p *UserPostgresRepository) BlockUser(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	log := p.log.With(
+		zap.String("Layer", "UserPostgresRepository.BlockUser"),
+		zap.Int("BlockerID", blockerID),
+		zap.Int("BlockedID", blockedID),
+	)
+
+	query := `
+		INSERT INTO user_blocks (blockerID, blockedID, createdAt)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (blockerID, blockedID) DO NOTHING
+	`
+	tag, err := p.db.Exec(ctx, query, blockerID, blockedID)
+	if err != nil {
+		log.Error("Failed to block user", zap.Error(err))
+		return false, mapError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (p *UserPostgresRepository) UnblockUser(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	log := p.log.With(
+		zap.String("Layer", "UserPostgresRepository.UnblockUser"),
+		zap.Int("BlockerID", blockerID),
+		zap.Int("BlockedID", blockedID),
+	)
+
+	tag, err := p.db.Exec(ctx, `DELETE FROM user_blocks WHERE blockerID = $1 AND blockedID = $2`, blockerID, blockedID)
+	if err != nil {
+		log.Error("Failed to unblock user", zap.Error(err))
+		return false, mapError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (p *UserPostgresRepository) IsBlocked(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	log := p.log.With(
+		zap.String("Layer", "UserPostgresRepository.IsBlocked"),
+		zap.Int("BlockerID", blockerID),
+		zap.Int("BlockedID", blockedID),
+	)
+
+	var blocked bool
+	query := `SELECT EXISTS (SELECT 1 FROM user_blocks WHERE blockerID = $1 AND blockedID = $2)`
+	if err := p.db.QueryRow(ctx, query, blockerID, blockedID).Scan(&blocked); err != nil {
+		log.Error("Failed to check block", zap.Error(err))
+		return false, mapError(err)
+	}
+	return blocked, nil
+}
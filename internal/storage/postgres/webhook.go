@@ -0,0 +1,326 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+const webhookColumns = `id, url, secret, eventTypes, isActive, createdAt`
+
+const webhookDeliveryColumns = `
+	id, webhookID, eventID, eventType, payload, status, attempts, responseStatus, lastError, nextAttemptAt, deliveredAt, createdAt
+`
+
+// WebhookPostgresRepository читает и пишет только в основную базу: вебхуков мало, а диспетчер
+// не должен видеть отставшую от записи реплику
+type WebhookPostgresRepository struct {
+	db  *DB
+	log *zap.Logger
+}
+
+func NewWebhookPostgresRepository(db *DB, log *zap.Logger) *WebhookPostgresRepository {
+	return &WebhookPostgresRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (w *WebhookPostgresRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookPostgresRepository.CreateWebhook"),
+		zap.String("URL", webhook.URL),
+	)
+
+	query := `
+		INSERT INTO webhooks (url, secret, eventTypes, isActive, createdAt)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING ` + webhookColumns
+
+	created, err := scanWebhook(w.db.Writer(ctx).QueryRow(ctx, query, webhook.URL, webhook.Secret, eventTypeNames(webhook.EventTypes), webhook.IsActive))
+	if err != nil {
+		log.Error("Failed to create webhook", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return created, nil
+}
+
+func (w *WebhookPostgresRepository) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	log := w.log.With(zap.String("Layer", "WebhookPostgresRepository.GetWebhooks"))
+
+	rows, err := w.db.Query(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY id`)
+	if err != nil {
+		log.Error("Failed to get webhooks", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*models.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			log.Error("Failed to scan webhook", zap.Error(err))
+			return nil, mapError(err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Failed to read webhooks", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return webhooks, nil
+}
+
+func (w *WebhookPostgresRepository) GetWebhookByID(ctx context.Context, id int) (*models.Webhook, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookPostgresRepository.GetWebhookByID"),
+		zap.Int("WebhookID", id),
+	)
+
+	webhook, err := scanWebhook(w.db.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Warn("Webhook not found")
+			return nil, mapError(err)
+		}
+		log.Error("Failed to get webhook", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return webhook, nil
+}
+
+func (w *WebhookPostgresRepository) UpdateWebhook(ctx context.Context, id int, input models.EditWebhook) (*models.Webhook, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookPostgresRepository.UpdateWebhook"),
+		zap.Int("WebhookID", id),
+	)
+
+	// Пустой список типов событий сервис не пропускает, поэтому nil означает, что список не меняется
+	var eventTypes []string
+	if input.EventTypes != nil {
+		eventTypes = eventTypeNames(input.EventTypes)
+	}
+	query := `
+		UPDATE webhooks
+		SET url = COALESCE($2, url), secret = COALESCE($3, secret), eventTypes = COALESCE($4, eventTypes), isActive = COALESCE($5, isActive)
+		WHERE id = $1
+		RETURNING ` + webhookColumns
+
+	webhook, err := scanWebhook(w.db.Writer(ctx).QueryRow(ctx, query, id, input.URL, input.Secret, eventTypes, input.IsActive))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Warn("Failed to update webhook: not found")
+			return nil, mapError(err)
+		}
+		log.Error("Failed to update webhook", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return webhook, nil
+}
+
+func (w *WebhookPostgresRepository) DeleteWebhook(ctx context.Context, id int) error {
+	log := w.log.With(
+		zap.String("Layer", "WebhookPostgresRepository.DeleteWebhook"),
+		zap.Int("WebhookID", id),
+	)
+
+	tag, err := w.db.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		log.Error("Failed to delete webhook", zap.Error(err))
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		log.Warn("Failed to delete webhook: not found")
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (w *WebhookPostgresRepository) CreateWebhookDeliveries(ctx context.Context, eventID int, eventType models.WebhookEventType, payload string) (int, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookPostgresRepository.CreateWebhookDeliveries"),
+		zap.Int("EventID", eventID),
+		zap.String("EventType", string(eventType)),
+	)
+
+	query := `
+		INSERT INTO webhook_deliveries (webhookID, eventID, eventType, payload, nextAttemptAt, createdAt)
+		SELECT id, $1, $2, $3, NOW(), NOW() FROM webhooks WHERE isActive AND $2 = ANY(eventTypes)
+		ON CONFLICT (webhookID, eventID) DO NOTHING
+	`
+	tag, err := w.db.Exec(ctx, query, eventID, string(eventType), payload)
+	if err != nil {
+		log.Error("Failed to create webhook deliveries", zap.Error(err))
+		return 0, mapError(err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// ClaimWebhookDeliveries работает как ClaimOutboxEvents: SKIP LOCKED не даёт двум экземплярам взять одну доставку
+func (w *WebhookPostgresRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	log := w.log.With(zap.String("Layer", "WebhookPostgresRepository.ClaimWebhookDeliveries"))
+
+	query := `
+		UPDATE webhook_deliveries SET nextAttemptAt = $2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d JOIN webhooks w ON d.webhookID = w.id
+			WHERE d.status = 'PENDING' AND d.nextAttemptAt <= $1 AND w.isActive
+			ORDER BY d.nextAttemptAt, d.id
+			LIMIT $3
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	rows, err := w.db.Writer(ctx).Query(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		log.Error("Failed to claim webhook deliveries", zap.Error(err))
+		return nil, mapError(err)
+	}
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		log.Error("Failed to scan webhook deliveries", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	// RETURNING не сохраняет порядок подзапроса
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries, nil
+}
+
+func (w *WebhookPostgresRepository) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	log := w.log.With(
+		zap.String("Layer", "WebhookPostgresRepository.UpdateWebhookDelivery"),
+		zap.Int("DeliveryID", delivery.ID),
+	)
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, responseStatus = $4, lastError = $5, nextAttemptAt = $6, deliveredAt = $7
+		WHERE id = $1
+	`
+	tag, err := w.db.Exec(ctx, query, delivery.ID, string(delivery.Status), delivery.Attempts, delivery.ResponseStatus,
+		delivery.LastError, delivery.NextAttemptAt, delivery.DeliveredAt)
+	if err != nil {
+		log.Error("Failed to update webhook delivery", zap.Error(err))
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		log.Warn("Failed to update webhook delivery: not found")
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (w *WebhookPostgresRepository) GetWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookPostgresRepository.GetWebhookDelivery"),
+		zap.Int("DeliveryID", id),
+	)
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	delivery, err := scanWebhookDelivery(w.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Warn("Webhook delivery not found")
+			return nil, mapError(err)
+		}
+		log.Error("Failed to get webhook delivery", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return delivery, nil
+}
+
+func (w *WebhookPostgresRepository) GetWebhookDeliveries(ctx context.Context, webhookID int, status *models.WebhookDeliveryStatus, limit int, offset int) ([]*models.WebhookDelivery, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookPostgresRepository.GetWebhookDeliveries"),
+		zap.Int("WebhookID", webhookID),
+	)
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE webhookID = $1 AND ($2::varchar IS NULL OR status = $2)
+		ORDER BY id DESC LIMIT $3 OFFSET $4
+	`
+	var statusFilter *string
+	if status != nil {
+		value := string(*status)
+		statusFilter = &value
+	}
+	rows, err := w.db.Query(ctx, query, webhookID, statusFilter, limit, offset)
+	if err != nil {
+		log.Error("Failed to get webhook deliveries", zap.Error(err))
+		return nil, mapError(err)
+	}
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		log.Error("Failed to scan webhook deliveries", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return deliveries, nil
+}
+
+func eventTypeNames(eventTypes []models.WebhookEventType) []string {
+	names := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		names = append(names, string(eventType))
+	}
+	return names
+}
+
+func scanWebhook(row pgx.Row) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var eventTypes []string
+	err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &eventTypes, &webhook.IsActive, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	webhook.EventTypes = make([]models.WebhookEventType, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		webhook.EventTypes = append(webhook.EventTypes, models.WebhookEventType(eventType))
+	}
+	return &webhook, nil
+}
+
+func scanWebhookDelivery(row pgx.Row) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var eventType, status string
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&eventType,
+		&delivery.Payload,
+		&status,
+		&delivery.Attempts,
+		&delivery.ResponseStatus,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	delivery.EventType = models.WebhookEventType(eventType)
+	delivery.Status = models.WebhookDeliveryStatus(status)
+	return &delivery, nil
+}
+
+func scanWebhookDeliveries(rows pgx.Rows) ([]*models.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
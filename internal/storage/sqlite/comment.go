@@ -0,0 +1,397 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"go.uber.org/zap"
+	"time"
+)
+
+// notBlockedCondition отбрасывает комментарии авторов, заблокированных зрителем (?4)
+const notBlockedCondition = `NOT EXISTS (SELECT 1 FROM user_blocks b WHERE b.blockerID = ?4 AND b.blockedID = c.authorID)`
+
+const commentColumns = `c.id, c.payload, c.format, c.postID, c.replyTo, c.isPinned, c.isLocked, c.isHidden, c.editedAt, c.createdAt, u.id, u.username`
+
+type CommentSQLiteRepository struct {
+	db  *sql.DB
+	log *zap.Logger
+}
+
+func NewCommentSQLiteRepository(db *sql.DB, log *zap.Logger) *CommentSQLiteRepository {
+	return &CommentSQLiteRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (c *CommentSQLiteRepository) CreateComment(ctx context.Context, input models.NewComment, hidden bool, mentionIDs []int) (*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentSQLiteRepository.CreateComment"),
+		zap.Int("PostID", input.PostID),
+		zap.Int("AuthorID", input.AuthorID),
+	)
+
+	format := models.ContentFormatPlain
+	if input.Format != nil {
+		format = *input.Format
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO comments (payload, format, postID, authorID, replyTo, isHidden, createdAt)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+		RETURNING id, createdAt, (SELECT username FROM users WHERE id = ?4)
+	`
+
+	var commentID int
+	var createdAt time.Time
+	author := &models.User{ID: input.AuthorID}
+
+	err = tx.QueryRowContext(ctx, query, input.Payload, string(format), input.PostID, input.AuthorID, input.ReplyTo, hidden, now()).Scan(&commentID, &createdAt, &author.Username)
+	if err != nil {
+		log.Error("Failed to create comment", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	if err = insertMentions(ctx, tx, commentID, mentionIDs); err != nil {
+		log.Error("Failed to save mentions", zap.Error(err))
+		return nil, mapError(err)
+	}
+	if !hidden {
+		if err = insertOutboxEvent(ctx, tx, models.OutboxEventCommentPublished, commentID); err != nil {
+			log.Error("Failed to write outbox event", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	comment := &models.Comment{
+		ID:        commentID,
+		Payload:   input.Payload,
+		Format:    format,
+		PostID:    input.PostID,
+		Author:    author,
+		ReplyTo:   input.ReplyTo,
+		IsHidden:  hidden,
+		CreatedAt: createdAt,
+	}
+	return comment, nil
+}
+
+func (c *CommentSQLiteRepository) GetCommentsByPostID(ctx context.Context, limit int, offset int, postID int, viewerID int) ([]*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentSQLiteRepository.GetCommentsByPostID"),
+		zap.Int("PostID", postID),
+	)
+
+	// Закреплённые комментарии всегда идут первыми
+	query := `
+		SELECT ` + commentColumns + `
+		FROM comments c JOIN users u ON c.authorID = u.id
+		WHERE c.postID = ?1
+		AND c.replyTo IS NULL
+		AND NOT c.isHidden
+		AND ` + notBlockedCondition + `
+		ORDER BY c.isPinned DESC, c.createdAt DESC, c.id DESC
+		LIMIT ?2 OFFSET ?3
+	`
+
+	rows, err := c.db.QueryContext(ctx, query, postID, limit, offset, viewerID)
+	if err != nil {
+		log.Error("Error getting comments", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	return c.scanComments(rows, make([]*models.Comment, 0, limit), log)
+}
+
+func (c *CommentSQLiteRepository) Replies(ctx context.Context, commentID int, limit int, offset int, viewerID int) ([]*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentSQLiteRepository.Replies"),
+		zap.Int("CommentID", commentID),
+	)
+
+	query := `
+		SELECT ` + commentColumns + `
+		FROM comments c JOIN users u ON c.authorID = u.id
+		WHERE c.replyTo = ?1 AND NOT c.isHidden AND ` + notBlockedCondition + `
+		ORDER BY c.isPinned DESC, c.createdAt DESC, c.id DESC LIMIT ?2 OFFSET ?3
+	`
+	rows, err := c.db.QueryContext(ctx, query, commentID, limit, offset, viewerID)
+	if err != nil {
+		log.Error("Error getting comments", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	return c.scanComments(rows, make([]*models.Comment, 0, limit), log)
+}
+
+func (c *CommentSQLiteRepository) GetCommentByID(ctx context.Context, commentID int) (*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentSQLiteRepository.GetCommentByID"),
+		zap.Int("CommentID", commentID),
+	)
+
+	query := `SELECT ` + commentColumns + ` FROM comments c JOIN users u ON c.authorID = u.id WHERE c.id = ?1`
+
+	comment, err := scanComment(c.db.QueryRowContext(ctx, query, commentID))
+	if err != nil {
+		err = mapError(err)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Warn("Failed to get comment", zap.Error(err))
+			return nil, err
+		}
+		log.Error("Failed to get comment", zap.Error(err))
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (c *CommentSQLiteRepository) SetCommentPinned(ctx context.Context, commentID int, pinned bool) (*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentSQLiteRepository.SetCommentPinned"),
+		zap.Int("CommentID", commentID),
+		zap.Bool("Pinned", pinned),
+	)
+
+	result, err := c.db.ExecContext(ctx, `UPDATE comments SET isPinned = ?2 WHERE id = ?1`, commentID, pinned)
+	if err != nil {
+		log.Error("Failed to update comment", zap.Error(err))
+		return nil, mapError(err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		log.Warn("Failed to update comment: not found")
+		return nil, storage.ErrNotFound
+	}
+	return c.GetCommentByID(ctx, commentID)
+}
+
+func (c *CommentSQLiteRepository) LockThread(ctx context.Context, commentID int) (*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentSQLiteRepository.LockThread"),
+		zap.Int("CommentID", commentID),
+	)
+
+	result, err := c.db.ExecContext(ctx, `UPDATE comments SET isLocked = true WHERE id = ?1`, commentID)
+	if err != nil {
+		log.Error("Failed to lock thread", zap.Error(err))
+		return nil, mapError(err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		log.Warn("Failed to lock thread: comment not found")
+		return nil, storage.ErrNotFound
+	}
+	return c.GetCommentByID(ctx, commentID)
+}
+
+func (c *CommentSQLiteRepository) IsThreadLocked(ctx context.Context, commentID int) (bool, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentSQLiteRepository.IsThreadLocked"),
+		zap.Int("CommentID", commentID),
+	)
+
+	// Проверяем сам комментарий и всех его предков
+	query := `
+		WITH RECURSIVE thread AS (
+			SELECT id, replyTo, isLocked FROM comments WHERE id = ?1
+			UNION ALL
+			SELECT c.id, c.replyTo, c.isLocked FROM comments c JOIN thread t ON c.id = t.replyTo
+		)
+		SELECT COALESCE(MAX(isLocked), false) FROM thread
+	`
+
+	var locked bool
+	if err := c.db.QueryRowContext(ctx, query, commentID).Scan(&locked); err != nil {
+		log.Error("Failed to check thread lock", zap.Error(err))
+		return false, mapError(err)
+	}
+	return locked, nil
+}
+
+func (c *CommentSQLiteRepository) scanComments(rows *sql.Rows, comments []*models.Comment, log *zap.Logger) ([]*models.Comment, error) {
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			log.Error("Failed to scan row", zap.Error(err))
+			return nil, mapError(err)
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("Error after reading rows", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return comments, nil
+}
+
+func scanComment(row scanner) (*models.Comment, error) {
+	var comment models.Comment
+	comment.Author = &models.User{}
+	err := row.Scan(
+		&comment.ID,
+		&comment.Payload,
+		&comment.Format,
+		&comment.PostID,
+		&comment.ReplyTo,
+		&comment.IsPinned,
+		&comment.IsLocked,
+		&comment.IsHidden,
+		&comment.EditedAt,
+		&comment.CreatedAt,
+		&comment.Author.ID,
+		&comment.Author.Username,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &comment, nil
+}
+
+func (c *CommentSQLiteRepository) UpdateComment(ctx context.Context, commentID int, payload string, editorID int, hold *models.ModerationItem) (*models.Comment, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentSQLiteRepository.UpdateComment"),
+		zap.Int("CommentID", commentID),
+		zap.Int("EditorID", editorID),
+	)
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	var oldPayload string
+	err = tx.QueryRowContext(ctx, `SELECT payload FROM comments WHERE id = ?1`, commentID).Scan(&oldPayload)
+	if err != nil {
+		err = mapError(err)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Warn("Failed to update comment: not found")
+			return nil, err
+		}
+		log.Error("Failed to get comment", zap.Error(err))
+		return nil, err
+	}
+
+	if err = insertRevision(ctx, tx, revisionTargetComment, commentID, nil, oldPayload, editorID); err != nil {
+		log.Error("Failed to save revision", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE comments SET payload = ?2, editedAt = ?3 WHERE id = ?1`, commentID, payload, now())
+	if err != nil {
+		log.Error("Failed to update comment", zap.Error(err))
+		return nil, mapError(err)
+	}
+	if hold != nil {
+		if _, err = enqueueModeration(ctx, tx, hold); err != nil {
+			log.Error("Failed to enqueue comment", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	return c.GetCommentByID(ctx, commentID)
+}
+
+func (c *CommentSQLiteRepository) GetCommentRevisions(ctx context.Context, commentID int) ([]*models.Revision, error) {
+	revisions, err := getRevisions(ctx, c.db, revisionTargetComment, commentID)
+	if err != nil {
+		c.log.Error("Failed to get comment revisions",
+			zap.String("Layer", "CommentSQLiteRepository.GetCommentRevisions"),
+			zap.Int("CommentID", commentID),
+			zap.Error(err),
+		)
+		return nil, mapError(err)
+	}
+	return revisions, nil
+}
+
+func (c *CommentSQLiteRepository) SaveMentions(ctx context.Context, commentID int, userIDs []int) error {
+	log := c.log.With(
+		zap.String("Layer", "CommentSQLiteRepository.SaveMentions"),
+		zap.Int("CommentID", commentID),
+	)
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return mapError(err)
+	}
+	defer tx.Rollback()
+
+	if err = insertMentions(ctx, tx, commentID, userIDs); err != nil {
+		log.Error("Failed to save mentions", zap.Error(err))
+		return mapError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return mapError(err)
+	}
+	return nil
+}
+
+// insertMentions сохраняет упоминания пользователей в комментарии, повторные упоминания пропускаются
+func insertMentions(ctx context.Context, tx *sql.Tx, commentID int, userIDs []int) error {
+	for _, userID := range userIDs {
+		_, err := tx.ExecContext(ctx, `INSERT INTO comment_mentions (commentID, userID) VALUES (?1, ?2) ON CONFLICT DO NOTHING`, commentID, userID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CommentSQLiteRepository) GetCommentMentions(ctx context.Context, commentID int) ([]*models.User, error) {
+	log := c.log.With(
+		zap.String("Layer", "CommentSQLiteRepository.GetCommentMentions"),
+		zap.Int("CommentID", commentID),
+	)
+
+	query := `
+		SELECT u.id, u.username
+		FROM comment_mentions m JOIN users u ON m.userID = u.id
+		WHERE m.commentID = ?1
+		ORDER BY u.username
+	`
+	rows, err := c.db.QueryContext(ctx, query, commentID)
+	if err != nil {
+		log.Error("Failed to get mentions", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0)
+	for rows.Next() {
+		var user models.User
+		if err = rows.Scan(&user.ID, &user.Username); err != nil {
+			log.Error("Failed to scan row", zap.Error(err))
+			return nil, mapError(err)
+		}
+		users = append(users, &user)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Error after reading rows", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return users, nil
+}
@@ -0,0 +1,123 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func createTestPost(t *testing.T, db *sql.DB) *models.Post {
+	post, err := NewPostSQLiteRepository(db, zap.NewNop()).CreatePost(context.Background(), models.NewPost{
+		Title:             "post",
+		Payload:           "payload",
+		AuthorID:          alice,
+		IsCommentsAllowed: true,
+	}, false)
+	require.NoError(t, err)
+	return post
+}
+
+func TestCommentSQLiteRepository_CommentsAndReplies(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewCommentSQLiteRepository(db, zap.NewNop())
+	post := createTestPost(t, db)
+
+	first, err := repo.CreateComment(ctx, models.NewComment{Payload: "first", PostID: post.ID, AuthorID: alice}, false, nil)
+	require.NoError(t, err)
+	second, err := repo.CreateComment(ctx, models.NewComment{Payload: "second", PostID: post.ID, AuthorID: alen}, false, nil)
+	require.NoError(t, err)
+	reply, err := repo.CreateComment(ctx, models.NewComment{Payload: "reply", PostID: post.ID, AuthorID: quizert, ReplyTo: &first.ID}, false, nil)
+	require.NoError(t, err)
+	_, err = repo.CreateComment(ctx, models.NewComment{Payload: "held", PostID: post.ID, AuthorID: alen}, true, nil)
+	require.NoError(t, err)
+
+	comments, err := repo.GetCommentsByPostID(ctx, 10, 0, post.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, comments, 2, "ответы и скрытые комментарии не попадают в список")
+	assert.Equal(t, second.ID, comments[0].ID, "новые комментарии идут первыми")
+	assert.Equal(t, "Alen", comments[0].Author.Username)
+
+	_, err = repo.SetCommentPinned(ctx, first.ID, true)
+	require.NoError(t, err)
+	comments, err = repo.GetCommentsByPostID(ctx, 1, 0, post.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, first.ID, comments[0].ID, "закреплённый комментарий идёт первым")
+	assert.True(t, comments[0].IsPinned)
+
+	replies, err := repo.Replies(ctx, first.ID, 10, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, replies, 1)
+	assert.Equal(t, reply.ID, replies[0].ID)
+	assert.Equal(t, first.ID, *replies[0].ReplyTo)
+
+	users := NewUserSQLiteRepository(db, zap.NewNop())
+	_, err = users.BlockUser(ctx, alice, quizert)
+	require.NoError(t, err)
+	replies, err = repo.Replies(ctx, first.ID, 10, 0, alice)
+	require.NoError(t, err)
+	assert.Empty(t, replies, "комментарии заблокированного автора скрыты от зрителя")
+
+	_, err = repo.GetCommentByID(ctx, 999)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestCommentSQLiteRepository_IsThreadLocked(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewCommentSQLiteRepository(db, zap.NewNop())
+	post := createTestPost(t, db)
+
+	root, err := repo.CreateComment(ctx, models.NewComment{Payload: "root", PostID: post.ID, AuthorID: alice}, false, nil)
+	require.NoError(t, err)
+	child, err := repo.CreateComment(ctx, models.NewComment{Payload: "child", PostID: post.ID, AuthorID: alen, ReplyTo: &root.ID}, false, nil)
+	require.NoError(t, err)
+
+	locked, err := repo.IsThreadLocked(ctx, child.ID)
+	require.NoError(t, err)
+	assert.False(t, locked)
+
+	_, err = repo.LockThread(ctx, root.ID)
+	require.NoError(t, err)
+	locked, err = repo.IsThreadLocked(ctx, child.ID)
+	require.NoError(t, err)
+	assert.True(t, locked, "блокировка предка распространяется на ветку")
+
+	_, err = repo.LockThread(ctx, 999)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestCommentSQLiteRepository_UpdateAndMentions(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewCommentSQLiteRepository(db, zap.NewNop())
+	post := createTestPost(t, db)
+
+	comment, err := repo.CreateComment(ctx, models.NewComment{Payload: "old", PostID: post.ID, AuthorID: alice}, false, nil)
+	require.NoError(t, err)
+
+	updated, err := repo.UpdateComment(ctx, comment.ID, "new", alice, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "new", updated.Payload)
+	assert.NotNil(t, updated.EditedAt)
+
+	revisions, err := repo.GetCommentRevisions(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "old", revisions[0].Payload)
+	assert.Nil(t, revisions[0].Title)
+
+	require.NoError(t, repo.SaveMentions(ctx, comment.ID, []int{alen, quizert, alen}))
+	mentions, err := repo.GetCommentMentions(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Len(t, mentions, 2)
+	assert.Equal(t, "Alen", mentions[0].Username)
+	assert.Equal(t, "Quizert", mentions[1].Username)
+}
@@ -0,0 +1,22 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/Quizert/PostCommentService/internal/storage/storagetest"
+	"go.uber.org/zap"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Providers {
+		db := newTestDB(t)
+		return storagetest.Providers{
+			Posts:      NewPostSQLiteRepository(db, zap.NewNop()),
+			Comments:   NewCommentSQLiteRepository(db, zap.NewNop()),
+			Users:      NewUserSQLiteRepository(db, zap.NewNop()),
+			Outbox:     NewOutboxSQLiteRepository(db, zap.NewNop()),
+			Webhooks:   NewWebhookSQLiteRepository(db, zap.NewNop()),
+			Moderation: NewModerationSQLiteRepository(db, zap.NewNop()),
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestModerationSQLiteRepository_HoldAndApprove(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewModerationSQLiteRepository(db, zap.NewNop())
+	posts := NewPostSQLiteRepository(db, zap.NewNop())
+	post := createTestPost(t, db)
+
+	item, err := repo.EnqueueModeration(ctx, &models.ModerationItem{
+		TargetType: models.TargetTypePost,
+		TargetID:   post.ID,
+		Title:      &post.Title,
+		Payload:    post.Payload,
+		Author:     &models.User{ID: alice},
+		Reason:     "reported",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.ModerationStatusPending, item.Status)
+
+	hidden, err := posts.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.True(t, hidden.IsHidden)
+
+	queue, err := repo.GetModerationQueue(ctx, models.ModerationStatusPending, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	assert.Equal(t, "Alice", queue[0].Author.Username)
+	assert.Nil(t, queue[0].Moderator)
+
+	resolved, err := repo.ResolveModerationItem(ctx, item.ID, models.ModerationStatusApproved, quizert, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.ModerationStatusApproved, resolved.Status)
+	require.NotNil(t, resolved.Moderator)
+	assert.Equal(t, "Quizert", resolved.Moderator.Username)
+	assert.NotNil(t, resolved.DecidedAt)
+
+	shown, err := posts.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.False(t, shown.IsHidden)
+
+	_, err = repo.ResolveModerationItem(ctx, item.ID, models.ModerationStatusRejected, quizert, nil)
+	assert.ErrorIs(t, err, storage.ErrNotFound, "разобранный элемент нельзя разобрать повторно")
+}
+
+func TestModerationSQLiteRepository_Reports(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewModerationSQLiteRepository(db, zap.NewNop())
+	post := createTestPost(t, db)
+
+	report := func(reporterID int, reason models.ReportReason) (*models.Report, int) {
+		created, count, err := repo.CreateReport(ctx, &models.Report{
+			TargetType: models.TargetTypePost,
+			TargetID:   post.ID,
+			Reporter:   &models.User{ID: reporterID},
+			Reason:     reason,
+		})
+		require.NoError(t, err)
+		return created, count
+	}
+
+	created, count := report(alen, models.ReportReasonSpam)
+	require.NotNil(t, created)
+	assert.Equal(t, 1, count)
+	created, count = report(alen, models.ReportReasonAbuse)
+	assert.Nil(t, created, "повторная жалоба не сохраняется")
+	assert.Equal(t, 1, count)
+	_, count = report(quizert, models.ReportReasonSpam)
+	assert.Equal(t, 2, count)
+
+	summaries, err := repo.GetReportSummaries(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, 2, summaries[0].Count)
+	require.Len(t, summaries[0].Reasons, 1)
+	assert.Equal(t, models.ReportReasonSpam, summaries[0].Reasons[0].Reason)
+	assert.False(t, summaries[0].LastReportedAt.IsZero())
+}
+
+func TestModerationSQLiteRepository_ApproveAlreadyPublished(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewModerationSQLiteRepository(db, zap.NewNop())
+	outbox := NewOutboxSQLiteRepository(db, zap.NewNop())
+
+	claim := func() []int {
+		events, err := outbox.ClaimOutboxEvents(ctx, time.Now(), 10, time.Minute)
+		require.NoError(t, err)
+		ids := make([]int, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.AggregateID)
+			require.NoError(t, outbox.MarkOutboxEventDelivered(ctx, event.ID))
+		}
+		return ids
+	}
+	enqueue := func(post *models.Post) *models.ModerationItem {
+		item, err := repo.EnqueueModeration(ctx, &models.ModerationItem{
+			TargetType: models.TargetTypePost,
+			TargetID:   post.ID,
+			Title:      &post.Title,
+			Payload:    post.Payload,
+			Author:     &models.User{ID: alice},
+			Reason:     "reported",
+		})
+		require.NoError(t, err)
+		return item
+	}
+	approve := func(item *models.ModerationItem) *models.ModerationItem {
+		resolved, err := repo.ResolveModerationItem(ctx, item.ID, models.ModerationStatusApproved, quizert, nil)
+		require.NoError(t, err)
+		return resolved
+	}
+
+	visible := createTestPost(t, db)
+	assert.Equal(t, []int{visible.ID}, claim())
+
+	reported := enqueue(visible)
+	assert.True(t, reported.WasPublished, "скрытый по жалобам контент уже был опубликован")
+	assert.True(t, approve(reported).WasPublished)
+	assert.Empty(t, claim(), "повторное одобрение не публикует контент заново")
+
+	held, err := NewPostSQLiteRepository(db, zap.NewNop()).CreatePost(ctx, models.NewPost{Title: "held", Payload: "payload", AuthorID: alice}, true)
+	require.NoError(t, err)
+	first := enqueue(held)
+	second := enqueue(held)
+	assert.False(t, first.WasPublished)
+	assert.False(t, second.WasPublished)
+
+	assert.False(t, approve(first).WasPublished)
+	assert.Equal(t, []int{held.ID}, claim())
+	assert.True(t, approve(second).WasPublished, "контент уже показан по первому элементу")
+	assert.Empty(t, claim())
+}
@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"go.uber.org/zap"
+	"strings"
+)
+
+type NotificationSQLiteRepository struct {
+	db  *sql.DB
+	log *zap.Logger
+}
+
+func NewNotificationSQLiteRepository(db *sql.DB, log *zap.Logger) *NotificationSQLiteRepository {
+	return &NotificationSQLiteRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (n *NotificationSQLiteRepository) CreateNotifications(ctx context.Context, notifications []*models.Notification) ([]*models.Notification, error) {
+	log := n.log.With(
+		zap.String("Layer", "NotificationSQLiteRepository.CreateNotifications"),
+		zap.Int("Count", len(notifications)),
+	)
+
+	tx, err := n.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO notifications (type, recipientID, actorID, postID, commentID, createdAt)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+		RETURNING id, createdAt
+	`
+
+	created := make([]*models.Notification, 0, len(notifications))
+	for _, notification := range notifications {
+		stored := *notification
+		stored.IsRead = false
+		err = tx.QueryRowContext(ctx, query,
+			stored.Type,
+			stored.RecipientID,
+			stored.Actor.ID,
+			stored.PostID,
+			stored.CommentID,
+			now(),
+		).Scan(&stored.ID, &stored.CreatedAt)
+		if err != nil {
+			log.Error("Failed to create notification", zap.Error(err))
+			return nil, mapError(err)
+		}
+		created = append(created, &stored)
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return created, nil
+}
+
+func (n *NotificationSQLiteRepository) GetNotifications(ctx context.Context, userID int, unreadOnly bool, limit int, after int) ([]*models.Notification, error) {
+	log := n.log.With(
+		zap.String("Layer", "NotificationSQLiteRepository.GetNotifications"),
+		zap.Int("UserID", userID),
+	)
+
+	query := `
+		SELECT n.id, n.type, n.postID, n.commentID, n.isRead, n.createdAt, n.recipientID, u.id, u.username
+		FROM notifications n JOIN users u ON n.actorID = u.id
+		WHERE n.recipientID = ?1
+		AND (NOT ?2 OR NOT n.isRead)
+		AND (?3 = 0 OR n.id < ?3)
+		ORDER BY n.id DESC
+		LIMIT ?4
+	`
+
+	rows, err := n.db.QueryContext(ctx, query, userID, unreadOnly, after, limit)
+	if err != nil {
+		log.Error("Error getting notifications", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	notifications := make([]*models.Notification, 0, limit)
+	for rows.Next() {
+		var notification models.Notification
+		notification.Actor = &models.User{}
+		err = rows.Scan(
+			&notification.ID,
+			&notification.Type,
+			&notification.PostID,
+			&notification.CommentID,
+			&notification.IsRead,
+			&notification.CreatedAt,
+			&notification.RecipientID,
+			&notification.Actor.ID,
+			&notification.Actor.Username,
+		)
+		if err != nil {
+			log.Error("Failed to scan row", zap.Error(err))
+			return nil, mapError(err)
+		}
+		notifications = append(notifications, &notification)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Error after reading rows", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return notifications, nil
+}
+
+func (n *NotificationSQLiteRepository) MarkNotificationsRead(ctx context.Context, userID int, ids []int) (int, error) {
+	log := n.log.With(
+		zap.String("Layer", "NotificationSQLiteRepository.MarkNotificationsRead"),
+		zap.Int("UserID", userID),
+	)
+
+	query := `UPDATE notifications SET isRead = true WHERE recipientID = ? AND NOT isRead`
+	args := []interface{}{userID}
+	if len(ids) > 0 {
+		query += ` AND id IN (?` + strings.Repeat(`, ?`, len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	result, err := n.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("Failed to mark notifications read", zap.Error(err))
+		return 0, mapError(err)
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNotificationSQLiteRepository_Pagination(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewNotificationSQLiteRepository(db, zap.NewNop())
+	post := createTestPost(t, db)
+	comment, err := NewCommentSQLiteRepository(db, zap.NewNop()).CreateComment(ctx, models.NewComment{Payload: "c", PostID: post.ID, AuthorID: alen}, false, nil)
+	require.NoError(t, err)
+
+	notifications := make([]*models.Notification, 3)
+	for i := range notifications {
+		notifications[i] = &models.Notification{
+			Type:        models.NotificationTypePostComment,
+			RecipientID: alice,
+			Actor:       &models.User{ID: alen},
+			PostID:      post.ID,
+			CommentID:   comment.ID,
+		}
+	}
+	created, err := repo.CreateNotifications(ctx, notifications)
+	require.NoError(t, err)
+	require.Len(t, created, 3)
+
+	page, err := repo.GetNotifications(ctx, alice, false, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, created[2].ID, page[0].ID, "новые уведомления идут первыми")
+	assert.Equal(t, "Alen", page[0].Actor.Username)
+
+	page, err = repo.GetNotifications(ctx, alice, false, 2, page[1].ID)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, created[0].ID, page[0].ID)
+
+	marked, err := repo.MarkNotificationsRead(ctx, alice, []int{created[0].ID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, marked)
+	unread, err := repo.GetNotifications(ctx, alice, true, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, unread, 2)
+
+	marked, err = repo.MarkNotificationsRead(ctx, alice, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, marked)
+}
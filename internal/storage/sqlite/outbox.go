@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+// insertOutboxEvent записывает событие в outbox. Вызывается в транзакции изменения, которое породило событие
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, eventType models.OutboxEventType, aggregateID int) error {
+	createdAt := now()
+	query := `
+		INSERT INTO outbox (eventType, aggregateID, nextAttemptAt, createdAt)
+		VALUES (?1, ?2, ?3, ?3)
+	`
+	_, err := tx.ExecContext(ctx, query, string(eventType), aggregateID, createdAt)
+	return err
+}
+
+// insertPublishedEvent записывает событие о том, что модератор вернул контент в выдачу.
+// Пост становится виден всем, только если он уже опубликован
+func insertPublishedEvent(ctx context.Context, tx *sql.Tx, targetType models.TargetType, targetID int) error {
+	if targetType == models.TargetTypeComment {
+		return insertOutboxEvent(ctx, tx, models.OutboxEventCommentPublished, targetID)
+	}
+	query := `
+		INSERT INTO outbox (eventType, aggregateID, nextAttemptAt, createdAt)
+		SELECT ?1, id, ?3, ?3 FROM posts WHERE id = ?2 AND status = 'PUBLISHED'
+	`
+	_, err := tx.ExecContext(ctx, query, string(models.OutboxEventPostPublished), targetID, now())
+	return err
+}
+
+type OutboxSQLiteRepository struct {
+	db  *sql.DB
+	log *zap.Logger
+}
+
+func NewOutboxSQLiteRepository(db *sql.DB, log *zap.Logger) *OutboxSQLiteRepository {
+	return &OutboxSQLiteRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// ClaimOutboxEvents выдаёт события, срок попытки которых наступил. Выборка и продление аренды - один запрос,
+// поэтому два диспетчера не получат одно событие
+func (o *OutboxSQLiteRepository) ClaimOutboxEvents(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	log := o.log.With(zap.String("Layer", "OutboxSQLiteRepository.ClaimOutboxEvents"))
+
+	query := `
+		UPDATE outbox SET nextAttemptAt = ?2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE deliveredAt IS NULL AND nextAttemptAt <= ?1
+			ORDER BY nextAttemptAt, id
+			LIMIT ?3
+		)
+		RETURNING id, eventType, aggregateID, attempts, lastError, nextAttemptAt, deliveredAt, createdAt
+	`
+	rows, err := o.db.QueryContext(ctx, query, now.UTC(), now.Add(lease).UTC(), limit)
+	if err != nil {
+		log.Error("Failed to claim outbox events", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	events := make([]*models.OutboxEvent, 0)
+	for rows.Next() {
+		var event models.OutboxEvent
+		var eventType string
+		err = rows.Scan(
+			&event.ID,
+			&eventType,
+			&event.AggregateID,
+			&event.Attempts,
+			&event.LastError,
+			&event.NextAttemptAt,
+			&event.DeliveredAt,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			log.Error("Failed to scan outbox event", zap.Error(err))
+			return nil, mapError(err)
+		}
+		event.Type = models.OutboxEventType(eventType)
+		events = append(events, &event)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Failed to read outbox events", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	// RETURNING не сохраняет порядок подзапроса
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+func (o *OutboxSQLiteRepository) MarkOutboxEventDelivered(ctx context.Context, id int) error {
+	log := o.log.With(zap.String("Layer", "OutboxSQLiteRepository.MarkOutboxEventDelivered"), zap.Int("EventID", id))
+
+	result, err := o.db.ExecContext(ctx, `UPDATE outbox SET deliveredAt = ?2, lastError = NULL WHERE id = ?1`, id, now())
+	if err != nil {
+		log.Error("Failed to mark outbox event delivered", zap.Error(err))
+		return mapError(err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		log.Warn("Failed to mark outbox event delivered: not found")
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (o *OutboxSQLiteRepository) RetryOutboxEvent(ctx context.Context, id int, nextAttemptAt time.Time, lastError string) error {
+	log := o.log.With(zap.String("Layer", "OutboxSQLiteRepository.RetryOutboxEvent"), zap.Int("EventID", id))
+
+	query := `UPDATE outbox SET nextAttemptAt = ?2, lastError = ?3 WHERE id = ?1 AND deliveredAt IS NULL`
+	result, err := o.db.ExecContext(ctx, query, id, nextAttemptAt.UTC(), lastError)
+	if err != nil {
+		log.Error("Failed to reschedule outbox event", zap.Error(err))
+		return mapError(err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		log.Warn("Failed to reschedule outbox event: not found or delivered")
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (o *OutboxSQLiteRepository) DeleteDeliveredOutboxEvents(ctx context.Context, before time.Time) (int, error) {
+	log := o.log.With(zap.String("Layer", "OutboxSQLiteRepository.DeleteDeliveredOutboxEvents"))
+
+	result, err := o.db.ExecContext(ctx, `DELETE FROM outbox WHERE deliveredAt IS NOT NULL AND deliveredAt < ?1`, before.UTC())
+	if err != nil {
+		log.Error("Failed to delete delivered outbox events", zap.Error(err))
+		return 0, mapError(err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, mapError(err)
+	}
+	return int(deleted), nil
+}
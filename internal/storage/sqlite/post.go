@@ -0,0 +1,433 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/Quizert/PostCommentService/internal/utils"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// visiblePostsCondition отбирает посты, которые видит пользователь ?3 в момент ?4: опубликованные и
+// отложенные с наступившим временем публикации, если они не скрыты модерацией, и все собственные посты
+const visiblePostsCondition = `(((p.status = 'PUBLISHED' OR (p.status = 'SCHEDULED' AND p.publishAt <= ?4)) AND NOT p.isHidden) OR p.authorID = ?3)`
+
+// postColumns - колонки поста, теги собираются в JSON-массив, так как массивов в SQLite нет
+const postColumns = `
+	p.id, p.title, p.payload, p.format, p.isCommentsAllowed, p.status, p.isHidden, p.publishAt, p.editedAt, p.createdAt, u.id, u.username,
+	(SELECT json_group_array(name) FROM (SELECT t.name FROM post_tags pt JOIN tags t ON pt.tagID = t.id WHERE pt.postID = p.id ORDER BY t.name))
+`
+
+type PostSQLiteRepository struct {
+	db  *sql.DB
+	log *zap.Logger
+}
+
+func NewPostSQLiteRepository(db *sql.DB, log *zap.Logger) *PostSQLiteRepository {
+	return &PostSQLiteRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (p *PostSQLiteRepository) CreatePost(ctx context.Context, input models.NewPost, hidden bool) (*models.Post, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostSQLiteRepository.CreatePost"),
+		zap.String("Title", input.Title),
+		zap.Int("AuthorID", input.AuthorID),
+	)
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	status := models.PostStatusPublished
+	if input.Status != nil {
+		status = *input.Status
+	}
+	format := models.ContentFormatPlain
+	if input.Format != nil {
+		format = *input.Format
+	}
+
+	// Опубликованный сразу пост получает publishAt = createdAt
+	query := `INSERT INTO posts (title, payload, authorID, isCommentsAllowed, status, publishAt, format, isHidden, createdAt)
+              VALUES (?1, ?2, ?3, ?4, ?5, CASE WHEN ?5 = 'PUBLISHED' THEN COALESCE(?6, ?9) ELSE ?6 END, ?7, ?8, ?9)
+              RETURNING id, publishAt, createdAt, (SELECT username FROM users WHERE id = ?3)`
+
+	var post models.Post
+	post.Author = &models.User{ID: input.AuthorID}
+	err = tx.QueryRowContext(ctx, query, input.Title, input.Payload, input.AuthorID, input.IsCommentsAllowed, string(status), input.PublishAt, string(format), hidden, now()).
+		Scan(&post.ID, &post.PublishAt, &post.CreatedAt, &post.Author.Username)
+	if err != nil {
+		log.Error("Failed to create post", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	for _, tag := range input.Tags {
+		// DO UPDATE нужен, чтобы RETURNING вернул id и для уже существующего тега
+		var tagID int
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO tags (name) VALUES (?1)
+			ON CONFLICT (name) DO UPDATE SET name = excluded.name
+			RETURNING id
+		`, tag).Scan(&tagID)
+		if err != nil {
+			log.Error("Failed to create tag", zap.String("Tag", tag), zap.Error(err))
+			return nil, mapError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO post_tags (postID, tagID) VALUES (?1, ?2) ON CONFLICT DO NOTHING`, post.ID, tagID)
+		if err != nil {
+			log.Error("Failed to attach tag to post", zap.String("Tag", tag), zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if status == models.PostStatusPublished && !hidden {
+		if err = insertOutboxEvent(ctx, tx, models.OutboxEventPostPublished, post.ID); err != nil {
+			log.Error("Failed to write outbox event", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	post.Title = input.Title
+	post.Payload = input.Payload
+	post.IsCommentsAllowed = input.IsCommentsAllowed
+	post.Tags = utils.SortTags(input.Tags)
+	post.Status = status
+	post.Format = format
+	post.IsHidden = hidden
+
+	return &post, nil
+}
+
+func (p *PostSQLiteRepository) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostSQLiteRepository.GetPostByID"),
+		zap.Int("PostID", id),
+	)
+
+	query := `SELECT ` + postColumns + ` FROM posts p JOIN users u ON p.authorID = u.id WHERE p.id = ?1`
+
+	post, err := scanPost(p.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		err = mapError(err)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Warn("Failed to get post", zap.Error(err))
+			return nil, err
+		}
+		log.Error("Failed to get post", zap.Error(err))
+		return nil, err
+	}
+	return post, nil
+}
+
+func (p *PostSQLiteRepository) GetAllPosts(ctx context.Context, limit int, offset int, viewerID int) ([]*models.Post, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostSQLiteRepository.GetAllPosts"),
+	)
+
+	query := `
+		SELECT ` + postColumns + `
+		FROM posts p JOIN users u ON p.authorID = u.id
+		WHERE ` + visiblePostsCondition + `
+		ORDER BY COALESCE(p.publishAt, p.createdAt) DESC LIMIT ?1 OFFSET ?2
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, limit, offset, viewerID, now())
+	if err != nil {
+		log.Error("Failed to get posts", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	return p.scanPosts(rows, make([]*models.Post, 0, limit), log)
+}
+
+func (p *PostSQLiteRepository) GetPostsByTag(ctx context.Context, tag string, limit int, offset int, viewerID int) ([]*models.Post, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostSQLiteRepository.GetPostsByTag"),
+		zap.String("Tag", tag),
+	)
+
+	query := `
+		SELECT ` + postColumns + `
+		FROM posts p
+		JOIN users u ON p.authorID = u.id
+		JOIN post_tags tpt ON tpt.postID = p.id
+		JOIN tags tt ON tpt.tagID = tt.id
+		WHERE tt.name = ?5 AND ` + visiblePostsCondition + `
+		ORDER BY COALESCE(p.publishAt, p.createdAt) DESC LIMIT ?1 OFFSET ?2
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, limit, offset, viewerID, now(), tag)
+	if err != nil {
+		log.Error("Failed to get posts", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	return p.scanPosts(rows, make([]*models.Post, 0, limit), log)
+}
+
+func (p *PostSQLiteRepository) GetTags(ctx context.Context, prefix string, limit int) ([]string, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostSQLiteRepository.GetTags"),
+		zap.String("Prefix", prefix),
+	)
+
+	// Экранируем спецсимволы LIKE, чтобы префикс искался буквально. В SQLite LIKE не различает регистр
+	// только для ASCII, теги уже нормализованы к нижнему регистру
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)
+
+	query := `
+		SELECT t.name
+		FROM tags t LEFT JOIN post_tags pt ON pt.tagID = t.id
+		WHERE t.name LIKE ?1 || '%' ESCAPE '\'
+		GROUP BY t.name
+		ORDER BY count(pt.postID) DESC, t.name
+		LIMIT ?2
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, escaped, limit)
+	if err != nil {
+		log.Error("Failed to get tags", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	tags := make([]string, 0, limit)
+	for rows.Next() {
+		var tag string
+		if err = rows.Scan(&tag); err != nil {
+			log.Error("Failed to scan row", zap.Error(err))
+			return nil, mapError(err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Error after reading rows", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return tags, nil
+}
+
+func (p *PostSQLiteRepository) scanPosts(rows *sql.Rows, posts []*models.Post, log *zap.Logger) ([]*models.Post, error) {
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			log.Error("Failed to get posts", zap.Error(err))
+			return nil, mapError(err)
+		}
+		posts = append(posts, post)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("Error after reading rows", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	return posts, nil
+}
+
+func scanPost(row scanner) (*models.Post, error) {
+	var post models.Post
+	var tags string
+	post.Author = &models.User{}
+	err := row.Scan(
+		&post.ID,
+		&post.Title,
+		&post.Payload,
+		&post.Format,
+		&post.IsCommentsAllowed,
+		&post.Status,
+		&post.IsHidden,
+		&post.PublishAt,
+		&post.EditedAt,
+		&post.CreatedAt,
+		&post.Author.ID,
+		&post.Author.Username,
+		&tags,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if err = json.Unmarshal([]byte(tags), &post.Tags); err != nil {
+		return nil, mapError(err)
+	}
+	return &post, nil
+}
+
+func (p *PostSQLiteRepository) UpdatePostStatus(ctx context.Context, postID int, status models.PostStatus, publishAt *time.Time) (*models.Post, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostSQLiteRepository.UpdatePostStatus"),
+		zap.Int("PostID", postID),
+		zap.String("Status", string(status)),
+	)
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	var oldStatus models.PostStatus
+	var hidden bool
+	err = tx.QueryRowContext(ctx, `SELECT status, isHidden FROM posts WHERE id = ?1`, postID).Scan(&oldStatus, &hidden)
+	if err != nil {
+		err = mapError(err)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Warn("Failed to update post status: post not found")
+			return nil, err
+		}
+		log.Error("Failed to get post", zap.Error(err))
+		return nil, err
+	}
+
+	query := `
+		UPDATE posts
+		SET status = ?2, publishAt = CASE WHEN ?2 = 'PUBLISHED' THEN COALESCE(?3, ?4) ELSE ?3 END
+		WHERE id = ?1
+	`
+	if _, err = tx.ExecContext(ctx, query, postID, string(status), publishAt, now()); err != nil {
+		log.Error("Failed to update post status", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	if status == models.PostStatusPublished && oldStatus != models.PostStatusPublished && !hidden {
+		if err = insertOutboxEvent(ctx, tx, models.OutboxEventPostPublished, postID); err != nil {
+			log.Error("Failed to write outbox event", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	return p.GetPostByID(ctx, postID)
+}
+
+func (p *PostSQLiteRepository) PublishScheduledPosts(ctx context.Context, now time.Time) (int, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostSQLiteRepository.PublishScheduledPosts"),
+	)
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return 0, mapError(err)
+	}
+	defer tx.Rollback()
+
+	// События о публикации пишутся в той же транзакции, скрытые модерацией посты их не получают
+	query := `
+		INSERT INTO outbox (eventType, aggregateID, nextAttemptAt, createdAt)
+		SELECT ?2, id, ?3, ?3 FROM posts WHERE status = 'SCHEDULED' AND publishAt <= ?1 AND NOT isHidden
+	`
+	if _, err = tx.ExecContext(ctx, query, now, string(models.OutboxEventPostPublished), now.UTC()); err != nil {
+		log.Error("Failed to write outbox events", zap.Error(err))
+		return 0, mapError(err)
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE posts SET status = 'PUBLISHED' WHERE status = 'SCHEDULED' AND publishAt <= ?1`, now)
+	if err != nil {
+		log.Error("Failed to publish scheduled posts", zap.Error(err))
+		return 0, mapError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return 0, mapError(err)
+	}
+	return int(affected), nil
+}
+
+func (p *PostSQLiteRepository) UpdatePost(ctx context.Context, postID int, input models.EditPost, editorID int, hold *models.ModerationItem) (*models.Post, error) {
+	log := p.log.With(
+		zap.String("Layer", "PostSQLiteRepository.UpdatePost"),
+		zap.Int("PostID", postID),
+		zap.Int("EditorID", editorID),
+	)
+
+	// BEGIN IMMEDIATE блокирует запись в базу до конца транзакции, поэтому FOR UPDATE не нужен
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	var oldTitle, oldPayload string
+	err = tx.QueryRowContext(ctx, `SELECT title, payload FROM posts WHERE id = ?1`, postID).Scan(&oldTitle, &oldPayload)
+	if err != nil {
+		err = mapError(err)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Warn("Failed to update post: not found")
+			return nil, err
+		}
+		log.Error("Failed to get post", zap.Error(err))
+		return nil, err
+	}
+
+	if err = insertRevision(ctx, tx, revisionTargetPost, postID, &oldTitle, oldPayload, editorID); err != nil {
+		log.Error("Failed to save revision", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	query := `
+		UPDATE posts
+		SET title = COALESCE(?2, title), payload = COALESCE(?3, payload), editedAt = ?4
+		WHERE id = ?1
+	`
+	if _, err = tx.ExecContext(ctx, query, postID, input.Title, input.Payload, now()); err != nil {
+		log.Error("Failed to update post", zap.Error(err))
+		return nil, mapError(err)
+	}
+	if hold != nil {
+		if _, err = enqueueModeration(ctx, tx, hold); err != nil {
+			log.Error("Failed to enqueue post", zap.Error(err))
+			return nil, mapError(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	return p.GetPostByID(ctx, postID)
+}
+
+func (p *PostSQLiteRepository) GetPostRevisions(ctx context.Context, postID int) ([]*models.Revision, error) {
+	revisions, err := getRevisions(ctx, p.db, revisionTargetPost, postID)
+	if err != nil {
+		p.log.Error("Failed to get post revisions",
+			zap.String("Layer", "PostSQLiteRepository.GetPostRevisions"),
+			zap.Int("PostID", postID),
+			zap.Error(err),
+		)
+		return nil, mapError(err)
+	}
+	return revisions, nil
+}
@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPostSQLiteRepository_CreateAndGet(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostSQLiteRepository(newTestDB(t), zap.NewNop())
+
+	created, err := repo.CreatePost(ctx, models.NewPost{
+		Title:             "Hello",
+		Payload:           "World",
+		AuthorID:          alice,
+		IsCommentsAllowed: true,
+		Tags:              []string{"go", "sqlite"},
+	}, false)
+	require.NoError(t, err)
+	assert.Equal(t, models.PostStatusPublished, created.Status)
+	require.NotNil(t, created.PublishAt)
+	assert.WithinDuration(t, time.Now(), created.CreatedAt, time.Second)
+
+	got, err := repo.GetPostByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", got.Title)
+	assert.Equal(t, models.ContentFormatPlain, got.Format)
+	assert.Equal(t, "Alice", got.Author.Username)
+	assert.Equal(t, []string{"go", "sqlite"}, got.Tags)
+	assert.True(t, got.IsCommentsAllowed)
+	assert.Nil(t, got.EditedAt)
+	assert.True(t, created.CreatedAt.Equal(got.CreatedAt))
+
+	_, err = repo.GetPostByID(ctx, 999)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestPostSQLiteRepository_Visibility(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostSQLiteRepository(newTestDB(t), zap.NewNop())
+
+	draft := models.PostStatusDraft
+	scheduled := models.PostStatusScheduled
+	future := time.Now().Add(time.Hour)
+
+	published, err := repo.CreatePost(ctx, models.NewPost{Title: "published", Payload: "p", AuthorID: alice, Tags: []string{"go"}}, false)
+	require.NoError(t, err)
+	_, err = repo.CreatePost(ctx, models.NewPost{Title: "draft", Payload: "p", AuthorID: alice, Status: &draft, Tags: []string{"go"}}, false)
+	require.NoError(t, err)
+	later, err := repo.CreatePost(ctx, models.NewPost{Title: "scheduled", Payload: "p", AuthorID: alice, Status: &scheduled, PublishAt: &future}, false)
+	require.NoError(t, err)
+	_, err = repo.CreatePost(ctx, models.NewPost{Title: "hidden", Payload: "p", AuthorID: alen}, true)
+	require.NoError(t, err)
+
+	posts, err := repo.GetAllPosts(ctx, 10, 0, quizert)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, published.ID, posts[0].ID)
+
+	posts, err = repo.GetAllPosts(ctx, 10, 0, alice)
+	require.NoError(t, err)
+	assert.Len(t, posts, 3, "автор видит свои черновики и отложенные посты")
+
+	posts, err = repo.GetPostsByTag(ctx, "go", 10, 0, quizert)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, published.ID, posts[0].ID)
+
+	published2, err := repo.PublishScheduledPosts(ctx, future.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, published2)
+	got, err := repo.GetPostByID(ctx, later.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PostStatusPublished, got.Status)
+}
+
+func TestPostSQLiteRepository_GetTags(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostSQLiteRepository(newTestDB(t), zap.NewNop())
+
+	for _, tags := range [][]string{{"golang", "go_tips"}, {"golang"}, {"gopher"}} {
+		_, err := repo.CreatePost(ctx, models.NewPost{Title: "t", Payload: "p", AuthorID: alice, Tags: tags}, false)
+		require.NoError(t, err)
+	}
+
+	tags, err := repo.GetTags(ctx, "go", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"golang", "go_tips", "gopher"}, tags)
+
+	tags, err = repo.GetTags(ctx, "go_", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go_tips"}, tags, "_ в префиксе ищется буквально")
+}
+
+func TestPostSQLiteRepository_UpdatePost(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostSQLiteRepository(newTestDB(t), zap.NewNop())
+
+	post, err := repo.CreatePost(ctx, models.NewPost{Title: "v1", Payload: "first", AuthorID: alice}, false)
+	require.NoError(t, err)
+
+	title := "v2"
+	updated, err := repo.UpdatePost(ctx, post.ID, models.EditPost{Title: &title}, quizert, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", updated.Title)
+	assert.Equal(t, "first", updated.Payload)
+	assert.NotNil(t, updated.EditedAt)
+
+	revisions, err := repo.GetPostRevisions(ctx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, 1, revisions[0].Version)
+	assert.Equal(t, "v1", *revisions[0].Title)
+	assert.Equal(t, "Quizert", revisions[0].Editor.Username)
+
+	_, err = repo.UpdatePost(ctx, 999, models.EditPost{Title: &title}, alice, nil)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Quizert/PostCommentService/internal/models"
+)
+
+const (
+	revisionTargetPost    = "POST"
+	revisionTargetComment = "COMMENT"
+)
+
+// insertRevision сохраняет версию контента до правки. Номер версии считается внутри той же транзакции
+func insertRevision(ctx context.Context, tx *sql.Tx, targetType string, targetID int, title *string, payload string, editorID int) error {
+	query := `
+		INSERT INTO revisions (targetType, targetID, version, title, payload, editorID, editedAt)
+		VALUES (?1, ?2,
+		        (SELECT COALESCE(MAX(version), 0) + 1 FROM revisions WHERE targetType = ?1 AND targetID = ?2),
+		        ?3, ?4, ?5, ?6)
+	`
+	_, err := tx.ExecContext(ctx, query, targetType, targetID, title, payload, editorID, now())
+	return err
+}
+
+func getRevisions(ctx context.Context, db *sql.DB, targetType string, targetID int) ([]*models.Revision, error) {
+	query := `
+		SELECT r.id, r.version, r.title, r.payload, r.editedAt, u.id, u.username
+		FROM revisions r JOIN users u ON r.editorID = u.id
+		WHERE r.targetType = ?1 AND r.targetID = ?2
+		ORDER BY r.version
+	`
+
+	rows, err := db.QueryContext(ctx, query, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]*models.Revision, 0)
+	for rows.Next() {
+		var revision models.Revision
+		revision.Editor = &models.User{}
+		err = rows.Scan(
+			&revision.ID,
+			&revision.Version,
+			&revision.Title,
+			&revision.Payload,
+			&revision.EditedAt,
+			&revision.Editor.ID,
+			&revision.Editor.Username,
+		)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, &revision)
+	}
+	return revisions, rows.Err()
+}
@@ -0,0 +1,163 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Quizert/PostCommentService/internal/migrator"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/Quizert/PostCommentService/migrations"
+	"go.uber.org/zap"
+	"net/url"
+	"strings"
+	"time"
+
+	driver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Параметры соединения: внешние ключи включаются для каждого соединения, WAL позволяет читать во время записи,
+// BEGIN IMMEDIATE сразу берёт блокировку на запись, чтобы транзакции не падали с SQLITE_BUSY при её повышении.
+// Время пишется в UTC (см. now) в формате, который сортируется как строка
+var connParams = url.Values{
+	"_pragma":      {"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(WAL)"},
+	"_txlock":      {"immediate"},
+	"_time_format": {"sqlite"},
+}
+
+// timeLayout - формат времени при _time_format=sqlite
+const timeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+// Open открывает файл базы SQLite и применяет к нему миграции
+func Open(ctx context.Context, log *zap.Logger, path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?"+connParams.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	applied, err := Migrate(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	log.Info("SQLite migrations are up to date", zap.String("Path", path), zap.Int("Applied", applied))
+	return db, nil
+}
+
+// Migrate применяет миграции SQLite. Версия схемы хранится в PRAGMA user_version, файл базы
+// принадлежит одному процессу, поэтому отдельная блокировка не нужна
+func Migrate(ctx context.Context, db *sql.DB) (int, error) {
+	migrationList, err := migrator.Load(migrations.SQLite())
+	if err != nil {
+		return 0, fmt.Errorf("load migrations: %w", err)
+	}
+
+	var version int
+	if err = db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, migration := range migrationList {
+		if migration.Version <= version {
+			continue
+		}
+		if err = applyMigration(ctx, db, migration); err != nil {
+			return applied, fmt.Errorf("migration %s up: %w", migration, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, migration migrator.Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, migration.Up); err != nil {
+		return err
+	}
+	// PRAGMA не принимает параметры, версия - число из имени файла
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf(`PRAGMA user_version = %d`, migration.Version)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// mapError переводит ошибки database/sql и SQLite в ошибки пакета storage. Остальные ошибки возвращаются как есть
+func mapError(err error) error {
+	if err == nil || storage.IsStorageError(err) {
+		return err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrNotFound
+	}
+
+	var sqliteErr *driver.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		// SQLite не сообщает, какой именно внешний ключ нарушен
+		return &storage.ConstraintError{Kind: storage.ErrForeignKey, Err: err}
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return &storage.ConstraintError{Kind: storage.ErrConflict, Field: uniqueField(sqliteErr.Error()), Err: err}
+	}
+	return err
+}
+
+// uniqueField достаёт столбцы из сообщения вида "UNIQUE constraint failed: reports.targetType, reports.targetID"
+// и приводит их к виду, в котором их сообщает Postgres: "targettype_targetid"
+func uniqueField(message string) string {
+	i := strings.LastIndex(message, "failed: ")
+	if i < 0 {
+		return ""
+	}
+	columns, _, _ := strings.Cut(message[i+len("failed: "):], " (")
+
+	fields := make([]string, 0)
+	for _, column := range strings.Split(columns, ", ") {
+		_, name, _ := strings.Cut(column, ".")
+		fields = append(fields, strings.ToLower(name))
+	}
+	return strings.Join(fields, "_")
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// textTime читает время из выражения без объявленного типа столбца, например MAX(createdAt).
+// Драйвер превращает во время только значения столбцов типа timestamp, а такие выражения отдаёт строкой
+type textTime struct {
+	*time.Time
+}
+
+func (t textTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		*t.Time = v
+		return nil
+	case string:
+		parsed, err := time.Parse(timeLayout, v)
+		if err != nil {
+			return err
+		}
+		*t.Time = parsed
+		return nil
+	}
+	return fmt.Errorf("unsupported time value %T", value)
+}
@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/Quizert/PostCommentService/internal/migrator"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Пользователи из первой миграции
+const (
+	alice   = 1
+	quizert = 2
+	alen    = 3
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := Open(context.Background(), zap.NewNop(), filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	postgresMigrations, err := migrator.Load(migrations.FS)
+	require.NoError(t, err)
+	var version int
+	require.NoError(t, db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version))
+	assert.Equal(t, len(postgresMigrations), version, "SQLite должен повторять все версии миграций Postgres")
+
+	applied, err := Migrate(ctx, db)
+	require.NoError(t, err)
+	assert.Zero(t, applied, "повторный запуск не применяет миграции")
+
+	user, err := NewUserSQLiteRepository(db, zap.NewNop()).GetUserByID(ctx, quizert)
+	require.NoError(t, err)
+	assert.Equal(t, "Quizert", user.Username)
+	assert.Equal(t, models.UserRoleUser, user.Role, "миграции не выдают роль модератора")
+}
+
+func TestMigrate_Down(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	sqliteMigrations, err := migrator.Load(migrations.SQLite())
+	require.NoError(t, err)
+	for i := len(sqliteMigrations) - 1; i >= 0; i-- {
+		_, err = db.ExecContext(ctx, sqliteMigrations[i].Down)
+		require.NoError(t, err, "migration %s down", sqliteMigrations[i])
+	}
+
+	var tables int
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`).Scan(&tables))
+	assert.Zero(t, tables)
+}
@@ -0,0 +1,264 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"go.uber.org/zap"
+	"strings"
+)
+
+type UserSQLiteRepository struct {
+	db  *sql.DB
+	log *zap.Logger
+}
+
+func NewUserSQLiteRepository(db *sql.DB, log *zap.Logger) *UserSQLiteRepository {
+	return &UserSQLiteRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (u *UserSQLiteRepository) GetUserByID(ctx context.Context, userID int) (*models.User, error) {
+	log := u.log.With(
+		zap.String("Layer", "UserSQLiteRepository.GetUserByID"),
+		zap.Int("UserID", userID),
+	)
+
+	var user models.User
+	query := `SELECT id, username, role FROM users WHERE id = ?1`
+	err := u.db.QueryRowContext(ctx, query, userID).Scan(&user.ID, &user.Username, &user.Role)
+	if err != nil {
+		err = mapError(err)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Warn("User does not exist")
+			return nil, err
+		}
+		log.Error("Error getting user", zap.Error(err))
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (u *UserSQLiteRepository) GetUsersByUsernames(ctx context.Context, usernames []string) ([]*models.User, error) {
+	log := u.log.With(
+		zap.String("Layer", "UserSQLiteRepository.GetUsersByUsernames"),
+		zap.Strings("Usernames", usernames),
+	)
+
+	users := make([]*models.User, 0, len(usernames))
+	if len(usernames) == 0 {
+		return users, nil
+	}
+
+	// Массивов в SQLite нет, поэтому список передаётся отдельными параметрами
+	args := make([]interface{}, len(usernames))
+	for i, username := range usernames {
+		args[i] = strings.ToLower(username)
+	}
+	query := `SELECT id, username, role FROM users WHERE lower(username) IN (?` + strings.Repeat(`, ?`, len(usernames)-1) + `)`
+	rows, err := u.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error("Error getting users", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+		if err = rows.Scan(&user.ID, &user.Username, &user.Role); err != nil {
+			log.Error("Failed to scan row", zap.Error(err))
+			return nil, mapError(err)
+		}
+		users = append(users, &user)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Error after reading rows", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return users, nil
+}
+
+func (u *UserSQLiteRepository) SetUserRole(ctx context.Context, userID int, role models.UserRole) error {
+	log := u.log.With(
+		zap.String("Layer", "UserSQLiteRepository.SetUserRole"),
+		zap.Int("UserID", userID),
+		zap.String("Role", string(role)),
+	)
+
+	result, err := u.db.ExecContext(ctx, `UPDATE users SET role = ?2 WHERE id = ?1`, userID, role)
+	if err != nil {
+		log.Error("Failed to update user role", zap.Error(err))
+		return mapError(err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		log.Warn("Failed to update user role: user not found")
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (u *UserSQLiteRepository) BanUser(ctx context.Context, ban *models.UserBan) (*models.UserBan, error) {
+	log := u.log.With(
+		zap.String("Layer", "UserSQLiteRepository.BanUser"),
+		zap.Int("UserID", ban.User.ID),
+	)
+
+	query := `
+		INSERT INTO user_bans (userID, until, reason, moderatorID, createdAt)
+		VALUES (?1, ?2, ?3, ?4, ?5)
+		ON CONFLICT (userID) DO UPDATE
+		SET until = excluded.until, reason = excluded.reason, moderatorID = excluded.moderatorID, createdAt = excluded.createdAt
+	`
+	_, err := u.db.ExecContext(ctx, query, ban.User.ID, ban.Until, ban.Reason, ban.Moderator.ID, now())
+	if err != nil {
+		log.Error("Failed to ban user", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return u.GetUserBan(ctx, ban.User.ID)
+}
+
+func (u *UserSQLiteRepository) GetUserBan(ctx context.Context, userID int) (*models.UserBan, error) {
+	log := u.log.With(
+		zap.String("Layer", "UserSQLiteRepository.GetUserBan"),
+		zap.Int("UserID", userID),
+	)
+
+	query := `
+		SELECT b.until, b.reason, b.createdAt, u.id, u.username, m.id, m.username
+		FROM user_bans b
+		JOIN users u ON b.userID = u.id
+		JOIN users m ON b.moderatorID = m.id
+		WHERE b.userID = ?1
+	`
+	ban := models.UserBan{User: &models.User{}, Moderator: &models.User{}}
+	err := u.db.QueryRowContext(ctx, query, userID).Scan(
+		&ban.Until,
+		&ban.Reason,
+		&ban.CreatedAt,
+		&ban.User.ID,
+		&ban.User.Username,
+		&ban.Moderator.ID,
+		&ban.Moderator.Username,
+	)
+	if err != nil {
+		err = mapError(err)
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Error("Error getting user ban", zap.Error(err))
+		}
+		return nil, err
+	}
+	return &ban, nil
+}
+
+func (u *UserSQLiteRepository) MuteUserOnPost(ctx context.Context, mute *models.PostMute) (*models.PostMute, error) {
+	log := u.log.With(
+		zap.String("Layer", "UserSQLiteRepository.MuteUserOnPost"),
+		zap.Int("PostID", mute.PostID),
+		zap.Int("UserID", mute.User.ID),
+	)
+
+	query := `
+		INSERT INTO post_mutes (postID, userID, until, mutedBy, createdAt)
+		VALUES (?1, ?2, ?3, ?4, ?5)
+		ON CONFLICT (postID, userID) DO UPDATE
+		SET until = excluded.until, mutedBy = excluded.mutedBy, createdAt = excluded.createdAt
+	`
+	_, err := u.db.ExecContext(ctx, query, mute.PostID, mute.User.ID, mute.Until, mute.MutedBy.ID, now())
+	if err != nil {
+		log.Error("Failed to mute user on post", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return u.GetPostMute(ctx, mute.PostID, mute.User.ID)
+}
+
+func (u *UserSQLiteRepository) GetPostMute(ctx context.Context, postID int, userID int) (*models.PostMute, error) {
+	log := u.log.With(
+		zap.String("Layer", "UserSQLiteRepository.GetPostMute"),
+		zap.Int("PostID", postID),
+		zap.Int("UserID", userID),
+	)
+
+	query := `
+		SELECT pm.postID, pm.until, pm.createdAt, u.id, u.username, m.id, m.username
+		FROM post_mutes pm
+		JOIN users u ON pm.userID = u.id
+		JOIN users m ON pm.mutedBy = m.id
+		WHERE pm.postID = ?1 AND pm.userID = ?2
+	`
+	mute := models.PostMute{User: &models.User{}, MutedBy: &models.User{}}
+	err := u.db.QueryRowContext(ctx, query, postID, userID).Scan(
+		&mute.PostID,
+		&mute.Until,
+		&mute.CreatedAt,
+		&mute.User.ID,
+		&mute.User.Username,
+		&mute.MutedBy.ID,
+		&mute.MutedBy.Username,
+	)
+	if err != nil {
+		err = mapError(err)
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Error("Error getting post mute", zap.Error(err))
+		}
+		return nil, err
+	}
+	return &mute, nil
+}
+
+func (u *UserSQLiteRepository) BlockUser(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	log := u.log.With(
+		zap.String("Layer", "UserSQLiteRepository.BlockUser"),
+		zap.Int("BlockerID", blockerID),
+		zap.Int("BlockedID", blockedID),
+	)
+
+	query := `
+		INSERT INTO user_blocks (blockerID, blockedID, createdAt)
+		VALUES (?1, ?2, ?3)
+		ON CONFLICT (blockerID, blockedID) DO NOTHING
+	`
+	result, err := u.db.ExecContext(ctx, query, blockerID, blockedID, now())
+	if err != nil {
+		log.Error("Failed to block user", zap.Error(err))
+		return false, mapError(err)
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (u *UserSQLiteRepository) UnblockUser(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	log := u.log.With(
+		zap.String("Layer", "UserSQLiteRepository.UnblockUser"),
+		zap.Int("BlockerID", blockerID),
+		zap.Int("BlockedID", blockedID),
+	)
+
+	result, err := u.db.ExecContext(ctx, `DELETE FROM user_blocks WHERE blockerID = ?1 AND blockedID = ?2`, blockerID, blockedID)
+	if err != nil {
+		log.Error("Failed to unblock user", zap.Error(err))
+		return false, mapError(err)
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (u *UserSQLiteRepository) IsBlocked(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	log := u.log.With(
+		zap.String("Layer", "UserSQLiteRepository.IsBlocked"),
+		zap.Int("BlockerID", blockerID),
+		zap.Int("BlockedID", blockedID),
+	)
+
+	var blocked bool
+	query := `SELECT EXISTS (SELECT 1 FROM user_blocks WHERE blockerID = ?1 AND blockedID = ?2)`
+	if err := u.db.QueryRowContext(ctx, query, blockerID, blockedID).Scan(&blocked); err != nil {
+		log.Error("Failed to check block", zap.Error(err))
+		return false, mapError(err)
+	}
+	return blocked, nil
+}
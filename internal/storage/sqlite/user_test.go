@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUserSQLiteRepository_GetUsersByUsernames(t *testing.T) {
+	repo := NewUserSQLiteRepository(newTestDB(t), zap.NewNop())
+
+	users, err := repo.GetUsersByUsernames(context.Background(), []string{"alice", "QUIZERT", "nobody"})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+
+	users, err = repo.GetUsersByUsernames(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	_, err = repo.GetUserByID(context.Background(), 999)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestUserSQLiteRepository_Restrictions(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewUserSQLiteRepository(db, zap.NewNop())
+	post := createTestPost(t, db)
+
+	_, err := repo.GetUserBan(ctx, alen)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	ban, err := repo.BanUser(ctx, &models.UserBan{User: &models.User{ID: alen}, Until: &until, Reason: "spam", Moderator: &models.User{ID: quizert}})
+	require.NoError(t, err)
+	assert.Equal(t, "Alen", ban.User.Username)
+	assert.Equal(t, "Quizert", ban.Moderator.Username)
+	require.NotNil(t, ban.Until)
+	assert.True(t, until.Equal(*ban.Until))
+
+	ban, err = repo.BanUser(ctx, &models.UserBan{User: &models.User{ID: alen}, Reason: "forever", Moderator: &models.User{ID: quizert}})
+	require.NoError(t, err)
+	assert.Nil(t, ban.Until, "повторный бан заменяет предыдущий")
+	assert.Equal(t, "forever", ban.Reason)
+
+	mute, err := repo.MuteUserOnPost(ctx, &models.PostMute{PostID: post.ID, User: &models.User{ID: alen}, MutedBy: &models.User{ID: alice}})
+	require.NoError(t, err)
+	assert.Equal(t, post.ID, mute.PostID)
+	assert.Equal(t, "Alice", mute.MutedBy.Username)
+
+	_, err = repo.GetPostMute(ctx, post.ID, quizert)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestUserSQLiteRepository_Blocks(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserSQLiteRepository(newTestDB(t), zap.NewNop())
+
+	changed, err := repo.BlockUser(ctx, alice, alen)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = repo.BlockUser(ctx, alice, alen)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	blocked, err := repo.IsBlocked(ctx, alice, alen)
+	require.NoError(t, err)
+	assert.True(t, blocked)
+	blocked, err = repo.IsBlocked(ctx, alen, alice)
+	require.NoError(t, err)
+	assert.False(t, blocked)
+
+	changed, err = repo.UnblockUser(ctx, alice, alen)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = repo.UnblockUser(ctx, alice, alen)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
@@ -0,0 +1,340 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+// webhookColumns - колонки вебхука, типы событий хранятся JSON-массивом
+const webhookColumns = `id, url, secret, eventTypes, isActive, createdAt`
+
+const webhookDeliveryColumns = `
+	id, webhookID, eventID, eventType, payload, status, attempts, responseStatus, lastError, nextAttemptAt, deliveredAt, createdAt
+`
+
+type WebhookSQLiteRepository struct {
+	db  *sql.DB
+	log *zap.Logger
+}
+
+func NewWebhookSQLiteRepository(db *sql.DB, log *zap.Logger) *WebhookSQLiteRepository {
+	return &WebhookSQLiteRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (w *WebhookSQLiteRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookSQLiteRepository.CreateWebhook"),
+		zap.String("URL", webhook.URL),
+	)
+
+	eventTypes, err := json.Marshal(webhook.EventTypes)
+	if err != nil {
+		log.Error("Failed to encode event types", zap.Error(err))
+		return nil, err
+	}
+	query := `
+		INSERT INTO webhooks (url, secret, eventTypes, isActive, createdAt)
+		VALUES (?1, ?2, ?3, ?4, ?5)
+		RETURNING ` + webhookColumns
+
+	created, err := scanWebhook(w.db.QueryRowContext(ctx, query, webhook.URL, webhook.Secret, string(eventTypes), webhook.IsActive, now()))
+	if err != nil {
+		log.Error("Failed to create webhook", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return created, nil
+}
+
+func (w *WebhookSQLiteRepository) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	log := w.log.With(zap.String("Layer", "WebhookSQLiteRepository.GetWebhooks"))
+
+	rows, err := w.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY id`)
+	if err != nil {
+		log.Error("Failed to get webhooks", zap.Error(err))
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*models.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			log.Error("Failed to scan webhook", zap.Error(err))
+			return nil, mapError(err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("Failed to read webhooks", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return webhooks, nil
+}
+
+func (w *WebhookSQLiteRepository) GetWebhookByID(ctx context.Context, id int) (*models.Webhook, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookSQLiteRepository.GetWebhookByID"),
+		zap.Int("WebhookID", id),
+	)
+
+	webhook, err := scanWebhook(w.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = ?1`, id))
+	if err != nil {
+		err = mapError(err)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Warn("Webhook not found")
+			return nil, err
+		}
+		log.Error("Failed to get webhook", zap.Error(err))
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (w *WebhookSQLiteRepository) UpdateWebhook(ctx context.Context, id int, input models.EditWebhook) (*models.Webhook, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookSQLiteRepository.UpdateWebhook"),
+		zap.Int("WebhookID", id),
+	)
+
+	// Пустой список типов событий сервис не пропускает, поэтому nil означает, что список не меняется
+	var eventTypes *string
+	if input.EventTypes != nil {
+		encoded, err := json.Marshal(input.EventTypes)
+		if err != nil {
+			log.Error("Failed to encode event types", zap.Error(err))
+			return nil, err
+		}
+		value := string(encoded)
+		eventTypes = &value
+	}
+	query := `
+		UPDATE webhooks
+		SET url = COALESCE(?2, url), secret = COALESCE(?3, secret), eventTypes = COALESCE(?4, eventTypes), isActive = COALESCE(?5, isActive)
+		WHERE id = ?1
+		RETURNING ` + webhookColumns
+
+	webhook, err := scanWebhook(w.db.QueryRowContext(ctx, query, id, input.URL, input.Secret, eventTypes, input.IsActive))
+	if err != nil {
+		err = mapError(err)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Warn("Failed to update webhook: not found")
+			return nil, err
+		}
+		log.Error("Failed to update webhook", zap.Error(err))
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (w *WebhookSQLiteRepository) DeleteWebhook(ctx context.Context, id int) error {
+	log := w.log.With(
+		zap.String("Layer", "WebhookSQLiteRepository.DeleteWebhook"),
+		zap.Int("WebhookID", id),
+	)
+
+	result, err := w.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?1`, id)
+	if err != nil {
+		log.Error("Failed to delete webhook", zap.Error(err))
+		return mapError(err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		log.Warn("Failed to delete webhook: not found")
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (w *WebhookSQLiteRepository) CreateWebhookDeliveries(ctx context.Context, eventID int, eventType models.WebhookEventType, payload string) (int, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookSQLiteRepository.CreateWebhookDeliveries"),
+		zap.Int("EventID", eventID),
+		zap.String("EventType", string(eventType)),
+	)
+
+	query := `
+		INSERT INTO webhook_deliveries (webhookID, eventID, eventType, payload, nextAttemptAt, createdAt)
+		SELECT w.id, ?1, ?2, ?3, ?4, ?4 FROM webhooks w
+		WHERE w.isActive AND EXISTS (SELECT 1 FROM json_each(w.eventTypes) WHERE value = ?2)
+		ON CONFLICT (webhookID, eventID) DO NOTHING
+	`
+	result, err := w.db.ExecContext(ctx, query, eventID, string(eventType), payload, now())
+	if err != nil {
+		log.Error("Failed to create webhook deliveries", zap.Error(err))
+		return 0, mapError(err)
+	}
+	created, err := result.RowsAffected()
+	if err != nil {
+		return 0, mapError(err)
+	}
+	return int(created), nil
+}
+
+// ClaimWebhookDeliveries работает как ClaimOutboxEvents: выборка и продление аренды - один запрос
+func (w *WebhookSQLiteRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	log := w.log.With(zap.String("Layer", "WebhookSQLiteRepository.ClaimWebhookDeliveries"))
+
+	query := `
+		UPDATE webhook_deliveries SET nextAttemptAt = ?2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d JOIN webhooks w ON d.webhookID = w.id
+			WHERE d.status = 'PENDING' AND d.nextAttemptAt <= ?1 AND w.isActive
+			ORDER BY d.nextAttemptAt, d.id
+			LIMIT ?3
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	rows, err := w.db.QueryContext(ctx, query, now.UTC(), now.Add(lease).UTC(), limit)
+	if err != nil {
+		log.Error("Failed to claim webhook deliveries", zap.Error(err))
+		return nil, mapError(err)
+	}
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		log.Error("Failed to scan webhook deliveries", zap.Error(err))
+		return nil, mapError(err)
+	}
+
+	// RETURNING не сохраняет порядок подзапроса
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries, nil
+}
+
+func (w *WebhookSQLiteRepository) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	log := w.log.With(
+		zap.String("Layer", "WebhookSQLiteRepository.UpdateWebhookDelivery"),
+		zap.Int("DeliveryID", delivery.ID),
+	)
+
+	var deliveredAt *time.Time
+	if delivery.DeliveredAt != nil {
+		utc := delivery.DeliveredAt.UTC()
+		deliveredAt = &utc
+	}
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?2, attempts = ?3, responseStatus = ?4, lastError = ?5, nextAttemptAt = ?6, deliveredAt = ?7
+		WHERE id = ?1
+	`
+	result, err := w.db.ExecContext(ctx, query, delivery.ID, string(delivery.Status), delivery.Attempts, delivery.ResponseStatus,
+		delivery.LastError, delivery.NextAttemptAt.UTC(), deliveredAt)
+	if err != nil {
+		log.Error("Failed to update webhook delivery", zap.Error(err))
+		return mapError(err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		log.Warn("Failed to update webhook delivery: not found")
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (w *WebhookSQLiteRepository) GetWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookSQLiteRepository.GetWebhookDelivery"),
+		zap.Int("DeliveryID", id),
+	)
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = ?1`
+	delivery, err := scanWebhookDelivery(w.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		err = mapError(err)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Warn("Webhook delivery not found")
+			return nil, err
+		}
+		log.Error("Failed to get webhook delivery", zap.Error(err))
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (w *WebhookSQLiteRepository) GetWebhookDeliveries(ctx context.Context, webhookID int, status *models.WebhookDeliveryStatus, limit int, offset int) ([]*models.WebhookDelivery, error) {
+	log := w.log.With(
+		zap.String("Layer", "WebhookSQLiteRepository.GetWebhookDeliveries"),
+		zap.Int("WebhookID", webhookID),
+	)
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE webhookID = ?1 AND (?2 IS NULL OR status = ?2)
+		ORDER BY id DESC LIMIT ?3 OFFSET ?4
+	`
+	var statusFilter *string
+	if status != nil {
+		value := string(*status)
+		statusFilter = &value
+	}
+	rows, err := w.db.QueryContext(ctx, query, webhookID, statusFilter, limit, offset)
+	if err != nil {
+		log.Error("Failed to get webhook deliveries", zap.Error(err))
+		return nil, mapError(err)
+	}
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		log.Error("Failed to scan webhook deliveries", zap.Error(err))
+		return nil, mapError(err)
+	}
+	return deliveries, nil
+}
+
+func scanWebhook(row scanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var eventTypes string
+	err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &eventTypes, &webhook.IsActive, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(eventTypes), &webhook.EventTypes); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func scanWebhookDelivery(row scanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var eventType, status string
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&eventType,
+		&delivery.Payload,
+		&status,
+		&delivery.Attempts,
+		&delivery.ResponseStatus,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	delivery.EventType = models.WebhookEventType(eventType)
+	delivery.Status = models.WebhookDeliveryStatus(status)
+	return &delivery, nil
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]*models.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
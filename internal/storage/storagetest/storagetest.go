@@ -0,0 +1,966 @@
+// Package storagetest - общий набор проверок для реализаций хранилища. Любой бэкенд, реализующий
+// PostProvider, CommentProvider, UserProvider, OutboxProvider, WebhookProvider и ModerationProvider, должен вести себя одинаково: порядок выдачи, пагинация,
+// ответы, авторы и поведение при отсутствии записи
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quizert/PostCommentService/internal/models"
+	"github.com/Quizert/PostCommentService/internal/service"
+	"github.com/Quizert/PostCommentService/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Пользователи, которые есть в каждом хранилище сразу после создания
+const (
+	Alice   = 1
+	Quizert = 2
+	Alen    = 3
+)
+
+const missingID = 1_000_000
+
+var usernames = map[int]string{
+	Alice:   "Alice",
+	Quizert: "Quizert",
+	Alen:    "Alen",
+}
+
+type Providers struct {
+	Posts      service.PostProvider
+	Comments   service.CommentProvider
+	Users      service.UserProvider
+	Outbox     service.OutboxProvider
+	Webhooks   service.WebhookProvider
+	Moderation service.ModerationProvider
+}
+
+// Factory возвращает пустое хранилище с пользователями Alice, Quizert и Alen. Вызывается для каждой проверки
+type Factory func(t *testing.T) Providers
+
+// Run прогоняет все проверки на хранилище, которое создаёт newProviders
+func Run(t *testing.T, newProviders Factory) {
+	t.Run("Posts", func(t *testing.T) { runPosts(t, newProviders) })
+	t.Run("Comments", func(t *testing.T) { runComments(t, newProviders) })
+	t.Run("Users", func(t *testing.T) { runUsers(t, newProviders) })
+	t.Run("Outbox", func(t *testing.T) { runOutbox(t, newProviders) })
+	t.Run("Webhooks", func(t *testing.T) { runWebhooks(t, newProviders) })
+}
+
+func runPosts(t *testing.T, newProviders Factory) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		p := newProviders(t)
+
+		created, err := p.Posts.CreatePost(ctx, models.NewPost{
+			Title:             "title",
+			Payload:           "payload",
+			AuthorID:          Alice,
+			IsCommentsAllowed: true,
+			Tags:              []string{"go", "graphql"},
+		}, false)
+		require.NoError(t, err)
+		assert.Positive(t, created.ID)
+		assertAuthor(t, Alice, created.Author)
+		assert.Equal(t, models.PostStatusPublished, created.Status)
+		assert.NotNil(t, created.PublishAt, "опубликованный пост получает время публикации")
+
+		got, err := p.Posts.GetPostByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, got.ID)
+		assert.Equal(t, "title", got.Title)
+		assert.Equal(t, "payload", got.Payload)
+		assert.True(t, got.IsCommentsAllowed)
+		assert.Equal(t, []string{"go", "graphql"}, got.Tags)
+		assertAuthor(t, Alice, got.Author)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		p := newProviders(t)
+
+		_, err := p.Posts.GetPostByID(ctx, missingID)
+		assertNotFound(t, err)
+
+		_, err = p.Posts.UpdatePostStatus(ctx, missingID, models.PostStatusPublished, nil)
+		assertNotFound(t, err)
+
+		title := "new"
+		_, err = p.Posts.UpdatePost(ctx, missingID, models.EditPost{Title: &title}, Alice, nil)
+		assertNotFound(t, err)
+	})
+
+	t.Run("ordering and pagination", func(t *testing.T) {
+		p := newProviders(t)
+
+		// ids[0] - самый старый пост
+		ids := make([]int, 5)
+		for i := range ids {
+			post := createPost(t, p, Alice, "go")
+			ids[i] = post.ID
+		}
+		newestFirst := []int{ids[4], ids[3], ids[2], ids[1], ids[0]}
+
+		tests := []struct {
+			name   string
+			limit  int
+			offset int
+			want   []int
+		}{
+			{name: "all", limit: 10, offset: 0, want: newestFirst},
+			{name: "first page", limit: 2, offset: 0, want: newestFirst[:2]},
+			{name: "second page", limit: 2, offset: 2, want: newestFirst[2:4]},
+			{name: "last page", limit: 2, offset: 4, want: newestFirst[4:]},
+			{name: "offset past end", limit: 2, offset: 5, want: []int{}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				posts, err := p.Posts.GetAllPosts(ctx, tt.limit, tt.offset, Alen)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, postIDs(posts))
+
+				byTag, err := p.Posts.GetPostsByTag(ctx, "go", tt.limit, tt.offset, Alen)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, postIDs(byTag))
+			})
+		}
+	})
+
+	t.Run("visibility", func(t *testing.T) {
+		p := newProviders(t)
+
+		published := createPost(t, p, Alice, "go")
+		draft := models.PostStatusDraft
+		hidden, err := p.Posts.CreatePost(ctx, models.NewPost{Title: "hidden", AuthorID: Alice, Tags: []string{"go"}}, true)
+		require.NoError(t, err)
+		drafted, err := p.Posts.CreatePost(ctx, models.NewPost{Title: "draft", AuthorID: Alice, Status: &draft, Tags: []string{"go"}}, false)
+		require.NoError(t, err)
+
+		posts, err := p.Posts.GetAllPosts(ctx, 10, 0, Alen)
+		require.NoError(t, err)
+		assert.Equal(t, []int{published.ID}, postIDs(posts), "черновики и скрытые посты видит только автор")
+
+		posts, err = p.Posts.GetAllPosts(ctx, 10, 0, Alice)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{published.ID, hidden.ID, drafted.ID}, postIDs(posts))
+
+		byTag, err := p.Posts.GetPostsByTag(ctx, "go", 10, 0, Alen)
+		require.NoError(t, err)
+		assert.Equal(t, []int{published.ID}, postIDs(byTag))
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		p := newProviders(t)
+
+		goPost := createPost(t, p, Alice, "go", "golang")
+		createPost(t, p, Alen, "go")
+		createPost(t, p, Alen, "rust")
+
+		byTag, err := p.Posts.GetPostsByTag(ctx, "golang", 10, 0, Alen)
+		require.NoError(t, err)
+		assert.Equal(t, []int{goPost.ID}, postIDs(byTag))
+
+		tags, err := p.Posts.GetTags(ctx, "go", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go", "golang"}, tags, "сначала самые популярные теги")
+	})
+
+	t.Run("tag order", func(t *testing.T) {
+		p := newProviders(t)
+
+		// Теги поста всегда отдаются отсортированными по имени, независимо от порядка при создании
+		want := []string{"api", "go", "graphql"}
+		created := createPost(t, p, Alice, "graphql", "go", "api")
+		assert.Equal(t, want, created.Tags)
+
+		got, err := p.Posts.GetPostByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, want, got.Tags)
+
+		posts, err := p.Posts.GetAllPosts(ctx, 10, 0, Alen)
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, want, posts[0].Tags)
+
+		byTag, err := p.Posts.GetPostsByTag(ctx, "go", 10, 0, Alen)
+		require.NoError(t, err)
+		require.Len(t, byTag, 1)
+		assert.Equal(t, want, byTag[0].Tags)
+	})
+
+	t.Run("scheduled publishing", func(t *testing.T) {
+		p := newProviders(t)
+
+		scheduled := models.PostStatusScheduled
+		publishAt := time.Now().Add(-time.Minute)
+		post, err := p.Posts.CreatePost(ctx, models.NewPost{Title: "scheduled", AuthorID: Alice, Status: &scheduled, PublishAt: &publishAt}, false)
+		require.NoError(t, err)
+
+		published, err := p.Posts.PublishScheduledPosts(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, published)
+
+		got, err := p.Posts.GetPostByID(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.PostStatusPublished, got.Status)
+	})
+
+	t.Run("edit keeps revisions", func(t *testing.T) {
+		p := newProviders(t)
+
+		post := createPost(t, p, Alice)
+		title := "edited"
+		updated, err := p.Posts.UpdatePost(ctx, post.ID, models.EditPost{Title: &title}, Alice, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "edited", updated.Title)
+		assert.Equal(t, post.Payload, updated.Payload)
+		assert.NotNil(t, updated.EditedAt)
+		assertAuthor(t, Alice, updated.Author)
+
+		revisions, err := p.Posts.GetPostRevisions(ctx, post.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+		assert.Equal(t, 1, revisions[0].Version)
+		require.NotNil(t, revisions[0].Title)
+		assert.Equal(t, post.Title, *revisions[0].Title)
+		assertAuthor(t, Alice, revisions[0].Editor)
+	})
+
+	t.Run("held edit", func(t *testing.T) {
+		p := newProviders(t)
+
+		post := createPost(t, p, Alice)
+		title := "edited"
+		updated, err := p.Posts.UpdatePost(ctx, post.ID, models.EditPost{Title: &title}, Alice, heldItem(models.TargetTypePost, post.ID, Alice))
+		require.NoError(t, err)
+		assert.Equal(t, "edited", updated.Title)
+		assert.True(t, updated.IsHidden, "правка скрывается в той же транзакции")
+
+		item := pendingItem(t, p, models.TargetTypePost, post.ID)
+		assert.True(t, item.WasPublished, "пост был опубликован до правки")
+	})
+}
+
+func runComments(t *testing.T, newProviders Factory) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+
+		created, err := p.Comments.CreateComment(ctx, models.NewComment{PostID: post.ID, AuthorID: Alen, Payload: "hello"}, false, nil)
+		require.NoError(t, err)
+		assert.Positive(t, created.ID)
+		assert.Equal(t, post.ID, created.PostID)
+		assertAuthor(t, Alen, created.Author)
+
+		got, err := p.Comments.GetCommentByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", got.Payload)
+		assert.Nil(t, got.ReplyTo)
+		assertAuthor(t, Alen, got.Author)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		p := newProviders(t)
+
+		_, err := p.Comments.GetCommentByID(ctx, missingID)
+		assertNotFound(t, err)
+
+		_, err = p.Comments.SetCommentPinned(ctx, missingID, true)
+		assertNotFound(t, err)
+
+		_, err = p.Comments.LockThread(ctx, missingID)
+		assertNotFound(t, err)
+
+		_, err = p.Comments.UpdateComment(ctx, missingID, "payload", Alice, nil)
+		assertNotFound(t, err)
+
+		locked, err := p.Comments.IsThreadLocked(ctx, missingID)
+		require.NoError(t, err)
+		assert.False(t, locked)
+	})
+
+	t.Run("missing references", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+		missing := missingID
+
+		inputs := map[string]models.NewComment{
+			"post":   {PostID: missingID, AuthorID: Alen, Payload: "comment"},
+			"author": {PostID: post.ID, AuthorID: missingID, Payload: "comment"},
+			"parent": {PostID: post.ID, AuthorID: Alen, Payload: "comment", ReplyTo: &missing},
+		}
+		for name, input := range inputs {
+			_, err := p.Comments.CreateComment(ctx, input, false, nil)
+			assert.ErrorIs(t, err, storage.ErrForeignKey, name)
+		}
+
+		_, err := p.Posts.CreatePost(ctx, models.NewPost{Title: "title", AuthorID: missingID}, false)
+		assert.ErrorIs(t, err, storage.ErrForeignKey)
+	})
+
+	t.Run("ordering and pagination", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+
+		ids := make([]int, 5)
+		for i := range ids {
+			ids[i] = createComment(t, p, post.ID, Alen, nil).ID
+		}
+		// Ответ не попадает в список комментариев поста
+		createComment(t, p, post.ID, Alice, &ids[0])
+		newestFirst := []int{ids[4], ids[3], ids[2], ids[1], ids[0]}
+
+		tests := []struct {
+			name   string
+			limit  int
+			offset int
+			want   []int
+		}{
+			{name: "all", limit: 10, offset: 0, want: newestFirst},
+			{name: "first page", limit: 2, offset: 0, want: newestFirst[:2]},
+			{name: "second page", limit: 2, offset: 2, want: newestFirst[2:4]},
+			{name: "last page", limit: 2, offset: 4, want: newestFirst[4:]},
+			{name: "offset past end", limit: 2, offset: 5, want: []int{}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				comments, err := p.Comments.GetCommentsByPostID(ctx, tt.limit, tt.offset, post.ID, Alice)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, commentIDs(comments))
+				for _, comment := range comments {
+					assertAuthor(t, Alen, comment.Author)
+				}
+			})
+		}
+	})
+
+	t.Run("pinned first", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+
+		older := createComment(t, p, post.ID, Alen, nil)
+		newer := createComment(t, p, post.ID, Alen, nil)
+		pinned, err := p.Comments.SetCommentPinned(ctx, older.ID, true)
+		require.NoError(t, err)
+		assert.True(t, pinned.IsPinned)
+
+		comments, err := p.Comments.GetCommentsByPostID(ctx, 10, 0, post.ID, Alice)
+		require.NoError(t, err)
+		assert.Equal(t, []int{older.ID, newer.ID}, commentIDs(comments))
+	})
+
+	t.Run("replies", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+
+		root := createComment(t, p, post.ID, Alen, nil)
+		other := createComment(t, p, post.ID, Alen, nil)
+		first := createComment(t, p, post.ID, Alice, &root.ID)
+		second := createComment(t, p, post.ID, Quizert, &root.ID)
+		// Ответ на ответ и ответ на другой комментарий в выдачу не попадают
+		createComment(t, p, post.ID, Alen, &first.ID)
+		createComment(t, p, post.ID, Alen, &other.ID)
+
+		replies, err := p.Comments.Replies(ctx, root.ID, 10, 0, Alen)
+		require.NoError(t, err)
+		require.Equal(t, []int{second.ID, first.ID}, commentIDs(replies))
+		assertAuthor(t, Quizert, replies[0].Author)
+		assertAuthor(t, Alice, replies[1].Author)
+		require.NotNil(t, replies[0].ReplyTo)
+		assert.Equal(t, root.ID, *replies[0].ReplyTo)
+
+		page, err := p.Comments.Replies(ctx, root.ID, 1, 1, Alen)
+		require.NoError(t, err)
+		assert.Equal(t, []int{first.ID}, commentIDs(page))
+
+		empty, err := p.Comments.Replies(ctx, missingID, 10, 0, Alen)
+		require.NoError(t, err)
+		assert.Empty(t, empty)
+	})
+
+	t.Run("hidden and blocked", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+
+		visible := createComment(t, p, post.ID, Quizert, nil)
+		_, err := p.Comments.CreateComment(ctx, models.NewComment{PostID: post.ID, AuthorID: Quizert, Payload: "hidden"}, true, nil)
+		require.NoError(t, err)
+		blocked := createComment(t, p, post.ID, Alen, nil)
+
+		_, err = p.Users.BlockUser(ctx, Alice, Alen)
+		require.NoError(t, err)
+
+		comments, err := p.Comments.GetCommentsByPostID(ctx, 10, 0, post.ID, Alice)
+		require.NoError(t, err)
+		assert.Equal(t, []int{visible.ID}, commentIDs(comments))
+
+		comments, err = p.Comments.GetCommentsByPostID(ctx, 10, 0, post.ID, Quizert)
+		require.NoError(t, err)
+		assert.Equal(t, []int{blocked.ID, visible.ID}, commentIDs(comments), "блокировка действует только на заблокировавшего")
+	})
+
+	t.Run("locked thread", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+
+		root := createComment(t, p, post.ID, Alen, nil)
+		reply := createComment(t, p, post.ID, Alice, &root.ID)
+		nested := createComment(t, p, post.ID, Alen, &reply.ID)
+
+		locked, err := p.Comments.LockThread(ctx, reply.ID)
+		require.NoError(t, err)
+		assert.True(t, locked.IsLocked)
+
+		for _, tt := range []struct {
+			id   int
+			want bool
+		}{{root.ID, false}, {reply.ID, true}, {nested.ID, true}} {
+			got, err := p.Comments.IsThreadLocked(ctx, tt.id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got, "comment %d", tt.id)
+		}
+	})
+
+	t.Run("edit keeps revisions", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+		comment := createComment(t, p, post.ID, Alen, nil)
+
+		updated, err := p.Comments.UpdateComment(ctx, comment.ID, "edited", Quizert, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "edited", updated.Payload)
+		assert.NotNil(t, updated.EditedAt)
+		assertAuthor(t, Alen, updated.Author)
+
+		revisions, err := p.Comments.GetCommentRevisions(ctx, comment.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+		assert.Equal(t, comment.Payload, revisions[0].Payload)
+		assert.Nil(t, revisions[0].Title)
+		assertAuthor(t, Quizert, revisions[0].Editor)
+	})
+
+	t.Run("held edit", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+		comment := createComment(t, p, post.ID, Alen, nil)
+
+		updated, err := p.Comments.UpdateComment(ctx, comment.ID, "edited", Alen, heldItem(models.TargetTypeComment, comment.ID, Alen))
+		require.NoError(t, err)
+		assert.Equal(t, "edited", updated.Payload)
+		assert.True(t, updated.IsHidden, "правка скрывается в той же транзакции")
+
+		comments, err := p.Comments.GetCommentsByPostID(ctx, 10, 0, post.ID, Alice)
+		require.NoError(t, err)
+		assert.Empty(t, comments)
+
+		item := pendingItem(t, p, models.TargetTypeComment, comment.ID)
+		assert.True(t, item.WasPublished, "комментарий был виден до правки")
+		assert.Equal(t, "edited", item.Payload)
+	})
+
+	t.Run("mentions", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+		comment := createComment(t, p, post.ID, Alen, nil)
+
+		require.NoError(t, p.Comments.SaveMentions(ctx, comment.ID, []int{Alice, Quizert}))
+
+		users, err := p.Comments.GetCommentMentions(ctx, comment.ID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{Alice, Quizert}, userIDs(users))
+	})
+
+	t.Run("mentions saved with comment", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+
+		comment, err := p.Comments.CreateComment(ctx, models.NewComment{PostID: post.ID, AuthorID: Alen, Payload: "hi"}, false, []int{Alice, Quizert})
+		require.NoError(t, err)
+
+		users, err := p.Comments.GetCommentMentions(ctx, comment.ID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{Alice, Quizert}, userIDs(users))
+
+		_, err = p.Comments.CreateComment(ctx, models.NewComment{PostID: post.ID, AuthorID: Alen, Payload: "hi"}, false, []int{missingID})
+		assert.ErrorIs(t, err, storage.ErrForeignKey, "комментарий не сохраняется без упоминаний")
+		comments, err := p.Comments.GetCommentsByPostID(ctx, 10, 0, post.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []int{comment.ID}, commentIDs(comments))
+	})
+}
+
+func runOutbox(t *testing.T, newProviders Factory) {
+	ctx := context.Background()
+
+	t.Run("visible comment writes event", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+		deliverOutbox(t, p)
+		visible := createComment(t, p, post.ID, Alen, nil)
+		_, err := p.Comments.CreateComment(ctx, models.NewComment{PostID: post.ID, AuthorID: Alen, Payload: "held"}, true, nil)
+		require.NoError(t, err)
+
+		events, err := p.Outbox.ClaimOutboxEvents(ctx, time.Now(), 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, events, 1, "скрытый комментарий не публикуется")
+		assert.Positive(t, events[0].ID)
+		assert.Equal(t, models.OutboxEventCommentPublished, events[0].Type)
+		assert.Equal(t, visible.ID, events[0].AggregateID)
+		assert.Equal(t, 1, events[0].Attempts)
+		assert.Nil(t, events[0].DeliveredAt)
+	})
+
+	t.Run("failed comment writes no event", func(t *testing.T) {
+		p := newProviders(t)
+
+		_, err := p.Comments.CreateComment(ctx, models.NewComment{PostID: missingID, AuthorID: Alen, Payload: "comment"}, false, nil)
+		require.Error(t, err)
+
+		events, err := p.Outbox.ClaimOutboxEvents(ctx, time.Now(), 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("claim, retry and deliver", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+		deliverOutbox(t, p)
+		first := createComment(t, p, post.ID, Alen, nil)
+		second := createComment(t, p, post.ID, Alen, nil)
+		now := time.Now()
+
+		events, err := p.Outbox.ClaimOutboxEvents(ctx, now, 1, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, events, 1, "не больше limit событий")
+		assert.Equal(t, first.ID, events[0].AggregateID, "сначала старые события")
+		firstEvent := events[0]
+
+		events, err = p.Outbox.ClaimOutboxEvents(ctx, now, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, events, 1, "выданное событие не выдаётся повторно до конца аренды")
+		assert.Equal(t, second.ID, events[0].AggregateID)
+		secondEvent := events[0]
+
+		require.NoError(t, p.Outbox.RetryOutboxEvent(ctx, firstEvent.ID, now, "subscriber failed"))
+		require.NoError(t, p.Outbox.MarkOutboxEventDelivered(ctx, secondEvent.ID))
+
+		events, err = p.Outbox.ClaimOutboxEvents(ctx, now, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, events, 1, "доставленное событие больше не выдаётся")
+		assert.Equal(t, firstEvent.ID, events[0].ID)
+		assert.Equal(t, 2, events[0].Attempts)
+		if assert.NotNil(t, events[0].LastError) {
+			assert.Equal(t, "subscriber failed", *events[0].LastError)
+		}
+
+		events, err = p.Outbox.ClaimOutboxEvents(ctx, now.Add(2*time.Minute), 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, events, 1, "после аренды событие выдаётся снова")
+		assert.Equal(t, firstEvent.ID, events[0].ID)
+
+		err = p.Outbox.RetryOutboxEvent(ctx, secondEvent.ID, now, "late failure")
+		assertNotFound(t, err)
+		assertNotFound(t, p.Outbox.MarkOutboxEventDelivered(ctx, missingID))
+	})
+
+	t.Run("delete delivered", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+		deliverOutbox(t, p)
+		createComment(t, p, post.ID, Alen, nil)
+		createComment(t, p, post.ID, Alen, nil)
+
+		events, err := p.Outbox.ClaimOutboxEvents(ctx, time.Now(), 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.NoError(t, p.Outbox.MarkOutboxEventDelivered(ctx, events[0].ID))
+
+		deleted, err := p.Outbox.DeleteDeliveredOutboxEvents(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, deleted, "свежие события остаются")
+
+		deleted, err = p.Outbox.DeleteDeliveredOutboxEvents(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 2, deleted, "недоставленные события не удаляются")
+	})
+
+	t.Run("published posts write events", func(t *testing.T) {
+		p := newProviders(t)
+		draft := models.PostStatusDraft
+		scheduled := models.PostStatusScheduled
+		publishAt := time.Now().Add(-time.Minute)
+
+		visible := createPost(t, p, Alice)
+		_, err := p.Posts.CreatePost(ctx, models.NewPost{Title: "held", AuthorID: Alice}, true)
+		require.NoError(t, err)
+		drafted, err := p.Posts.CreatePost(ctx, models.NewPost{Title: "draft", AuthorID: Alice, Status: &draft}, false)
+		require.NoError(t, err)
+		planned, err := p.Posts.CreatePost(ctx, models.NewPost{Title: "scheduled", AuthorID: Alice, Status: &scheduled, PublishAt: &publishAt}, false)
+		require.NoError(t, err)
+		_, err = p.Posts.CreatePost(ctx, models.NewPost{Title: "hidden scheduled", AuthorID: Alice, Status: &scheduled, PublishAt: &publishAt}, true)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{visible.ID}, deliverOutbox(t, p), "скрытые посты и черновики не публикуются")
+
+		_, err = p.Posts.UpdatePostStatus(ctx, drafted.ID, models.PostStatusPublished, nil)
+		require.NoError(t, err)
+		_, err = p.Posts.UpdatePostStatus(ctx, visible.ID, models.PostStatusPublished, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []int{drafted.ID}, deliverOutbox(t, p), "повторная публикация не порождает событие")
+
+		published, err := p.Posts.PublishScheduledPosts(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 2, published)
+		assert.Equal(t, []int{planned.ID}, deliverOutbox(t, p))
+	})
+}
+
+func runWebhooks(t *testing.T, newProviders Factory) {
+	ctx := context.Background()
+
+	t.Run("create, update and delete", func(t *testing.T) {
+		p := newProviders(t)
+
+		created, err := p.Webhooks.CreateWebhook(ctx, &models.Webhook{
+			URL:        "https://example.com/hook",
+			Secret:     "secret",
+			EventTypes: []models.WebhookEventType{models.WebhookEventTypePostPublished},
+			IsActive:   true,
+		})
+		require.NoError(t, err)
+		assert.Positive(t, created.ID)
+		assert.Equal(t, "secret", created.Secret)
+		assert.False(t, created.CreatedAt.IsZero())
+
+		url := "https://example.com/other"
+		inactive := false
+		updated, err := p.Webhooks.UpdateWebhook(ctx, created.ID, models.EditWebhook{URL: &url, IsActive: &inactive})
+		require.NoError(t, err)
+		assert.Equal(t, url, updated.URL)
+		assert.False(t, updated.IsActive)
+		assert.Equal(t, "secret", updated.Secret, "непереданные поля не меняются")
+		assert.Equal(t, []models.WebhookEventType{models.WebhookEventTypePostPublished}, updated.EventTypes)
+
+		got, err := p.Webhooks.GetWebhookByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, updated.URL, got.URL)
+
+		webhooks, err := p.Webhooks.GetWebhooks(ctx)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		assert.Equal(t, created.ID, webhooks[0].ID)
+
+		require.NoError(t, p.Webhooks.DeleteWebhook(ctx, created.ID))
+		_, err = p.Webhooks.GetWebhookByID(ctx, created.ID)
+		assertNotFound(t, err)
+		assertNotFound(t, p.Webhooks.DeleteWebhook(ctx, created.ID))
+		_, err = p.Webhooks.UpdateWebhook(ctx, missingID, models.EditWebhook{URL: &url})
+		assertNotFound(t, err)
+	})
+
+	t.Run("deliveries", func(t *testing.T) {
+		p := newProviders(t)
+		posts := createWebhook(t, p, true, models.WebhookEventTypePostPublished)
+		all := createWebhook(t, p, true, models.WebhookEventTypePostPublished, models.WebhookEventTypeCommentPublished)
+		createWebhook(t, p, false, models.WebhookEventTypeCommentPublished)
+
+		created, err := p.Webhooks.CreateWebhookDeliveries(ctx, 1, models.WebhookEventTypeCommentPublished, `{"id":1}`)
+		require.NoError(t, err)
+		assert.Equal(t, 1, created, "только активные вебхуки, подписанные на событие")
+		created, err = p.Webhooks.CreateWebhookDeliveries(ctx, 2, models.WebhookEventTypePostPublished, `{"id":2}`)
+		require.NoError(t, err)
+		assert.Equal(t, 2, created)
+		created, err = p.Webhooks.CreateWebhookDeliveries(ctx, 2, models.WebhookEventTypePostPublished, `{"id":2}`)
+		require.NoError(t, err)
+		assert.Zero(t, created, "повторное событие не дублирует доставки")
+
+		deliveries, err := p.Webhooks.GetWebhookDeliveries(ctx, all.ID, nil, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		assert.Equal(t, 2, deliveries[0].EventID, "сначала новые доставки")
+		assert.Equal(t, models.WebhookEventTypePostPublished, deliveries[0].EventType)
+		assert.Equal(t, `{"id":2}`, deliveries[0].Payload)
+		assert.Equal(t, models.WebhookDeliveryStatusPending, deliveries[0].Status)
+		assert.Zero(t, deliveries[0].Attempts)
+
+		deliveries, err = p.Webhooks.GetWebhookDeliveries(ctx, all.ID, nil, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, 1, deliveries[0].EventID)
+
+		require.NoError(t, p.Webhooks.DeleteWebhook(ctx, posts.ID))
+		deliveries, err = p.Webhooks.GetWebhookDeliveries(ctx, posts.ID, nil, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries, "доставки удаляются вместе с вебхуком")
+	})
+
+	t.Run("claim and update delivery", func(t *testing.T) {
+		p := newProviders(t)
+		webhook := createWebhook(t, p, true, models.WebhookEventTypePostPublished)
+		_, err := p.Webhooks.CreateWebhookDeliveries(ctx, 1, models.WebhookEventTypePostPublished, "{}")
+		require.NoError(t, err)
+		_, err = p.Webhooks.CreateWebhookDeliveries(ctx, 2, models.WebhookEventTypePostPublished, "{}")
+		require.NoError(t, err)
+		now := time.Now()
+
+		claimed, err := p.Webhooks.ClaimWebhookDeliveries(ctx, now, 1, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1, "не больше limit доставок")
+		assert.Equal(t, 1, claimed[0].EventID, "сначала старые доставки")
+		assert.Equal(t, 1, claimed[0].Attempts)
+		first := claimed[0]
+
+		claimed, err = p.Webhooks.ClaimWebhookDeliveries(ctx, now, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1, "выданная доставка не выдаётся повторно до конца аренды")
+		second := claimed[0]
+
+		status := 200
+		deliveredAt := now
+		first.Status = models.WebhookDeliveryStatusSucceeded
+		first.ResponseStatus = &status
+		first.DeliveredAt = &deliveredAt
+		require.NoError(t, p.Webhooks.UpdateWebhookDelivery(ctx, first))
+
+		lastError := "receiver returned 500"
+		second.LastError = &lastError
+		second.NextAttemptAt = now
+		require.NoError(t, p.Webhooks.UpdateWebhookDelivery(ctx, second))
+
+		claimed, err = p.Webhooks.ClaimWebhookDeliveries(ctx, now, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1, "успешная доставка больше не выдаётся")
+		assert.Equal(t, second.ID, claimed[0].ID)
+		assert.Equal(t, 2, claimed[0].Attempts)
+
+		got, err := p.Webhooks.GetWebhookDelivery(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.WebhookDeliveryStatusSucceeded, got.Status)
+		if assert.NotNil(t, got.ResponseStatus) {
+			assert.Equal(t, 200, *got.ResponseStatus)
+		}
+		assert.NotNil(t, got.DeliveredAt)
+
+		succeeded := models.WebhookDeliveryStatusSucceeded
+		deliveries, err := p.Webhooks.GetWebhookDeliveries(ctx, webhook.ID, &succeeded, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, first.ID, deliveries[0].ID)
+
+		inactive := false
+		_, err = p.Webhooks.UpdateWebhook(ctx, webhook.ID, models.EditWebhook{IsActive: &inactive})
+		require.NoError(t, err)
+		claimed, err = p.Webhooks.ClaimWebhookDeliveries(ctx, now.Add(2*time.Minute), 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, claimed, "доставки выключенного вебхука не выдаются")
+
+		_, err = p.Webhooks.GetWebhookDelivery(ctx, missingID)
+		assertNotFound(t, err)
+		assertNotFound(t, p.Webhooks.UpdateWebhookDelivery(ctx, &models.WebhookDelivery{ID: missingID, Status: models.WebhookDeliveryStatusFailed}))
+	})
+}
+
+func runUsers(t *testing.T, newProviders Factory) {
+	ctx := context.Background()
+
+	t.Run("get", func(t *testing.T) {
+		p := newProviders(t)
+
+		user, err := p.Users.GetUserByID(ctx, Quizert)
+		require.NoError(t, err)
+		assertAuthor(t, Quizert, user)
+		assert.Equal(t, models.UserRoleUser, user.Role)
+
+		_, err = p.Users.GetUserByID(ctx, missingID)
+		assertNotFound(t, err)
+
+		users, err := p.Users.GetUsersByUsernames(ctx, []string{"alice", "ALEN", "nobody"})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{Alice, Alen}, userIDs(users), "поиск по имени без учёта регистра")
+	})
+
+	t.Run("role", func(t *testing.T) {
+		p := newProviders(t)
+
+		require.NoError(t, p.Users.SetUserRole(ctx, Quizert, models.UserRoleModerator))
+		user, err := p.Users.GetUserByID(ctx, Quizert)
+		require.NoError(t, err)
+		assert.True(t, user.IsModerator())
+
+		assertNotFound(t, p.Users.SetUserRole(ctx, missingID, models.UserRoleModerator))
+	})
+
+	t.Run("restrictions", func(t *testing.T) {
+		p := newProviders(t)
+		post := createPost(t, p, Alice)
+
+		_, err := p.Users.GetUserBan(ctx, Alen)
+		assertNotFound(t, err)
+		_, err = p.Users.GetPostMute(ctx, post.ID, Alen)
+		assertNotFound(t, err)
+
+		ban, err := p.Users.BanUser(ctx, &models.UserBan{User: &models.User{ID: Alen}, Reason: "spam", Moderator: &models.User{ID: Quizert}})
+		require.NoError(t, err)
+		assertAuthor(t, Alen, ban.User)
+		assertAuthor(t, Quizert, ban.Moderator)
+
+		got, err := p.Users.GetUserBan(ctx, Alen)
+		require.NoError(t, err)
+		assert.Equal(t, "spam", got.Reason)
+		assert.Nil(t, got.Until)
+
+		_, err = p.Users.MuteUserOnPost(ctx, &models.PostMute{PostID: post.ID, User: &models.User{ID: Alen}, MutedBy: &models.User{ID: Alice}})
+		require.NoError(t, err)
+		mute, err := p.Users.GetPostMute(ctx, post.ID, Alen)
+		require.NoError(t, err)
+		assertAuthor(t, Alice, mute.MutedBy)
+	})
+
+	t.Run("blocks", func(t *testing.T) {
+		p := newProviders(t)
+
+		steps := []struct {
+			name  string
+			call  func() (bool, error)
+			want  bool
+			state bool
+		}{
+			{"block", func() (bool, error) { return p.Users.BlockUser(ctx, Alice, Alen) }, true, true},
+			{"block again", func() (bool, error) { return p.Users.BlockUser(ctx, Alice, Alen) }, false, true},
+			{"unblock", func() (bool, error) { return p.Users.UnblockUser(ctx, Alice, Alen) }, true, false},
+			{"unblock again", func() (bool, error) { return p.Users.UnblockUser(ctx, Alice, Alen) }, false, false},
+		}
+		for _, step := range steps {
+			changed, err := step.call()
+			require.NoError(t, err, step.name)
+			assert.Equal(t, step.want, changed, step.name)
+
+			blocked, err := p.Users.IsBlocked(ctx, Alice, Alen)
+			require.NoError(t, err, step.name)
+			assert.Equal(t, step.state, blocked, step.name)
+		}
+
+		reverse, err := p.Users.IsBlocked(ctx, Alen, Alice)
+		require.NoError(t, err)
+		assert.False(t, reverse, "блокировка односторонняя")
+	})
+}
+
+// assertNotFound проверяет, что бэкенд сообщил об отсутствии записи
+func assertNotFound(t *testing.T, err error) {
+	t.Helper()
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+// heldItem - элемент очереди модерации для правки, отложенной фильтром
+func heldItem(targetType models.TargetType, targetID int, authorID int) *models.ModerationItem {
+	filter := "words"
+	return &models.ModerationItem{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Payload:    "edited",
+		Author:     &models.User{ID: authorID},
+		Filter:     &filter,
+		Reason:     "held",
+	}
+}
+
+// pendingItem возвращает единственный ожидающий элемент очереди модерации и проверяет, что он про targetID
+func pendingItem(t *testing.T, p Providers, targetType models.TargetType, targetID int) *models.ModerationItem {
+	t.Helper()
+	items, err := p.Moderation.GetModerationQueue(context.Background(), models.ModerationStatusPending, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, targetType, items[0].TargetType)
+	assert.Equal(t, targetID, items[0].TargetID)
+	return items[0]
+}
+
+func assertAuthor(t *testing.T, wantID int, user *models.User) {
+	t.Helper()
+	if assert.NotNil(t, user, "пользователь должен быть заполнен") {
+		assert.Equal(t, wantID, user.ID)
+		assert.Equal(t, usernames[wantID], user.Username)
+	}
+}
+
+// deliverOutbox помечает доставленными все ожидающие события и возвращает id их агрегатов
+func deliverOutbox(t *testing.T, p Providers) []int {
+	t.Helper()
+	events, err := p.Outbox.ClaimOutboxEvents(context.Background(), time.Now(), 100, time.Minute)
+	require.NoError(t, err)
+	ids := make([]int, 0, len(events))
+	for _, event := range events {
+		require.NoError(t, p.Outbox.MarkOutboxEventDelivered(context.Background(), event.ID))
+		ids = append(ids, event.AggregateID)
+	}
+	return ids
+}
+
+func createWebhook(t *testing.T, p Providers, active bool, eventTypes ...models.WebhookEventType) *models.Webhook {
+	t.Helper()
+	webhook, err := p.Webhooks.CreateWebhook(context.Background(), &models.Webhook{
+		URL:        "https://example.com/hook",
+		Secret:     "secret",
+		EventTypes: eventTypes,
+		IsActive:   active,
+	})
+	require.NoError(t, err)
+	return webhook
+}
+
+func createPost(t *testing.T, p Providers, authorID int, tags ...string) *models.Post {
+	t.Helper()
+	post, err := p.Posts.CreatePost(context.Background(), models.NewPost{
+		Title:             "title",
+		Payload:           "payload",
+		AuthorID:          authorID,
+		IsCommentsAllowed: true,
+		Tags:              tags,
+	}, false)
+	require.NoError(t, err)
+	return post
+}
+
+func createComment(t *testing.T, p Providers, postID int, authorID int, replyTo *int) *models.Comment {
+	t.Helper()
+	comment, err := p.Comments.CreateComment(context.Background(), models.NewComment{
+		PostID:   postID,
+		AuthorID: authorID,
+		Payload:  "comment",
+		ReplyTo:  replyTo,
+	}, false, nil)
+	require.NoError(t, err)
+	return comment
+}
+
+func postIDs(posts []*models.Post) []int {
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
+
+func commentIDs(comments []*models.Comment) []int {
+	ids := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	return ids
+}
+
+func userIDs(users []*models.User) []int {
+	ids := make([]int, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
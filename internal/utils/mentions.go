@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"regexp"
+)
+
+// mentionRegexp находит @username, перед которым нет буквы или цифры (чтобы не цеплять email)
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}_]+)`)
+
+// ParseMentions возвращает уникальные имена пользователей, упомянутых в тексте, в порядке появления
+func ParseMentions(payload string, maxCount int) []string {
+	var usernames []string
+	seen := make(map[string]struct{})
+
+	for _, match := range mentionRegexp.FindAllStringSubmatch(payload, -1) {
+		username := match[1]
+		if _, ok := seen[username]; ok {
+			continue
+		}
+		seen[username] = struct{}{}
+		usernames = append(usernames, username)
+
+		if len(usernames) == maxCount {
+			break
+		}
+	}
+	return usernames
+}
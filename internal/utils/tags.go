@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// NormalizeTag приводит тег к каноническому виду: без '#', в нижнем регистре, пробелы заменены на '-'
+func NormalizeTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	tag = strings.TrimLeft(tag, "#")
+	tag = strings.ToLower(tag)
+	return strings.Join(strings.Fields(tag), "-")
+}
+
+// NormalizeTags нормализует теги, отбрасывает пустые и дубликаты, сохраняя исходный порядок
+func NormalizeTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]struct{}, len(tags))
+
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		result = append(result, tag)
+	}
+	return result
+}
+
+// SortTags возвращает отсортированную по имени копию тегов. Так теги поста отдают все хранилища
+func SortTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	result := make([]string, len(tags))
+	copy(result, tags)
+	sort.Strings(result)
+	return result
+}
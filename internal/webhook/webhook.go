@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки запроса доставки
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const signaturePrefix = "sha256="
+
+// maxErrorBody - сколько байт ответа получателя попадает в текст ошибки
+const maxErrorBody = 256
+
+// Sign возвращает подпись доставки: HMAC-SHA256 с секретом вебхука от "timestamp.body" в hex.
+// Timestamp входит в подпись, чтобы перехваченный запрос нельзя было выдать за новый
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет значение заголовка X-Webhook-Signature. Свежесть timestamp проверяет получатель
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	signature, ok := strings.CutPrefix(signature, signaturePrefix)
+	if !ok {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// Request - одна попытка доставки события
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int
+	Body       []byte
+}
+
+// Sender отправляет подписанные доставки по HTTP
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender создаёт Sender, timeout ограничивает одну попытку целиком. Редиректы не выполняются:
+// получатель должен отвечать по зарегистрированному адресу
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Send отправляет тело POST-запросом и возвращает код ответа, 0 - если ответа не было.
+// Ошибка возвращается на любой ответ, кроме 2xx
+func (s *Sender) Send(ctx context.Context, request Request) (int, error) {
+	timestamp := s.now().Unix()
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, request.URL, bytes.NewReader(request.Body))
+	if err != nil {
+		return 0, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set(HeaderEvent, request.Event)
+	httpRequest.Header.Set(HeaderDelivery, strconv.Itoa(request.DeliveryID))
+	httpRequest.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpRequest.Header.Set(HeaderSignature, signaturePrefix+Sign(request.Secret, timestamp, request.Body))
+
+	response, err := s.client.Do(httpRequest)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+		return response.StatusCode, fmt.Errorf("receiver responded with %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+	}
+	// Тело дочитывается, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxErrorBody))
+	return response.StatusCode, nil
+}
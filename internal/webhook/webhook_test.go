@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	signature := "sha256=" + Sign("secret", 100, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		signature string
+		expected  bool
+	}{
+		{
+			name:      "valid",
+			secret:    "secret",
+			timestamp: 100,
+			body:      body,
+			signature: signature,
+			expected:  true,
+		},
+		{
+			name:      "other secret",
+			secret:    "other",
+			timestamp: 100,
+			body:      body,
+			signature: signature,
+		},
+		{
+			name:      "other timestamp",
+			secret:    "secret",
+			timestamp: 101,
+			body:      body,
+			signature: signature,
+		},
+		{
+			name:      "tampered body",
+			secret:    "secret",
+			timestamp: 100,
+			body:      []byte(`{"id":2}`),
+			signature: signature,
+		},
+		{
+			name:      "missing prefix",
+			secret:    "secret",
+			timestamp: 100,
+			body:      body,
+			signature: Sign("secret", 100, body),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Verify(tt.secret, tt.timestamp, tt.body, tt.signature))
+		})
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	body := []byte(`{"id":7,"type":"POST_PUBLISHED"}`)
+
+	tests := []struct {
+		name           string
+		responseStatus int
+		expectErr      bool
+	}{
+		{
+			name:           "accepted",
+			responseStatus: http.StatusNoContent,
+		},
+		{
+			name:           "receiver error",
+			responseStatus: http.StatusInternalServerError,
+			expectErr:      true,
+		},
+		{
+			name:           "redirect is not followed",
+			responseStatus: http.StatusFound,
+			expectErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan *http.Request, 1)
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, body, got)
+
+				timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+				assert.NoError(t, err)
+				assert.True(t, Verify("secret", timestamp, got, r.Header.Get(HeaderSignature)), "подпись должна сходиться")
+				received <- r
+
+				if tt.responseStatus == http.StatusFound {
+					w.Header().Set("Location", "/elsewhere")
+				}
+				w.WriteHeader(tt.responseStatus)
+			}))
+			defer receiver.Close()
+
+			sender := NewSender(time.Second)
+			sender.now = func() time.Time { return time.Unix(1700000000, 0) }
+			status, err := sender.Send(context.Background(), Request{
+				URL:        receiver.URL,
+				Secret:     "secret",
+				Event:      "POST_PUBLISHED",
+				DeliveryID: 42,
+				Body:       body,
+			})
+			assert.Equal(t, tt.responseStatus, status)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			require.Len(t, received, 1, "редирект не выполняется")
+			r := <-received
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.Equal(t, "POST_PUBLISHED", r.Header.Get(HeaderEvent))
+			assert.Equal(t, "42", r.Header.Get(HeaderDelivery))
+			assert.Equal(t, "1700000000", r.Header.Get(HeaderTimestamp))
+		})
+	}
+}
+
+func TestSender_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer receiver.Close()
+	defer close(release)
+
+	status, err := NewSender(50*time.Millisecond).Send(context.Background(), Request{URL: receiver.URL, Secret: "secret", Body: []byte("{}")})
+	assert.Error(t, err)
+	assert.Zero(t, status, "без ответа код не известен")
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS reports (
+    id serial primary key,
+    targetType varchar(20) not null,
+    targetID int not null,
+    reporterID int not null references users(id) on delete cascade,
+    reason varchar(20) not null,
+    note TEXT,
+    createdAt timestamp with time zone default now(),
+    UNIQUE (targetType, targetID, reporterID)
+);
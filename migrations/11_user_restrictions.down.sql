@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS post_mutes;
+DROP TABLE IF EXISTS user_bans;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blockerID int not null references users(id) on delete cascade,
+    blockedID int not null references users(id) on delete cascade,
+    createdAt timestamp with time zone default now(),
+    primary key (blockerID, blockedID)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id serial primary key,
+    eventType varchar(50) not null,
+    aggregateID int not null,
+    attempts int not null default 0,
+    lastError TEXT,
+    nextAttemptAt timestamp with time zone not null default now(),
+    deliveredAt timestamp with time zone,
+    createdAt timestamp with time zone default now()
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (nextAttemptAt, id) WHERE deliveredAt IS NULL;
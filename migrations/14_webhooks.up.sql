@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id serial primary key,
+    url TEXT not null,
+    secret TEXT not null,
+    eventTypes varchar(50)[] not null,
+    isActive boolean not null default true,
+    createdAt timestamp with time zone default now()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id serial primary key,
+    webhookID int not null references webhooks(id) on delete cascade,
+    eventID int not null,
+    eventType varchar(50) not null,
+    payload TEXT not null,
+    status varchar(20) not null default 'PENDING',
+    attempts int not null default 0,
+    responseStatus int,
+    lastError TEXT,
+    nextAttemptAt timestamp with time zone not null default now(),
+    deliveredAt timestamp with time zone,
+    createdAt timestamp with time zone default now(),
+    unique (webhookID, eventID)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (nextAttemptAt, id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhookID, id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id serial primary key,
+    name varchar(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+    postID int not null references posts(id) on delete cascade,
+    tagID int not null references tags(id) on delete cascade,
+    primary key (postID, tagID)
+);
+
+CREATE INDEX IF NOT EXISTS post_tags_tagID_idx ON post_tags (tagID);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS posts_status_publishAt_idx;
+ALTER TABLE posts DROP COLUMN IF EXISTS publishAt;
+ALTER TABLE posts DROP COLUMN IF EXISTS status;
\ No newline at end of file
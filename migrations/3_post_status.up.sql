@@ -0,0 +1,6 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'PUBLISHED';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS publishAt timestamp with time zone;
+
+UPDATE posts SET publishAt = createdAt WHERE publishAt IS NULL AND status = 'PUBLISHED';
+
+CREATE INDEX IF NOT EXISTS posts_status_publishAt_idx ON posts (status, publishAt);
@@ -0,0 +1,2 @@
+ALTER TABLE comments DROP COLUMN IF EXISTS isLocked;
+ALTER TABLE comments DROP COLUMN IF EXISTS isPinned;
\ No newline at end of file
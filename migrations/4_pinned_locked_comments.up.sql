@@ -0,0 +1,2 @@
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS isPinned boolean NOT NULL DEFAULT false;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS isLocked boolean NOT NULL DEFAULT false;
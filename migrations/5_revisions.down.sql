@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS revisions;
+ALTER TABLE comments DROP COLUMN IF EXISTS editedAt;
+ALTER TABLE posts DROP COLUMN IF EXISTS editedAt;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
\ No newline at end of file
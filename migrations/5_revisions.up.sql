@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role varchar(20) NOT NULL DEFAULT 'USER';
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS editedAt timestamp with time zone;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS editedAt timestamp with time zone;
+
+CREATE TABLE IF NOT EXISTS revisions (
+    id serial primary key,
+    targetType varchar(20) NOT NULL,
+    targetID int NOT NULL,
+    version int NOT NULL,
+    title varchar(200),
+    payload TEXT NOT NULL,
+    editorID int not null references users(id) on delete cascade,
+    editedAt timestamp with time zone default now(),
+    unique (targetType, targetID, version)
+);
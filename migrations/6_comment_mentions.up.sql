@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    commentID int not null references comments(id) on delete cascade,
+    userID int not null references users(id) on delete cascade,
+    primary key (commentID, userID)
+);
+
+CREATE INDEX IF NOT EXISTS comment_mentions_userID_idx ON comment_mentions (userID);
@@ -0,0 +1,2 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS format varchar(20) not null default 'PLAIN';
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS format varchar(20) not null default 'PLAIN';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS moderation_queue;
+ALTER TABLE comments DROP COLUMN IF EXISTS isHidden;
+ALTER TABLE posts DROP COLUMN IF EXISTS isHidden;
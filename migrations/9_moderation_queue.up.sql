@@ -0,0 +1,20 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS isHidden boolean not null default false;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS isHidden boolean not null default false;
+
+CREATE TABLE IF NOT EXISTS moderation_queue (
+    id serial primary key,
+    targetType varchar(20) not null,
+    targetID int not null,
+    title varchar(200),
+    payload TEXT not null,
+    authorID int not null references users(id) on delete cascade,
+    filter varchar(200),
+    reason TEXT not null,
+    status varchar(20) not null default 'PENDING',
+    moderatorID int references users(id) on delete set null,
+    decisionReason TEXT,
+    createdAt timestamp with time zone default now(),
+    decidedAt timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS moderation_queue_status_id_idx ON moderation_queue (status, id);
@@ -0,0 +1,24 @@
+// Package migrations содержит SQL-миграции схемы, встроенные в бинарник
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS - миграции Postgres в формате <version>_<name>.up.sql / <version>_<name>.down.sql
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// SQLite - те же версии миграций в диалекте SQLite
+func SQLite() fs.FS {
+	sub, err := fs.Sub(sqliteFS, "sqlite")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
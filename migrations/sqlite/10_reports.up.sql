@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS reports (
+    id integer primary key autoincrement,
+    targetType varchar(20) not null,
+    targetID int not null,
+    reporterID int not null references users(id) on delete cascade,
+    reason varchar(20) not null,
+    note TEXT,
+    createdAt timestamp default CURRENT_TIMESTAMP,
+    UNIQUE (targetType, targetID, reporterID)
+);
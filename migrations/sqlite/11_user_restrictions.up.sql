@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS user_bans (
+    userID int primary key references users(id) on delete cascade,
+    until timestamp,
+    reason TEXT not null,
+    moderatorID int not null references users(id) on delete cascade,
+    createdAt timestamp default CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS post_mutes (
+    postID int not null references posts(id) on delete cascade,
+    userID int not null references users(id) on delete cascade,
+    until timestamp,
+    mutedBy int not null references users(id) on delete cascade,
+    createdAt timestamp default CURRENT_TIMESTAMP,
+    primary key (postID, userID)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id integer primary key autoincrement,
+    eventType varchar(50) not null,
+    aggregateID int not null,
+    attempts int not null default 0,
+    lastError TEXT,
+    nextAttemptAt timestamp not null default CURRENT_TIMESTAMP,
+    deliveredAt timestamp,
+    createdAt timestamp default CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (nextAttemptAt, id) WHERE deliveredAt IS NULL;
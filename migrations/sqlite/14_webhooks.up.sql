@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id integer primary key autoincrement,
+    url TEXT not null,
+    secret TEXT not null,
+    eventTypes TEXT not null,
+    isActive boolean not null default true,
+    createdAt timestamp default CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id integer primary key autoincrement,
+    webhookID int not null references webhooks(id) on delete cascade,
+    eventID int not null,
+    eventType varchar(50) not null,
+    payload TEXT not null,
+    status varchar(20) not null default 'PENDING',
+    attempts int not null default 0,
+    responseStatus int,
+    lastError TEXT,
+    nextAttemptAt timestamp not null default CURRENT_TIMESTAMP,
+    deliveredAt timestamp,
+    createdAt timestamp default CURRENT_TIMESTAMP,
+    unique (webhookID, eventID)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (nextAttemptAt, id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhookID, id);
@@ -0,0 +1 @@
+ALTER TABLE moderation_queue DROP COLUMN wasPublished;
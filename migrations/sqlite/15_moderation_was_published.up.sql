@@ -0,0 +1,2 @@
+ALTER TABLE moderation_queue ADD COLUMN wasPublished boolean not null default false;
+UPDATE moderation_queue SET wasPublished = true WHERE filter = 'reports';
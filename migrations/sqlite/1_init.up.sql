@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS users (
+    id integer primary key autoincrement,
+    username varchar(200) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+    id integer primary key autoincrement,
+    title varchar(200) NOT NULL,
+    payload TEXT NOT NULL,
+    authorID int not null references users(id) on delete cascade,
+    isCommentsAllowed boolean default true,
+    createdAt timestamp default CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id integer primary key autoincrement,
+    payload TEXT not null,
+    postID int not null references posts(id) on delete cascade,
+    authorID int not null references users(id) on delete cascade,
+    replyTo int references comments(id) on delete cascade,
+    createdAt timestamp default CURRENT_TIMESTAMP
+);
+
+INSERT INTO users (username) VALUES ('Alice');
+INSERT INTO users (username) VALUES ('Quizert');
+INSERT INTO users (username) VALUES ('Alen');
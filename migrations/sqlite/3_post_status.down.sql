@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS posts_status_publishAt_idx;
+ALTER TABLE posts DROP COLUMN publishAt;
+ALTER TABLE posts DROP COLUMN status;
@@ -0,0 +1,2 @@
+ALTER TABLE comments DROP COLUMN isLocked;
+ALTER TABLE comments DROP COLUMN isPinned;
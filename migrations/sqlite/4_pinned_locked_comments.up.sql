@@ -0,0 +1,2 @@
+ALTER TABLE comments ADD COLUMN isPinned boolean NOT NULL DEFAULT false;
+ALTER TABLE comments ADD COLUMN isLocked boolean NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS revisions;
+ALTER TABLE comments DROP COLUMN editedAt;
+ALTER TABLE posts DROP COLUMN editedAt;
+ALTER TABLE users DROP COLUMN role;
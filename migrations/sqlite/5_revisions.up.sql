@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN role varchar(20) NOT NULL DEFAULT 'USER';
+
+ALTER TABLE posts ADD COLUMN editedAt timestamp;
+ALTER TABLE comments ADD COLUMN editedAt timestamp;
+
+CREATE TABLE IF NOT EXISTS revisions (
+    id integer primary key autoincrement,
+    targetType varchar(20) NOT NULL,
+    targetID int NOT NULL,
+    version int NOT NULL,
+    title varchar(200),
+    payload TEXT NOT NULL,
+    editorID int not null references users(id) on delete cascade,
+    editedAt timestamp default CURRENT_TIMESTAMP,
+    unique (targetType, targetID, version)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id integer primary key autoincrement,
+    type varchar(20) not null,
+    recipientID int not null references users(id) on delete cascade,
+    actorID int not null references users(id) on delete cascade,
+    postID int not null references posts(id) on delete cascade,
+    commentID int not null references comments(id) on delete cascade,
+    isRead boolean not null default false,
+    createdAt timestamp default CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notifications_recipientID_id_idx ON notifications (recipientID, id DESC);
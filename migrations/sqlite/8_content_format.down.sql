@@ -0,0 +1,2 @@
+ALTER TABLE comments DROP COLUMN format;
+ALTER TABLE posts DROP COLUMN format;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS moderation_queue;
+ALTER TABLE comments DROP COLUMN isHidden;
+ALTER TABLE posts DROP COLUMN isHidden;